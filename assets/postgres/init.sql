@@ -1,20 +1,49 @@
+create extension if not exists pg_trgm;
+
 create table if not exists films(
     id serial,
     title varchar(150) not null check(length(title) > 0),
     description varchar(1000),
     release_date date,
     rating double precision check (rating >= 0 and rating <= 10),
+    external_id varchar(100) unique,
+    search_vector tsvector generated always as (
+        setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('russian', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(description, '')), 'B') ||
+        setweight(to_tsvector('russian', coalesce(description, '')), 'B')
+    ) stored,
     primary key (id)
 );
 
+create index if not exists films_search_vector_idx on films using gin (search_vector);
+create index if not exists films_title_trgm_idx on films using gin (title gin_trgm_ops);
+create index if not exists films_title_prefix_idx on films (lower(title) text_pattern_ops);
+
 create table if not exists actors(
     id serial,
     name varchar(100) not null,
     gender varchar(20),
     birth_date date,
+    external_id varchar(100) unique,
+    search_vector tsvector generated always as (to_tsvector('simple', coalesce(name, ''))) stored,
     primary key (id)
 );
 
+create index if not exists actors_search_vector_idx on actors using gin (search_vector);
+create index if not exists actors_name_trgm_idx on actors using gin (name gin_trgm_ops);
+create index if not exists actors_name_prefix_idx on actors (lower(name) text_pattern_ops);
+
+-- Deleted films and actors stay in the trash until they are restored or
+-- purged, their cast links are kept meanwhile.
+alter table films add column if not exists deleted_at timestamptz;
+alter table films add column if not exists deleted_by varchar(50);
+alter table actors add column if not exists deleted_at timestamptz;
+alter table actors add column if not exists deleted_by varchar(50);
+
+create index if not exists films_deleted_at_idx on films (deleted_at) where deleted_at is not null;
+create index if not exists actors_deleted_at_idx on actors (deleted_at) where deleted_at is not null;
+
 create table if not exists films_x_actors(
     film_id integer,
     actor_id integer,
@@ -29,4 +58,81 @@ create table if not exists users(
     hashed_password varchar(100),
     is_admin boolean,
     primary key (login)
-);
\ No newline at end of file
+);
+
+-- Every change of a film, actor or user, written in the transaction of the
+-- change itself. entity_id is the login for users.
+create table if not exists audit_events(
+    id bigserial,
+    at timestamptz not null default now(),
+    login varchar(50) not null,
+    request_id varchar(100) not null,
+    entity varchar(10) not null,
+    entity_id varchar(50) not null,
+    action varchar(10) not null,
+    diff jsonb not null,
+    primary key (id)
+);
+
+create index if not exists audit_events_entity_idx on audit_events (entity, entity_id, id);
+create index if not exists audit_events_at_idx on audit_events (at);
+
+-- Every version of a film, numbered from 1 for each film. film is the
+-- models.FilmRequest that brings the film back to the revision.
+create table if not exists film_revisions(
+    film_id integer not null,
+    revision integer not null,
+    at timestamptz not null default now(),
+    login varchar(50) not null,
+    request_id varchar(100) not null,
+    film jsonb not null,
+    primary key (film_id, revision),
+    foreign key (film_id)
+    references films(id)
+);
+
+-- Outbox of film and actor changes for downstream consumers, written in the
+-- transaction of the change. Writers lock the table until they commit, so
+-- that seq grows in commit order.
+create table if not exists change_events(
+    seq bigserial,
+    at timestamptz not null default now(),
+    entity varchar(10) not null,
+    entity_id integer not null,
+    action varchar(10) not null,
+    primary key (seq)
+);
+
+create index if not exists change_events_at_idx on change_events (at);
+
+-- Webhook subscriptions, events is a JSON array of event types. last_seq is
+-- the last change event the webhook has been given deliveries for.
+create table if not exists webhooks(
+    id serial,
+    url varchar(500) not null,
+    events jsonb not null,
+    secret varchar(200) not null,
+    last_seq bigint not null,
+    created_at timestamptz not null default now(),
+    primary key (id)
+);
+
+create table if not exists webhook_deliveries(
+    id bigserial,
+    webhook_id integer not null,
+    event varchar(20) not null,
+    payload jsonb not null,
+    status varchar(10) not null,
+    attempts integer not null default 0,
+    response_code integer not null default 0,
+    error text not null default '',
+    created_at timestamptz not null default now(),
+    next_attempt_at timestamptz,
+    delivered_at timestamptz,
+    primary key (id),
+    foreign key (webhook_id)
+    references webhooks(id)
+);
+
+create index if not exists webhook_deliveries_webhook_idx on webhook_deliveries (webhook_id, id);
+create index if not exists webhook_deliveries_due_idx on webhook_deliveries (next_attempt_at) where status = 'pending';
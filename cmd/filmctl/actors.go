@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/pkg/client"
+)
+
+func runActors(b backend, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("actors: missing subcommand")
+	}
+
+	switch args[0] {
+	case "list":
+		flags := flag.NewFlagSet("actors list", flag.ExitOnError)
+		q := &client.ActorQuery{}
+		flags.StringVar(&q.SearchName, "search-name", "", "fragment of the name")
+		flags.StringVar(&q.Gender, "gender", "", "gender")
+		flags.BoolVar(&q.Fuzzy, "fuzzy", false, "typo tolerant search by -search-name")
+		sort := flags.String("sort", "", `comma separated fields, "-" for descending, e.g. -film_count,name`)
+		flags.Parse(args[1:])
+		if *sort != "" {
+			q.Sort = strings.Split(*sort, ",")
+		}
+
+		actors, err := b.Actors(q)
+		if err != nil {
+			return err
+		}
+		return printActors(actors)
+
+	case "show":
+		id, err := idArg("actors show", args[1:])
+		if err != nil {
+			return err
+		}
+		actor, err := b.Actor(id)
+		if err != nil {
+			return actorError(id, err)
+		}
+		return printActor(actor)
+
+	case "add":
+		req := parseActorRequest("actors add", args[1:])
+		if !(*models.ActorRequest)(req).ValidateForInsert() {
+			return fmt.Errorf("name must be 1 to 100 characters, gender up to 20 and birth date YYYY-MM-DD")
+		}
+		id, err := b.CreateActor(req)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("actor %d created\n", id)
+
+	case "edit":
+		id, err := idArg("actors edit", args[1:2])
+		if err != nil {
+			return err
+		}
+		req := parseActorRequest("actors edit", args[2:])
+		if !(*models.ActorRequest)(req).ValidateForUpdate() {
+			return fmt.Errorf("name must be up to 100 characters, gender up to 20 and birth date YYYY-MM-DD")
+		}
+		if err := b.ModifyActor(id, req); err != nil {
+			return actorError(id, err)
+		}
+		fmt.Printf("actor %d modified\n", id)
+
+	case "delete":
+		id, err := idArg("actors delete", args[1:])
+		if err != nil {
+			return err
+		}
+		if err := b.DeleteActor(id); err != nil {
+			return actorError(id, err)
+		}
+		fmt.Printf("actor %d deleted\n", id)
+
+	default:
+		return fmt.Errorf("actors: unknown subcommand %q", args[0])
+	}
+
+	return nil
+}
+
+func parseActorRequest(name string, args []string) *client.ActorRequest {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	req := &client.ActorRequest{}
+	flags.StringVar(&req.Name, "name", "", "name")
+	flags.StringVar(&req.Gender, "gender", "", "gender")
+	flags.StringVar(&req.BirthDate, "born", "", "birth date, YYYY-MM-DD")
+	flags.Parse(args)
+	return req
+}
+
+func actorError(id int, err error) error {
+	if err == errNotFound {
+		return fmt.Errorf("actor %d not found", id)
+	}
+	return err
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"io"
+
+	"github.com/Rbd3178/filmDatabase/pkg/client"
+)
+
+var (
+	errNotFound      = errors.New("not found")
+	errAlreadyExists = errors.New("already exists")
+)
+
+// backend is what the commands need, served either by the database directly
+// or by a running API server.
+type backend interface {
+	CreateUser(login, password string, isAdmin bool) error
+	SetAdmin(login string, isAdmin bool) error
+	SetPassword(login, password string) error
+	Users() ([]client.User, error)
+
+	Films(*client.FilmQuery) ([]client.Film, error)
+	Film(int) (*client.Film, error)
+	CreateFilm(*client.FilmRequest) (int, error)
+	ModifyFilm(int, *client.FilmRequest) error
+	DeleteFilm(int) error
+
+	Actors(*client.ActorQuery) ([]client.Actor, error)
+	Actor(int) (*client.Actor, error)
+	CreateActor(*client.ActorRequest) (int, error)
+	ModifyActor(int, *client.ActorRequest) error
+	DeleteActor(int) error
+
+	Import(entity, format string, r io.Reader, dryRun bool) (*client.ImportReport, error)
+	Export(format string, actors, films bool, w io.Writer) error
+
+	Close() error
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/catalog"
+)
+
+func runImport(b backend, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	entity := flags.String("entity", "", "actor or film, may be omitted when every row has an entity field")
+	format := flags.String("format", "", "json, csv or ndjson, guessed from the file extension when empty")
+	dryRun := flags.Bool("dry-run", false, "validate without storing anything")
+	flags.Parse(args)
+
+	var r io.Reader = os.Stdin
+	path := flags.Arg(0)
+	if path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	if *format == "" {
+		*format = formatFromPath(path)
+	}
+
+	report, err := b.Import(*entity, *format, r, *dryRun)
+	if err != nil {
+		return err
+	}
+	return printImportReport(report)
+}
+
+func runExport(b backend, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", "", "json, csv or ndjson, guessed from the file extension when empty")
+	entities := flags.String("entities", "films,actors", "comma separated entities to export")
+	flags.Parse(args)
+
+	var actors, films bool
+	for _, entity := range strings.Split(*entities, ",") {
+		switch strings.TrimSpace(entity) {
+		case "actors":
+			actors = true
+		case "films":
+			films = true
+		default:
+			return fmt.Errorf("unknown entity %q", entity)
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	path := flags.Arg(0)
+	if path != "" && path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if *format == "" {
+		*format = formatFromPath(path)
+	}
+
+	return b.Export(*format, actors, films, w)
+}
+
+// formatFromPath guesses the format by extension, defaulting to JSON.
+func formatFromPath(path string) string {
+	switch {
+	case strings.HasSuffix(path, ".csv"):
+		return catalog.FormatCSV
+	case strings.HasSuffix(path, ".ndjson"), strings.HasSuffix(path, ".jsonl"):
+		return catalog.FormatNDJSON
+	}
+	return catalog.FormatJSON
+}
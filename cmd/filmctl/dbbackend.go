@@ -0,0 +1,271 @@
+package main
+
+import (
+	"io"
+	"strings"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/apiserver"
+	"github.com/Rbd3178/filmDatabase/internal/app/catalog"
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/Rbd3178/filmDatabase/pkg/client"
+)
+
+const importChunkSize = 500
+
+// dbAuthor is recorded in the audit trail for changes made with --database.
+var dbAuthor = models.Author{Login: "filmctl"}
+
+// dbBackend works on the database directly, bypassing the API and its roles.
+type dbBackend struct {
+	database store.Store
+	close    func() error
+}
+
+func newDBBackend(databaseURL string) (*dbBackend, error) {
+	database, closeDatabase, err := apiserver.OpenDatabase(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dbBackend{database: database, close: closeDatabase}, nil
+}
+
+func (b *dbBackend) Close() error {
+	return b.close()
+}
+
+func (b *dbBackend) CreateUser(login, password string, isAdmin bool) error {
+	done, err := b.database.User().Create(&models.UserRequest{Login: login, Password: password, IsAdmin: isAdmin}, dbAuthor)
+	if err != nil {
+		return err
+	}
+	if !done {
+		return errAlreadyExists
+	}
+	return nil
+}
+
+func (b *dbBackend) SetAdmin(login string, isAdmin bool) error {
+	return found(b.database.User().SetAdmin(login, isAdmin, dbAuthor))
+}
+
+func (b *dbBackend) SetPassword(login, password string) error {
+	return found(b.database.User().SetPassword(login, password, dbAuthor))
+}
+
+func (b *dbBackend) Users() ([]client.User, error) {
+	users, err := b.database.User().GetAll()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]client.User, 0, len(users))
+	for _, u := range users {
+		list = append(list, client.User{Login: u.Login, IsAdmin: u.IsAdmin})
+	}
+	return list, nil
+}
+
+func (b *dbBackend) Films(q *client.FilmQuery) ([]client.Film, error) {
+	if q.Fuzzy {
+		return clientFilms(b.database.Film().FuzzySearch(q.SearchTitle, q.SearchActor, threshold(q.Threshold)))
+	}
+
+	filter := &store.FilmFilter{
+		SearchTitle:    q.SearchTitle,
+		SearchActor:    q.SearchActor,
+		RatingMin:      q.RatingMin,
+		RatingMax:      q.RatingMax,
+		ReleasedAfter:  q.ReleasedAfter,
+		ReleasedBefore: q.ReleasedBefore,
+		ActorIDs:       q.ActorIDs,
+		ActorMatch:     q.ActorMatch,
+		HasDescription: q.HasDescription,
+		Sort:           sortFields(q.Sort),
+	}
+	if filter.ActorMatch == "" {
+		filter.ActorMatch = store.ActorMatchAny
+	}
+	if len(filter.Sort) == 0 {
+		filter.Sort = []store.SortField{{Field: "rating", Desc: true}}
+	}
+	return clientFilms(b.database.Film().GetAll(filter))
+}
+
+func (b *dbBackend) Film(id int) (*client.Film, error) {
+	film, err := b.database.Film().Find(id)
+	if err == store.ErrRecordNotFound {
+		return nil, errNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	film.ID = id
+	return clientFilm(film), nil
+}
+
+func (b *dbBackend) CreateFilm(req *client.FilmRequest) (int, error) {
+	return b.database.Film().Create((*models.FilmRequest)(req), dbAuthor)
+}
+
+func (b *dbBackend) ModifyFilm(id int, req *client.FilmRequest) error {
+	return found(b.database.Film().Modify(id, (*models.FilmRequest)(req), dbAuthor))
+}
+
+func (b *dbBackend) DeleteFilm(id int) error {
+	return found(b.database.Film().Delete(id, dbAuthor))
+}
+
+func (b *dbBackend) Actors(q *client.ActorQuery) ([]client.Actor, error) {
+	if q.Fuzzy {
+		return clientActors(b.database.Actor().FuzzySearch(q.SearchName, threshold(q.Threshold)))
+	}
+
+	return clientActors(b.database.Actor().GetAll(&store.ActorFilter{
+		SearchName: q.SearchName,
+		Gender:     q.Gender,
+		BornAfter:  q.BornAfter,
+		BornBefore: q.BornBefore,
+		Sort:       sortFields(q.Sort),
+	}))
+}
+
+func (b *dbBackend) Actor(id int) (*client.Actor, error) {
+	actor, err := b.database.Actor().Find(id)
+	if err == store.ErrRecordNotFound {
+		return nil, errNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	actor.ID = id
+	return clientActor(actor), nil
+}
+
+func (b *dbBackend) CreateActor(req *client.ActorRequest) (int, error) {
+	return b.database.Actor().Create((*models.ActorRequest)(req), dbAuthor)
+}
+
+func (b *dbBackend) ModifyActor(id int, req *client.ActorRequest) error {
+	return found(b.database.Actor().Modify(id, (*models.ActorRequest)(req), dbAuthor))
+}
+
+func (b *dbBackend) DeleteActor(id int) error {
+	return found(b.database.Actor().Delete(id, dbAuthor))
+}
+
+func (b *dbBackend) Import(entity, format string, r io.Reader, dryRun bool) (*client.ImportReport, error) {
+	reader, err := catalog.NewReader(format, r, entity)
+	if err != nil {
+		return nil, err
+	}
+	report, err := catalog.NewImporter(b.database.Catalog(), dbAuthor, importChunkSize, dryRun).Import(reader)
+	if err != nil {
+		return nil, err
+	}
+	errs := make([]client.ImportError, 0, len(report.Errors))
+	for _, e := range report.Errors {
+		errs = append(errs, client.ImportError(e))
+	}
+	return &client.ImportReport{
+		DryRun: report.DryRun,
+		Rows:   report.Rows,
+		Actors: client.ImportResult(report.Actors),
+		Films:  client.ImportResult(report.Films),
+		Errors: errs,
+	}, nil
+}
+
+func (b *dbBackend) Export(format string, actors, films bool, w io.Writer) error {
+	writer, err := catalog.NewWriter(format, w)
+	if err != nil {
+		return err
+	}
+	if err := catalog.Export(b.database.Catalog(), writer, actors, films); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// found turns the done flag of the repositories into errNotFound.
+func found(done bool, err error) error {
+	if err != nil {
+		return err
+	}
+	if !done {
+		return errNotFound
+	}
+	return nil
+}
+
+func sortFields(sort []string) []store.SortField {
+	var fields []store.SortField
+	for _, field := range sort {
+		fields = append(fields, store.SortField{
+			Field: strings.TrimPrefix(field, "-"),
+			Desc:  strings.HasPrefix(field, "-"),
+		})
+	}
+	return fields
+}
+
+func threshold(value float64) float64 {
+	if value == 0 {
+		return defaultThreshold
+	}
+	return value
+}
+
+// The commands work on the client's types, the database on the internal
+// models.
+
+func clientFilms(films []models.Film, err error) ([]client.Film, error) {
+	if err != nil {
+		return nil, err
+	}
+	list := make([]client.Film, 0, len(films))
+	for i := range films {
+		list = append(list, *clientFilm(&films[i]))
+	}
+	return list, nil
+}
+
+func clientFilm(f *models.Film) *client.Film {
+	actors := make([]client.ActorBasic, 0, len(f.Actors))
+	for _, a := range f.Actors {
+		actors = append(actors, client.ActorBasic(a))
+	}
+	return &client.Film{
+		ID:          f.ID,
+		Title:       f.Title,
+		Description: f.Description,
+		ReleaseDate: f.ReleaseDate,
+		Rating:      f.Rating,
+		Actors:      actors,
+	}
+}
+
+func clientActors(actors []models.Actor, err error) ([]client.Actor, error) {
+	if err != nil {
+		return nil, err
+	}
+	list := make([]client.Actor, 0, len(actors))
+	for i := range actors {
+		list = append(list, *clientActor(&actors[i]))
+	}
+	return list, nil
+}
+
+func clientActor(a *models.Actor) *client.Actor {
+	films := make([]client.FilmBasic, 0, len(a.Films))
+	for _, f := range a.Films {
+		films = append(films, client.FilmBasic(f))
+	}
+	return &client.Actor{
+		ID:        a.ID,
+		Name:      a.Name,
+		Gender:    a.Gender,
+		BirthDate: a.BirthDate,
+		Films:     films,
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/pkg/client"
+)
+
+func runFilms(b backend, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("films: missing subcommand")
+	}
+
+	switch args[0] {
+	case "list":
+		flags := flag.NewFlagSet("films list", flag.ExitOnError)
+		q := &client.FilmQuery{}
+		flags.StringVar(&q.SearchTitle, "search-title", "", "fragment of the title")
+		flags.StringVar(&q.SearchActor, "search-actor", "", "fragment of an actor's name")
+		flags.BoolVar(&q.Fuzzy, "fuzzy", false, "typo tolerant search by -search-title or -search-actor")
+		sort := flags.String("sort", "", `comma separated fields, "-" for descending, e.g. -rating,title`)
+		flags.Parse(args[1:])
+		if *sort != "" {
+			q.Sort = strings.Split(*sort, ",")
+		}
+
+		films, err := b.Films(q)
+		if err != nil {
+			return err
+		}
+		return printFilms(films)
+
+	case "show":
+		id, err := idArg("films show", args[1:])
+		if err != nil {
+			return err
+		}
+		film, err := b.Film(id)
+		if err != nil {
+			return filmError(id, err)
+		}
+		return printFilm(film)
+
+	case "add":
+		req, err := parseFilmRequest("films add", args[1:])
+		if err != nil {
+			return err
+		}
+		if !(*models.FilmRequest)(req).ValidateForInsert() {
+			return fmt.Errorf("title must be 1 to 150 characters, description up to 1000, rating 0 to 10 and release date YYYY-MM-DD")
+		}
+		id, err := b.CreateFilm(req)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("film %d created\n", id)
+
+	case "edit":
+		id, err := idArg("films edit", args[1:2])
+		if err != nil {
+			return err
+		}
+		req, err := parseFilmRequest("films edit", args[2:])
+		if err != nil {
+			return err
+		}
+		if !(*models.FilmRequest)(req).ValidateForUpdate() {
+			return fmt.Errorf("title must be up to 150 characters, description up to 1000, rating 0 to 10 and release date YYYY-MM-DD")
+		}
+		if err := b.ModifyFilm(id, req); err != nil {
+			return filmError(id, err)
+		}
+		fmt.Printf("film %d modified\n", id)
+
+	case "delete":
+		id, err := idArg("films delete", args[1:])
+		if err != nil {
+			return err
+		}
+		if err := b.DeleteFilm(id); err != nil {
+			return filmError(id, err)
+		}
+		fmt.Printf("film %d deleted\n", id)
+
+	default:
+		return fmt.Errorf("films: unknown subcommand %q", args[0])
+	}
+
+	return nil
+}
+
+// parseFilmRequest reads the film flags, unset ones stay empty so that edit
+// leaves them unchanged.
+func parseFilmRequest(name string, args []string) (*client.FilmRequest, error) {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	req := &client.FilmRequest{}
+	flags.StringVar(&req.Title, "title", "", "title")
+	flags.StringVar(&req.Description, "description", "", "description")
+	flags.StringVar(&req.ReleaseDate, "released", "", "release date, YYYY-MM-DD")
+	flags.Float64Var(&req.Rating, "rating", 0, "rating from 0 to 10")
+	actors := flags.String("actors", "", "comma separated actor ids, replaces the cast")
+	flags.Parse(args)
+
+	if *actors != "" {
+		for _, rawID := range strings.Split(*actors, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(rawID))
+			if err != nil {
+				return nil, fmt.Errorf("invalid actor id %q", rawID)
+			}
+			req.ActorsIDs = append(req.ActorsIDs, id)
+		}
+	}
+
+	return req, nil
+}
+
+func filmError(id int, err error) error {
+	if err == errNotFound {
+		return fmt.Errorf("film %d not found", id)
+	}
+	return err
+}
+
+func idArg(name string, args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("usage: %s <id>", name)
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q", args[0])
+	}
+	return id, nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/Rbd3178/filmDatabase/pkg/client"
+)
+
+// httpBackend goes through a running API server with the given credentials,
+// so the server's role checks apply.
+type httpBackend struct {
+	client *client.Client
+	ctx    context.Context
+}
+
+func newHTTPBackend(baseURL, login, password string) (*httpBackend, error) {
+	var opts []client.Option
+	if login != "" {
+		opts = append(opts, client.WithBasicAuth(login, password))
+	}
+	c, err := client.New(baseURL, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &httpBackend{client: c, ctx: context.Background()}, nil
+}
+
+func (b *httpBackend) Close() error {
+	return nil
+}
+
+func (b *httpBackend) CreateUser(login, password string, isAdmin bool) error {
+	return convert(b.client.CreateUser(b.ctx, login, password, isAdmin))
+}
+
+func (b *httpBackend) SetAdmin(login string, isAdmin bool) error {
+	return convert(b.client.UpdateUser(b.ctx, login, &client.UserUpdate{IsAdmin: &isAdmin}))
+}
+
+func (b *httpBackend) SetPassword(login, password string) error {
+	return convert(b.client.UpdateUser(b.ctx, login, &client.UserUpdate{Password: &password}))
+}
+
+func (b *httpBackend) Users() ([]client.User, error) {
+	users, err := b.client.Users(b.ctx)
+	return users, convert(err)
+}
+
+func (b *httpBackend) Films(q *client.FilmQuery) ([]client.Film, error) {
+	films, err := b.client.Films(b.ctx, q)
+	return films, convert(err)
+}
+
+func (b *httpBackend) Film(id int) (*client.Film, error) {
+	film, err := b.client.Film(b.ctx, id)
+	if err != nil {
+		return nil, convert(err)
+	}
+	film.ID = id
+	return film, nil
+}
+
+func (b *httpBackend) CreateFilm(req *client.FilmRequest) (int, error) {
+	id, err := b.client.CreateFilm(b.ctx, req)
+	return id, convert(err)
+}
+
+func (b *httpBackend) ModifyFilm(id int, req *client.FilmRequest) error {
+	return convert(b.client.UpdateFilm(b.ctx, id, req))
+}
+
+func (b *httpBackend) DeleteFilm(id int) error {
+	return convert(b.client.DeleteFilm(b.ctx, id))
+}
+
+func (b *httpBackend) Actors(q *client.ActorQuery) ([]client.Actor, error) {
+	actors, err := b.client.Actors(b.ctx, q)
+	return actors, convert(err)
+}
+
+func (b *httpBackend) Actor(id int) (*client.Actor, error) {
+	actor, err := b.client.Actor(b.ctx, id)
+	if err != nil {
+		return nil, convert(err)
+	}
+	actor.ID = id
+	return actor, nil
+}
+
+func (b *httpBackend) CreateActor(req *client.ActorRequest) (int, error) {
+	id, err := b.client.CreateActor(b.ctx, req)
+	return id, convert(err)
+}
+
+func (b *httpBackend) ModifyActor(id int, req *client.ActorRequest) error {
+	return convert(b.client.UpdateActor(b.ctx, id, req))
+}
+
+func (b *httpBackend) DeleteActor(id int) error {
+	return convert(b.client.DeleteActor(b.ctx, id))
+}
+
+func (b *httpBackend) Import(entity, format string, r io.Reader, dryRun bool) (*client.ImportReport, error) {
+	report, err := b.client.Import(b.ctx, entity, format, r, dryRun)
+	return report, convert(err)
+}
+
+func (b *httpBackend) Export(format string, actors, films bool, w io.Writer) error {
+	var entities []string
+	if actors {
+		entities = append(entities, "actors")
+	}
+	if films {
+		entities = append(entities, "films")
+	}
+
+	body, err := b.client.Export(b.ctx, format, entities...)
+	if err != nil {
+		return convert(err)
+	}
+	defer body.Close()
+
+	_, err = io.Copy(w, body)
+	return err
+}
+
+// convert maps API errors to the ones the database backend returns, so the
+// commands report both the same way.
+func convert(err error) error {
+	switch {
+	case errors.Is(err, client.ErrNotFound):
+		return errNotFound
+	case errors.Is(err, client.ErrConflict):
+		return errAlreadyExists
+	}
+	return err
+}
@@ -0,0 +1,122 @@
+// Command filmctl manages users, films and actors either directly in the
+// database or through a running API server.
+//
+//	filmctl [global flags] users create|promote|demote|reset-password|list
+//	filmctl [global flags] films list|show|add|edit|delete
+//	filmctl [global flags] actors list|show|add|edit|delete
+//	filmctl [global flags] import|export
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/BurntSushi/toml"
+	"github.com/Rbd3178/filmDatabase/internal/app/apiserver"
+)
+
+const defaultThreshold = 0.4
+
+var (
+	configPath string
+	apiURL     string
+	login      string
+	password   string
+	output     string
+)
+
+func init() {
+	flag.StringVar(&configPath, "config-path", "configs/apiserver.toml", "path to configuration file, used to reach the database when -url is empty")
+	flag.StringVar(&apiURL, "url", "", "API server address, e.g. http://localhost:8080; the database is used directly when empty")
+	flag.StringVar(&login, "user", "", "login for the API server")
+	flag.StringVar(&password, "password", "", "password for the API server, $FILMCTL_PASSWORD when empty")
+	flag.StringVar(&output, "output", outputTable, "output format: table or json")
+	flag.Usage = usage
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), `Usage: filmctl [flags] <command> [arguments]
+
+Commands:
+  users create -login <login> [-password <password>] [-admin]
+  users promote|demote <login>
+  users reset-password -login <login> [-password <password>]
+  users list
+  films list [-search-title] [-search-actor] [-fuzzy] [-sort]
+  films show <id>
+  films add -title <title> -released <date> [-description] [-rating] [-actors 1,2]
+  films edit <id> [-title] [-released] [-description] [-rating] [-actors 1,2]
+  films delete <id>
+  actors list [-search-name] [-gender] [-fuzzy] [-sort]
+  actors show <id>
+  actors add -name <name> -born <date> [-gender]
+  actors edit <id> [-name] [-born] [-gender]
+  actors delete <id>
+  import [-entity actor|film] [-format json|csv|ndjson] [-dry-run] [file]
+  export [-format json|csv|ndjson] [-entities films,actors] [file]
+
+Passwords not given as flags are read from standard input.
+
+Flags:
+`)
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	if output != outputTable && output != outputJSON {
+		fatalf("unknown output format %q", output)
+	}
+
+	b, err := openBackend()
+	if err != nil {
+		fatalf("%v", err)
+	}
+	defer b.Close()
+
+	if err := run(b, flag.Args()); err != nil {
+		b.Close()
+		fatalf("%v", err)
+	}
+}
+
+func openBackend() (backend, error) {
+	if apiURL != "" {
+		if password == "" {
+			password = os.Getenv("FILMCTL_PASSWORD")
+		}
+		return newHTTPBackend(apiURL, login, password)
+	}
+
+	config := apiserver.NewConfig()
+	if _, err := toml.DecodeFile(configPath, config); err != nil {
+		return nil, err
+	}
+	return newDBBackend(config.DatabaseURL)
+}
+
+func run(b backend, args []string) error {
+	switch args[0] {
+	case "users":
+		return runUsers(b, args[1:])
+	case "films":
+		return runFilms(b, args[1:])
+	case "actors":
+		return runActors(b, args[1:])
+	case "import":
+		return runImport(b, args[1:])
+	case "export":
+		return runExport(b, args[1:])
+	}
+	return fmt.Errorf("unknown command %q, see filmctl -h", args[0])
+}
+
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "filmctl: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Rbd3178/filmDatabase/pkg/client"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// printTable writes tab separated rows as aligned columns.
+func printTable(header string, rows []string) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, header)
+	for _, row := range rows {
+		fmt.Fprintln(w, row)
+	}
+	return w.Flush()
+}
+
+func printUsers(users []client.User) error {
+	if output == outputJSON {
+		return printJSON(users)
+	}
+
+	rows := make([]string, 0, len(users))
+	for _, u := range users {
+		rows = append(rows, fmt.Sprintf("%s\t%t", u.Login, u.IsAdmin))
+	}
+	return printTable("LOGIN\tADMIN", rows)
+}
+
+func printFilms(films []client.Film) error {
+	if output == outputJSON {
+		return printJSON(films)
+	}
+
+	rows := make([]string, 0, len(films))
+	for _, f := range films {
+		rows = append(rows, fmt.Sprintf("%d\t%s\t%s\t%s\t%d", f.ID, f.Title, f.ReleaseDate, formatRating(f.Rating), len(f.Actors)))
+	}
+	return printTable("ID\tTITLE\tRELEASED\tRATING\tACTORS", rows)
+}
+
+func printFilm(film *client.Film) error {
+	if output == outputJSON {
+		return printJSON(film)
+	}
+
+	actors := make([]string, 0, len(film.Actors))
+	for _, a := range film.Actors {
+		actors = append(actors, fmt.Sprintf("%s (%d)", a.Name, a.ActorID))
+	}
+	return printTable("FIELD\tVALUE", []string{
+		fmt.Sprintf("id\t%d", film.ID),
+		fmt.Sprintf("title\t%s", film.Title),
+		fmt.Sprintf("description\t%s", film.Description),
+		fmt.Sprintf("released\t%s", film.ReleaseDate),
+		fmt.Sprintf("rating\t%s", formatRating(film.Rating)),
+		fmt.Sprintf("actors\t%s", strings.Join(actors, ", ")),
+	})
+}
+
+func printActors(actors []client.Actor) error {
+	if output == outputJSON {
+		return printJSON(actors)
+	}
+
+	rows := make([]string, 0, len(actors))
+	for _, a := range actors {
+		rows = append(rows, fmt.Sprintf("%d\t%s\t%s\t%s\t%d", a.ID, a.Name, a.Gender, a.BirthDate, len(a.Films)))
+	}
+	return printTable("ID\tNAME\tGENDER\tBORN\tFILMS", rows)
+}
+
+func printActor(actor *client.Actor) error {
+	if output == outputJSON {
+		return printJSON(actor)
+	}
+
+	films := make([]string, 0, len(actor.Films))
+	for _, f := range actor.Films {
+		films = append(films, fmt.Sprintf("%s (%d)", f.Title, f.FilmID))
+	}
+	return printTable("FIELD\tVALUE", []string{
+		fmt.Sprintf("id\t%d", actor.ID),
+		fmt.Sprintf("name\t%s", actor.Name),
+		fmt.Sprintf("gender\t%s", actor.Gender),
+		fmt.Sprintf("born\t%s", actor.BirthDate),
+		fmt.Sprintf("films\t%s", strings.Join(films, ", ")),
+	})
+}
+
+func printImportReport(report *client.ImportReport) error {
+	if output == outputJSON {
+		return printJSON(report)
+	}
+
+	rows := []string{
+		fmt.Sprintf("actors\t%d\t%d", report.Actors.Created, report.Actors.Updated),
+		fmt.Sprintf("films\t%d\t%d", report.Films.Created, report.Films.Updated),
+	}
+	if err := printTable("ENTITY\tCREATED\tUPDATED", rows); err != nil {
+		return err
+	}
+	if report.DryRun {
+		fmt.Println("dry run, nothing was stored")
+	}
+	for _, e := range report.Errors {
+		fmt.Printf("row %d: %s\n", e.Row, e.Message)
+	}
+	return nil
+}
+
+func formatRating(rating float64) string {
+	return strconv.FormatFloat(rating, 'f', -1, 64)
+}
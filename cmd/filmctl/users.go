@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"golang.org/x/term"
+)
+
+func runUsers(b backend, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("users: missing subcommand")
+	}
+
+	switch args[0] {
+	case "create":
+		flags := flag.NewFlagSet("users create", flag.ExitOnError)
+		userLogin := flags.String("login", "", "login of the new user")
+		userPassword := flags.String("password", "", "password of the new user, read from standard input when empty")
+		admin := flags.Bool("admin", false, "make the user an administrator")
+		flags.Parse(args[1:])
+
+		req := &models.UserRequest{Login: *userLogin, Password: *userPassword, IsAdmin: *admin}
+		if req.Password == "" {
+			req.Password = readPassword()
+		}
+		if !req.Validate() {
+			return fmt.Errorf("login must be 1 to 50 characters, password 6 to 50")
+		}
+		if err := b.CreateUser(req.Login, req.Password, req.IsAdmin); err != nil {
+			return userError(req.Login, err)
+		}
+		fmt.Printf("user %s created\n", req.Login)
+
+	case "promote", "demote":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: users %s <login>", args[0])
+		}
+		if err := b.SetAdmin(args[1], args[0] == "promote"); err != nil {
+			return userError(args[1], err)
+		}
+		fmt.Printf("user %s %sd\n", args[1], args[0])
+
+	case "reset-password":
+		flags := flag.NewFlagSet("users reset-password", flag.ExitOnError)
+		userLogin := flags.String("login", "", "login of the user")
+		userPassword := flags.String("password", "", "new password, read from standard input when empty")
+		flags.Parse(args[1:])
+
+		update := &models.UserUpdate{Password: userPassword}
+		if *update.Password == "" {
+			*update.Password = readPassword()
+		}
+		if !update.Validate() {
+			return fmt.Errorf("password must be 6 to 50 characters")
+		}
+		if err := b.SetPassword(*userLogin, *update.Password); err != nil {
+			return userError(*userLogin, err)
+		}
+		fmt.Printf("password of %s reset\n", *userLogin)
+
+	case "list":
+		users, err := b.Users()
+		if err != nil {
+			return err
+		}
+		return printUsers(users)
+
+	default:
+		return fmt.Errorf("users: unknown subcommand %q", args[0])
+	}
+
+	return nil
+}
+
+func userError(login string, err error) error {
+	switch err {
+	case errNotFound:
+		return fmt.Errorf("user %s not found", login)
+	case errAlreadyExists:
+		return fmt.Errorf("login %s already taken", login)
+	}
+	return err
+}
+
+// readPassword reads a password from standard input, without echoing it when
+// standard input is a terminal.
+func readPassword() string {
+	fmt.Fprint(os.Stderr, "password: ")
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		password, _ := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return string(password)
+	}
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimRight(line, "\r\n")
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/Rbd3178/filmDatabase/internal/app/apiserver"
+	"github.com/Rbd3178/filmDatabase/internal/app/imdb"
+)
+
+var (
+	configPath string
+	dataDir    string
+	titleTypes string
+	chunkSize  int
+	checkpoint string
+)
+
+func init() {
+	flag.StringVar(&configPath, "config-path", "configs/apiserver.toml", "path to configuration file")
+	flag.StringVar(&dataDir, "data-dir", ".", "directory with title.basics.tsv, name.basics.tsv and title.principals.tsv, gzipped or not")
+	flag.StringVar(&titleTypes, "title-types", "movie", "comma separated title types to import as films")
+	flag.IntVar(&chunkSize, "chunk-size", 1000, "number of rows stored in one transaction")
+	flag.StringVar(&checkpoint, "checkpoint", "imdbimport.checkpoint.json", "file to keep progress in, empty to always start over")
+}
+
+func main() {
+	flag.Parse()
+
+	config := apiserver.NewConfig()
+	_, err := toml.DecodeFile(configPath, config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	database, closeDatabase, err := apiserver.OpenDatabase(config.DatabaseURL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeDatabase()
+
+	loader := imdb.NewLoader(
+		&imdb.Config{
+			TitleBasics:     datasetFile("title.basics.tsv"),
+			NameBasics:      datasetFile("name.basics.tsv"),
+			TitlePrincipals: datasetFile("title.principals.tsv"),
+			TitleTypes:      strings.Split(titleTypes, ","),
+			ChunkSize:       chunkSize,
+			Checkpoint:      checkpoint,
+		},
+		database,
+		func(entity string, done, total int) {
+			log.Printf("%s: %d/%d", entity, done, total)
+		},
+	)
+
+	log.Printf("reading dataset from %s", dataDir)
+	stats, err := loader.Run()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if stats.StaleCheckpoint {
+		log.Printf("the checkpoint in %s was made from other files, started over", checkpoint)
+	}
+	for _, link := range stats.DroppedCast {
+		log.Printf("dropped %s from the cast of %s, the person was not stored", link.Actor, link.Film)
+	}
+	log.Printf(
+		"done: %d actors and %d films stored, %d actors and %d films resumed from checkpoint, %d actors and %d films skipped as invalid, %d cast links dropped",
+		stats.Actors, stats.Films, stats.ResumedActors, stats.ResumedFilms, stats.SkippedActors, stats.SkippedFilms, len(stats.DroppedCast),
+	)
+}
+
+// datasetFile prefers the file as downloaded, falling back to the unpacked one.
+func datasetFile(name string) string {
+	path := filepath.Join(dataDir, name)
+	if matches, _ := filepath.Glob(path + ".gz"); len(matches) > 0 {
+		return matches[0]
+	}
+	return path
+}
@@ -1,25 +1,138 @@
 package apiserver
 
 import (
+	"net"
 	"net/http"
+	"strings"
 
+	"github.com/Rbd3178/filmDatabase/internal/app/auth"
+	"github.com/Rbd3178/filmDatabase/internal/app/grpcapi"
+	"github.com/Rbd3178/filmDatabase/internal/app/hasher"
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/cache"
 	"github.com/Rbd3178/filmDatabase/internal/app/store/postgres"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/sqlite"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/testdb"
+	"github.com/Rbd3178/filmDatabase/internal/app/webhook"
 	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
 )
 
-// Start
+// Start serves the REST API and, unless GRPCPort is empty, the gRPC API until
+// either of them fails.
 func Start(config *Config) error {
-	db, err := newDB(config.DatabaseURL)
+	if err := hasher.SetCost(config.BcryptCost); err != nil {
+		return err
+	}
+	database, closeStore, err := openStore(config)
 	if err != nil {
 		return err
 	}
-	defer db.Close()
+	defer closeStore()
 
-	database := postgres.New(db)
+	if config.Cache {
+		database = cache.New(database, config.CacheSize, config.CacheTTL)
+	}
 	srv := newServer(database)
-	return http.ListenAndServe(config.Port, srv)
+	srv.credentials = auth.NewVerifier(database.User(), config.CredentialCacheSize, config.CredentialCacheTTL)
+
+	if config.TrashRetention > 0 {
+		go srv.purgeTrash(config.TrashRetention, trashPurgeInterval)
+	}
+	if config.ChangeRetention > 0 {
+		go srv.purgeChanges(config.ChangeRetention, changePurgeInterval)
+	}
+	if config.WebhookInterval > 0 {
+		client := &http.Client{Timeout: webhookTimeout}
+		dispatcher := webhook.NewDispatcher(database.Webhook(), client, srv.logger, webhookBackoff, webhookMaxAttempts)
+		go dispatcher.Run(config.WebhookInterval)
+	}
+
+	errs := make(chan error, 2)
+	if config.GRPCPort != "" {
+		listener, err := net.Listen("tcp", config.GRPCPort)
+		if err != nil {
+			return err
+		}
+		grpcServer := grpcapi.New(database, srv.credentials, srv.logger, srv.suggestions.clear)
+		defer grpcServer.Stop()
+		go func() {
+			errs <- grpcServer.Serve(listener)
+		}()
+	}
+	go func() {
+		errs <- http.ListenAndServe(config.Port, srv)
+	}()
+
+	return <-errs
+}
+
+// NewHandler returns the REST API on top of database without listening
+// anywhere, e.g. to mount it on an httptest server.
+func NewHandler(database store.Store) http.Handler {
+	return newServer(database)
+}
+
+// openStore picks the store named in the config, the returned function
+// releases it.
+func openStore(config *Config) (store.Store, func() error, error) {
+	switch config.Store {
+	case "", "database", "postgres":
+		return OpenDatabase(config.DatabaseURL)
+	case "memory":
+		database := testdb.NewEmpty()
+		if err := createAdmin(database, config.AdminLogin, config.AdminPassword); err != nil {
+			return nil, nil, err
+		}
+		return database, func() error { return nil }, nil
+	}
+
+	return nil, nil, errors.Errorf("unknown store %q", config.Store)
 }
 
+// createAdmin adds an admin to a store that starts without users, if login
+// is set.
+func createAdmin(database store.Store, login string, password string) error {
+	if login == "" {
+		return nil
+	}
+	req := &models.UserRequest{Login: login, Password: password, IsAdmin: true}
+	if !req.Validate() {
+		return errors.New("invalid admin login or password")
+	}
+
+	author := models.Author{Login: login}
+	if _, err := database.User().Create(req, author); err != nil {
+		return errors.Wrap(err, "create admin")
+	}
+	if _, err := database.User().SetAdmin(login, true, author); err != nil {
+		return errors.Wrap(err, "create admin")
+	}
+
+	return nil
+}
+
+// OpenDatabase opens the store behind databaseURL: sqlite for sqlite://path
+// URLs and postgres for anything else. The returned function closes it.
+func OpenDatabase(databaseURL string) (store.Store, func() error, error) {
+	if path, ok := strings.CutPrefix(databaseURL, sqliteScheme); ok {
+		db, err := sqlite.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return sqlite.New(db), db.Close, nil
+	}
+
+	db, err := newDB(databaseURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	return postgres.New(db), db.Close, nil
+}
+
+const sqliteScheme = "sqlite://"
+
 func newDB(databaseURL string) (*sqlx.DB, error) {
 	db, err := sqlx.Open("postgres", databaseURL)
 	if err != nil {
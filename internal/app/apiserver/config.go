@@ -1,16 +1,65 @@
 package apiserver
 
+import (
+	"time"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/hasher"
+)
+
 // Config
 type Config struct {
-	Port     string `toml:"port"`
-	LogLevel string `toml:"log_level"`
+	Port        string `toml:"port"`
+	GRPCPort    string `toml:"grpc_port"`
+	LogLevel    string `toml:"log_level"`
 	DatabaseURL string `toml:"database_url"`
+	// Store is "database" or "memory", the latter keeps everything in the
+	// process and ignores DatabaseURL. A database is postgres unless the URL
+	// is sqlite://path; "postgres" is still accepted for "database".
+	Store string `toml:"store"`
+	// AdminLogin and AdminPassword create the first admin of the memory
+	// store, which starts without users. They are ignored by databases.
+	AdminLogin    string `toml:"admin_login"`
+	AdminPassword string `toml:"admin_password"`
+	// Cache keeps up to CacheSize films and actors found in the store in
+	// memory, each for at most CacheTTL.
+	Cache     bool          `toml:"cache"`
+	CacheSize int           `toml:"cache_size"`
+	CacheTTL  time.Duration `toml:"cache_ttl"`
+	// BcryptCost is used for new password hashes, older ones are rehashed
+	// when their users log in.
+	BcryptCost int `toml:"bcrypt_cost"`
+	// CredentialCacheSize successful logins are remembered for at most
+	// CredentialCacheTTL so that bcrypt does not run on every request, 0
+	// turns this off.
+	CredentialCacheSize int           `toml:"credential_cache_size"`
+	CredentialCacheTTL  time.Duration `toml:"credential_cache_ttl"`
+	// TrashRetention is how long deleted films and actors stay restorable
+	// before they are purged for good, 0 keeps them forever.
+	TrashRetention time.Duration `toml:"trash_retention"`
+	// ChangeRetention is how long change events stay in the feed for
+	// consumers to poll, 0 keeps them forever. Events are kept longer until
+	// every webhook has been given them.
+	ChangeRetention time.Duration `toml:"change_retention"`
+	// WebhookInterval is how often change events are sent to webhooks, 0
+	// turns sending off. Only one server per database should send them.
+	WebhookInterval time.Duration `toml:"webhook_interval"`
 }
 
 // NewConfig
 func NewConfig() *Config {
 	return &Config{
-		Port:     "8080",
-		LogLevel: "debug",
+		Port:      "8080",
+		GRPCPort:  ":9090",
+		LogLevel:  "debug",
+		Store:     "database",
+		CacheSize: 10000,
+		CacheTTL:  time.Minute,
+
+		BcryptCost:          hasher.DefaultCost,
+		CredentialCacheSize: credentialCacheSize,
+		CredentialCacheTTL:  credentialCacheTTL,
+		TrashRetention:      trashRetention,
+		ChangeRetention:     changeRetention,
+		WebhookInterval:     webhookInterval,
 	}
 }
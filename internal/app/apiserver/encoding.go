@@ -0,0 +1,333 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/pkg/errors"
+)
+
+// encoder renders a response body. name is the singular name of the
+// resource, formats that need element names derive them from it.
+type encoder struct {
+	mediaType string
+	encode    func(w io.Writer, name string, v any) error
+}
+
+// encoders are tried in order when the client accepts several types equally,
+// so the first one is the default.
+var encoders = []*encoder{
+	{mediaType: "application/json", encode: encodeJSON},
+	{mediaType: "text/csv", encode: encodeCSV},
+	{mediaType: "application/xml", encode: encodeXML},
+	ndjsonEncoder,
+}
+
+const mediaTypeNDJSON = "application/x-ndjson"
+
+var ndjsonEncoder = &encoder{mediaType: mediaTypeNDJSON, encode: encodeNDJSON}
+
+// negotiate picks the encoder for the Accept header, JSON when it is absent.
+func negotiate(accept string) (*encoder, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return encoders[0], true
+	}
+
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if rawQ, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(rawQ, 64)
+			if err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	for _, mr := range ranges {
+		for _, enc := range encoders {
+			if mediaTypeMatches(mr.mediaType, enc.mediaType) {
+				return enc, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func mediaTypeMatches(pattern, mediaType string) bool {
+	if pattern == "*/*" || pattern == mediaType {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(pattern, "/*")
+	return ok && strings.HasPrefix(mediaType, prefix+"/")
+}
+
+// respond writes v in the format the client asked for, or 406 when none of
+// the accepted formats is supported.
+func (s *server) respond(w http.ResponseWriter, r *http.Request, name string, v any) {
+	enc, ok := negotiate(r.Header.Get("Accept"))
+	if !ok {
+		http.Error(w, "Not acceptable", http.StatusNotAcceptable)
+		return
+	}
+
+	// Formats other than CSV render a list of records as the records alone.
+	if list, ok := v.(*recordList); ok && enc.mediaType != "text/csv" {
+		v = list.records
+	}
+
+	buf := &bytes.Buffer{}
+	if err := enc.encode(buf, name, v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when encoding response")
+		return
+	}
+	w.Header().Set("Content-Type", enc.mediaType)
+	w.Write(buf.Bytes())
+}
+
+func encodeJSON(w io.Writer, _ string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// encodeNDJSON writes every element of a list on its own line.
+func encodeNDJSON(w io.Writer, _ string, v any) error {
+	e := json.NewEncoder(w)
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Slice {
+		return e.Encode(v)
+	}
+	for i := 0; i < value.Len(); i++ {
+		if err := e.Encode(value.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ndjsonFlushEvery is how many streamed lines are sent to the client at once.
+const ndjsonFlushEvery = 100
+
+// iterator is the common part of the repository iterators.
+type iterator interface {
+	Next() bool
+	Err() error
+	Close() error
+}
+
+// streamNDJSON writes the values of it as NDJSON while reading them, so the
+// listing is never held in memory as a whole. Errors after the first line
+// can only cut the response short.
+func (s *server) streamNDJSON(w http.ResponseWriter, it iterator, value func() any) {
+	defer it.Close()
+
+	w.Header().Set("Content-Type", mediaTypeNDJSON)
+	flusher, _ := w.(http.Flusher)
+	e := json.NewEncoder(w)
+	count := 0
+	for it.Next() {
+		if err := e.Encode(value()); err != nil {
+			s.logger.WithError(err).Info("Error when streaming response")
+			return
+		}
+		count++
+		if flusher != nil && count%ndjsonFlushEvery == 0 {
+			flusher.Flush()
+		}
+	}
+	if err := it.Err(); err != nil {
+		s.logger.WithError(err).Info("Error when streaming response")
+		if count == 0 {
+			w.Header().Del("Content-Type")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+// encodeXML wraps lists into a root element named after the resource in
+// plural, e.g. <films><film>...</film></films>.
+func encodeXML(w io.Writer, name string, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	e := xml.NewEncoder(w)
+	item := xml.StartElement{Name: xml.Name{Local: name}}
+
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Slice {
+		if err := e.EncodeElement(v, item); err != nil {
+			return err
+		}
+		return e.Flush()
+	}
+
+	root := xml.StartElement{Name: xml.Name{Local: name + "s"}}
+	if err := e.EncodeToken(root); err != nil {
+		return err
+	}
+	for i := 0; i < value.Len(); i++ {
+		if err := e.EncodeElement(value.Index(i).Interface(), item); err != nil {
+			return err
+		}
+	}
+	if err := e.EncodeToken(root.End()); err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+// listSeparator joins nested lists in a single CSV cell.
+const listSeparator = "|"
+
+// encodeCSV writes one row per film, actor or user. Nested lists become two
+// columns with ids and names joined by listSeparator, in the same order.
+// Users are written without their password hashes.
+func encodeCSV(w io.Writer, _ string, v any) error {
+	var header []string
+	var rows [][]string
+
+	switch v := v.(type) {
+	case []models.Film:
+		header = filmCSVHeader
+		for i := range v {
+			rows = append(rows, filmCSVRow(&v[i]))
+		}
+	case *models.Film:
+		header = filmCSVHeader
+		rows = append(rows, filmCSVRow(v))
+	case []models.Actor:
+		header = actorCSVHeader
+		for i := range v {
+			rows = append(rows, actorCSVRow(&v[i]))
+		}
+	case *models.Actor:
+		header = actorCSVHeader
+		rows = append(rows, actorCSVRow(v))
+	case []models.User:
+		header = userCSVHeader
+		for i := range v {
+			rows = append(rows, userCSVRow(&v[i]))
+		}
+	case *recordList:
+		header = v.template.csvHeader()
+		for _, rec := range v.records {
+			rows = append(rows, rec.csvRow())
+		}
+	case *record:
+		header = v.csvHeader()
+		rows = append(rows, v.csvRow())
+	default:
+		return errors.Errorf("no CSV rendering for %T", v)
+	}
+
+	for _, row := range rows {
+		for i, cell := range row {
+			row[i] = csvSafe(cell)
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	cw.WriteAll(rows)
+	return cw.Error()
+}
+
+// csvSafe keeps spreadsheets from reading a cell as a formula, cells that
+// would start one get a leading quote.
+func csvSafe(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+var filmCSVHeader = []string{"id", "title", "description", "release_date", "rating", "actor_ids", "actor_names"}
+
+func filmCSVRow(f *models.Film) []string {
+	ids := make([]string, 0, len(f.Actors))
+	names := make([]string, 0, len(f.Actors))
+	for _, a := range f.Actors {
+		ids = append(ids, strconv.Itoa(a.ActorID))
+		names = append(names, a.Name)
+	}
+	return []string{
+		strconv.Itoa(f.ID),
+		f.Title,
+		f.Description,
+		f.ReleaseDate,
+		strconv.FormatFloat(f.Rating, 'f', -1, 64),
+		strings.Join(ids, listSeparator),
+		strings.Join(names, listSeparator),
+	}
+}
+
+var actorCSVHeader = []string{"id", "name", "gender", "birth_date", "film_ids", "film_titles"}
+
+func actorCSVRow(a *models.Actor) []string {
+	ids := make([]string, 0, len(a.Films))
+	titles := make([]string, 0, len(a.Films))
+	for _, f := range a.Films {
+		ids = append(ids, strconv.Itoa(f.FilmID))
+		titles = append(titles, f.Title)
+	}
+	return []string{
+		strconv.Itoa(a.ID),
+		a.Name,
+		a.Gender,
+		a.BirthDate,
+		strings.Join(ids, listSeparator),
+		strings.Join(titles, listSeparator),
+	}
+}
+
+var userCSVHeader = []string{"login", "is_admin"}
+
+func userCSVRow(u *models.User) []string {
+	return []string{u.Login, strconv.FormatBool(u.IsAdmin)}
+}
+
+// writeJSON responds with v as JSON whatever the request accepts.
+func (s *server) writeJSON(w http.ResponseWriter, v any) {
+	jsonData, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when marshalling json")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonData)
+}
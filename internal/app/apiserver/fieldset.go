@@ -0,0 +1,252 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+)
+
+var (
+	filmFields  = []string{"id", "title", "description", "release_date", "rating"}
+	actorFields = []string{"id", "name", "gender", "birth_date"}
+)
+
+// fieldset is what the client asked to see with ?fields= and ?expand=. When
+// neither is given the full representation is returned, as before they were
+// introduced.
+type fieldset struct {
+	fields []string
+	expand bool
+	sparse bool
+}
+
+// parseFieldset reads fields, a comma separated subset of allowed, and
+// expand, which may only name relation. The id is always part of the fields.
+func parseFieldset(query url.Values, allowed []string, relation string) (*fieldset, bool) {
+	fs := &fieldset{}
+
+	if rawExpand, ok := query["expand"]; ok {
+		fs.sparse = true
+		for _, part := range strings.Split(strings.Join(rawExpand, ","), ",") {
+			if strings.TrimSpace(part) != relation {
+				return nil, false
+			}
+		}
+		fs.expand = true
+	}
+
+	rawFields := query.Get("fields")
+	if rawFields == "" {
+		fs.fields = allowed
+		return fs, true
+	}
+	fs.sparse = true
+	fs.fields = []string{"id"}
+	for _, part := range strings.Split(rawFields, ",") {
+		field := strings.TrimSpace(part)
+		if !contains(allowed, field) {
+			return nil, false
+		}
+		if !contains(fs.fields, field) {
+			fs.fields = append(fs.fields, field)
+		}
+	}
+
+	return fs, true
+}
+
+// record is a sparse representation of a resource. It keeps the order of
+// its fields in every format.
+type record struct {
+	keys   []string
+	values []any
+}
+
+func (r *record) add(key string, value any) {
+	r.keys = append(r.keys, key)
+	r.values = append(r.values, value)
+}
+
+// filmRecord
+func (fs *fieldset) filmRecord(f *models.Film) *record {
+	rec := &record{}
+	for _, field := range fs.fields {
+		switch field {
+		case "id":
+			rec.add(field, f.ID)
+		case "title":
+			rec.add(field, f.Title)
+		case "description":
+			rec.add(field, f.Description)
+		case "release_date":
+			rec.add(field, f.ReleaseDate)
+		case "rating":
+			rec.add(field, f.Rating)
+		}
+	}
+	if fs.expand {
+		actors := f.Actors
+		if actors == nil {
+			actors = []models.ActorBasic{}
+		}
+		rec.add("actors", actors)
+	}
+	return rec
+}
+
+// actorRecord
+func (fs *fieldset) actorRecord(a *models.Actor) *record {
+	rec := &record{}
+	for _, field := range fs.fields {
+		switch field {
+		case "id":
+			rec.add(field, a.ID)
+		case "name":
+			rec.add(field, a.Name)
+		case "gender":
+			rec.add(field, a.Gender)
+		case "birth_date":
+			rec.add(field, a.BirthDate)
+		}
+	}
+	if fs.expand {
+		films := a.Films
+		if films == nil {
+			films = []models.FilmBasic{}
+		}
+		rec.add("films", films)
+	}
+	return rec
+}
+
+// recordList is a list of sparse records. Its CSV header comes from
+// template, so an empty list still names the projected fields.
+type recordList struct {
+	template *record
+	records  []*record
+}
+
+// filmRecords
+func (fs *fieldset) filmRecords(films []models.Film) *recordList {
+	list := &recordList{template: fs.filmRecord(&models.Film{}), records: make([]*record, 0, len(films))}
+	for i := range films {
+		list.records = append(list.records, fs.filmRecord(&films[i]))
+	}
+	return list
+}
+
+// actorRecords
+func (fs *fieldset) actorRecords(actors []models.Actor) *recordList {
+	list := &recordList{template: fs.actorRecord(&models.Actor{}), records: make([]*record, 0, len(actors))}
+	for i := range actors {
+		list.records = append(list.records, fs.actorRecord(&actors[i]))
+	}
+	return list
+}
+
+// MarshalJSON
+func (r *record) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+	for i, key := range r.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(r.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// MarshalXML writes lists as an element named after the key holding one
+// element per item, e.g. <actors><actor>...</actor></actors>.
+func (r *record) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for i, key := range r.keys {
+		element := xml.StartElement{Name: xml.Name{Local: key}}
+		value := reflect.ValueOf(r.values[i])
+		if value.Kind() != reflect.Slice {
+			if err := e.EncodeElement(r.values[i], element); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := e.EncodeToken(element); err != nil {
+			return err
+		}
+		item := xml.StartElement{Name: xml.Name{Local: strings.TrimSuffix(key, "s")}}
+		for j := 0; j < value.Len(); j++ {
+			if err := e.EncodeElement(value.Index(j).Interface(), item); err != nil {
+				return err
+			}
+		}
+		if err := e.EncodeToken(element.End()); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// csvHeader names the columns of the record, relations take two columns
+// like in the full CSV rendering.
+func (r *record) csvHeader() []string {
+	var header []string
+	for i, key := range r.keys {
+		switch r.values[i].(type) {
+		case []models.ActorBasic:
+			header = append(header, "actor_ids", "actor_names")
+		case []models.FilmBasic:
+			header = append(header, "film_ids", "film_titles")
+		default:
+			header = append(header, key)
+		}
+	}
+	return header
+}
+
+func (r *record) csvRow() []string {
+	var row []string
+	for _, value := range r.values {
+		switch value := value.(type) {
+		case []models.ActorBasic:
+			ids := make([]string, 0, len(value))
+			names := make([]string, 0, len(value))
+			for _, a := range value {
+				ids = append(ids, strconv.Itoa(a.ActorID))
+				names = append(names, a.Name)
+			}
+			row = append(row, strings.Join(ids, listSeparator), strings.Join(names, listSeparator))
+		case []models.FilmBasic:
+			ids := make([]string, 0, len(value))
+			titles := make([]string, 0, len(value))
+			for _, f := range value {
+				ids = append(ids, strconv.Itoa(f.FilmID))
+				titles = append(titles, f.Title)
+			}
+			row = append(row, strings.Join(ids, listSeparator), strings.Join(titles, listSeparator))
+		case int:
+			row = append(row, strconv.Itoa(value))
+		case float64:
+			row = append(row, strconv.FormatFloat(value, 'f', -1, 64))
+		case string:
+			row = append(row, value)
+		}
+	}
+	return row
+}
@@ -0,0 +1,274 @@
+package apiserver
+
+import (
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+var (
+	filmSortFields  = []string{"title", "rating", "release_date"}
+	actorSortFields = []string{"name", "birth_date", "film_count"}
+
+	// filmListParams are the GET /films parameters that fuzzy search does
+	// not support.
+	filmListParams = []string{
+		"rating_min", "rating_max", "released_after", "released_before",
+		"actor_id", "actor_match", "has_description", "include_deleted",
+		"sort", "orderby", "order",
+	}
+
+	// actorListParams are the GET /actors parameters that fuzzy search does
+	// not support.
+	actorListParams = []string{"gender", "born_after", "born_before", "include_deleted", "sort"}
+)
+
+// parseFilmFilter reads GET /films query parameters. The legacy orderby and
+// order parameters are used only when sort is absent.
+func parseFilmFilter(query url.Values) (*store.FilmFilter, bool) {
+	filter := &store.FilmFilter{
+		SearchTitle:    query.Get("searchtitle"),
+		SearchActor:    query.Get("searchactor"),
+		ReleasedAfter:  query.Get("released_after"),
+		ReleasedBefore: query.Get("released_before"),
+		ActorMatch:     query.Get("actor_match"),
+	}
+
+	var ok bool
+	if filter.RatingMin, ok = parseOptionalRating(query.Get("rating_min")); !ok {
+		return nil, false
+	}
+	if filter.RatingMax, ok = parseOptionalRating(query.Get("rating_max")); !ok {
+		return nil, false
+	}
+	if !validDate(filter.ReleasedAfter) || !validDate(filter.ReleasedBefore) {
+		return nil, false
+	}
+
+	for _, rawID := range query["actor_id"] {
+		id, err := strconv.Atoi(rawID)
+		if err != nil {
+			return nil, false
+		}
+		filter.ActorIDs = append(filter.ActorIDs, id)
+	}
+	if filter.ActorMatch == "" {
+		filter.ActorMatch = store.ActorMatchAny
+	}
+	if filter.ActorMatch != store.ActorMatchAny && filter.ActorMatch != store.ActorMatchAll {
+		return nil, false
+	}
+
+	if rawHasDescription := query.Get("has_description"); rawHasDescription != "" {
+		hasDescription, err := strconv.ParseBool(rawHasDescription)
+		if err != nil {
+			return nil, false
+		}
+		filter.HasDescription = &hasDescription
+	}
+
+	if filter.IncludeDeleted, ok = parseIncludeDeleted(query); !ok {
+		return nil, false
+	}
+
+	if rawSort := query.Get("sort"); rawSort != "" {
+		if filter.Sort, ok = parseSort(rawSort, filmSortFields); !ok {
+			return nil, false
+		}
+		return filter, true
+	}
+
+	orderBy := query.Get("orderby")
+	if orderBy == "" {
+		orderBy = "rating"
+	}
+
+	order := query.Get("order")
+	if order == "" {
+		order = "desc"
+	}
+
+	if !contains(filmSortFields, orderBy) || order != "asc" && order != "desc" {
+		return nil, false
+	}
+	filter.Sort = []store.SortField{{Field: orderBy, Desc: order == "desc"}}
+
+	return filter, true
+}
+
+// hasAnyParam reports whether any of params is present in query.
+func hasAnyParam(query url.Values, params []string) bool {
+	for _, param := range params {
+		if _, ok := query[param]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseActorFilter reads GET /actors query parameters.
+func parseActorFilter(query url.Values) (*store.ActorFilter, bool) {
+	filter := &store.ActorFilter{
+		SearchName: query.Get("searchname"),
+		Gender:     query.Get("gender"),
+		BornAfter:  query.Get("born_after"),
+		BornBefore: query.Get("born_before"),
+	}
+
+	if !validDate(filter.BornAfter) || !validDate(filter.BornBefore) {
+		return nil, false
+	}
+
+	var ok bool
+	if filter.IncludeDeleted, ok = parseIncludeDeleted(query); !ok {
+		return nil, false
+	}
+
+	if rawSort := query.Get("sort"); rawSort != "" {
+		if filter.Sort, ok = parseSort(rawSort, actorSortFields); !ok {
+			return nil, false
+		}
+	}
+
+	return filter, true
+}
+
+// parseIncludeDeleted reads the include_deleted flag, false when absent.
+func parseIncludeDeleted(query url.Values) (bool, bool) {
+	raw := query.Get("include_deleted")
+	if raw == "" {
+		return false, true
+	}
+	includeDeleted, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, false
+	}
+	return includeDeleted, true
+}
+
+// parseAuditFilter reads GET /audit query parameters, since and until are
+// RFC 3339 times. Limit defaults to defaultAuditLimit.
+func parseAuditFilter(query url.Values) (*store.AuditFilter, bool) {
+	filter := &store.AuditFilter{
+		Entity:    query.Get("entity"),
+		EntityID:  query.Get("entity_id"),
+		Action:    query.Get("action"),
+		Login:     query.Get("login"),
+		RequestID: query.Get("request_id"),
+		Limit:     defaultAuditLimit,
+	}
+
+	var err error
+	if raw := query.Get("since"); raw != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, raw); err != nil {
+			return nil, false
+		}
+	}
+	if raw := query.Get("until"); raw != "" {
+		if filter.Until, err = time.Parse(time.RFC3339, raw); err != nil {
+			return nil, false
+		}
+	}
+	if raw := query.Get("before_id"); raw != "" {
+		if filter.BeforeID, err = strconv.Atoi(raw); err != nil || filter.BeforeID <= 0 {
+			return nil, false
+		}
+	}
+	if raw := query.Get("limit"); raw != "" {
+		if filter.Limit, err = strconv.Atoi(raw); err != nil || filter.Limit <= 0 || filter.Limit > maxAuditLimit {
+			return nil, false
+		}
+	}
+
+	return filter, true
+}
+
+// parseChangesQuery reads the seq to list change events after, 0 by default,
+// and their limit.
+func parseChangesQuery(query url.Values) (int, int, bool) {
+	since, limit := 0, defaultChangesLimit
+
+	var err error
+	if raw := query.Get("since"); raw != "" {
+		if since, err = strconv.Atoi(raw); err != nil || since < 0 {
+			return 0, 0, false
+		}
+	}
+	if raw := query.Get("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil || limit <= 0 || limit > maxChangesLimit {
+			return 0, 0, false
+		}
+	}
+
+	return since, limit, true
+}
+
+// parseDeliveriesLimit reads the limit of GET /webhooks/{id}/deliveries,
+// defaultDeliveriesLimit by default.
+func parseDeliveriesLimit(query url.Values) (int, bool) {
+	limit := defaultDeliveriesLimit
+	if raw := query.Get("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil || limit <= 0 || limit > maxDeliveriesLimit {
+			return 0, false
+		}
+	}
+
+	return limit, true
+}
+
+// parseSort reads a comma separated list of fields, each optionally prefixed
+// with "-" for descending order, e.g. "-rating,title".
+func parseSort(rawSort string, allowed []string) ([]store.SortField, bool) {
+	var fields []store.SortField
+	for _, part := range strings.Split(rawSort, ",") {
+		field := store.SortField{Field: strings.TrimSpace(part)}
+		if strings.HasPrefix(field.Field, "-") {
+			field.Field = field.Field[1:]
+			field.Desc = true
+		}
+		if !contains(allowed, field.Field) {
+			return nil, false
+		}
+		fields = append(fields, field)
+	}
+
+	return fields, true
+}
+
+// parseOptionalRating rejects everything that is not a rating from 0 to 10,
+// including NaN and infinities that strconv.ParseFloat accepts.
+func parseOptionalRating(raw string) (*float64, bool) {
+	if raw == "" {
+		return nil, true
+	}
+
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(value) || value < 0 || value > 10 {
+		return nil, false
+	}
+
+	return &value, true
+}
+
+func validDate(raw string) bool {
+	if raw == "" {
+		return true
+	}
+
+	_, err := time.Parse("2006-01-02", raw)
+	return err == nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package apiserver
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+)
+
+// requestIDHeader carries the id of a request, clients may choose it and
+// the server echoes it in the response.
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength keeps client chosen ids within the audit trail column.
+const maxRequestIDLength = 100
+
+type contextKey int
+
+const requestIDKey contextKey = iota
+
+// withRequestID makes sure the request has an id, generating one unless the
+// client sent a usable one.
+func withRequestID(w http.ResponseWriter, r *http.Request) *http.Request {
+	id := r.Header.Get(requestIDHeader)
+	if id == "" || len(id) > maxRequestIDLength {
+		id = newRequestID()
+	}
+	w.Header().Set(requestIDHeader, id)
+
+	return r.WithContext(context.WithValue(r.Context(), requestIDKey, id))
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey).(string)
+	return id
+}
+
+// author is who makes the changes of an authenticated request.
+func author(r *http.Request) models.Author {
+	login, _, _ := r.BasicAuth()
+	return models.Author{Login: login, RequestID: requestID(r)}
+}
@@ -0,0 +1,161 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+// handleFilmRevisions serves /films/{id}/revisions, /films/{id}/revisions/diff,
+// /films/{id}/revisions/{n} and /films/{id}/revisions/{n}/revert, parts is
+// the split path.
+func (s *server) handleFilmRevisions(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) > 6 || len(parts) == 6 && parts[5] != "revert" {
+		http.NotFound(w, r)
+		return
+	}
+
+	id, err := strconv.Atoi(parts[2])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	n := 0
+	if len(parts) > 4 && parts[4] != "diff" {
+		if n, err = strconv.Atoi(parts[4]); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	registered, isAdmin := s.authenticateUser(w, r)
+	if !registered {
+		return
+	}
+
+	if len(parts) == 6 {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !isAdmin {
+			http.Error(w, "Not enough rights", http.StatusForbidden)
+			return
+		}
+		s.revertFilm(w, r, id, n)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	switch {
+	case len(parts) == 4:
+		s.getFilmRevisions(w, r, id)
+	case parts[4] == "diff":
+		s.diffFilmRevisions(w, r, id)
+	default:
+		s.findFilmRevision(w, r, id, n)
+	}
+}
+
+// getFilmRevisions lists the revisions newest first. Films in the trash keep
+// theirs, a film without any is looked up to tell whether it exists.
+func (s *server) getFilmRevisions(w http.ResponseWriter, r *http.Request, id int) {
+	revisions, err := s.database.Film().GetRevisions(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when getting film revisions")
+		return
+	}
+	if len(revisions) == 0 {
+		_, err := s.database.Film().Find(id)
+		if err == store.ErrRecordNotFound {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			s.logger.WithError(err).Info("Error when finding film")
+			return
+		}
+	}
+
+	s.writeJSON(w, revisions)
+}
+
+func (s *server) findFilmRevision(w http.ResponseWriter, r *http.Request, id int, n int) {
+	revision, err := s.database.Film().FindRevision(id, n)
+	if err == store.ErrRecordNotFound {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when finding film revision")
+		return
+	}
+
+	s.writeJSON(w, revision)
+}
+
+// diffFilmRevisions compares the revisions given by the from and to query
+// parameters.
+func (s *server) diffFilmRevisions(w http.ResponseWriter, r *http.Request, id int) {
+	query := r.URL.Query()
+	from, errFrom := strconv.Atoi(query.Get("from"))
+	to, errTo := strconv.Atoi(query.Get("to"))
+	if errFrom != nil || errTo != nil {
+		http.Error(w, "invalid query parameters", http.StatusBadRequest)
+		return
+	}
+
+	var snapshots []store.Snapshot
+	for _, n := range []int{from, to} {
+		revision, err := s.database.Film().FindRevision(id, n)
+		if err == store.ErrRecordNotFound {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			s.logger.WithError(err).Info("Error when finding film revision")
+			return
+		}
+		snapshots = append(snapshots, store.FilmRequestSnapshot(&revision.Film))
+	}
+
+	diff, err := store.Diff(snapshots[0], snapshots[1])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when comparing film revisions")
+		return
+	}
+	if diff == nil {
+		diff = json.RawMessage("{}")
+	}
+
+	s.writeJSON(w, &models.FilmRevisionDiff{FilmID: id, From: from, To: to, Diff: diff})
+}
+
+// revertFilm sets the film to the revision, which adds a new revision unless
+// it already matches.
+func (s *server) revertFilm(w http.ResponseWriter, r *http.Request, id int, n int) {
+	done, err := s.database.Film().Revert(id, n, author(r))
+	if err == store.ErrRecordNotFound || err == nil && !done {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when reverting film")
+		return
+	}
+	s.suggestions.clear()
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Film successfully reverted"))
+}
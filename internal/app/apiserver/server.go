@@ -3,45 +3,102 @@ package apiserver
 import (
 	"encoding/json"
 	"fmt"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
-	"github.com/Rbd3178/filmDatabase/internal/app/hasher"
+	"github.com/Rbd3178/filmDatabase/internal/app/auth"
+	"github.com/Rbd3178/filmDatabase/internal/app/catalog"
+	"github.com/Rbd3178/filmDatabase/internal/app/graphapi"
 	"github.com/Rbd3178/filmDatabase/internal/app/models"
 	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/cache"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	searchModeSubstring = "substring"
+	searchModeFuzzy     = "fuzzy"
+
+	defaultSimilarityThreshold = 0.4
+
+	importChunkSize = 500
+
+	credentialCacheSize = 10000
+	credentialCacheTTL  = time.Minute
+
+	trashRetention     = 30 * 24 * time.Hour
+	trashPurgeInterval = time.Hour
+
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+
+	changeRetention     = 7 * 24 * time.Hour
+	changePurgeInterval = time.Hour
+
+	defaultChangesLimit = 100
+	maxChangesLimit     = 1000
+
+	webhookInterval    = 5 * time.Second
+	webhookTimeout     = 10 * time.Second
+	webhookBackoff     = 30 * time.Second
+	webhookMaxAttempts = 8
+	webhookSecretBytes = 32
+
+	defaultDeliveriesLimit = 100
+	maxDeliveriesLimit     = 1000
+)
+
 type server struct {
-	router   *http.ServeMux
-	logger   *logrus.Logger
-	database store.Store
+	router      *http.ServeMux
+	logger      *logrus.Logger
+	database    store.Store
+	credentials *auth.Verifier
+	suggestions *suggestCache
+	graphql     *graphapi.API
 }
 
 func newServer(database store.Store) *server {
 	s := &server{
-		router:   http.NewServeMux(),
-		logger:   logrus.New(),
-		database: database,
+		router:      http.NewServeMux(),
+		logger:      logrus.New(),
+		database:    database,
+		credentials: auth.NewVerifier(database.User(), credentialCacheSize, credentialCacheTTL),
+		suggestions: newSuggestCache(suggestCacheTTL, suggestCacheSize),
 	}
 
+	s.graphql = graphapi.New(database, s.suggestions.clear)
 	s.configureRouter()
 
 	return s
 }
 
 func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	s.router.ServeHTTP(w, r)
+	s.router.ServeHTTP(w, withRequestID(w, r))
 }
 
 func (s *server) configureRouter() {
 	s.router.HandleFunc("/register", s.handleRegister)
 	s.router.HandleFunc("/users", s.handleUsers)
+	s.router.HandleFunc("/users/", s.handleUsersLogin)
 	s.router.HandleFunc("/actors", s.handleActors)
 	s.router.HandleFunc("/actors/", s.handleActorsID)
 	s.router.HandleFunc("/films", s.handleFilms)
 	s.router.HandleFunc("/films/", s.handleFilmsID)
+	s.router.HandleFunc("/search", s.handleSearch)
+	s.router.HandleFunc("/suggest", s.handleSuggest)
+	s.router.HandleFunc("/import", s.handleImport)
+	s.router.HandleFunc("/export", s.handleExport)
+	s.router.HandleFunc("/graphql", s.handleGraphQL)
+	s.router.HandleFunc("/cache", s.handleCache)
+	s.router.HandleFunc("/trash", s.handleTrash)
+	s.router.HandleFunc("/audit", s.handleAudit)
+	s.router.HandleFunc("/changes", s.handleChanges)
+	s.router.HandleFunc("/webhooks", s.handleWebhooks)
+	s.router.HandleFunc("/webhooks/", s.handleWebhooksID)
 }
 
 func (s *server) handleRegister(w http.ResponseWriter, r *http.Request) {
@@ -60,11 +117,12 @@ func (s *server) registerUser(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	req.IsAdmin = false
 	if !req.Validate() {
 		http.Error(w, "Invalid login or password", http.StatusUnprocessableEntity)
 		return
 	}
-	done, err := s.database.User().Create(req)
+	done, err := s.database.User().Create(req, models.Author{Login: req.Login, RequestID: requestID(r)})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		s.logger.WithError(err).Info("Error when creating user")
@@ -81,7 +139,7 @@ func (s *server) registerUser(w http.ResponseWriter, r *http.Request) {
 func (s *server) handleUsers(w http.ResponseWriter, r *http.Request) {
 	s.logRequest(r)
 
-	if r.Method != http.MethodGet {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
@@ -95,7 +153,88 @@ func (s *server) handleUsers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.getUsers(w)
+	if r.Method == http.MethodPost {
+		s.createUser(w, r)
+	} else {
+		s.getUsers(w, r)
+	}
+}
+
+func (s *server) createUser(w http.ResponseWriter, r *http.Request) {
+	req := &models.UserRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !req.Validate() {
+		http.Error(w, "Invalid login or password", http.StatusUnprocessableEntity)
+		return
+	}
+	done, err := s.database.User().Create(req, author(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when creating user")
+		return
+	}
+	if !done {
+		http.Error(w, "Login already taken", http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte("User successfully created"))
+}
+
+func (s *server) handleUsersLogin(w http.ResponseWriter, r *http.Request) {
+	s.logRequest(r)
+
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) != 3 || parts[2] == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	registered, isAdmin := s.authenticateUser(w, r)
+	if !registered {
+		return
+	}
+
+	if r.Method != http.MethodPatch {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !isAdmin {
+		http.Error(w, "Not enough rights", http.StatusForbidden)
+		return
+	}
+
+	s.modifyUser(w, r, parts[2])
+}
+
+func (s *server) modifyUser(w http.ResponseWriter, r *http.Request, login string) {
+	req := &models.UserUpdate{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !req.Validate() {
+		http.Error(w, "Invalid fields in payload", http.StatusUnprocessableEntity)
+		return
+	}
+
+	done, err := s.database.User().Modify(login, req, author(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when modifying user")
+		return
+	}
+	if !done {
+		http.NotFound(w, r)
+		return
+	}
+	s.credentials.Forget(login)
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("User successfully modified"))
 }
 
 func (s *server) authenticateUser(w http.ResponseWriter, r *http.Request) (bool, bool) {
@@ -106,29 +245,28 @@ func (s *server) authenticateUser(w http.ResponseWriter, r *http.Request) (bool,
 		return false, false
 	}
 
-	user, err := s.database.User().Find(login)
+	user, err := s.credentials.Verify(login, password)
 	if err == store.ErrRecordNotFound {
 		w.Header().Add("WWW-Authenticate", `Basic realm="Give username and password"`)
 		http.Error(w, "Incorrect login", http.StatusUnauthorized)
 		return false, false
 	}
-	if err != nil {
+	if err == auth.ErrIncorrectPassword {
 		w.Header().Add("WWW-Authenticate", `Basic realm="Give username and password"`)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		s.logger.WithError(err).Info("Error when finding user")
+		http.Error(w, "Incorrect password", http.StatusUnauthorized)
 		return false, false
 	}
-
-	if !hasher.CheckPasswordHash(password, user.HashedPassword) {
+	if err != nil {
 		w.Header().Add("WWW-Authenticate", `Basic realm="Give username and password"`)
-		http.Error(w, "Incorrect password", http.StatusUnauthorized)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when finding user")
 		return false, false
 	}
 
 	return true, user.IsAdmin
 }
 
-func (s *server) getUsers(w http.ResponseWriter) {
+func (s *server) getUsers(w http.ResponseWriter, r *http.Request) {
 	users, err := s.database.User().GetAll()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -136,14 +274,7 @@ func (s *server) getUsers(w http.ResponseWriter) {
 		return
 	}
 
-	jsonData, err := json.Marshal(users)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		s.logger.WithError(err).Info("Error when marshalling json")
-		return
-	}
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	s.respond(w, r, "user", users)
 }
 
 func (s *server) handleActors(w http.ResponseWriter, r *http.Request) {
@@ -156,7 +287,7 @@ func (s *server) handleActors(w http.ResponseWriter, r *http.Request) {
 
 	switch r.Method {
 	case http.MethodGet:
-		s.getActors(w)
+		s.getActors(w, r, isAdmin)
 
 	case http.MethodPost:
 		if !isAdmin {
@@ -170,22 +301,72 @@ func (s *server) handleActors(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (s *server) getActors(w http.ResponseWriter) {
-	actors, err := s.database.Actor().GetAll()
+func (s *server) getActors(w http.ResponseWriter, r *http.Request, isAdmin bool) {
+	query := r.URL.Query()
+
+	filter, ok := parseActorFilter(query)
+	if !ok {
+		http.Error(w, "invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	if filter.IncludeDeleted && !isAdmin {
+		http.Error(w, "Not enough rights", http.StatusForbidden)
+		return
+	}
+	fs, ok := parseFieldset(query, actorFields, "films")
+	if !ok {
+		http.Error(w, "invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	if fs.sparse {
+		filter.Fields = fs.fields
+		filter.OmitFilms = !fs.expand
+	}
+
+	var actors []models.Actor
+	var err error
+	switch query.Get("mode") {
+	case "", searchModeSubstring:
+		if enc, _ := negotiate(r.Header.Get("Accept")); enc == ndjsonEncoder {
+			it, err := s.database.Actor().Iterate(filter)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				s.logger.WithError(err).Info("Error when getting all actors")
+				return
+			}
+			s.streamNDJSON(w, it, func() any {
+				if fs.sparse {
+					return fs.actorRecord(it.Actor())
+				}
+				return it.Actor()
+			})
+			return
+		}
+		actors, err = s.database.Actor().GetAll(filter)
+
+	case searchModeFuzzy:
+		threshold, ok := parseThreshold(query)
+		if filter.SearchName == "" || !ok || hasAnyParam(query, actorListParams) {
+			http.Error(w, "invalid query parameters", http.StatusBadRequest)
+			return
+		}
+		actors, err = s.database.Actor().FuzzySearch(filter.SearchName, threshold)
+
+	default:
+		http.Error(w, "invalid query parameters", http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		s.logger.WithError(err).Info("Error when getting all actors")
 		return
 	}
 
-	jsonData, err := json.Marshal(actors)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		s.logger.WithError(err).Info("Error when marshalling json")
+	if fs.sparse {
+		s.respond(w, r, "actor", fs.actorRecords(actors))
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	s.respond(w, r, "actor", actors)
 }
 
 func (s *server) addActor(w http.ResponseWriter, r *http.Request) {
@@ -198,12 +379,13 @@ func (s *server) addActor(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid fields in payload", http.StatusUnprocessableEntity)
 		return
 	}
-	id, err := s.database.Actor().Create(req)
+	id, err := s.database.Actor().Create(req, author(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		s.logger.WithError(err).Info("Error when creating actor")
 		return
 	}
+	s.suggestions.clear()
 	w.Header().Set("Location", fmt.Sprintf("/actors/%d", id))
 	w.WriteHeader(http.StatusCreated)
 	w.Write([]byte("Actor successfully added"))
@@ -213,7 +395,7 @@ func (s *server) handleActorsID(w http.ResponseWriter, r *http.Request) {
 	s.logRequest(r)
 
 	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) != 3 {
+	if len(parts) != 3 && (len(parts) != 4 || parts[3] != "restore" && parts[3] != "history") {
 		http.NotFound(w, r)
 		return
 	}
@@ -229,6 +411,27 @@ func (s *server) handleActorsID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(parts) == 4 && parts[3] == "history" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.getHistory(w, r, models.AuditEntityActor, id)
+		return
+	}
+	if len(parts) == 4 {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !isAdmin {
+			http.Error(w, "Not enough rights", http.StatusForbidden)
+			return
+		}
+		s.restoreActor(w, r, id)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		s.findActor(w, r, id)
@@ -253,6 +456,12 @@ func (s *server) handleActorsID(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *server) findActor(w http.ResponseWriter, r *http.Request, id int) {
+	fs, ok := parseFieldset(r.URL.Query(), actorFields, "films")
+	if !ok {
+		http.Error(w, "invalid query parameters", http.StatusBadRequest)
+		return
+	}
+
 	actor, err := s.database.Actor().Find(id)
 	if err == store.ErrRecordNotFound {
 		http.NotFound(w, r)
@@ -264,14 +473,11 @@ func (s *server) findActor(w http.ResponseWriter, r *http.Request, id int) {
 		return
 	}
 
-	jsonData, err := json.Marshal(actor)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		s.logger.WithError(err).Info("Error when marshalling json")
+	if fs.sparse {
+		s.respond(w, r, "actor", fs.actorRecord(actor))
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	s.respond(w, r, "actor", actor)
 }
 
 func (s *server) modifyActor(w http.ResponseWriter, r *http.Request, id int) {
@@ -285,7 +491,7 @@ func (s *server) modifyActor(w http.ResponseWriter, r *http.Request, id int) {
 		return
 	}
 
-	done, err := s.database.Actor().Modify(id, req)
+	done, err := s.database.Actor().Modify(id, req, author(r))
 	if !done {
 		http.NotFound(w, r)
 		return
@@ -295,12 +501,13 @@ func (s *server) modifyActor(w http.ResponseWriter, r *http.Request, id int) {
 		s.logger.WithError(err).Info("Error when modifying actor")
 		return
 	}
+	s.suggestions.clear()
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Actor information successfully modified"))
 }
 
 func (s *server) deleteActor(w http.ResponseWriter, r *http.Request, id int) {
-	done, err := s.database.Actor().Delete(id)
+	done, err := s.database.Actor().Delete(id, author(r))
 	if !done {
 		http.NotFound(w, r)
 		return
@@ -310,9 +517,26 @@ func (s *server) deleteActor(w http.ResponseWriter, r *http.Request, id int) {
 		s.logger.WithError(err).Info("Error when deleting actor")
 		return
 	}
+	s.suggestions.clear()
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (s *server) restoreActor(w http.ResponseWriter, r *http.Request, id int) {
+	done, err := s.database.Actor().Restore(id, author(r))
+	if !done {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when restoring actor")
+		return
+	}
+	s.suggestions.clear()
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Actor successfully restored"))
+}
+
 func (s *server) handleFilms(w http.ResponseWriter, r *http.Request) {
 	s.logRequest(r)
 
@@ -323,7 +547,7 @@ func (s *server) handleFilms(w http.ResponseWriter, r *http.Request) {
 
 	switch r.Method {
 	case http.MethodGet:
-		s.getFilms(w, r)
+		s.getFilms(w, r, isAdmin)
 
 	case http.MethodPost:
 		if !isAdmin {
@@ -348,61 +572,112 @@ func (s *server) addFilm(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id, err := s.database.Film().Create(req)
+	id, err := s.database.Film().Create(req, author(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		s.logger.WithError(err).Info("Error when creating film")
 		return
 	}
 
+	s.suggestions.clear()
 	w.Header().Set("Location", fmt.Sprintf("/films/%d", id))
 	w.WriteHeader(http.StatusCreated)
 	w.Write([]byte("Film successfully added"))
 }
 
-func (s *server) getFilms(w http.ResponseWriter, r *http.Request) {
+func (s *server) getFilms(w http.ResponseWriter, r *http.Request, isAdmin bool) {
 	query := r.URL.Query()
 
-	orderBy := query.Get("orderby")
-	if orderBy == "" {
-		orderBy = "rating"
+	filter, ok := parseFilmFilter(query)
+	if !ok {
+		http.Error(w, "invalid query parameters", http.StatusBadRequest)
+		return
 	}
-
-	order := query.Get("order")
-	if order == "" {
-		order = "desc"
+	if filter.IncludeDeleted && !isAdmin {
+		http.Error(w, "Not enough rights", http.StatusForbidden)
+		return
 	}
-
-	if orderBy != "rating" && orderBy != "title" && orderBy != "release_date" || order != "asc" && order != "desc" {
+	fs, ok := parseFieldset(query, filmFields, "actors")
+	if !ok {
 		http.Error(w, "invalid query parameters", http.StatusBadRequest)
 		return
 	}
+	if fs.sparse {
+		filter.Fields = fs.fields
+		filter.OmitActors = !fs.expand
+	}
 
-	searchTitle := query.Get("searchtitle")
-	searchActor := query.Get("searchactor")
+	var films []models.Film
+	var err error
+	switch query.Get("mode") {
+	case "", searchModeSubstring:
+		if enc, _ := negotiate(r.Header.Get("Accept")); enc == ndjsonEncoder {
+			it, err := s.database.Film().Iterate(filter)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				s.logger.WithError(err).Info("Error when getting all films")
+				return
+			}
+			s.streamNDJSON(w, it, func() any {
+				if fs.sparse {
+					return fs.filmRecord(it.Film())
+				}
+				return it.Film()
+			})
+			return
+		}
+		films, err = s.database.Film().GetAll(filter)
+
+	case searchModeFuzzy:
+		threshold, ok := parseThreshold(query)
+		if filter.SearchTitle == "" && filter.SearchActor == "" || !ok || hasAnyParam(query, filmListParams) {
+			http.Error(w, "invalid query parameters", http.StatusBadRequest)
+			return
+		}
+		films, err = s.database.Film().FuzzySearch(filter.SearchTitle, filter.SearchActor, threshold)
 
-	films, err := s.database.Film().GetAll(orderBy, order, searchTitle, searchActor)
+	default:
+		http.Error(w, "invalid query parameters", http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		s.logger.WithError(err).Info("Error when getting all films")
 		return
 	}
 
-	jsonData, err := json.Marshal(films)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		s.logger.WithError(err).Info("Error when marshalling json")
+	if fs.sparse {
+		s.respond(w, r, "film", fs.filmRecords(films))
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	s.respond(w, r, "film", films)
+}
+
+// parseThreshold reads the similarity threshold for fuzzy search, which must
+// be in (0, 1].
+func parseThreshold(query url.Values) (float64, bool) {
+	rawThreshold := query.Get("threshold")
+	if rawThreshold == "" {
+		return defaultSimilarityThreshold, true
+	}
+
+	threshold, err := strconv.ParseFloat(rawThreshold, 64)
+	if err != nil || threshold <= 0 || threshold > 1 {
+		return 0, false
+	}
+
+	return threshold, true
 }
 
 func (s *server) handleFilmsID(w http.ResponseWriter, r *http.Request) {
 	s.logRequest(r)
 
 	parts := strings.Split(r.URL.Path, "/")
-	if len(parts) != 3 {
+	if len(parts) >= 4 && parts[3] == "revisions" {
+		s.handleFilmRevisions(w, r, parts)
+		return
+	}
+	if len(parts) != 3 && (len(parts) != 4 || parts[3] != "restore" && parts[3] != "history") {
 		http.NotFound(w, r)
 		return
 	}
@@ -418,6 +693,27 @@ func (s *server) handleFilmsID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(parts) == 4 && parts[3] == "history" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.getHistory(w, r, models.AuditEntityFilm, id)
+		return
+	}
+	if len(parts) == 4 {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !isAdmin {
+			http.Error(w, "Not enough rights", http.StatusForbidden)
+			return
+		}
+		s.restoreFilm(w, r, id)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		s.findFilm(w, r, id)
@@ -442,7 +738,13 @@ func (s *server) handleFilmsID(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *server) findFilm(w http.ResponseWriter, r *http.Request, id int) {
-	actor, err := s.database.Film().Find(id)
+	fs, ok := parseFieldset(r.URL.Query(), filmFields, "actors")
+	if !ok {
+		http.Error(w, "invalid query parameters", http.StatusBadRequest)
+		return
+	}
+
+	film, err := s.database.Film().Find(id)
 	if err == store.ErrRecordNotFound {
 		http.NotFound(w, r)
 		return
@@ -453,14 +755,11 @@ func (s *server) findFilm(w http.ResponseWriter, r *http.Request, id int) {
 		return
 	}
 
-	jsonData, err := json.Marshal(actor)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		s.logger.WithError(err).Info("Error when marshalling json")
+	if fs.sparse {
+		s.respond(w, r, "film", fs.filmRecord(film))
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(jsonData)
+	s.respond(w, r, "film", film)
 }
 
 func (s *server) modifyFilm(w http.ResponseWriter, r *http.Request, id int) {
@@ -474,7 +773,7 @@ func (s *server) modifyFilm(w http.ResponseWriter, r *http.Request, id int) {
 		return
 	}
 
-	done, err := s.database.Film().Modify(id, req)
+	done, err := s.database.Film().Modify(id, req, author(r))
 	if !done {
 		http.NotFound(w, r)
 		return
@@ -484,12 +783,13 @@ func (s *server) modifyFilm(w http.ResponseWriter, r *http.Request, id int) {
 		s.logger.WithError(err).Info("Error when modifying film")
 		return
 	}
+	s.suggestions.clear()
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Film information successfully modified"))
 }
 
 func (s *server) deleteFilm(w http.ResponseWriter, r *http.Request, id int) {
-	done, err := s.database.Film().Delete(id)
+	done, err := s.database.Film().Delete(id, author(r))
 	if !done {
 		http.NotFound(w, r)
 		return
@@ -499,14 +799,550 @@ func (s *server) deleteFilm(w http.ResponseWriter, r *http.Request, id int) {
 		s.logger.WithError(err).Info("Error when deleting film")
 		return
 	}
+	s.suggestions.clear()
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (s *server) restoreFilm(w http.ResponseWriter, r *http.Request, id int) {
+	done, err := s.database.Film().Restore(id, author(r))
+	if !done {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when restoring film")
+		return
+	}
+	s.suggestions.clear()
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Film successfully restored"))
+}
+
+func (s *server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	s.logRequest(r)
+
+	registered, _ := s.authenticateUser(w, r)
+	if !registered {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.search(w, r)
+}
+
+func (s *server) search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		http.Error(w, "invalid query parameters", http.StatusBadRequest)
+		return
+	}
+
+	limit := 20
+	if rawLimit := query.Get("limit"); rawLimit != "" {
+		var err error
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil || limit < 1 || limit > 100 {
+			http.Error(w, "invalid query parameters", http.StatusBadRequest)
+			return
+		}
+	}
+
+	results, err := s.database.Search().FullText(q, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when searching")
+		return
+	}
+
+	jsonData, err := json.Marshal(results)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when marshalling json")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonData)
+}
+
+func (s *server) handleSuggest(w http.ResponseWriter, r *http.Request) {
+	s.logRequest(r)
+
+	registered, _ := s.authenticateUser(w, r)
+	if !registered {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.suggest(w, r)
+}
+
+func (s *server) suggest(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	prefix := strings.TrimSpace(query.Get("q"))
+	kind := query.Get("type")
+	if prefix == "" || kind != "" && kind != models.SearchTypeFilm && kind != models.SearchTypeActor {
+		http.Error(w, "invalid query parameters", http.StatusBadRequest)
+		return
+	}
+
+	limit := 10
+	if rawLimit := query.Get("limit"); rawLimit != "" {
+		var err error
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil || limit < 1 || limit > 50 {
+			http.Error(w, "invalid query parameters", http.StatusBadRequest)
+			return
+		}
+	}
+
+	key := fmt.Sprintf("%s|%d|%s", kind, limit, strings.ToLower(prefix))
+	suggestions, ok := s.suggestions.get(key)
+	if !ok {
+		var err error
+		suggestions, err = s.database.Search().Suggest(prefix, kind, limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			s.logger.WithError(err).Info("Error when getting suggestions")
+			return
+		}
+		s.suggestions.set(key, suggestions)
+	}
+
+	jsonData, err := json.Marshal(suggestions)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when marshalling json")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonData)
+}
+
+// handleCache reports how well the store cache works, if the store has one.
+func (s *server) handleCache(w http.ResponseWriter, r *http.Request) {
+	s.logRequest(r)
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	registered, isAdmin := s.authenticateUser(w, r)
+	if !registered {
+		return
+	}
+	if !isAdmin {
+		http.Error(w, "Not enough rights", http.StatusForbidden)
+		return
+	}
+
+	cached, ok := s.database.(*cache.Store)
+	if !ok {
+		http.Error(w, "Cache is disabled", http.StatusNotFound)
+		return
+	}
+
+	jsonData, err := json.Marshal(cached.Stats())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when marshalling json")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonData)
+}
+
+// getHistory lists the changes of a single film or actor, newest first.
+func (s *server) getHistory(w http.ResponseWriter, r *http.Request, entity string, id int) {
+	filter, ok := parseAuditFilter(r.URL.Query())
+	if !ok {
+		http.Error(w, "invalid query parameters", http.StatusBadRequest)
+		return
+	}
+	filter.Entity, filter.EntityID = entity, strconv.Itoa(id)
+
+	s.listAudit(w, filter)
+}
+
+// handleAudit lists the changes of all films, actors and users.
+func (s *server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	s.logRequest(r)
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	registered, isAdmin := s.authenticateUser(w, r)
+	if !registered {
+		return
+	}
+	if !isAdmin {
+		http.Error(w, "Not enough rights", http.StatusForbidden)
+		return
+	}
+
+	filter, ok := parseAuditFilter(r.URL.Query())
+	if !ok {
+		http.Error(w, "invalid query parameters", http.StatusBadRequest)
+		return
+	}
+
+	s.listAudit(w, filter)
+}
+
+func (s *server) listAudit(w http.ResponseWriter, filter *store.AuditFilter) {
+	events, err := s.database.Audit().List(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when listing audit events")
+		return
+	}
+
+	s.writeJSON(w, events)
+}
+
+// handleTrash lists deleted films and actors that can still be restored.
+func (s *server) handleTrash(w http.ResponseWriter, r *http.Request) {
+	s.logRequest(r)
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	registered, isAdmin := s.authenticateUser(w, r)
+	if !registered {
+		return
+	}
+	if !isAdmin {
+		http.Error(w, "Not enough rights", http.StatusForbidden)
+		return
+	}
+
+	records, err := s.database.Trash().List()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when listing trash")
+		return
+	}
+
+	jsonData, err := json.Marshal(records)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when marshalling json")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonData)
+}
+
+// purgeTrash hard-deletes every interval the films and actors deleted more
+// than retention ago.
+func (s *server) purgeTrash(retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; ; <-ticker.C {
+		purged, err := s.database.Trash().Purge(time.Now().Add(-retention))
+		if err != nil {
+			s.logger.WithError(err).Info("Error when purging trash")
+			continue
+		}
+		if purged > 0 {
+			s.logger.Infof("Purged %d deleted records", purged)
+		}
+	}
+}
+
+// handleChanges is the feed of film and actor changes for consumers that poll
+// with the seq of the last event they have seen.
+func (s *server) handleChanges(w http.ResponseWriter, r *http.Request) {
+	s.logRequest(r)
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	registered, _ := s.authenticateUser(w, r)
+	if !registered {
+		return
+	}
+
+	since, limit, ok := parseChangesQuery(r.URL.Query())
+	if !ok {
+		http.Error(w, "invalid query parameters", http.StatusBadRequest)
+		return
+	}
+
+	events, err := s.database.Outbox().List(since, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when listing changes")
+		return
+	}
+
+	s.writeJSON(w, events)
+}
+
+// purgeChanges drops every interval the change events older than retention.
+func (s *server) purgeChanges(retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; ; <-ticker.C {
+		purged, err := s.database.Outbox().Purge(time.Now().Add(-retention))
+		if err != nil {
+			s.logger.WithError(err).Info("Error when purging change events")
+			continue
+		}
+		if purged > 0 {
+			s.logger.Infof("Purged %d change events", purged)
+		}
+	}
+}
+
+func (s *server) handleImport(w http.ResponseWriter, r *http.Request) {
+	s.logRequest(r)
+
+	registered, isAdmin := s.authenticateUser(w, r)
+	if !registered {
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !isAdmin {
+		http.Error(w, "Not enough rights", http.StatusForbidden)
+		return
+	}
+
+	s.importCatalog(w, r)
+}
+
+func (s *server) importCatalog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format, ok := catalogFormat(r.Header.Get("Content-Type"))
+	if !ok {
+		http.Error(w, "Content-Type must be application/json, text/csv or application/x-ndjson", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	entity := query.Get("entity")
+	if entity != "" && entity != catalog.EntityActor && entity != catalog.EntityFilm {
+		http.Error(w, "invalid query parameters", http.StatusBadRequest)
+		return
+	}
+
+	dryRun := false
+	if rawDryRun := query.Get("dry_run"); rawDryRun != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(rawDryRun)
+		if err != nil {
+			http.Error(w, "invalid query parameters", http.StatusBadRequest)
+			return
+		}
+	}
+
+	reader, err := catalog.NewReader(format, r.Body, entity)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := catalog.NewImporter(s.database.Catalog(), author(r), importChunkSize, dryRun).Import(reader)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when importing")
+		return
+	}
+	if !dryRun {
+		s.suggestions.clear()
+	}
+
+	jsonData, err := json.Marshal(report)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when marshalling json")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonData)
+}
+
+// catalogFormat maps a media type to a catalog format.
+func catalogFormat(contentType string) (string, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+	switch mediaType {
+	case "application/json":
+		return catalog.FormatJSON, true
+	case "text/csv":
+		return catalog.FormatCSV, true
+	case "application/x-ndjson":
+		return catalog.FormatNDJSON, true
+	default:
+		return "", false
+	}
+}
+
+func (s *server) handleExport(w http.ResponseWriter, r *http.Request) {
+	s.logRequest(r)
+
+	registered, _ := s.authenticateUser(w, r)
+	if !registered {
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.exportCatalog(w, r)
+}
+
+var exportContentTypes = map[string]string{
+	catalog.FormatJSON:   "application/json",
+	catalog.FormatCSV:    "text/csv",
+	catalog.FormatNDJSON: "application/x-ndjson",
+}
+
+func (s *server) exportCatalog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = catalog.FormatJSON
+	}
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		http.Error(w, "invalid query parameters", http.StatusBadRequest)
+		return
+	}
+
+	actors, films := true, true
+	if rawEntities := query.Get("entities"); rawEntities != "" {
+		actors, films = false, false
+		for _, entity := range strings.Split(rawEntities, ",") {
+			switch strings.TrimSpace(entity) {
+			case "actors":
+				actors = true
+			case "films":
+				films = true
+			default:
+				http.Error(w, "invalid query parameters", http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	tw := &trackingWriter{ResponseWriter: w}
+	writer, err := catalog.NewWriter(format, tw)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="export.%s"`, format))
+
+	err = catalog.Export(s.database.Catalog(), writer, actors, films)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		s.logger.WithError(err).Info("Error when exporting")
+		// Once the body has started the status can not be changed, the
+		// client sees a truncated document.
+		if !tw.written {
+			w.Header().Del("Content-Disposition")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+func (s *server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	s.logRequest(r)
+
+	registered, isAdmin := s.authenticateUser(w, r)
+	if !registered {
+		return
+	}
+
+	req := &graphapi.Request{}
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if rawVariables := query.Get("variables"); rawVariables != "" {
+			if err := json.Unmarshal([]byte(rawVariables), &req.Variables); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if req.Query == "" {
+		http.Error(w, "Missing query", http.StatusBadRequest)
+		return
+	}
+
+	// Field errors are reported in the body next to partial data, as the
+	// GraphQL spec expects, so the status stays 200.
+	result := s.graphql.Execute(r.Context(), req, author(r), isAdmin)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		s.logger.WithError(err).Info("Error when encoding GraphQL result")
+	}
+}
+
+// trackingWriter remembers whether anything has been sent to the client.
+type trackingWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *trackingWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
 func (s *server) logRequest(r *http.Request) {
 	s.logger.WithFields(logrus.Fields{
-        "method": r.Method,
-        "path":   r.URL.Path,
-        "query":  r.URL.RawQuery,
-        "remote": r.RemoteAddr,
+        "method":     r.Method,
+        "path":       r.URL.Path,
+        "query":      r.URL.RawQuery,
+        "remote":     r.RemoteAddr,
+        "request_id": requestID(r),
     }).Info("Incoming request")
 }
\ No newline at end of file
@@ -3,12 +3,23 @@ package apiserver
 import (
 	"bytes"
 	"encoding/json"
+	"encoding/xml"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/cache"
 	"github.com/Rbd3178/filmDatabase/internal/app/store/testdb"
+	"github.com/Rbd3178/filmDatabase/internal/app/webhook"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestServer_HandleRegister(t *testing.T) {
@@ -47,6 +58,15 @@ func TestServer_HandleRegister(t *testing.T) {
 			},
 			expectedCode: http.StatusConflict,
 		},
+		{
+			name: "admin role ignored",
+			payload: map[string]interface{}{
+				"login":    "climber",
+				"password": "secret",
+				"is_admin": true,
+			},
+			expectedCode: http.StatusCreated,
+		},
 	}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
@@ -58,6 +78,10 @@ func TestServer_HandleRegister(t *testing.T) {
 			assert.Equal(t, tc.expectedCode, rec.Code)
 		})
 	}
+
+	u, err := s.database.User().Find("climber")
+	assert.NoError(t, err)
+	assert.False(t, u.IsAdmin)
 }
 
 // Primarily a way to test authorization, for other hadlers only check 403 response
@@ -107,6 +131,199 @@ func TestServer_HandleUsers(t *testing.T) {
 	}
 }
 
+func TestServer_HandleUsersCreate(t *testing.T) {
+	s := newServer(testdb.New())
+
+	var tests = []struct {
+		name         string
+		login        string
+		password     string
+		payload      any
+		expectedCode int
+	}{
+		{
+			name:     "Not admin",
+			login:    "normal",
+			password: "correct",
+			payload: map[string]interface{}{
+				"login":    "chief",
+				"password": "secret",
+				"is_admin": true,
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "Invalid payload",
+			login:        "admin",
+			password:     "adminpass",
+			payload:      "invalid",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Invalid params",
+			login:    "admin",
+			password: "adminpass",
+			payload: map[string]interface{}{
+				"login":    "chief",
+				"password": "short",
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:     "Admin",
+			login:    "admin",
+			password: "adminpass",
+			payload: map[string]interface{}{
+				"login":    "chief",
+				"password": "secret",
+				"is_admin": true,
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:     "Login already taken",
+			login:    "admin",
+			password: "adminpass",
+			payload: map[string]interface{}{
+				"login":    "chief",
+				"password": "qwerty",
+			},
+			expectedCode: http.StatusConflict,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodPost, "/users", b)
+			req.SetBasicAuth(tc.login, tc.password)
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	u, err := s.database.User().Find("chief")
+	assert.NoError(t, err)
+	assert.True(t, u.IsAdmin)
+}
+
+func TestServer_HandleUsersModify(t *testing.T) {
+	s := newServer(testdb.New())
+	s.database.User().Create(&models.UserRequest{Login: "somebody", Password: "secret"}, models.Author{})
+
+	var tests = []struct {
+		name         string
+		target       string
+		login        string
+		password     string
+		payload      any
+		expectedCode int
+	}{
+		{
+			name:         "Not admin",
+			target:       "/users/somebody",
+			login:        "normal",
+			password:     "correct",
+			payload:      map[string]interface{}{"is_admin": true},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "Promote",
+			target:       "/users/somebody",
+			login:        "admin",
+			password:     "adminpass",
+			payload:      map[string]interface{}{"is_admin": true},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Reset password",
+			target:       "/users/somebody",
+			login:        "admin",
+			password:     "adminpass",
+			payload:      map[string]interface{}{"password": "newsecret"},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Password too short",
+			target:       "/users/somebody",
+			login:        "admin",
+			password:     "adminpass",
+			payload:      map[string]interface{}{"password": "short"},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "Nothing to change",
+			target:       "/users/somebody",
+			login:        "admin",
+			password:     "adminpass",
+			payload:      map[string]interface{}{},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "Unknown user",
+			target:       "/users/nobody",
+			login:        "admin",
+			password:     "adminpass",
+			payload:      map[string]interface{}{"is_admin": true},
+			expectedCode: http.StatusNotFound,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodPatch, tc.target, b)
+			req.SetBasicAuth(tc.login, tc.password)
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	// The promoted user now passes the admin check with the new password.
+	req, _ := http.NewRequest(http.MethodGet, "/users", nil)
+	req.SetBasicAuth("somebody", "newsecret")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestServer_RememberedCredentials(t *testing.T) {
+	s := newServer(testdb.New())
+	s.database.User().Create(&models.UserRequest{Login: "somebody", Password: "secret"}, models.Author{})
+
+	get := func(login string, password string) int {
+		req, _ := http.NewRequest(http.MethodGet, "/users", nil)
+		req.SetBasicAuth(login, password)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	modify := func(payload any) {
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(payload)
+		req, _ := http.NewRequest(http.MethodPatch, "/users/somebody", b)
+		req.SetBasicAuth("admin", "adminpass")
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	assert.Equal(t, http.StatusForbidden, get("somebody", "secret"))
+	assert.Equal(t, http.StatusUnauthorized, get("somebody", "wrong"))
+
+	modify(map[string]interface{}{"is_admin": true})
+	assert.Equal(t, http.StatusOK, get("somebody", "secret"))
+
+	modify(map[string]interface{}{"password": "newsecret"})
+	assert.Equal(t, http.StatusUnauthorized, get("somebody", "secret"))
+	assert.Equal(t, http.StatusOK, get("somebody", "newsecret"))
+
+	modify(map[string]interface{}{"is_admin": false})
+	assert.Equal(t, http.StatusForbidden, get("somebody", "newsecret"))
+}
+
 func TestServer_HandleActorsGet(t *testing.T) {
 	s := newServer(testdb.New())
 
@@ -882,3 +1099,1958 @@ func TestServer_HandleFilmsIDModify(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_HandleSearch(t *testing.T) {
+	s := newServer(testdb.New())
+
+	payload := map[string]interface{}{
+		"title":        "Title",
+		"description":  "Description",
+		"release_date": "2024-03-18",
+		"rating":       5.2,
+		"actors_ids":   []int{1, 2},
+	}
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(payload)
+	req, _ := http.NewRequest(http.MethodPost, "/films", b)
+	req.SetBasicAuth("admin", "adminpass")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	var tests = []struct {
+		name          string
+		method        string
+		login         string
+		password      string
+		query         string
+		expectedCode  int
+		expectedCount int
+	}{
+		{
+			name:         "Request with incorrect method",
+			method:       http.MethodPost,
+			login:        "normal",
+			password:     "correct",
+			query:        "q=title",
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:         "Unregistered",
+			method:       http.MethodGet,
+			login:        "nobody",
+			password:     "pass",
+			query:        "q=title",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "Request without query",
+			method:       http.MethodGet,
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Request with invalid limit",
+			method:       http.MethodGet,
+			login:        "normal",
+			password:     "correct",
+			query:        "q=title&limit=0",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:          "Request by normal user",
+			method:        http.MethodGet,
+			login:         "normal",
+			password:      "correct",
+			query:         "q=title",
+			expectedCode:  http.StatusOK,
+			expectedCount: 1,
+		},
+		{
+			name:          "Request with no matches",
+			method:        http.MethodGet,
+			login:         "normal",
+			password:      "correct",
+			query:         "q=nothing",
+			expectedCode:  http.StatusOK,
+			expectedCount: 0,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tc.method, "/search?"+tc.query, nil)
+			req.SetBasicAuth(tc.login, tc.password)
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedCode == http.StatusOK {
+				var results []map[string]interface{}
+				assert.NoError(t, json.NewDecoder(rec.Body).Decode(&results))
+				assert.Equal(t, tc.expectedCount, len(results))
+			}
+		})
+	}
+}
+
+func TestServer_HandleFilmsGetFuzzy(t *testing.T) {
+	s := newServer(testdb.New())
+
+	for _, name := range []string{"First Actor", "Second Actor", "Third Actor"} {
+		s.database.Actor().Create(&models.ActorRequest{Name: name, Gender: "male", BirthDate: "1970-01-01"}, models.Author{Login: "admin"})
+	}
+
+	payload := map[string]interface{}{
+		"title":        "Titanic",
+		"description":  "Description",
+		"release_date": "1997-12-19",
+		"rating":       7.9,
+		"actors_ids":   []int{1, 2},
+	}
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(payload)
+	req, _ := http.NewRequest(http.MethodPost, "/films", b)
+	req.SetBasicAuth("admin", "adminpass")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	var tests = []struct {
+		name          string
+		query         string
+		expectedCode  int
+		expectedCount int
+	}{
+		{
+			name:          "Typo in title",
+			query:         "mode=fuzzy&searchtitle=Titanc",
+			expectedCode:  http.StatusOK,
+			expectedCount: 1,
+		},
+		{
+			name:          "Typo in actor name",
+			query:         "mode=fuzzy&searchactor=Secnd%20Actor&threshold=0.7",
+			expectedCode:  http.StatusOK,
+			expectedCount: 1,
+		},
+		{
+			name:          "Threshold too high",
+			query:         "mode=fuzzy&searchtitle=Titanc&threshold=1",
+			expectedCode:  http.StatusOK,
+			expectedCount: 0,
+		},
+		{
+			name:         "No search parameters",
+			query:        "mode=fuzzy",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid threshold",
+			query:        "mode=fuzzy&searchtitle=Titanc&threshold=2",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Rating filter",
+			query:        "mode=fuzzy&searchtitle=Titanc&rating_min=5",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Actor filter",
+			query:        "mode=fuzzy&searchtitle=Titanc&actor_id=1",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Sort",
+			query:        "mode=fuzzy&searchtitle=Titanc&sort=title",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unknown mode",
+			query:        "mode=exact&searchtitle=Titanic",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/films?"+tc.query, nil)
+			req.SetBasicAuth("normal", "correct")
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedCode == http.StatusOK {
+				var films []map[string]interface{}
+				assert.NoError(t, json.NewDecoder(rec.Body).Decode(&films))
+				assert.Equal(t, tc.expectedCount, len(films))
+			}
+		})
+	}
+}
+
+func TestServer_HandleActorsGetFuzzy(t *testing.T) {
+	s := newServer(testdb.New())
+
+	payload := map[string]interface{}{
+		"name":       "Leonardo DiCaprio",
+		"gender":     "male",
+		"birth_date": "1974-11-11",
+	}
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(payload)
+	req, _ := http.NewRequest(http.MethodPost, "/actors", b)
+	req.SetBasicAuth("admin", "adminpass")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	var tests = []struct {
+		name          string
+		query         string
+		expectedCode  int
+		expectedCount int
+	}{
+		{
+			name:          "Name with a space",
+			query:         "mode=fuzzy&searchname=Di%20Caprio",
+			expectedCode:  http.StatusOK,
+			expectedCount: 1,
+		},
+		{
+			name:          "Unrelated name",
+			query:         "mode=fuzzy&searchname=Winslet",
+			expectedCode:  http.StatusOK,
+			expectedCount: 0,
+		},
+		{
+			name:         "No search parameters",
+			query:        "mode=fuzzy",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid threshold",
+			query:        "mode=fuzzy&searchname=Di%20Caprio&threshold=abc",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Gender filter",
+			query:        "mode=fuzzy&searchname=Di%20Caprio&gender=male",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Birth date filter",
+			query:        "mode=fuzzy&searchname=Di%20Caprio&born_after=1970-01-01",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Sort",
+			query:        "mode=fuzzy&searchname=Di%20Caprio&sort=name",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/actors?"+tc.query, nil)
+			req.SetBasicAuth("normal", "correct")
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedCode == http.StatusOK {
+				var actors []map[string]interface{}
+				assert.NoError(t, json.NewDecoder(rec.Body).Decode(&actors))
+				assert.Equal(t, tc.expectedCount, len(actors))
+			}
+		})
+	}
+}
+
+func TestServer_HandleSuggest(t *testing.T) {
+	s := newServer(testdb.New())
+
+	addFilm := func(title string) {
+		payload := map[string]interface{}{
+			"title":        title,
+			"description":  "Description",
+			"release_date": "2024-03-18",
+			"rating":       5.2,
+		}
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(payload)
+		req, _ := http.NewRequest(http.MethodPost, "/films", b)
+		req.SetBasicAuth("admin", "adminpass")
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+	}
+	suggest := func(query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/suggest?"+query, nil)
+		req.SetBasicAuth("normal", "correct")
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	addFilm("Toy Story")
+
+	var tests = []struct {
+		name          string
+		query         string
+		expectedCode  int
+		expectedCount int
+	}{
+		{
+			name:          "Prefix of a film",
+			query:         "q=to",
+			expectedCode:  http.StatusOK,
+			expectedCount: 1,
+		},
+		{
+			name:          "Only actors",
+			query:         "q=to&type=actor",
+			expectedCode:  http.StatusOK,
+			expectedCount: 0,
+		},
+		{
+			name:         "Empty prefix",
+			query:        "q=",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unknown type",
+			query:        "q=to&type=user",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid limit",
+			query:        "q=to&limit=100",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := suggest(tc.query)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedCode == http.StatusOK {
+				var suggestions []map[string]interface{}
+				assert.NoError(t, json.NewDecoder(rec.Body).Decode(&suggestions))
+				assert.Equal(t, tc.expectedCount, len(suggestions))
+			}
+		})
+	}
+
+	t.Run("Cache is invalidated by changes", func(t *testing.T) {
+		addFilm("Top Gun")
+		var suggestions []map[string]interface{}
+		assert.NoError(t, json.NewDecoder(suggest("q=to").Body).Decode(&suggestions))
+		assert.Equal(t, 2, len(suggestions))
+	})
+}
+
+func TestServer_HandleFilmsGetFiltered(t *testing.T) {
+	s := newServer(testdb.New())
+
+	for _, name := range []string{"First Actor", "Second Actor", "Third Actor"} {
+		s.database.Actor().Create(&models.ActorRequest{Name: name, Gender: "male", BirthDate: "1970-01-01"}, models.Author{Login: "admin"})
+	}
+
+	for _, payload := range []map[string]interface{}{
+		{
+			"title":        "Alpha",
+			"description":  "Description",
+			"release_date": "1999-05-01",
+			"rating":       7.8,
+			"actors_ids":   []int{1, 2},
+		},
+		{
+			"title":        "Beta",
+			"release_date": "2005-01-01",
+			"rating":       7.8,
+			"actors_ids":   []int{2},
+		},
+		{
+			"title":        "Gamma",
+			"description":  "Description",
+			"release_date": "2015-01-01",
+			"rating":       5.5,
+		},
+	} {
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(payload)
+		req, _ := http.NewRequest(http.MethodPost, "/films", b)
+		req.SetBasicAuth("admin", "adminpass")
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+	}
+
+	var tests = []struct {
+		name           string
+		query          string
+		expectedCode   int
+		expectedTitles []string
+	}{
+		{
+			name:           "Default order",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Alpha", "Beta", "Gamma"},
+		},
+		{
+			name:           "Legacy order parameters",
+			query:          "orderby=title&order=desc",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Gamma", "Beta", "Alpha"},
+		},
+		{
+			name:           "Multi-key sort",
+			query:          "sort=-rating,-title",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Beta", "Alpha", "Gamma"},
+		},
+		{
+			name:           "Rating and release date ranges",
+			query:          "rating_min=6&released_after=2000-01-01",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Beta"},
+		},
+		{
+			name:           "All of actors",
+			query:          "actor_id=1&actor_id=2&actor_match=all",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Alpha"},
+		},
+		{
+			name:           "Without description",
+			query:          "has_description=false",
+			expectedCode:   http.StatusOK,
+			expectedTitles: []string{"Beta"},
+		},
+		{
+			name:         "Unknown sort field",
+			query:        "sort=-budget",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid rating",
+			query:        "rating_min=high",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "NaN rating",
+			query:        "rating_min=NaN",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Infinite rating",
+			query:        "rating_max=Inf",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Negative rating",
+			query:        "rating_min=-1",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Rating above 10",
+			query:        "rating_max=10.5",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid date",
+			query:        "released_before=yesterday",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid actor match",
+			query:        "actor_id=1&actor_match=some",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/films?"+tc.query, nil)
+			req.SetBasicAuth("normal", "correct")
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedCode == http.StatusOK {
+				var films []map[string]interface{}
+				assert.NoError(t, json.NewDecoder(rec.Body).Decode(&films))
+				titles := make([]string, 0, len(films))
+				for _, film := range films {
+					titles = append(titles, film["title"].(string))
+				}
+				assert.Equal(t, tc.expectedTitles, titles)
+			}
+		})
+	}
+}
+
+func TestServer_HandleActorsGetFiltered(t *testing.T) {
+	s := newServer(testdb.New())
+
+	for _, payload := range []map[string]interface{}{
+		{"name": "Tom Hanks", "gender": "male", "birth_date": "1956-07-09"},
+		{"name": "Sophie Patel", "gender": "female", "birth_date": "1997-11-03"},
+		{"name": "Tom Hardy", "gender": "male", "birth_date": "1977-09-15"},
+	} {
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(payload)
+		req, _ := http.NewRequest(http.MethodPost, "/actors", b)
+		req.SetBasicAuth("admin", "adminpass")
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+	}
+
+	var tests = []struct {
+		name          string
+		query         string
+		expectedCode  int
+		expectedNames []string
+	}{
+		{
+			name:          "Default order",
+			expectedCode:  http.StatusOK,
+			expectedNames: []string{"Sophie Patel", "Tom Hanks", "Tom Hardy"},
+		},
+		{
+			name:          "Name search and gender",
+			query:         "searchname=tom&gender=male&sort=-name",
+			expectedCode:  http.StatusOK,
+			expectedNames: []string{"Tom Hardy", "Tom Hanks"},
+		},
+		{
+			name:          "Birth date range",
+			query:         "born_after=1970-01-01&sort=birth_date",
+			expectedCode:  http.StatusOK,
+			expectedNames: []string{"Tom Hardy", "Sophie Patel"},
+		},
+		{
+			name:         "Unknown sort field",
+			query:        "sort=height",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid date",
+			query:        "born_before=someday",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/actors?"+tc.query, nil)
+			req.SetBasicAuth("normal", "correct")
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedCode == http.StatusOK {
+				var actors []map[string]interface{}
+				assert.NoError(t, json.NewDecoder(rec.Body).Decode(&actors))
+				names := make([]string, 0, len(actors))
+				for _, actor := range actors {
+					names = append(names, actor["name"].(string))
+				}
+				assert.Equal(t, tc.expectedNames, names)
+			}
+		})
+	}
+}
+
+func TestServer_HandleImport(t *testing.T) {
+	const body = `entity,external_id,name,gender,birth_date,title,description,release_date,rating,cast
+actor,nm1,Keanu Reeves,male,1964-09-02,,,,,
+film,tt1,,,,The Matrix,Hacker learns the truth,1999-03-31,8.7,nm1
+film,tt2,,,,,No title,1999-03-31,5,
+`
+
+	var tests = []struct {
+		name            string
+		query           string
+		contentType     string
+		login           string
+		password        string
+		expectedCode    int
+		expectedCreated int
+	}{
+		{
+			name:         "Not admin",
+			contentType:  "text/csv",
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "Unsupported content type",
+			contentType:  "text/plain",
+			login:        "admin",
+			password:     "adminpass",
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:         "Unknown entity",
+			query:        "?entity=user",
+			contentType:  "text/csv",
+			login:        "admin",
+			password:     "adminpass",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:            "Dry run",
+			query:           "?dry_run=true",
+			contentType:     "text/csv; charset=utf-8",
+			login:           "admin",
+			password:        "adminpass",
+			expectedCode:    http.StatusOK,
+			expectedCreated: 0,
+		},
+		{
+			name:            "Import",
+			contentType:     "text/csv",
+			login:           "admin",
+			password:        "adminpass",
+			expectedCode:    http.StatusOK,
+			expectedCreated: 1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newServer(testdb.New())
+			req, _ := http.NewRequest(http.MethodPost, "/import"+tc.query, strings.NewReader(body))
+			req.Header.Set("Content-Type", tc.contentType)
+			req.SetBasicAuth(tc.login, tc.password)
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			report := &models.ImportReport{}
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(report))
+			assert.Equal(t, 3, report.Rows)
+			assert.Equal(t, tc.expectedCreated, report.Films.Created)
+			assert.Equal(t, tc.expectedCreated, report.Actors.Created)
+			assert.Equal(t, []models.ImportError{{Row: 3, Message: report.Errors[0].Message}}, report.Errors)
+
+			films, _ := s.database.Film().GetAll(&store.FilmFilter{})
+			assert.Equal(t, tc.expectedCreated, len(films))
+		})
+	}
+}
+
+func TestServer_HandleExport(t *testing.T) {
+	const data = `entity,external_id,name,gender,birth_date,title,description,release_date,rating,cast
+actor,nm1,Keanu Reeves,male,1964-09-02,,,,,
+actor,,Laurence Fishburne,male,1961-07-30,,,,,
+film,tt1,,,,The Matrix,"Hacker, learns the truth",1999-03-31,8.7,nm1|Laurence Fishburne
+`
+	request := func(s *server, method, target, contentType string, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		req.SetBasicAuth("admin", "adminpass")
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	source := newServer(testdb.New())
+	assert.Equal(t, http.StatusOK, request(source, http.MethodPost, "/import", "text/csv", data).Code)
+
+	var tests = []struct {
+		name                string
+		query               string
+		expectedCode        int
+		expectedContentType string
+	}{
+		{
+			name:                "JSON by default",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/json",
+		},
+		{
+			name:                "CSV",
+			query:               "?format=csv",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/csv",
+		},
+		{
+			name:                "NDJSON",
+			query:               "?format=ndjson&entities=actors,films",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+		},
+		{
+			name:         "Unknown format",
+			query:        "?format=xml",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unknown entity",
+			query:        "?entities=users",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := request(source, http.MethodGet, "/export"+tc.query, "", "")
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tc.expectedContentType, rec.Header().Get("Content-Type"))
+
+			target := newServer(testdb.New())
+			imported := request(target, http.MethodPost, "/import", tc.expectedContentType, rec.Body.String())
+			assert.Equal(t, http.StatusOK, imported.Code)
+			report := &models.ImportReport{}
+			json.NewDecoder(imported.Body).Decode(report)
+			assert.Empty(t, report.Errors)
+			assert.Equal(t, models.ImportResult{Created: 2}, report.Actors)
+			assert.Equal(t, models.ImportResult{Created: 1}, report.Films)
+
+			exported := request(target, http.MethodGet, "/export?format=csv", "", "")
+			assert.Equal(t, data, exported.Body.String())
+		})
+	}
+
+	t.Run("Only films", func(t *testing.T) {
+		rec := request(source, http.MethodGet, "/export?format=ndjson&entities=films", "", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, 1, strings.Count(rec.Body.String(), "\n"))
+		assert.Contains(t, rec.Body.String(), `"cast":["nm1","Laurence Fishburne"]`)
+	})
+}
+
+func TestServer_ContentNegotiation(t *testing.T) {
+	s := newServer(testdb.New())
+
+	actorID, _ := s.database.Actor().Create(&models.ActorRequest{
+		Name:      "Keanu Reeves",
+		Gender:    "male",
+		BirthDate: "1964-09-02",
+	}, models.Author{Login: "admin"})
+	s.database.Actor().Create(&models.ActorRequest{
+		Name:      "Laurence Fishburne",
+		Gender:    "male",
+		BirthDate: "1961-07-30",
+	}, models.Author{Login: "admin"})
+	s.database.Film().Create(&models.FilmRequest{
+		Title:       "The Matrix, part one",
+		Description: "Hacker learns the truth",
+		ReleaseDate: "1999-03-31",
+		Rating:      8.7,
+		ActorsIDs:   []int{1, 2},
+	}, models.Author{Login: "admin"})
+	s.database.Film().Create(&models.FilmRequest{
+		Title:       "=1+2",
+		Description: "@SUM(A1)",
+		ReleaseDate: "1999-04-01",
+		Rating:      5,
+	}, models.Author{Login: "admin"})
+
+	var tests = []struct {
+		name                string
+		target              string
+		login               string
+		password            string
+		accept              string
+		expectedCode        int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "JSON by default",
+			target:              "/films",
+			accept:              "",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        `[{"id":1,"title":"The Matrix, part one"`,
+		},
+		{
+			name:                "Films as CSV",
+			target:              "/films",
+			accept:              "text/csv",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/csv",
+			expectedBody:        "id,title,description,release_date,rating,actor_ids,actor_names\n1,\"The Matrix, part one\",Hacker learns the truth,1999-03-31,8.7,1|2,Keanu Reeves|Laurence Fishburne\n",
+		},
+		{
+			name:                "Film as XML",
+			target:              "/films/1",
+			accept:              "application/xml",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/xml",
+			expectedBody:        `<title>The Matrix, part one</title><description>Hacker learns the truth</description><release_date>1999-03-31</release_date><rating>8.7</rating><actors><actor><actor_id>1</actor_id><name>Keanu Reeves</name></actor><actor><actor_id>2</actor_id><name>Laurence Fishburne</name></actor></actors></film>`,
+		},
+		{
+			name:                "Actors as XML",
+			target:              "/actors",
+			accept:              "application/xml",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/xml",
+			expectedBody:        `<actors><actor><id>1</id><name>Keanu Reeves</name>`,
+		},
+		{
+			name:                "Actor as CSV",
+			target:              "/actors/" + strconv.Itoa(actorID),
+			accept:              "text/csv",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/csv",
+			expectedBody:        ",Keanu Reeves,male,1964-09-02,1,\"The Matrix, part one\"\n",
+		},
+		{
+			name:                "Users as CSV",
+			target:              "/users",
+			login:               "admin",
+			password:            "adminpass",
+			accept:              "text/csv",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/csv",
+			expectedBody:        "login,is_admin\n",
+		},
+		{
+			name:                "Formulas in CSV",
+			target:              "/films?searchtitle=%3D",
+			accept:              "text/csv",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/csv",
+			expectedBody:        "\n2,'=1+2,'@SUM(A1),1999-04-01,5,,\n",
+		},
+		{
+			name:                "Preferred by quality",
+			target:              "/films",
+			accept:              "application/json;q=0.5, text/csv;q=0.9",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/csv",
+		},
+		{
+			name:                "Wildcard",
+			target:              "/films",
+			accept:              "text/*",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/csv",
+		},
+		{
+			name:         "Not acceptable",
+			target:       "/films",
+			accept:       "application/pdf",
+			expectedCode: http.StatusNotAcceptable,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tc.target, nil)
+			if tc.login == "" {
+				tc.login, tc.password = "normal", "correct"
+			}
+			req.SetBasicAuth(tc.login, tc.password)
+			req.Header.Set("Accept", tc.accept)
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tc.expectedContentType, rec.Header().Get("Content-Type"))
+			assert.Contains(t, rec.Body.String(), tc.expectedBody)
+		})
+	}
+}
+
+func TestServer_StreamNDJSON(t *testing.T) {
+	s := newServer(testdb.New())
+
+	s.database.Actor().Create(&models.ActorRequest{Name: "Keanu Reeves", Gender: "male", BirthDate: "1964-09-02"}, models.Author{Login: "admin"})
+	s.database.Actor().Create(&models.ActorRequest{Name: "Carrie-Anne Moss", Gender: "female", BirthDate: "1967-08-21"}, models.Author{Login: "admin"})
+	s.database.Film().Create(&models.FilmRequest{Title: "Alpha", ReleaseDate: "1999-03-31", Rating: 8.7, ActorsIDs: []int{1}}, models.Author{Login: "admin"})
+	s.database.Film().Create(&models.FilmRequest{Title: "Beta", ReleaseDate: "2003-05-15", Rating: 7.2}, models.Author{Login: "admin"})
+
+	var tests = []struct {
+		name           string
+		target         string
+		expectedFirsts []string
+	}{
+		{
+			name:           "Films",
+			target:         "/films?sort=title",
+			expectedFirsts: []string{`{"id":1,"title":"Alpha"`, `{"id":2,"title":"Beta"`},
+		},
+		{
+			name:           "Actors",
+			target:         "/actors?sort=name",
+			expectedFirsts: []string{`{"id":2,"name":"Carrie-Anne Moss"`, `{"id":1,"name":"Keanu Reeves"`},
+		},
+		{
+			name:           "Fuzzy films",
+			target:         "/films?mode=fuzzy&searchtitle=Alpah&threshold=0.3",
+			expectedFirsts: []string{`{"id":1,"title":"Alpha"`},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tc.target, nil)
+			req.SetBasicAuth("normal", "correct")
+			req.Header.Set("Accept", "application/x-ndjson")
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+
+			lines := strings.Split(strings.TrimSuffix(rec.Body.String(), "\n"), "\n")
+			assert.Equal(t, len(tc.expectedFirsts), len(lines))
+			for i, line := range lines {
+				assert.True(t, strings.HasPrefix(line, tc.expectedFirsts[i]), line)
+				assert.True(t, json.Valid([]byte(line)))
+			}
+		})
+	}
+}
+
+func TestServer_SparseFieldsets(t *testing.T) {
+	s := newServer(testdb.New())
+
+	s.database.Actor().Create(&models.ActorRequest{Name: "Keanu Reeves", Gender: "male", BirthDate: "1964-09-02"}, models.Author{Login: "admin"})
+	s.database.Film().Create(&models.FilmRequest{
+		Title:       "The Matrix",
+		Description: "Hacker learns the truth",
+		ReleaseDate: "1999-03-31",
+		Rating:      8.7,
+		ActorsIDs:   []int{1},
+	}, models.Author{Login: "admin"})
+
+	var tests = []struct {
+		name         string
+		target       string
+		accept       string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Films fields",
+			target:       "/films?fields=rating,title",
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"id":1,"rating":8.7,"title":"The Matrix"}]`,
+		},
+		{
+			name:         "Films fields with expand",
+			target:       "/films?fields=title&expand=actors",
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"id":1,"title":"The Matrix","actors":[{"actor_id":1,"name":"Keanu Reeves"}]}]`,
+		},
+		{
+			name:         "Films expand only",
+			target:       "/films?expand=actors",
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"id":1,"title":"The Matrix","description":"Hacker learns the truth","release_date":"1999-03-31","rating":8.7,"actors":[{"actor_id":1,"name":"Keanu Reeves"}]}]`,
+		},
+		{
+			name:         "Films fields as CSV",
+			target:       "/films?fields=title&expand=actors",
+			accept:       "text/csv",
+			expectedCode: http.StatusOK,
+			expectedBody: "id,title,actor_ids,actor_names\n1,The Matrix,1,Keanu Reeves\n",
+		},
+		{
+			name:         "Empty films fields as CSV",
+			target:       "/films?fields=title&expand=actors&searchtitle=Nothing",
+			accept:       "text/csv",
+			expectedCode: http.StatusOK,
+			expectedBody: "id,title,actor_ids,actor_names\n",
+		},
+		{
+			name:         "Films fields as XML",
+			target:       "/films?fields=title&expand=actors",
+			accept:       "application/xml",
+			expectedCode: http.StatusOK,
+			expectedBody: xml.Header + `<films><film><id>1</id><title>The Matrix</title><actors><actor><actor_id>1</actor_id><name>Keanu Reeves</name></actor></actors></film></films>`,
+		},
+		{
+			name:         "Films fields as NDJSON",
+			target:       "/films?fields=title",
+			accept:       "application/x-ndjson",
+			expectedCode: http.StatusOK,
+			expectedBody: "{\"id\":1,\"title\":\"The Matrix\"}\n",
+		},
+		{
+			name:         "Actors fields",
+			target:       "/actors?fields=name,gender",
+			expectedCode: http.StatusOK,
+			expectedBody: `[{"id":1,"name":"Keanu Reeves","gender":"male"}]`,
+		},
+		{
+			name:         "Film by id",
+			target:       "/films/1?fields=title",
+			expectedCode: http.StatusOK,
+			expectedBody: `"title":"The Matrix"}`,
+		},
+		{
+			name:         "Unknown field",
+			target:       "/films?fields=title,budget",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Unknown relation",
+			target:       "/actors?expand=actors",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tc.target, nil)
+			req.SetBasicAuth("normal", "correct")
+			req.Header.Set("Accept", tc.accept)
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedCode == http.StatusOK {
+				assert.True(t, strings.HasSuffix(rec.Body.String(), tc.expectedBody), rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServer_HandleGraphQL(t *testing.T) {
+	s := newServer(testdb.New())
+
+	s.database.Actor().Create(&models.ActorRequest{Name: "Keanu Reeves", Gender: "male", BirthDate: "1964-09-02"}, models.Author{Login: "admin"})
+	s.database.Film().Create(&models.FilmRequest{
+		Title:       "The Matrix",
+		ReleaseDate: "1999-03-31",
+		Rating:      8.7,
+		ActorsIDs:   []int{1},
+	}, models.Author{Login: "admin"})
+
+	var tests = []struct {
+		name         string
+		method       string
+		target       string
+		payload      any
+		login        string
+		password     string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Unauthorized",
+			method:       http.MethodPost,
+			target:       "/graphql",
+			payload:      map[string]string{"query": "{ films { title } }"},
+			login:        "normal",
+			password:     "wrong",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "Nested query",
+			method:       http.MethodPost,
+			target:       "/graphql",
+			payload:      map[string]string{"query": "{ films { title actors { name } } }"},
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"data":{"films":[{"actors":[{"name":"Keanu Reeves"}],"title":"The Matrix"}]}}`,
+		},
+		{
+			name:         "Query in URL",
+			method:       http.MethodGet,
+			target:       "/graphql?query=" + url.QueryEscape("{ actor(id: 1) { name } }"),
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"data":{"actor":{"name":"Keanu Reeves"}}}`,
+		},
+		{
+			name:         "Mutation by normal user",
+			method:       http.MethodPost,
+			target:       "/graphql",
+			payload:      map[string]string{"query": "mutation { deleteFilm(id: 1) }"},
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusOK,
+			expectedBody: `"message":"not enough rights"`,
+		},
+		{
+			name:         "Mutation by admin",
+			method:       http.MethodPost,
+			target:       "/graphql",
+			payload:      map[string]string{"query": "mutation { deleteFilm(id: 1) }"},
+			login:        "admin",
+			password:     "adminpass",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"data":{"deleteFilm":true}}`,
+		},
+		{
+			name:         "Missing query",
+			method:       http.MethodPost,
+			target:       "/graphql",
+			payload:      map[string]string{},
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Wrong method",
+			method:       http.MethodPut,
+			target:       "/graphql",
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b := &bytes.Buffer{}
+			if tc.payload != nil {
+				json.NewEncoder(b).Encode(tc.payload)
+			}
+			req, _ := http.NewRequest(tc.method, tc.target, b)
+			req.SetBasicAuth(tc.login, tc.password)
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedBody != "" {
+				assert.Contains(t, rec.Body.String(), tc.expectedBody)
+			}
+		})
+	}
+}
+
+func TestOpenStore(t *testing.T) {
+	_, _, err := openStore(&Config{Store: "files"})
+	assert.Error(t, err)
+
+	database, closeStore, err := openStore(&Config{Store: "database", DatabaseURL: "sqlite://:memory:"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer closeStore()
+
+	s := newServer(database)
+	s.database.User().Create(&models.UserRequest{Login: "somebody", Password: "secret"}, models.Author{})
+	req, _ := http.NewRequest(http.MethodGet, "/films", nil)
+	req.SetBasicAuth("somebody", "secret")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestOpenStore_Memory(t *testing.T) {
+	_, _, err := openStore(&Config{Store: "memory", AdminLogin: "root", AdminPassword: "short"})
+	assert.Error(t, err)
+
+	database, _, err := openStore(&Config{Store: "memory"})
+	require.NoError(t, err)
+	_, err = database.User().Find("admin")
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+
+	database, _, err = openStore(&Config{Store: "memory", AdminLogin: "root", AdminPassword: "rootpass"})
+	require.NoError(t, err)
+	users, err := database.User().GetAll()
+	require.NoError(t, err)
+	require.Equal(t, 1, len(users))
+	assert.Equal(t, "root", users[0].Login)
+	assert.True(t, users[0].IsAdmin)
+}
+
+func TestServer_HandleCache(t *testing.T) {
+	database := testdb.New()
+	id, _ := database.Film().Create(&models.FilmRequest{Title: "Alpha", ReleaseDate: "1999-05-01", Rating: 7.8}, models.Author{Login: "admin"})
+
+	req, _ := http.NewRequest(http.MethodGet, "/cache", nil)
+	req.SetBasicAuth("admin", "adminpass")
+	rec := httptest.NewRecorder()
+	newServer(database).ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	s := newServer(cache.New(database, 10, time.Minute))
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "/films/"+strconv.Itoa(id), nil)
+		req.SetBasicAuth("normal", "correct")
+		s.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	var tests = []struct {
+		name         string
+		login        string
+		password     string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Not admin",
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "Admin",
+			login:        "admin",
+			password:     "adminpass",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"hits":1,"misses":1,"evictions":0,"entries":1}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/cache", nil)
+			req.SetBasicAuth(tc.login, tc.password)
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServer_Trash(t *testing.T) {
+	database := testdb.New()
+	actorID, _ := database.Actor().Create(&models.ActorRequest{Name: "Keanu Reeves", Gender: "male", BirthDate: "1964-09-02"}, models.Author{Login: "admin"})
+	filmID, _ := database.Film().Create(&models.FilmRequest{Title: "The Matrix", ReleaseDate: "1999-03-31", Rating: 8.7, ActorsIDs: []int{actorID}}, models.Author{Login: "admin"})
+	s := newServer(database)
+	location := "/films/" + strconv.Itoa(filmID)
+
+	serve := func(method, target, login, password string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, target, nil)
+		req.SetBasicAuth(login, password)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodDelete, location, "admin", "adminpass")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = serve(http.MethodGet, location, "normal", "correct")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec = serve(http.MethodGet, "/films", "normal", "correct")
+	assert.JSONEq(t, `[]`, rec.Body.String())
+
+	var tests = []struct {
+		name         string
+		method       string
+		target       string
+		login        string
+		password     string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Include deleted by normal user",
+			method:       http.MethodGet,
+			target:       "/films?include_deleted=true",
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "Include deleted with invalid value",
+			method:       http.MethodGet,
+			target:       "/films?include_deleted=maybe",
+			login:        "admin",
+			password:     "adminpass",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Trash by normal user",
+			method:       http.MethodGet,
+			target:       "/trash",
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "Restore with incorrect method",
+			method:       http.MethodGet,
+			target:       location + "/restore",
+			login:        "admin",
+			password:     "adminpass",
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:         "Restore by normal user",
+			method:       http.MethodPost,
+			target:       location + "/restore",
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "Restore of a film that is not deleted",
+			method:       http.MethodPost,
+			target:       location + "2/restore",
+			login:        "admin",
+			password:     "adminpass",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "Unknown subresource",
+			method:       http.MethodPost,
+			target:       location + "/undelete",
+			login:        "admin",
+			password:     "adminpass",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serve(tc.method, tc.target, tc.login, tc.password)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	rec = serve(http.MethodGet, "/films?include_deleted=true", "admin", "adminpass")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var films []models.Film
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &films))
+	require.Len(t, films, 1)
+	assert.Equal(t, "admin", films[0].DeletedBy)
+	assert.NotEmpty(t, films[0].DeletedAt)
+
+	rec = serve(http.MethodGet, "/trash", "admin", "adminpass")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var records []models.DeletedRecord
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &records))
+	require.Len(t, records, 1)
+	assert.Equal(t, models.SearchTypeFilm, records[0].Type)
+	assert.Equal(t, filmID, records[0].ID)
+	assert.Equal(t, "The Matrix", records[0].Label)
+	assert.Equal(t, "admin", records[0].DeletedBy)
+
+	rec = serve(http.MethodPost, location+"/restore", "admin", "adminpass")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serve(http.MethodGet, location, "normal", "correct")
+	require.Equal(t, http.StatusOK, rec.Code)
+	film := &models.Film{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), film))
+	assert.Empty(t, film.DeletedAt)
+	if assert.Len(t, film.Actors, 1) {
+		assert.Equal(t, actorID, film.Actors[0].ActorID)
+	}
+
+	rec = serve(http.MethodGet, "/trash", "admin", "adminpass")
+	assert.JSONEq(t, `[]`, rec.Body.String())
+}
+
+func TestServer_Audit(t *testing.T) {
+	s := newServer(testdb.New())
+
+	serve := func(method, target, login, password string, body any, requestID string) *httptest.ResponseRecorder {
+		b := &bytes.Buffer{}
+		if body != nil {
+			json.NewEncoder(b).Encode(body)
+		}
+		req, _ := http.NewRequest(method, target, b)
+		req.SetBasicAuth(login, password)
+		if requestID != "" {
+			req.Header.Set(requestIDHeader, requestID)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodPost, "/films", "admin", "adminpass", map[string]interface{}{
+		"title":        "Title",
+		"release_date": "2024-03-18",
+		"rating":       5.2,
+	}, "create-film")
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "create-film", rec.Header().Get(requestIDHeader))
+	location := rec.Header().Get("Location")
+
+	rec = serve(http.MethodPatch, location, "admin", "adminpass", map[string]interface{}{"rating": 6.1}, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	generatedID := rec.Header().Get(requestIDHeader)
+	assert.NotEmpty(t, generatedID)
+
+	rec = serve(http.MethodGet, location+"/history", "normal", "correct", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var events []models.AuditEvent
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &events))
+	require.Len(t, events, 2)
+	assert.Equal(t, models.AuditActionModify, events[0].Action)
+	assert.Equal(t, generatedID, events[0].RequestID)
+	assert.JSONEq(t, `{"rating":{"before":5.2,"after":6.1}}`, string(events[0].Diff))
+	assert.Equal(t, models.AuditActionCreate, events[1].Action)
+	assert.Equal(t, "admin", events[1].Login)
+	assert.Equal(t, "create-film", events[1].RequestID)
+
+	rec = serve(http.MethodPost, "/register", "", "", map[string]interface{}{"login": "newcomer", "password": "password"}, "")
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	var tests = []struct {
+		name            string
+		method          string
+		target          string
+		login           string
+		password        string
+		expectedCode    int
+		expectedActions []string
+	}{
+		{
+			name:         "History with incorrect method",
+			method:       http.MethodPost,
+			target:       location + "/history",
+			login:        "admin",
+			password:     "adminpass",
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:            "History with leading zeros in the id",
+			method:          http.MethodGet,
+			target:          "/films/001/history",
+			login:           "normal",
+			password:        "correct",
+			expectedCode:    http.StatusOK,
+			expectedActions: []string{"modify", "create"},
+		},
+		{
+			name:            "History of an actor without changes",
+			method:          http.MethodGet,
+			target:          "/actors/1/history",
+			login:           "normal",
+			password:        "correct",
+			expectedCode:    http.StatusOK,
+			expectedActions: []string{},
+		},
+		{
+			name:         "Audit by normal user",
+			method:       http.MethodGet,
+			target:       "/audit",
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:            "Audit by admin",
+			method:          http.MethodGet,
+			target:          "/audit",
+			login:           "admin",
+			password:        "adminpass",
+			expectedCode:    http.StatusOK,
+			expectedActions: []string{"create", "modify", "create"},
+		},
+		{
+			name:            "Audit of users",
+			method:          http.MethodGet,
+			target:          "/audit?entity=user&login=newcomer",
+			login:           "admin",
+			password:        "adminpass",
+			expectedCode:    http.StatusOK,
+			expectedActions: []string{"create"},
+		},
+		{
+			name:            "Audit page",
+			method:          http.MethodGet,
+			target:          "/audit?limit=1&before_id=3",
+			login:           "admin",
+			password:        "adminpass",
+			expectedCode:    http.StatusOK,
+			expectedActions: []string{"modify"},
+		},
+		{
+			name:         "Audit with invalid limit",
+			method:       http.MethodGet,
+			target:       "/audit?limit=0",
+			login:        "admin",
+			password:     "adminpass",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Audit with invalid since",
+			method:       http.MethodGet,
+			target:       "/audit?since=yesterday",
+			login:        "admin",
+			password:     "adminpass",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serve(tc.method, tc.target, tc.login, tc.password, nil, "")
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedActions == nil {
+				return
+			}
+			var events []models.AuditEvent
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &events))
+			actions := make([]string, 0, len(events))
+			for _, event := range events {
+				actions = append(actions, event.Action)
+			}
+			assert.Equal(t, tc.expectedActions, actions)
+		})
+	}
+}
+
+func TestServer_FilmRevisions(t *testing.T) {
+	s := newServer(testdb.New())
+
+	serve := func(method, target, login, password string, body any) *httptest.ResponseRecorder {
+		b := &bytes.Buffer{}
+		if body != nil {
+			json.NewEncoder(b).Encode(body)
+		}
+		req, _ := http.NewRequest(method, target, b)
+		req.SetBasicAuth(login, password)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodPost, "/films", "admin", "adminpass", map[string]interface{}{
+		"title":        "Title",
+		"release_date": "2024-03-18",
+		"rating":       5.2,
+	})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	location := rec.Header().Get("Location")
+	rec = serve(http.MethodPatch, location, "admin", "adminpass", map[string]interface{}{"title": "New title", "rating": 6.1})
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var tests = []struct {
+		name         string
+		method       string
+		target       string
+		login        string
+		password     string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Revisions",
+			method:       http.MethodGet,
+			target:       location + "/revisions",
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Revisions of unknown film",
+			method:       http.MethodGet,
+			target:       "/films/100/revisions",
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "Revision",
+			method:       http.MethodGet,
+			target:       location + "/revisions/1",
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Unknown revision",
+			method:       http.MethodGet,
+			target:       location + "/revisions/3",
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "Invalid revision",
+			method:       http.MethodGet,
+			target:       location + "/revisions/first",
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "Diff",
+			method:       http.MethodGet,
+			target:       location + "/revisions/diff?from=1&to=2",
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"film_id":1,"from":1,"to":2,"diff":{"title":{"before":"Title","after":"New title"},"rating":{"before":5.2,"after":6.1}}}`,
+		},
+		{
+			name:         "Diff of the same revision",
+			method:       http.MethodGet,
+			target:       location + "/revisions/diff?from=2&to=2",
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"film_id":1,"from":2,"to":2,"diff":{}}`,
+		},
+		{
+			name:         "Diff without to",
+			method:       http.MethodGet,
+			target:       location + "/revisions/diff?from=1",
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Revert by normal user",
+			method:       http.MethodPost,
+			target:       location + "/revisions/1/revert",
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "Revert with incorrect method",
+			method:       http.MethodGet,
+			target:       location + "/revisions/1/revert",
+			login:        "admin",
+			password:     "adminpass",
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:         "Revert to unknown revision",
+			method:       http.MethodPost,
+			target:       location + "/revisions/5/revert",
+			login:        "admin",
+			password:     "adminpass",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "Revert",
+			method:       http.MethodPost,
+			target:       location + "/revisions/1/revert",
+			login:        "admin",
+			password:     "adminpass",
+			expectedCode: http.StatusOK,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serve(tc.method, tc.target, tc.login, tc.password, nil)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, rec.Body.String())
+			}
+		})
+	}
+
+	rec = serve(http.MethodGet, location+"/revisions", "normal", "correct", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var revisions []models.FilmRevision
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &revisions))
+	require.Len(t, revisions, 3)
+	assert.Equal(t, 3, revisions[0].Revision)
+	assert.Equal(t, "admin", revisions[0].Login)
+	assert.Equal(t, revisions[2].Film, revisions[0].Film)
+
+	rec = serve(http.MethodGet, location, "normal", "correct", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var film models.Film
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &film))
+	assert.Equal(t, "Title", film.Title)
+	assert.Equal(t, 5.2, film.Rating)
+}
+
+func TestServer_Changes(t *testing.T) {
+	s := newServer(testdb.New())
+
+	serve := func(method, target, login, password string, body any) *httptest.ResponseRecorder {
+		b := &bytes.Buffer{}
+		if body != nil {
+			json.NewEncoder(b).Encode(body)
+		}
+		req, _ := http.NewRequest(method, target, b)
+		req.SetBasicAuth(login, password)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodPost, "/films", "admin", "adminpass", map[string]interface{}{
+		"title":        "Title",
+		"release_date": "2024-03-18",
+		"rating":       5.2,
+	})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	location := rec.Header().Get("Location")
+	rec = serve(http.MethodPatch, location, "admin", "adminpass", map[string]interface{}{"rating": 6.1})
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = serve(http.MethodDelete, location, "admin", "adminpass", nil)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	var tests = []struct {
+		name            string
+		method          string
+		target          string
+		login           string
+		password        string
+		expectedCode    int
+		expectedActions []string
+	}{
+		{
+			name:            "All changes",
+			method:          http.MethodGet,
+			target:          "/changes",
+			login:           "normal",
+			password:        "correct",
+			expectedCode:    http.StatusOK,
+			expectedActions: []string{"create", "modify", "delete"},
+		},
+		{
+			name:            "Since",
+			method:          http.MethodGet,
+			target:          "/changes?since=1",
+			login:           "normal",
+			password:        "correct",
+			expectedCode:    http.StatusOK,
+			expectedActions: []string{"modify", "delete"},
+		},
+		{
+			name:            "Since and limit",
+			method:          http.MethodGet,
+			target:          "/changes?since=1&limit=1",
+			login:           "normal",
+			password:        "correct",
+			expectedCode:    http.StatusOK,
+			expectedActions: []string{"modify"},
+		},
+		{
+			name:            "Since the last change",
+			method:          http.MethodGet,
+			target:          "/changes?since=3",
+			login:           "normal",
+			password:        "correct",
+			expectedCode:    http.StatusOK,
+			expectedActions: []string{},
+		},
+		{
+			name:         "Invalid since",
+			method:       http.MethodGet,
+			target:       "/changes?since=-1",
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid limit",
+			method:       http.MethodGet,
+			target:       "/changes?limit=1001",
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Incorrect method",
+			method:       http.MethodPost,
+			target:       "/changes",
+			login:        "admin",
+			password:     "adminpass",
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:         "Unauthorized",
+			method:       http.MethodGet,
+			target:       "/changes",
+			login:        "normal",
+			password:     "incorrect",
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serve(tc.method, tc.target, tc.login, tc.password, nil)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedActions == nil {
+				return
+			}
+			var events []models.ChangeEvent
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &events))
+			actions := make([]string, 0, len(events))
+			for _, event := range events {
+				assert.Equal(t, models.AuditEntityFilm, event.Entity)
+				actions = append(actions, event.Action)
+			}
+			assert.Equal(t, tc.expectedActions, actions)
+		})
+	}
+}
+
+func TestServer_Webhooks(t *testing.T) {
+	database := testdb.New()
+	s := newServer(database)
+
+	serve := func(method, target, login, password string, body any) *httptest.ResponseRecorder {
+		b := &bytes.Buffer{}
+		if body != nil {
+			json.NewEncoder(b).Encode(body)
+		}
+		req, _ := http.NewRequest(method, target, b)
+		req.SetBasicAuth(login, password)
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec
+	}
+
+	var signatures, bodies []string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signatures = append(signatures, r.Header.Get(webhook.SignatureHeader))
+		bodies = append(bodies, string(body))
+	}))
+	defer receiver.Close()
+
+	var tests = []struct {
+		name         string
+		method       string
+		target       string
+		login        string
+		password     string
+		payload      any
+		expectedCode int
+	}{
+		{
+			name:         "Not an admin",
+			method:       http.MethodGet,
+			target:       "/webhooks",
+			login:        "normal",
+			password:     "correct",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "Unauthorized",
+			method:       http.MethodGet,
+			target:       "/webhooks",
+			login:        "admin",
+			password:     "incorrect",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "Unknown event",
+			method:       http.MethodPost,
+			target:       "/webhooks",
+			login:        "admin",
+			password:     "adminpass",
+			payload:      map[string]interface{}{"url": receiver.URL, "events": []string{"film.watched"}},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "Invalid url",
+			method:       http.MethodPost,
+			target:       "/webhooks",
+			login:        "admin",
+			password:     "adminpass",
+			payload:      map[string]interface{}{"url": "ftp://localhost", "events": []string{"film.created"}},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "Webhook not found",
+			method:       http.MethodGet,
+			target:       "/webhooks/100",
+			login:        "admin",
+			password:     "adminpass",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "Deliveries of unknown webhook",
+			method:       http.MethodGet,
+			target:       "/webhooks/100/deliveries",
+			login:        "admin",
+			password:     "adminpass",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "Invalid limit",
+			method:       http.MethodGet,
+			target:       "/webhooks/1/deliveries?limit=0",
+			login:        "admin",
+			password:     "adminpass",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Incorrect method",
+			method:       http.MethodPut,
+			target:       "/webhooks/1",
+			login:        "admin",
+			password:     "adminpass",
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:         "Unknown path",
+			method:       http.MethodGet,
+			target:       "/webhooks/1/secret",
+			login:        "admin",
+			password:     "adminpass",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serve(tc.method, tc.target, tc.login, tc.password, tc.payload)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	rec := serve(http.MethodPost, "/webhooks", "admin", "adminpass", map[string]interface{}{
+		"url":    receiver.URL,
+		"events": []string{"film.created", "film.deleted"},
+	})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	location := rec.Header().Get("Location")
+	var created models.Webhook
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "/webhooks/"+strconv.Itoa(created.ID), location)
+	assert.Len(t, created.Secret, 64)
+
+	rec = serve(http.MethodGet, location, "admin", "adminpass", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var found models.Webhook
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &found))
+	assert.Equal(t, []string{"film.created", "film.deleted"}, found.Events)
+	assert.Empty(t, found.Secret)
+
+	rec = serve(http.MethodGet, "/webhooks", "admin", "adminpass", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var webhooks []models.Webhook
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &webhooks))
+	require.Len(t, webhooks, 1)
+	assert.Empty(t, webhooks[0].Secret)
+
+	rec = serve(http.MethodPost, "/films", "admin", "adminpass", map[string]interface{}{
+		"title":        "Title",
+		"release_date": "2024-03-18",
+		"rating":       5.2,
+	})
+	require.Equal(t, http.StatusCreated, rec.Code)
+	film := rec.Header().Get("Location")
+	rec = serve(http.MethodPatch, film, "admin", "adminpass", map[string]interface{}{"rating": 6.1})
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	dispatcher := webhook.NewDispatcher(database.Webhook(), receiver.Client(), s.logger, time.Minute, 3)
+	sent, err := dispatcher.Dispatch(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	require.Len(t, bodies, 1)
+	assert.Equal(t, webhook.Sign(created.Secret, []byte(bodies[0])), signatures[0])
+	var payload models.WebhookPayload
+	require.NoError(t, json.Unmarshal([]byte(bodies[0]), &payload))
+	assert.Equal(t, "film.created", payload.Event)
+
+	rec = serve(http.MethodGet, location+"/deliveries", "admin", "adminpass", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var deliveries []models.WebhookDelivery
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &deliveries))
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, models.WebhookStatusSucceeded, deliveries[0].Status)
+	assert.Equal(t, receiver.URL, deliveries[0].URL)
+
+	rec = serve(http.MethodGet, location+"/deliveries/"+strconv.Itoa(deliveries[0].ID)+"/redeliver", "admin", "adminpass", nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	rec = serve(http.MethodPost, location+"/deliveries/100/redeliver", "admin", "adminpass", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec = serve(http.MethodPost, location+"/deliveries/"+strconv.Itoa(deliveries[0].ID)+"/redeliver", "admin", "adminpass", nil)
+	require.Equal(t, http.StatusAccepted, rec.Code)
+
+	sent, err = dispatcher.Dispatch(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	require.Len(t, bodies, 2)
+	assert.Equal(t, bodies[0], bodies[1])
+
+	rec = serve(http.MethodGet, location+"/deliveries?limit=1", "admin", "adminpass", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &deliveries))
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+	assert.Equal(t, models.WebhookStatusSucceeded, deliveries[0].Status)
+
+	rec = serve(http.MethodDelete, location, "admin", "adminpass", nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	rec = serve(http.MethodDelete, location, "admin", "adminpass", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
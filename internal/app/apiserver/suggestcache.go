@@ -0,0 +1,87 @@
+package apiserver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+)
+
+const (
+	suggestCacheTTL  = 30 * time.Second
+	suggestCacheSize = 1024
+)
+
+type suggestCacheEntry struct {
+	suggestions []models.Suggestion
+	expires     time.Time
+}
+
+// suggestCache keeps recent autocomplete answers in memory. Short prefixes are
+// typed by everyone, so most requests are served without touching the database.
+type suggestCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	size    int
+	entries map[string]suggestCacheEntry
+}
+
+func newSuggestCache(ttl time.Duration, size int) *suggestCache {
+	return &suggestCache{
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[string]suggestCacheEntry),
+	}
+}
+
+func (c *suggestCache) get(key string) ([]models.Suggestion, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	return entry.suggestions, true
+}
+
+func (c *suggestCache) set(key string, suggestions []models.Suggestion) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= c.size {
+		var oldestKey string
+		var oldest time.Time
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+				continue
+			}
+			if oldestKey == "" || entry.expires.Before(oldest) {
+				oldestKey, oldest = k, entry.expires
+			}
+		}
+		if len(c.entries) >= c.size {
+			delete(c.entries, oldestKey)
+		}
+	}
+
+	c.entries[key] = suggestCacheEntry{
+		suggestions: suggestions,
+		expires:     now.Add(c.ttl),
+	}
+}
+
+// clear drops everything, it is called whenever films or actors change.
+func (c *suggestCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]suggestCacheEntry)
+}
@@ -0,0 +1,230 @@
+package apiserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+func (s *server) handleWebhooks(w http.ResponseWriter, r *http.Request) {
+	s.logRequest(r)
+
+	if !s.authenticateAdmin(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.getWebhooks(w)
+
+	case http.MethodPost:
+		s.addWebhook(w, r)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleWebhooksID serves /webhooks/{id}, /webhooks/{id}/deliveries and
+// /webhooks/{id}/deliveries/{d}/redeliver.
+func (s *server) handleWebhooksID(w http.ResponseWriter, r *http.Request) {
+	s.logRequest(r)
+
+	parts := strings.Split(r.URL.Path, "/")
+	if len(parts) != 3 && (len(parts) != 4 || parts[3] != "deliveries") &&
+		(len(parts) != 6 || parts[3] != "deliveries" || parts[5] != "redeliver") {
+		http.NotFound(w, r)
+		return
+	}
+
+	id, err := strconv.Atoi(parts[2])
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	deliveryID := 0
+	if len(parts) == 6 {
+		if deliveryID, err = strconv.Atoi(parts[4]); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	if !s.authenticateAdmin(w, r) {
+		return
+	}
+
+	switch {
+	case len(parts) == 6:
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.redeliver(w, r, id, deliveryID)
+
+	case len(parts) == 4:
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.getDeliveries(w, r, id)
+
+	case r.Method == http.MethodGet:
+		s.getWebhook(w, r, id)
+
+	case r.Method == http.MethodDelete:
+		s.deleteWebhook(w, r, id)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// authenticateAdmin answers the request itself unless the user is an admin.
+func (s *server) authenticateAdmin(w http.ResponseWriter, r *http.Request) bool {
+	registered, isAdmin := s.authenticateUser(w, r)
+	if !registered {
+		return false
+	}
+	if !isAdmin {
+		http.Error(w, "Not enough rights", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+// getWebhooks lists the webhooks without their secrets.
+func (s *server) getWebhooks(w http.ResponseWriter) {
+	webhooks, err := s.database.Webhook().GetAll()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when getting webhooks")
+		return
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	s.writeJSON(w, webhooks)
+}
+
+// addWebhook creates the webhook, with a random secret unless the request
+// has one, and returns it including the secret, which is not shown again.
+func (s *server) addWebhook(w http.ResponseWriter, r *http.Request) {
+	req := &models.WebhookRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !req.Validate() {
+		http.Error(w, "Invalid fields in payload", http.StatusUnprocessableEntity)
+		return
+	}
+	if req.Secret == "" {
+		b := make([]byte, webhookSecretBytes)
+		rand.Read(b)
+		req.Secret = hex.EncodeToString(b)
+	}
+
+	id, err := s.database.Webhook().Create(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when creating webhook")
+		return
+	}
+	webhook, err := s.database.Webhook().Find(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when finding webhook")
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/webhooks/%d", id))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(webhook)
+}
+
+func (s *server) getWebhook(w http.ResponseWriter, r *http.Request, id int) {
+	webhook, err := s.database.Webhook().Find(id)
+	if err == store.ErrRecordNotFound {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when finding webhook")
+		return
+	}
+	webhook.Secret = ""
+
+	s.writeJSON(w, webhook)
+}
+
+func (s *server) deleteWebhook(w http.ResponseWriter, r *http.Request, id int) {
+	done, err := s.database.Webhook().Delete(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when deleting webhook")
+		return
+	}
+	if !done {
+		http.NotFound(w, r)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getDeliveries lists the latest deliveries of the webhook, newest first.
+func (s *server) getDeliveries(w http.ResponseWriter, r *http.Request, id int) {
+	limit, ok := parseDeliveriesLimit(r.URL.Query())
+	if !ok {
+		http.Error(w, "invalid query parameters", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := s.database.Webhook().Find(id); err != nil {
+		if err == store.ErrRecordNotFound {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when finding webhook")
+		return
+	}
+	deliveries, err := s.database.Webhook().GetDeliveries(id, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when getting webhook deliveries")
+		return
+	}
+
+	s.writeJSON(w, deliveries)
+}
+
+// redeliver queues the payload of the delivery again as a new delivery,
+// which the dispatcher sends on its next run.
+func (s *server) redeliver(w http.ResponseWriter, r *http.Request, id int, deliveryID int) {
+	newID, err := s.database.Webhook().Redeliver(id, deliveryID)
+	if err == store.ErrRecordNotFound {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		s.logger.WithError(err).Info("Error when redelivering webhook delivery")
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/webhooks/%d/deliveries", id))
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte(fmt.Sprintf("Delivery %d queued", newID)))
+}
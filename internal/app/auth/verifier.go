@@ -0,0 +1,157 @@
+// Package auth checks user credentials for the APIs. Successful checks are
+// remembered for a short while so that bcrypt does not run on every request.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/hasher"
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+// ErrIncorrectPassword
+var ErrIncorrectPassword = errors.New("incorrect password")
+
+// checkPassword is replaced in tests to count bcrypt runs.
+var checkPassword = hasher.CheckPasswordHash
+
+// verification is a successful check. Passwords are kept as an HMAC under a
+// key that never leaves the process, and an entry only counts while the
+// stored hash is the one the password was checked against.
+type verification struct {
+	mac     []byte
+	hash    string
+	expires time.Time
+}
+
+// Verifier
+type Verifier struct {
+	users   store.UserRepository
+	key     []byte
+	size    int
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]verification
+}
+
+// NewVerifier remembers up to size successful checks for ttl each, size 0
+// turns remembering off.
+func NewVerifier(users store.UserRepository, size int, ttl time.Duration) *Verifier {
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+
+	return &Verifier{
+		users:   users,
+		key:     key,
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]verification),
+	}
+}
+
+// Verify returns the user if password is theirs, store.ErrRecordNotFound for
+// unknown logins and ErrIncorrectPassword otherwise. The user is always read
+// from the store, so role changes apply at once. A hash made with another
+// bcrypt cost than the current one is replaced on the way.
+func (v *Verifier) Verify(login string, password string) (*models.User, error) {
+	user, err := v.users.Find(login)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := v.mac(login, password)
+	if v.remembered(login, mac, user.HashedPassword) {
+		return user, nil
+	}
+
+	if !checkPassword(password, user.HashedPassword) {
+		return nil, ErrIncorrectPassword
+	}
+	if hasher.NeedsRehash(user.HashedPassword) {
+		// A failed rehash is not the user's problem, the next login retries.
+		hash, err := hasher.HashPassword(password)
+		if err == nil {
+			done, err := v.users.ReplaceHash(login, user.HashedPassword, hash)
+			if err == nil && done {
+				user.HashedPassword = hash
+			}
+		}
+	}
+	v.remember(login, mac, user.HashedPassword)
+
+	return user, nil
+}
+
+// Forget drops the check remembered for login, to be called when its
+// password or role changes.
+func (v *Verifier) Forget(login string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	delete(v.entries, login)
+}
+
+func (v *Verifier) mac(login string, password string) []byte {
+	h := hmac.New(sha256.New, v.key)
+	h.Write([]byte(login))
+	h.Write([]byte{0})
+	h.Write([]byte(password))
+	return h.Sum(nil)
+}
+
+func (v *Verifier) remembered(login string, mac []byte, hash string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	entry, ok := v.entries[login]
+	if !ok {
+		return false
+	}
+	if time.Now().After(entry.expires) || entry.hash != hash {
+		delete(v.entries, login)
+		return false
+	}
+
+	return hmac.Equal(entry.mac, mac)
+}
+
+func (v *Verifier) remember(login string, mac []byte, hash string) {
+	if v.size <= 0 {
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	now := time.Now()
+	if _, ok := v.entries[login]; !ok && len(v.entries) >= v.size {
+		var oldestLogin string
+		var oldest time.Time
+		for l, entry := range v.entries {
+			if now.After(entry.expires) {
+				delete(v.entries, l)
+				continue
+			}
+			if oldestLogin == "" || entry.expires.Before(oldest) {
+				oldestLogin, oldest = l, entry.expires
+			}
+		}
+		if len(v.entries) >= v.size {
+			delete(v.entries, oldestLogin)
+		}
+	}
+
+	v.entries[login] = verification{
+		mac:     mac,
+		hash:    hash,
+		expires: now.Add(v.ttl),
+	}
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/hasher"
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// countChecks counts bcrypt runs until the test ends.
+func countChecks(t *testing.T) *int {
+	var checks int
+	checkPassword = func(password string, hash string) bool {
+		checks++
+		return hasher.CheckPasswordHash(password, hash)
+	}
+	t.Cleanup(func() { checkPassword = hasher.CheckPasswordHash })
+	return &checks
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	checks := countChecks(t)
+	s := testdb.New()
+	_, err := s.User().Create(&models.UserRequest{Login: "somebody", Password: "secret"}, models.Author{})
+	require.NoError(t, err)
+	v := NewVerifier(s.User(), 10, time.Minute)
+
+	_, err = v.Verify("nobody", "secret")
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+
+	user, err := v.Verify("somebody", "secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "somebody", user.Login)
+	_, err = v.Verify("somebody", "secret")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, *checks)
+
+	_, err = v.Verify("somebody", "wrong")
+	assert.ErrorIs(t, err, ErrIncorrectPassword)
+	assert.Equal(t, 2, *checks)
+
+	t.Run("Role changes apply at once", func(t *testing.T) {
+		_, err := s.User().SetAdmin("somebody", true, models.Author{Login: "admin"})
+		require.NoError(t, err)
+
+		user, err := v.Verify("somebody", "secret")
+		assert.NoError(t, err)
+		assert.True(t, user.IsAdmin)
+	})
+
+	t.Run("Password changes are noticed", func(t *testing.T) {
+		_, err := s.User().SetPassword("somebody", "changed", models.Author{Login: "admin"})
+		require.NoError(t, err)
+
+		_, err = v.Verify("somebody", "secret")
+		assert.ErrorIs(t, err, ErrIncorrectPassword)
+		_, err = v.Verify("somebody", "changed")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Forget", func(t *testing.T) {
+		before := *checks
+		v.Forget("somebody")
+
+		_, err := v.Verify("somebody", "changed")
+		assert.NoError(t, err)
+		assert.Equal(t, before+1, *checks)
+	})
+}
+
+func TestVerifier_Bounds(t *testing.T) {
+	checks := countChecks(t)
+	s := testdb.New()
+	s.User().Create(&models.UserRequest{Login: "first", Password: "secret"}, models.Author{})
+	s.User().Create(&models.UserRequest{Login: "second", Password: "secret"}, models.Author{})
+
+	v := NewVerifier(s.User(), 1, time.Minute)
+	v.Verify("first", "secret")
+	v.Verify("second", "secret")
+	v.Verify("first", "secret")
+	assert.Equal(t, 3, *checks)
+
+	v = NewVerifier(s.User(), 10, time.Millisecond)
+	v.Verify("first", "secret")
+	time.Sleep(5 * time.Millisecond)
+	v.Verify("first", "secret")
+	assert.Equal(t, 5, *checks)
+
+	v = NewVerifier(s.User(), 0, time.Minute)
+	v.Verify("first", "secret")
+	v.Verify("first", "secret")
+	assert.Equal(t, 7, *checks)
+}
+
+func TestVerifier_Rehash(t *testing.T) {
+	require.NoError(t, hasher.SetCost(bcrypt.MinCost))
+	defer hasher.SetCost(hasher.DefaultCost)
+
+	s := testdb.New()
+	s.User().Create(&models.UserRequest{Login: "somebody", Password: "secret"}, models.Author{})
+	require.NoError(t, hasher.SetCost(bcrypt.MinCost+1))
+
+	user, err := NewVerifier(s.User(), 10, time.Minute).Verify("somebody", "secret")
+	require.NoError(t, err)
+
+	stored, err := s.User().Find("somebody")
+	require.NoError(t, err)
+	assert.Equal(t, user.HashedPassword, stored.HashedPassword)
+	cost, err := bcrypt.Cost([]byte(stored.HashedPassword))
+	assert.NoError(t, err)
+	assert.Equal(t, bcrypt.MinCost+1, cost)
+	assert.True(t, hasher.CheckPasswordHash("secret", stored.HashedPassword))
+	assert.False(t, hasher.NeedsRehash(stored.HashedPassword))
+}
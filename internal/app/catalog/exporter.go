@@ -0,0 +1,27 @@
+package catalog
+
+import (
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+// Export writes the selected entities from one snapshot of the repository,
+// actors first so that the output can be imported back as is. The writer is
+// not closed.
+func Export(repository store.CatalogRepository, w Writer, actors, films bool) error {
+	var actorFn func(*models.ActorRecord) error
+	if actors {
+		actorFn = func(a *models.ActorRecord) error {
+			return w.Write(FromActor(a))
+		}
+	}
+
+	var filmFn func(*models.FilmRecord) error
+	if films {
+		filmFn = func(f *models.FilmRecord) error {
+			return w.Write(FromFilm(f))
+		}
+	}
+
+	return repository.Export(actorFn, filmFn)
+}
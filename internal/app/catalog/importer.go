@@ -0,0 +1,212 @@
+package catalog
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+// Importer validates records and writes them in chunks, each chunk in its own
+// transaction. Actors are always written before the films that follow them, so
+// a film can reference actors from earlier rows of the same file.
+type Importer struct {
+	repository store.CatalogRepository
+	author     models.Author
+	chunkSize  int
+	dryRun     bool
+	actors     []models.ActorRecord
+	films      []models.FilmRecord
+	filmRows   []int
+	// castKeys are the external ids and names of the valid actor rows read
+	// so far, which films may use even when a dry run has not written them.
+	castKeys map[string]bool
+	report   *models.ImportReport
+}
+
+// NewImporter writes the changes as made by author.
+func NewImporter(repository store.CatalogRepository, author models.Author, chunkSize int, dryRun bool) *Importer {
+	return &Importer{
+		repository: repository,
+		author:     author,
+		chunkSize:  chunkSize,
+		dryRun:     dryRun,
+	}
+}
+
+// Import reads r to the end. Invalid rows are skipped and listed in the
+// report, the rest is upserted unless the importer is in dry-run mode.
+func (i *Importer) Import(r Reader) (*models.ImportReport, error) {
+	i.report = &models.ImportReport{
+		DryRun: i.dryRun,
+		Errors: make([]models.ImportError, 0),
+	}
+	i.actors = make([]models.ActorRecord, 0, i.chunkSize)
+	i.films = make([]models.FilmRecord, 0, i.chunkSize)
+	i.filmRows = make([]int, 0, i.chunkSize)
+	i.castKeys = make(map[string]bool)
+
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if rowErr, ok := err.(*RowError); ok {
+			i.report.Rows++
+			i.addError(rowErr.Row, rowErr.Err.Error())
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		i.report.Rows++
+
+		if err := i.add(rec); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := i.flushActors(); err != nil {
+		return nil, err
+	}
+	if err := i.flushFilms(); err != nil {
+		return nil, err
+	}
+	// Films are checked when they are flushed, after the rows that follow.
+	sort.SliceStable(i.report.Errors, func(a, b int) bool {
+		return i.report.Errors[a].Row < i.report.Errors[b].Row
+	})
+
+	return i.report, nil
+}
+
+func (i *Importer) add(rec *Record) error {
+	switch rec.Entity {
+	case EntityActor:
+		actor := rec.Actor()
+		if !actor.Request().ValidateForInsert() {
+			i.addError(i.report.Rows, "invalid actor: name must have 1 to 100 characters, gender up to 20, birth_date must be YYYY-MM-DD")
+			return nil
+		}
+		i.actors = append(i.actors, actor)
+		if actor.ExternalID != "" {
+			i.castKeys[actor.ExternalID] = true
+		}
+		i.castKeys[actor.Name] = true
+		if len(i.actors) >= i.chunkSize {
+			return i.flushActors()
+		}
+
+	case EntityFilm:
+		film := rec.Film()
+		if !film.Request().ValidateForInsert() {
+			i.addError(i.report.Rows, "invalid film: title must have 1 to 150 characters, description up to 1000, release_date must be YYYY-MM-DD, rating from 0 to 10")
+			return nil
+		}
+		i.films = append(i.films, film)
+		i.filmRows = append(i.filmRows, i.report.Rows)
+		if len(i.films) >= i.chunkSize {
+			if err := i.flushActors(); err != nil {
+				return err
+			}
+			return i.flushFilms()
+		}
+
+	default:
+		i.addError(i.report.Rows, "unknown entity, must be actor or film")
+	}
+
+	return nil
+}
+
+func (i *Importer) addError(row int, message string) {
+	i.report.Errors = append(i.report.Errors, models.ImportError{Row: row, Message: message})
+}
+
+func (i *Importer) flushActors() error {
+	if len(i.actors) == 0 || i.dryRun {
+		i.actors = i.actors[:0]
+		return nil
+	}
+
+	res, err := i.repository.ImportActors(i.actors, i.author)
+	if err != nil {
+		return errors.Wrap(err, "import actors")
+	}
+	i.report.Actors.Created += res.Created
+	i.report.Actors.Updated += res.Updated
+	i.actors = i.actors[:0]
+
+	return nil
+}
+
+func (i *Importer) flushFilms() error {
+	if len(i.films) == 0 {
+		return nil
+	}
+
+	films, err := i.checkCast()
+	if err != nil {
+		return err
+	}
+	i.films = i.films[:0]
+	i.filmRows = i.filmRows[:0]
+	if len(films) == 0 || i.dryRun {
+		return nil
+	}
+
+	res, err := i.repository.ImportFilms(films, i.author)
+	if err != nil {
+		return errors.Wrap(err, "import films")
+	}
+	i.report.Films.Created += res.Created
+	i.report.Films.Updated += res.Updated
+
+	return nil
+}
+
+// checkCast reports every cast key of the pending films that matches neither
+// an actor row of the file nor an actor in the store, and returns the films
+// without such keys.
+func (i *Importer) checkCast() ([]models.FilmRecord, error) {
+	var keys []string
+	for _, film := range i.films {
+		for _, key := range film.Cast {
+			if !i.castKeys[key] {
+				keys = append(keys, key)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return append([]models.FilmRecord(nil), i.films...), nil
+	}
+
+	unknownKeys, err := i.repository.UnknownCast(keys)
+	if err != nil {
+		return nil, errors.Wrap(err, "check cast")
+	}
+	unknown := make(map[string]bool, len(unknownKeys))
+	for _, key := range unknownKeys {
+		unknown[key] = true
+	}
+
+	films := make([]models.FilmRecord, 0, len(i.films))
+	for n, film := range i.films {
+		valid := true
+		for _, key := range film.Cast {
+			if unknown[key] && !i.castKeys[key] {
+				i.addError(i.filmRows[n], fmt.Sprintf("unknown cast member %q", key))
+				valid = false
+			}
+		}
+		if valid {
+			films = append(films, film)
+		}
+	}
+
+	return films, nil
+}
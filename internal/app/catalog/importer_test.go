@@ -0,0 +1,142 @@
+package catalog_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/catalog"
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/testdb"
+	"github.com/stretchr/testify/assert"
+)
+
+var author = models.Author{Login: "admin"}
+
+func TestImporter_Import(t *testing.T) {
+	var tests = []struct {
+		name           string
+		format         string
+		entity         string
+		data           string
+		dryRun         bool
+		expectedRows   int
+		expectedActors models.ImportResult
+		expectedFilms  models.ImportResult
+		expectedErrors []int
+	}{
+		{
+			name:   "CSV",
+			format: catalog.FormatCSV,
+			data: `entity,external_id,name,gender,birth_date,title,description,release_date,rating,cast
+actor,nm1,Keanu Reeves,male,1964-09-02,,,,,
+film,tt1,,,,The Matrix,"Hacker, learns the truth",1999-03-31,8.7,nm1|Nobody
+film,tt2,,,,Bad rating,,1999-03-31,eleven,
+actor,nm2,,male,1964-09-02,,,,,
+`,
+			expectedRows:   4,
+			expectedActors: models.ImportResult{Created: 1},
+			expectedErrors: []int{2, 3, 4},
+		},
+		{
+			name:   "NDJSON with default entity",
+			format: catalog.FormatNDJSON,
+			entity: catalog.EntityFilm,
+			data: `{"external_id":"tt1","title":"The Matrix","release_date":"1999-03-31","rating":8.7}
+
+{"entity":"actor","name":"Keanu Reeves","gender":"male","birth_date":"1964-09-02"}
+{"title":
+`,
+			expectedRows:   3,
+			expectedActors: models.ImportResult{Created: 1},
+			expectedFilms:  models.ImportResult{Created: 1},
+			expectedErrors: []int{3},
+		},
+		{
+			name:   "Dry run",
+			format: catalog.FormatCSV,
+			data: `entity,name,gender,birth_date
+actor,Keanu Reeves,male,1964-09-02
+actor,Keanu Reeves,male
+`,
+			dryRun:         true,
+			expectedRows:   2,
+			expectedErrors: []int{2},
+		},
+		{
+			name:   "Dry run with unknown cast",
+			format: catalog.FormatCSV,
+			data: `entity,external_id,name,gender,birth_date,title,release_date,rating,cast
+actor,nm1,Keanu Reeves,male,1964-09-02,,,,
+film,tt1,,,,The Matrix,1999-03-31,8.7,nm1|Keanu Reeves
+film,tt2,,,,John Wick,2014-10-24,7.4,nm1|nm404
+`,
+			dryRun:         true,
+			expectedRows:   3,
+			expectedErrors: []int{3},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := testdb.New()
+			reader, err := catalog.NewReader(tc.format, strings.NewReader(tc.data), tc.entity)
+			assert.NoError(t, err)
+
+			report, err := catalog.NewImporter(s.Catalog(), author, 1, tc.dryRun).Import(reader)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.dryRun, report.DryRun)
+			assert.Equal(t, tc.expectedRows, report.Rows)
+			assert.Equal(t, tc.expectedActors, report.Actors)
+			assert.Equal(t, tc.expectedFilms, report.Films)
+
+			rows := make([]int, 0, len(report.Errors))
+			for _, importErr := range report.Errors {
+				rows = append(rows, importErr.Row)
+			}
+			assert.Equal(t, tc.expectedErrors, rows)
+		})
+	}
+}
+
+func TestImporter_ImportCast(t *testing.T) {
+	s := testdb.New()
+	data := `entity,external_id,name,gender,birth_date,title,release_date,rating,cast
+film,tt1,,,,The Matrix,1999-03-31,8.7,nm1
+actor,nm1,Keanu Reeves,male,1964-09-02,,,,
+`
+	reader, _ := catalog.NewReader(catalog.FormatCSV, strings.NewReader(data), "")
+	_, err := catalog.NewImporter(s.Catalog(), author, 10, false).Import(reader)
+	assert.NoError(t, err)
+
+	films, _ := s.Film().GetAll(&store.FilmFilter{})
+	assert.Equal(t, 1, len(films))
+	assert.Equal(t, []models.ActorBasic{{ActorID: 1, Name: "Keanu Reeves"}}, films[0].Actors)
+}
+
+func TestImporter_ImportUnknownCast(t *testing.T) {
+	s := testdb.New()
+	_, err := s.Actor().Create(&models.ActorRequest{Name: "Keanu Reeves", Gender: "male", BirthDate: "1964-09-02"}, models.Author{})
+	assert.NoError(t, err)
+	data := `entity,title,release_date,rating,cast
+film,The Matrix,1999-03-31,8.7,Keanu Reeves|Nobody|Somebody
+film,John Wick,2014-10-24,7.4,Keanu Reeves
+`
+	reader, _ := catalog.NewReader(catalog.FormatCSV, strings.NewReader(data), "")
+	report, err := catalog.NewImporter(s.Catalog(), author, 10, true).Import(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ImportError{
+		{Row: 1, Message: `unknown cast member "Nobody"`},
+		{Row: 1, Message: `unknown cast member "Somebody"`},
+	}, report.Errors)
+
+	reader, _ = catalog.NewReader(catalog.FormatCSV, strings.NewReader(data), "")
+	report, err = catalog.NewImporter(s.Catalog(), author, 10, false).Import(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(report.Errors))
+	assert.Equal(t, models.ImportResult{Created: 1}, report.Films)
+}
+
+func TestNewReader(t *testing.T) {
+	_, err := catalog.NewReader("xml", strings.NewReader(""), "")
+	assert.Error(t, err)
+}
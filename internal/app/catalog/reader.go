@@ -0,0 +1,203 @@
+package catalog
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Reader reads records one by one and returns io.EOF after the last one.
+// Problems with a single row are reported as *RowError, reading can go on
+// after them.
+type Reader interface {
+	Read() (*Record, error)
+}
+
+// RowError
+type RowError struct {
+	Row int
+	Err error
+}
+
+// Error
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %s", e.Row, e.Err)
+}
+
+// NewReader creates a reader of the given format. Rows that do not name their
+// entity are treated as entity, which may be empty if every row has one.
+func NewReader(format string, r io.Reader, entity string) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		return &csvReader{reader: cr, entity: entity}, nil
+	case FormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		return &ndjsonReader{scanner: scanner, entity: entity}, nil
+	case FormatJSON:
+		return &jsonReader{decoder: json.NewDecoder(r), entity: entity}, nil
+	default:
+		return nil, errors.Errorf("unsupported format %q", format)
+	}
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	entity  string
+	columns map[string]int
+	row     int
+}
+
+func (r *csvReader) Read() (*Record, error) {
+	if r.columns == nil {
+		header, err := r.reader.Read()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "read header")
+		}
+		r.columns = make(map[string]int, len(header))
+		for i, column := range header {
+			r.columns[strings.TrimSpace(column)] = i
+		}
+	}
+
+	fields, err := r.reader.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	r.row++
+	if err != nil {
+		if _, ok := err.(*csv.ParseError); ok {
+			return nil, &RowError{Row: r.row, Err: err}
+		}
+		return nil, errors.Wrap(err, "read row")
+	}
+	if len(fields) != len(r.columns) {
+		return nil, &RowError{Row: r.row, Err: errors.New("wrong number of fields")}
+	}
+
+	get := func(column string) string {
+		i, ok := r.columns[column]
+		if !ok {
+			return ""
+		}
+		return fields[i]
+	}
+
+	rec := &Record{
+		Entity:      get("entity"),
+		ExternalID:  get("external_id"),
+		Name:        get("name"),
+		Gender:      get("gender"),
+		BirthDate:   get("birth_date"),
+		Title:       get("title"),
+		Description: get("description"),
+		ReleaseDate: get("release_date"),
+	}
+	if rawRating := get("rating"); rawRating != "" {
+		rec.Rating, err = strconv.ParseFloat(rawRating, 64)
+		if err != nil {
+			return nil, &RowError{Row: r.row, Err: errors.New("invalid rating")}
+		}
+	}
+	if cast := get("cast"); cast != "" {
+		rec.Cast = strings.Split(cast, castSeparator)
+	}
+	if rec.Entity == "" {
+		rec.Entity = r.entity
+	}
+
+	return rec, nil
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	entity  string
+	row     int
+}
+
+func (r *ndjsonReader) Read() (*Record, error) {
+	for r.scanner.Scan() {
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" {
+			continue
+		}
+		r.row++
+
+		rec := &Record{}
+		if err := json.Unmarshal([]byte(line), rec); err != nil {
+			return nil, &RowError{Row: r.row, Err: err}
+		}
+		if rec.Entity == "" {
+			rec.Entity = r.entity
+		}
+
+		return rec, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "read line")
+	}
+
+	return nil, io.EOF
+}
+
+// jsonReader reads a single array of records without loading it whole.
+type jsonReader struct {
+	decoder *json.Decoder
+	entity  string
+	started bool
+	done    bool
+	row     int
+}
+
+func (r *jsonReader) Read() (*Record, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+	if !r.started {
+		token, err := r.decoder.Token()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "read array")
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != '[' {
+			return nil, errors.New("expected an array of records")
+		}
+		r.started = true
+	}
+
+	if !r.decoder.More() {
+		if _, err := r.decoder.Token(); err != nil {
+			return nil, errors.Wrap(err, "read array")
+		}
+		r.done = true
+		return nil, io.EOF
+	}
+	r.row++
+
+	rec := &Record{}
+	if err := r.decoder.Decode(rec); err != nil {
+		// The decoder skips values of a wrong type, so reading can go on.
+		if _, ok := err.(*json.UnmarshalTypeError); ok {
+			return nil, &RowError{Row: r.row, Err: err}
+		}
+		return nil, errors.Wrap(err, "read record")
+	}
+	if rec.Entity == "" {
+		rec.Entity = r.entity
+	}
+
+	return rec, nil
+}
@@ -0,0 +1,100 @@
+package catalog
+
+import (
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+)
+
+const (
+	// EntityActor
+	EntityActor = "actor"
+	// EntityFilm
+	EntityFilm = "film"
+)
+
+const (
+	// FormatCSV
+	FormatCSV = "csv"
+	// FormatNDJSON
+	FormatNDJSON = "ndjson"
+	// FormatJSON
+	FormatJSON = "json"
+)
+
+// csvColumns is the header of CSV files, actor and film rows share it and
+// leave the other entity's columns empty.
+var csvColumns = []string{
+	"entity",
+	"external_id",
+	"name",
+	"gender",
+	"birth_date",
+	"title",
+	"description",
+	"release_date",
+	"rating",
+	"cast",
+}
+
+// castSeparator joins cast keys in a single CSV cell
+const castSeparator = "|"
+
+// Record is one row of an import or export file. Only the fields of its entity
+// are meaningful.
+type Record struct {
+	Entity      string   `json:"entity"`
+	ExternalID  string   `json:"external_id,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	Gender      string   `json:"gender,omitempty"`
+	BirthDate   string   `json:"birth_date,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	ReleaseDate string   `json:"release_date,omitempty"`
+	Rating      float64  `json:"rating,omitempty"`
+	Cast        []string `json:"cast,omitempty"`
+}
+
+// Actor
+func (r *Record) Actor() models.ActorRecord {
+	return models.ActorRecord{
+		ExternalID: r.ExternalID,
+		Name:       r.Name,
+		Gender:     r.Gender,
+		BirthDate:  r.BirthDate,
+	}
+}
+
+// Film
+func (r *Record) Film() models.FilmRecord {
+	return models.FilmRecord{
+		ExternalID:  r.ExternalID,
+		Title:       r.Title,
+		Description: r.Description,
+		ReleaseDate: r.ReleaseDate,
+		Rating:      r.Rating,
+		Cast:        r.Cast,
+	}
+}
+
+// FromActor
+func FromActor(a *models.ActorRecord) *Record {
+	return &Record{
+		Entity:     EntityActor,
+		ExternalID: a.ExternalID,
+		Name:       a.Name,
+		Gender:     a.Gender,
+		BirthDate:  a.BirthDate,
+	}
+}
+
+// FromFilm
+func FromFilm(f *models.FilmRecord) *Record {
+	return &Record{
+		Entity:      EntityFilm,
+		ExternalID:  f.ExternalID,
+		Title:       f.Title,
+		Description: f.Description,
+		ReleaseDate: f.ReleaseDate,
+		Rating:      f.Rating,
+		Cast:        f.Cast,
+	}
+}
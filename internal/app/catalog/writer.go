@@ -0,0 +1,128 @@
+package catalog
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Writer writes records one by one in the format read back by NewReader.
+// Close must be called to finish the document, it does not close the
+// underlying writer.
+type Writer interface {
+	Write(*Record) error
+	Close() error
+}
+
+// NewWriter
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{writer: csv.NewWriter(w)}, nil
+	case FormatNDJSON:
+		buf := bufio.NewWriter(w)
+		return &ndjsonWriter{buf: buf, encoder: json.NewEncoder(buf)}, nil
+	case FormatJSON:
+		return &jsonWriter{buf: bufio.NewWriter(w)}, nil
+	default:
+		return nil, errors.Errorf("unsupported format %q", format)
+	}
+}
+
+type csvWriter struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+func (w *csvWriter) writeHeader() error {
+	if w.headerWritten {
+		return nil
+	}
+	w.headerWritten = true
+	return w.writer.Write(csvColumns)
+}
+
+func (w *csvWriter) Write(rec *Record) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+
+	rating := ""
+	if rec.Entity == EntityFilm {
+		rating = strconv.FormatFloat(rec.Rating, 'f', -1, 64)
+	}
+
+	return w.writer.Write([]string{
+		rec.Entity,
+		rec.ExternalID,
+		rec.Name,
+		rec.Gender,
+		rec.BirthDate,
+		rec.Title,
+		rec.Description,
+		rec.ReleaseDate,
+		rating,
+		strings.Join(rec.Cast, castSeparator),
+	})
+}
+
+func (w *csvWriter) Close() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type ndjsonWriter struct {
+	buf     *bufio.Writer
+	encoder *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(rec *Record) error {
+	return w.encoder.Encode(rec)
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.buf.Flush()
+}
+
+// jsonWriter writes a single array, element by element.
+type jsonWriter struct {
+	buf   *bufio.Writer
+	count int
+}
+
+func (w *jsonWriter) Write(rec *Record) error {
+	sep := ",\n"
+	if w.count == 0 {
+		sep = "[\n"
+	}
+	w.count++
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := w.buf.WriteString(sep); err != nil {
+		return err
+	}
+	_, err = w.buf.Write(data)
+	return err
+}
+
+func (w *jsonWriter) Close() error {
+	end := "\n]\n"
+	if w.count == 0 {
+		end = "[]\n"
+	}
+	if _, err := w.buf.WriteString(end); err != nil {
+		return err
+	}
+	return w.buf.Flush()
+}
@@ -0,0 +1,54 @@
+package catalog_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/catalog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriter_RoundTrip(t *testing.T) {
+	records := []*catalog.Record{
+		{Entity: catalog.EntityActor, ExternalID: "nm1", Name: "Keanu Reeves", Gender: "male", BirthDate: "1964-09-02"},
+		{Entity: catalog.EntityFilm, Title: "The \"Matrix\", part one", Description: "Line one\nline two", ReleaseDate: "1999-03-31", Rating: 8.7, Cast: []string{"nm1", "Carrie-Anne Moss"}},
+		{Entity: catalog.EntityFilm, Title: "Unrated", ReleaseDate: "2000-01-01"},
+	}
+
+	for _, format := range []string{catalog.FormatCSV, catalog.FormatNDJSON, catalog.FormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w, err := catalog.NewWriter(format, buf)
+			assert.NoError(t, err)
+			for _, rec := range records {
+				assert.NoError(t, w.Write(rec))
+			}
+			assert.NoError(t, w.Close())
+
+			r, err := catalog.NewReader(format, buf, "")
+			assert.NoError(t, err)
+			for _, expected := range records {
+				rec, err := r.Read()
+				assert.NoError(t, err)
+				assert.Equal(t, expected, rec)
+			}
+			_, err = r.Read()
+			assert.Equal(t, io.EOF, err)
+		})
+	}
+}
+
+func TestWriter_Empty(t *testing.T) {
+	for _, format := range []string{catalog.FormatCSV, catalog.FormatNDJSON, catalog.FormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w, _ := catalog.NewWriter(format, buf)
+			assert.NoError(t, w.Close())
+
+			r, _ := catalog.NewReader(format, buf, "")
+			_, err := r.Read()
+			assert.Equal(t, io.EOF, err)
+		})
+	}
+}
@@ -8,6 +8,9 @@ type ActorWithFilm struct {
 	BirthDate string  `db:"birth_date"`
 	FilmId    *int    `db:"film_id"`
 	Title     *string `db:"title"`
+	Score     float64 `db:"score"`
+	DeletedAt string  `db:"deleted_at"`
+	DeletedBy string  `db:"deleted_by"`
 }
 
 // Actor
@@ -9,6 +9,9 @@ type FilmWithActor struct {
 	Rating      float64 `db:"rating"`
 	ActorID     *int    `db:"actor_id"`
 	Name        *string `db:"name"`
+	Score       float64 `db:"score"`
+	DeletedAt   string  `db:"deleted_at"`
+	DeletedBy   string  `db:"deleted_by"`
 }
 
 // Film
@@ -19,3 +22,13 @@ type Film struct {
 	ReleaseDate string  `db:"release_date"`
 	Rating      float64 `db:"rating"`
 }
+
+// FilmRevision
+type FilmRevision struct {
+	FilmID    int    `db:"film_id"`
+	Revision  int    `db:"revision"`
+	At        string `db:"at"`
+	Login     string `db:"login"`
+	RequestID string `db:"request_id"`
+	Film      []byte `db:"film"`
+}
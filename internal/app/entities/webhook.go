@@ -0,0 +1,11 @@
+package entities
+
+// Webhook
+type Webhook struct {
+	ID        int    `db:"id"`
+	URL       string `db:"url"`
+	Events    []byte `db:"events"`
+	Secret    string `db:"secret"`
+	LastSeq   int    `db:"last_seq"`
+	CreatedAt string `db:"created_at"`
+}
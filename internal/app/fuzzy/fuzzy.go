@@ -0,0 +1,117 @@
+// Package fuzzy provides the pg_trgm matching of the postgres store to stores
+// that have no such extension, either approximated or computed the same way.
+package fuzzy
+
+import (
+	"strings"
+	"unicode"
+)
+
+// WordSimilarity mimics pg_trgm word_similarity with Levenshtein distance: the
+// query is compared with every run of consecutive words of the text, ignoring
+// case and spaces, and the best similarity in [0, 1] is returned.
+func WordSimilarity(query string, text string) float64 {
+	q := []rune(strings.ToLower(strings.Join(strings.Fields(query), "")))
+	if len(q) == 0 {
+		return 0
+	}
+
+	words := strings.Fields(strings.ToLower(text))
+	var best float64
+	for i := range words {
+		for j := i + 1; j <= len(words); j++ {
+			candidate := []rune(strings.Join(words[i:j], ""))
+			longest := len(q)
+			if len(candidate) > longest {
+				longest = len(candidate)
+			}
+			sim := 1 - float64(levenshtein(q, candidate))/float64(longest)
+			if sim > best {
+				best = sim
+			}
+		}
+	}
+
+	return best
+}
+
+func levenshtein(a []rune, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+// TrigramWordSimilarity is word_similarity as pg_trgm computes it: the
+// greatest similarity between the trigrams of the query and any continuous
+// extent of the ordered trigrams of the text. Similarity of two sets is the
+// number of shared trigrams over the size of their union.
+func TrigramWordSimilarity(query string, text string) float64 {
+	queryTrigrams := make(map[string]bool)
+	for _, trigram := range trigrams(query) {
+		queryTrigrams[trigram] = true
+	}
+	if len(queryTrigrams) == 0 {
+		return 0
+	}
+
+	textTrigrams := trigrams(text)
+	var best float64
+	for lower := range textTrigrams {
+		if !queryTrigrams[textTrigrams[lower]] {
+			continue
+		}
+		extent := make(map[string]bool)
+		shared := 0
+		for upper := lower; upper < len(textTrigrams); upper++ {
+			trigram := textTrigrams[upper]
+			if !extent[trigram] {
+				extent[trigram] = true
+				if queryTrigrams[trigram] {
+					shared++
+				}
+			}
+			if !queryTrigrams[trigram] {
+				continue
+			}
+			sim := float64(shared) / float64(len(queryTrigrams)+len(extent)-shared)
+			if sim > best {
+				best = sim
+			}
+		}
+	}
+
+	return best
+}
+
+// trigrams lists the trigrams of every word in order, words being runs of
+// letters and digits padded like pg_trgm does: two spaces before, one after.
+func trigrams(s string) []string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var result []string
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			result = append(result, string(padded[i:i+3]))
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,53 @@
+package fuzzy_test
+
+import (
+	"testing"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/fuzzy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrigramWordSimilarity(t *testing.T) {
+	var tests = []struct {
+		name     string
+		query    string
+		text     string
+		expected float64
+	}{
+		{
+			name:     "Whole word",
+			query:    "word",
+			text:     "two words",
+			expected: 0.8,
+		},
+		{
+			name:     "Case and punctuation",
+			query:    "Di Caprio",
+			text:     "Leonardo DiCaprio",
+			expected: 7.0 / 12.0,
+		},
+		{
+			name:     "Exact",
+			query:    "matrix",
+			text:     "The Matrix",
+			expected: 1,
+		},
+		{
+			name:     "Nothing shared",
+			query:    "xyz",
+			text:     "The Matrix",
+			expected: 0,
+		},
+		{
+			name:     "Empty query",
+			query:    " ",
+			text:     "The Matrix",
+			expected: 0,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.InDelta(t, tc.expected, fuzzy.TrigramWordSimilarity(tc.query, tc.text), 1e-9)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Package graphapi serves the catalogue over GraphQL. Nested films and actors
+// are resolved through per-request loaders, so a query costs one store call
+// per level instead of one per object.
+package graphapi
+
+import (
+	"context"
+
+	"github.com/graphql-go/graphql"
+	"github.com/pkg/errors"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+var (
+	errNotEnoughRights = errors.New("not enough rights")
+	errInvalidInput    = errors.New("invalid input")
+	errNotFound        = errors.New("not found")
+)
+
+// Request is the body of a GraphQL request.
+type Request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// API
+type API struct {
+	database store.Store
+	onChange func()
+	schema   graphql.Schema
+}
+
+// New builds the schema. onChange is called after every successful mutation.
+// The schema is static, so failing to build it is a bug and New panics.
+func New(database store.Store, onChange func()) *API {
+	if onChange == nil {
+		onChange = func() {}
+	}
+	a := &API{
+		database: database,
+		onChange: onChange,
+	}
+
+	schema, err := a.newSchema()
+	if err != nil {
+		panic(errors.Wrap(err, "build schema"))
+	}
+	a.schema = schema
+
+	return a
+}
+
+type contextKey int
+
+const viewerKey contextKey = iota
+
+// viewer is the authenticated user of a request.
+type viewer struct {
+	author  models.Author
+	isAdmin bool
+	loaders *loaders
+}
+
+// Execute runs the request on behalf of a user authenticated by the caller,
+// mutations are recorded in the audit trail as made by author.
+func (a *API) Execute(ctx context.Context, req *Request, author models.Author, isAdmin bool) *graphql.Result {
+	ctx = context.WithValue(ctx, viewerKey, &viewer{
+		author:  author,
+		isAdmin: isAdmin,
+		loaders: newLoaders(a.database),
+	})
+
+	return graphql.Do(graphql.Params{
+		Schema:         a.schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        ctx,
+	})
+}
+
+func viewerFrom(ctx context.Context) *viewer {
+	return ctx.Value(viewerKey).(*viewer)
+}
+
+func requireAdmin(ctx context.Context) error {
+	if !viewerFrom(ctx).isAdmin {
+		return errNotEnoughRights
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package graphapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/graphapi"
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/testdb"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingStore counts GetAll calls to check that nested fields are batched.
+type countingStore struct {
+	*testdb.Store
+	films  int
+	actors int
+}
+
+func (s *countingStore) Film() store.FilmRepository {
+	return &countingFilms{FilmRepository: s.Store.Film(), calls: &s.films}
+}
+
+func (s *countingStore) Actor() store.ActorRepository {
+	return &countingActors{ActorRepository: s.Store.Actor(), calls: &s.actors}
+}
+
+type countingFilms struct {
+	store.FilmRepository
+	calls *int
+}
+
+func (r *countingFilms) GetAll(filter *store.FilmFilter) ([]models.Film, error) {
+	*r.calls++
+	return r.FilmRepository.GetAll(filter)
+}
+
+type countingActors struct {
+	store.ActorRepository
+	calls *int
+}
+
+func (r *countingActors) GetAll(filter *store.ActorFilter) ([]models.Actor, error) {
+	*r.calls++
+	return r.ActorRepository.GetAll(filter)
+}
+
+func newCountingStore() *countingStore {
+	s := &countingStore{Store: testdb.New()}
+	s.Store.Actor().Create(&models.ActorRequest{Name: "Keanu Reeves", Gender: "male", BirthDate: "1964-09-02"}, models.Author{Login: "admin"})
+	s.Store.Actor().Create(&models.ActorRequest{Name: "Carrie-Anne Moss", Gender: "female", BirthDate: "1967-08-21"}, models.Author{Login: "admin"})
+	s.Store.Actor().Create(&models.ActorRequest{Name: "Laurence Fishburne", Gender: "male", BirthDate: "1961-07-30"}, models.Author{Login: "admin"})
+	s.Store.Film().Create(&models.FilmRequest{Title: "The Matrix", ReleaseDate: "1999-03-31", Rating: 8.7, ActorsIDs: []int{1, 2, 3}}, models.Author{Login: "admin"})
+	s.Store.Film().Create(&models.FilmRequest{Title: "John Wick", ReleaseDate: "2014-10-24", Rating: 7.4, ActorsIDs: []int{1}}, models.Author{Login: "admin"})
+	return s
+}
+
+func TestAPI_NestedQueryIsBatched(t *testing.T) {
+	database := newCountingStore()
+	api := graphapi.New(database, nil)
+
+	result := api.Execute(context.Background(), &graphapi.Request{
+		Query: `{ films { title actors { name films { title } } } }`,
+	}, models.Author{Login: "normal"}, false)
+	assert.Empty(t, result.Errors)
+
+	data, _ := json.Marshal(result.Data)
+	assert.JSONEq(t, `{"films":[
+		{"title":"The Matrix","actors":[
+			{"name":"Keanu Reeves","films":[{"title":"The Matrix"},{"title":"John Wick"}]},
+			{"name":"Carrie-Anne Moss","films":[{"title":"The Matrix"}]},
+			{"name":"Laurence Fishburne","films":[{"title":"The Matrix"}]}
+		]},
+		{"title":"John Wick","actors":[
+			{"name":"Keanu Reeves","films":[{"title":"The Matrix"},{"title":"John Wick"}]}
+		]}
+	]}`, string(data))
+	// One call for the list, one for every actor at the second level, the
+	// films of the third level were already loaded by the first.
+	assert.Equal(t, 1, database.films)
+	assert.Equal(t, 1, database.actors)
+}
+
+func TestAPI_Film(t *testing.T) {
+	database := newCountingStore()
+	api := graphapi.New(database, nil)
+
+	result := api.Execute(context.Background(), &graphapi.Request{
+		Query:     `query ($id: Int!) { film(id: $id) { id title releaseDate actors { id } } missing: film(id: 42) { id } }`,
+		Variables: map[string]interface{}{"id": 2},
+	}, models.Author{Login: "normal"}, false)
+	assert.Empty(t, result.Errors)
+
+	data, _ := json.Marshal(result.Data)
+	assert.JSONEq(t, `{"film":{"id":2,"title":"John Wick","releaseDate":"2014-10-24","actors":[{"id":1}]},"missing":null}`, string(data))
+	assert.Equal(t, 1, database.films)
+}
+
+func TestAPI_Mutations(t *testing.T) {
+	changes := 0
+	api := graphapi.New(newCountingStore(), func() { changes++ })
+
+	var tests = []struct {
+		name            string
+		query           string
+		isAdmin         bool
+		expectedData    string
+		expectedError   string
+		expectedChanges int
+	}{
+		{
+			name:          "Not admin",
+			query:         `mutation { deleteFilm(id: 1) }`,
+			isAdmin:       false,
+			expectedError: "not enough rights",
+		},
+		{
+			name:            "Create film",
+			query:           `mutation { createFilm(input: {title: "Speed", releaseDate: "1994-06-10", rating: 7.2, actorIds: [1]}) { id title actors { name } } }`,
+			isAdmin:         true,
+			expectedData:    `{"createFilm":{"id":3,"title":"Speed","actors":[{"name":"Keanu Reeves"}]}}`,
+			expectedChanges: 1,
+		},
+		{
+			name:          "Invalid film",
+			query:         `mutation { createFilm(input: {title: "", releaseDate: "1994-06-10"}) { id } }`,
+			isAdmin:       true,
+			expectedError: "invalid input",
+		},
+		{
+			name:            "Update actor",
+			query:           `mutation { updateActor(id: 2, input: {name: "Carrie-Anne Moss", gender: "female", birthDate: "1967-08-21"}) { id name } }`,
+			isAdmin:         true,
+			expectedData:    `{"updateActor":{"id":2,"name":"Carrie-Anne Moss"}}`,
+			expectedChanges: 1,
+		},
+		{
+			name:          "Update missing actor",
+			query:         `mutation { updateActor(id: 42, input: {name: "Nobody"}) { id } }`,
+			isAdmin:       true,
+			expectedError: "not found",
+		},
+		{
+			name:            "Delete film",
+			query:           `mutation { deleteFilm(id: 2) }`,
+			isAdmin:         true,
+			expectedData:    `{"deleteFilm":true}`,
+			expectedChanges: 1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			changes = 0
+			result := api.Execute(context.Background(), &graphapi.Request{Query: tc.query}, models.Author{Login: "admin"}, tc.isAdmin)
+			if tc.expectedError != "" {
+				if assert.Len(t, result.Errors, 1) {
+					assert.Equal(t, tc.expectedError, result.Errors[0].Message)
+				}
+				return
+			}
+			assert.Empty(t, result.Errors)
+			data, _ := json.Marshal(result.Data)
+			assert.JSONEq(t, tc.expectedData, string(data))
+			assert.Equal(t, tc.expectedChanges, changes)
+		})
+	}
+}
+
+func TestAPI_Users(t *testing.T) {
+	api := graphapi.New(testdb.New(), nil)
+
+	result := api.Execute(context.Background(), &graphapi.Request{Query: `{ users { login } }`}, models.Author{Login: "normal"}, false)
+	if assert.Len(t, result.Errors, 1) {
+		assert.Equal(t, "not enough rights", result.Errors[0].Message)
+	}
+
+	result = api.Execute(context.Background(), &graphapi.Request{Query: `{ users { login isAdmin } }`}, models.Author{Login: "admin"}, true)
+	assert.Empty(t, result.Errors)
+	data, _ := json.Marshal(result.Data)
+	assert.Contains(t, string(data), `{"isAdmin":true,"login":"admin"}`)
+}
@@ -0,0 +1,123 @@
+package graphapi
+
+import (
+	"sync"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+// loader collects the ids requested by resolvers at one level of the query
+// and fetches them with a single call the first time one of the results is
+// needed, which graphql-go does only after all resolvers of the level ran.
+type loader[T any] struct {
+	mu      sync.Mutex
+	fetch   func(ids []int) (map[int]*T, error)
+	pending []int
+	cache   map[int]*T
+}
+
+func newLoader[T any](fetch func(ids []int) (map[int]*T, error)) *loader[T] {
+	return &loader[T]{fetch: fetch, cache: make(map[int]*T)}
+}
+
+// loadMany returns a thunk resolving to the found values in the order of ids,
+// ids that do not exist are left out.
+func (l *loader[T]) loadMany(ids []int) func() (interface{}, error) {
+	l.mu.Lock()
+	for _, id := range ids {
+		if _, ok := l.cache[id]; !ok {
+			l.pending = append(l.pending, id)
+		}
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		if err := l.flush(); err != nil {
+			return nil, err
+		}
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		values := make([]*T, 0, len(ids))
+		for _, id := range ids {
+			if value := l.cache[id]; value != nil {
+				values = append(values, value)
+			}
+		}
+		return values, nil
+	}
+}
+
+// load is loadMany for a single id, resolving to nil when it does not exist.
+func (l *loader[T]) load(id int) func() (interface{}, error) {
+	many := l.loadMany([]int{id})
+	return func() (interface{}, error) {
+		values, err := many()
+		if err != nil || len(values.([]*T)) == 0 {
+			return nil, err
+		}
+		return values.([]*T)[0], nil
+	}
+}
+
+func (l *loader[T]) flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.pending) == 0 {
+		return nil
+	}
+	ids := l.pending
+	l.pending = nil
+
+	found, err := l.fetch(ids)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		// nil marks ids known to be missing, so they are not fetched again
+		l.cache[id] = found[id]
+	}
+	return nil
+}
+
+// prime stores values that were already fetched by other means.
+func (l *loader[T]) prime(id int, value *T) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cache[id] = value
+}
+
+// loaders live for one request, so cached values are never stale.
+type loaders struct {
+	films  *loader[models.Film]
+	actors *loader[models.Actor]
+}
+
+func newLoaders(database store.Store) *loaders {
+	return &loaders{
+		films: newLoader(func(ids []int) (map[int]*models.Film, error) {
+			films, err := database.Film().GetAll(&store.FilmFilter{IDs: ids})
+			if err != nil {
+				return nil, err
+			}
+			found := make(map[int]*models.Film, len(films))
+			for i := range films {
+				found[films[i].ID] = &films[i]
+			}
+			return found, nil
+		}),
+		actors: newLoader(func(ids []int) (map[int]*models.Actor, error) {
+			actors, err := database.Actor().GetAll(&store.ActorFilter{IDs: ids})
+			if err != nil {
+				return nil, err
+			}
+			found := make(map[int]*models.Actor, len(actors))
+			for i := range actors {
+				found[actors[i].ID] = &actors[i]
+			}
+			return found, nil
+		}),
+	}
+}
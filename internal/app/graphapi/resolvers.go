@@ -0,0 +1,247 @@
+package graphapi
+
+import (
+	"context"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+func (a *API) resolveFilms(p graphql.ResolveParams) (interface{}, error) {
+	filter := &store.FilmFilter{
+		SearchTitle:    stringArg(p.Args, "searchTitle"),
+		SearchActor:    stringArg(p.Args, "searchActor"),
+		ReleasedAfter:  stringArg(p.Args, "releasedAfter"),
+		ReleasedBefore: stringArg(p.Args, "releasedBefore"),
+		ActorIDs:       intsArg(p.Args, "actorIds"),
+		ActorMatch:     p.Args["actorMatch"].(string),
+		Sort:           parseSortArg(p.Args["sort"]),
+	}
+	if !validDate(filter.ReleasedAfter) || !validDate(filter.ReleasedBefore) {
+		return nil, errInvalidInput
+	}
+	if value, ok := p.Args["ratingMin"].(float64); ok {
+		filter.RatingMin = &value
+	}
+	if value, ok := p.Args["ratingMax"].(float64); ok {
+		filter.RatingMax = &value
+	}
+	if value, ok := p.Args["hasDescription"].(bool); ok {
+		filter.HasDescription = &value
+	}
+	if len(filter.Sort) == 0 {
+		filter.Sort = []store.SortField{{Field: "rating", Desc: true}}
+	}
+
+	films, err := a.database.Film().GetAll(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	loader := viewerFrom(p.Context).loaders.films
+	result := make([]*models.Film, 0, len(films))
+	for i := range films {
+		loader.prime(films[i].ID, &films[i])
+		result = append(result, &films[i])
+	}
+	return result, nil
+}
+
+func (a *API) resolveActors(p graphql.ResolveParams) (interface{}, error) {
+	filter := &store.ActorFilter{
+		SearchName: stringArg(p.Args, "searchName"),
+		Gender:     stringArg(p.Args, "gender"),
+		BornAfter:  stringArg(p.Args, "bornAfter"),
+		BornBefore: stringArg(p.Args, "bornBefore"),
+		Sort:       parseSortArg(p.Args["sort"]),
+	}
+	if !validDate(filter.BornAfter) || !validDate(filter.BornBefore) {
+		return nil, errInvalidInput
+	}
+
+	actors, err := a.database.Actor().GetAll(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	loader := viewerFrom(p.Context).loaders.actors
+	result := make([]*models.Actor, 0, len(actors))
+	for i := range actors {
+		loader.prime(actors[i].ID, &actors[i])
+		result = append(result, &actors[i])
+	}
+	return result, nil
+}
+
+func (a *API) createFilm(p graphql.ResolveParams) (interface{}, error) {
+	if err := requireAdmin(p.Context); err != nil {
+		return nil, err
+	}
+	req := filmRequest(p.Args["input"])
+	if !req.ValidateForInsert() {
+		return nil, errInvalidInput
+	}
+
+	id, err := a.database.Film().Create(req, viewerFrom(p.Context).author)
+	if err != nil {
+		return nil, err
+	}
+	a.onChange()
+
+	return a.reloadFilm(p.Context, id)
+}
+
+func (a *API) updateFilm(p graphql.ResolveParams) (interface{}, error) {
+	if err := requireAdmin(p.Context); err != nil {
+		return nil, err
+	}
+	req := filmRequest(p.Args["input"])
+	if !req.ValidateForUpdate() {
+		return nil, errInvalidInput
+	}
+
+	id := p.Args["id"].(int)
+	done, err := a.database.Film().Modify(id, req, viewerFrom(p.Context).author)
+	if err != nil {
+		return nil, err
+	}
+	if !done {
+		return nil, errNotFound
+	}
+	a.onChange()
+
+	return a.reloadFilm(p.Context, id)
+}
+
+func (a *API) deleteFilm(p graphql.ResolveParams) (interface{}, error) {
+	if err := requireAdmin(p.Context); err != nil {
+		return nil, err
+	}
+
+	done, err := a.database.Film().Delete(p.Args["id"].(int), viewerFrom(p.Context).author)
+	if err != nil {
+		return nil, err
+	}
+	if done {
+		a.onChange()
+	}
+	return done, nil
+}
+
+func (a *API) createActor(p graphql.ResolveParams) (interface{}, error) {
+	if err := requireAdmin(p.Context); err != nil {
+		return nil, err
+	}
+	req := actorRequest(p.Args["input"])
+	if !req.ValidateForInsert() {
+		return nil, errInvalidInput
+	}
+
+	id, err := a.database.Actor().Create(req, viewerFrom(p.Context).author)
+	if err != nil {
+		return nil, err
+	}
+	a.onChange()
+
+	return a.reloadActor(p.Context, id)
+}
+
+func (a *API) updateActor(p graphql.ResolveParams) (interface{}, error) {
+	if err := requireAdmin(p.Context); err != nil {
+		return nil, err
+	}
+	req := actorRequest(p.Args["input"])
+	if !req.ValidateForUpdate() {
+		return nil, errInvalidInput
+	}
+
+	id := p.Args["id"].(int)
+	done, err := a.database.Actor().Modify(id, req, viewerFrom(p.Context).author)
+	if err != nil {
+		return nil, err
+	}
+	if !done {
+		return nil, errNotFound
+	}
+	a.onChange()
+
+	return a.reloadActor(p.Context, id)
+}
+
+func (a *API) deleteActor(p graphql.ResolveParams) (interface{}, error) {
+	if err := requireAdmin(p.Context); err != nil {
+		return nil, err
+	}
+
+	done, err := a.database.Actor().Delete(p.Args["id"].(int), viewerFrom(p.Context).author)
+	if err != nil {
+		return nil, err
+	}
+	if done {
+		a.onChange()
+	}
+	return done, nil
+}
+
+// reloadFilm reads a film written by a mutation, replacing whatever the
+// request loaded before.
+func (a *API) reloadFilm(ctx context.Context, id int) (*models.Film, error) {
+	films, err := a.database.Film().GetAll(&store.FilmFilter{IDs: []int{id}})
+	if err != nil {
+		return nil, err
+	}
+	if len(films) == 0 {
+		return nil, errNotFound
+	}
+	viewerFrom(ctx).loaders.films.prime(id, &films[0])
+	return &films[0], nil
+}
+
+func (a *API) reloadActor(ctx context.Context, id int) (*models.Actor, error) {
+	actors, err := a.database.Actor().GetAll(&store.ActorFilter{IDs: []int{id}})
+	if err != nil {
+		return nil, err
+	}
+	if len(actors) == 0 {
+		return nil, errNotFound
+	}
+	viewerFrom(ctx).loaders.actors.prime(id, &actors[0])
+	return &actors[0], nil
+}
+
+func filmRequest(raw interface{}) *models.FilmRequest {
+	input := raw.(map[string]interface{})
+	req := &models.FilmRequest{
+		Title:       stringArg(input, "title"),
+		Description: stringArg(input, "description"),
+		ReleaseDate: stringArg(input, "releaseDate"),
+		ActorsIDs:   intsArg(input, "actorIds"),
+	}
+	req.Rating, _ = input["rating"].(float64)
+	return req
+}
+
+func actorRequest(raw interface{}) *models.ActorRequest {
+	input := raw.(map[string]interface{})
+	return &models.ActorRequest{
+		Name:      stringArg(input, "name"),
+		Gender:    stringArg(input, "gender"),
+		BirthDate: stringArg(input, "birthDate"),
+	}
+}
+
+func stringArg(args map[string]interface{}, name string) string {
+	value, _ := args[name].(string)
+	return value
+}
+
+func intsArg(args map[string]interface{}, name string) []int {
+	list, _ := args[name].([]interface{})
+	values := make([]int, 0, len(list))
+	for _, item := range list {
+		values = append(values, item.(int))
+	}
+	return values
+}
@@ -0,0 +1,289 @@
+package graphapi
+
+import (
+	"time"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+func (a *API) newSchema() (graphql.Schema, error) {
+	var filmType, actorType *graphql.Object
+
+	filmType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Film",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"title":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"description": &graphql.Field{Type: graphql.String},
+				"releaseDate": &graphql.Field{Type: graphql.String},
+				"rating":      &graphql.Field{Type: graphql.Float},
+				"actors": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(actorType))),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						film := p.Source.(*models.Film)
+						ids := make([]int, 0, len(film.Actors))
+						for _, a := range film.Actors {
+							ids = append(ids, a.ActorID)
+						}
+						return viewerFrom(p.Context).loaders.actors.loadMany(ids), nil
+					},
+				},
+			}
+		}),
+	})
+
+	actorType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Actor",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+				"name":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"gender":    &graphql.Field{Type: graphql.String},
+				"birthDate": &graphql.Field{Type: graphql.String},
+				"films": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(filmType))),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						actor := p.Source.(*models.Actor)
+						ids := make([]int, 0, len(actor.Films))
+						for _, f := range actor.Films {
+							ids = append(ids, f.FilmID)
+						}
+						return viewerFrom(p.Context).loaders.films.loadMany(ids), nil
+					},
+				},
+			}
+		}),
+	})
+
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"login":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"isAdmin": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		},
+	})
+
+	searchResultType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "SearchResult",
+		Fields: graphql.Fields{
+			"type":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"id":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"label":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"snippet": &graphql.Field{Type: graphql.String},
+			"rank":    &graphql.Field{Type: graphql.Float},
+		},
+	})
+
+	filmSortType := sortInput("FilmSort", "FilmSortField", []string{"title", "rating", "release_date"})
+	actorSortType := sortInput("ActorSort", "ActorSortField", []string{"name", "birth_date", "film_count"})
+
+	actorMatchType := graphql.NewEnum(graphql.EnumConfig{
+		Name: "ActorMatch",
+		Values: graphql.EnumValueConfigMap{
+			"ANY": &graphql.EnumValueConfig{Value: store.ActorMatchAny},
+			"ALL": &graphql.EnumValueConfig{Value: store.ActorMatchAll},
+		},
+	})
+
+	filmInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "FilmInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"description": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"releaseDate": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"rating":      &graphql.InputObjectFieldConfig{Type: graphql.Float},
+			"actorIds":    &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.Int))},
+		},
+	})
+
+	actorInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "ActorInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"name":      &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"gender":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"birthDate": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"films": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(filmType))),
+				Args: graphql.FieldConfigArgument{
+					"searchTitle":    &graphql.ArgumentConfig{Type: graphql.String},
+					"searchActor":    &graphql.ArgumentConfig{Type: graphql.String},
+					"ratingMin":      &graphql.ArgumentConfig{Type: graphql.Float},
+					"ratingMax":      &graphql.ArgumentConfig{Type: graphql.Float},
+					"releasedAfter":  &graphql.ArgumentConfig{Type: graphql.String},
+					"releasedBefore": &graphql.ArgumentConfig{Type: graphql.String},
+					"actorIds":       &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.Int))},
+					"actorMatch":     &graphql.ArgumentConfig{Type: actorMatchType, DefaultValue: store.ActorMatchAny},
+					"hasDescription": &graphql.ArgumentConfig{Type: graphql.Boolean},
+					"sort":           &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(filmSortType))},
+				},
+				Resolve: a.resolveFilms,
+			},
+			"film": &graphql.Field{
+				Type: filmType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return viewerFrom(p.Context).loaders.films.load(p.Args["id"].(int)), nil
+				},
+			},
+			"actors": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(actorType))),
+				Args: graphql.FieldConfigArgument{
+					"searchName": &graphql.ArgumentConfig{Type: graphql.String},
+					"gender":     &graphql.ArgumentConfig{Type: graphql.String},
+					"bornAfter":  &graphql.ArgumentConfig{Type: graphql.String},
+					"bornBefore": &graphql.ArgumentConfig{Type: graphql.String},
+					"sort":       &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(actorSortType))},
+				},
+				Resolve: a.resolveActors,
+			},
+			"actor": &graphql.Field{
+				Type: actorType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return viewerFrom(p.Context).loaders.actors.load(p.Args["id"].(int)), nil
+				},
+			},
+			"users": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(userType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if err := requireAdmin(p.Context); err != nil {
+						return nil, err
+					}
+					return a.database.User().GetAll()
+				},
+			},
+			"search": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(searchResultType))),
+				Args: graphql.FieldConfigArgument{
+					"q":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 20},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					q, limit := p.Args["q"].(string), p.Args["limit"].(int)
+					if q == "" || limit < 1 || limit > 100 {
+						return nil, errInvalidInput
+					}
+					return a.database.Search().FullText(q, limit)
+				},
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createFilm": &graphql.Field{
+				Type: graphql.NewNonNull(filmType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(filmInputType)},
+				},
+				Resolve: a.createFilm,
+			},
+			"updateFilm": &graphql.Field{
+				Type: graphql.NewNonNull(filmType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(filmInputType)},
+				},
+				Resolve: a.updateFilm,
+			},
+			"deleteFilm": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: a.deleteFilm,
+			},
+			"createActor": &graphql.Field{
+				Type: graphql.NewNonNull(actorType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(actorInputType)},
+				},
+				Resolve: a.createActor,
+			},
+			"updateActor": &graphql.Field{
+				Type: graphql.NewNonNull(actorType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(actorInputType)},
+				},
+				Resolve: a.updateActor,
+			},
+			"deleteActor": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: a.deleteActor,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    query,
+		Mutation: mutation,
+	})
+}
+
+// sortInput builds an input type {field, desc} with an enum of the given
+// store sort fields.
+func sortInput(name, fieldName string, fields []string) *graphql.InputObject {
+	values := graphql.EnumValueConfigMap{}
+	for _, field := range fields {
+		values[upper(field)] = &graphql.EnumValueConfig{Value: field}
+	}
+
+	return graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: name,
+		Fields: graphql.InputObjectConfigFieldMap{
+			"field": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.NewEnum(graphql.EnumConfig{
+				Name:   fieldName,
+				Values: values,
+			}))},
+			"desc": &graphql.InputObjectFieldConfig{Type: graphql.Boolean, DefaultValue: false},
+		},
+	})
+}
+
+func parseSortArg(raw interface{}) []store.SortField {
+	list, _ := raw.([]interface{})
+	fields := make([]store.SortField, 0, len(list))
+	for _, item := range list {
+		m := item.(map[string]interface{})
+		desc, _ := m["desc"].(bool)
+		fields = append(fields, store.SortField{Field: m["field"].(string), Desc: desc})
+	}
+	return fields
+}
+
+func upper(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c >= 'a' && c <= 'z' {
+			b[i] = c - 'a' + 'A'
+		}
+	}
+	return string(b)
+}
+
+func validDate(raw string) bool {
+	if raw == "" {
+		return true
+	}
+	_, err := time.Parse("2006-01-02", raw)
+	return err == nil
+}
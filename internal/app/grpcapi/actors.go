@@ -0,0 +1,149 @@
+package grpcapi
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/Rbd3178/filmDatabase/pkg/filmdbpb"
+)
+
+var actorSortFields = []string{"name", "birth_date", "film_count"}
+
+type actorService struct {
+	filmdbpb.UnimplementedActorServiceServer
+	*server
+}
+
+func (s *actorService) ListActors(req *filmdbpb.ListActorsRequest, stream filmdbpb.ActorService_ListActorsServer) error {
+	filter := &store.ActorFilter{
+		SearchName: req.GetSearchName(),
+		Gender:     req.GetGender(),
+		BornAfter:  req.GetBornAfter(),
+		BornBefore: req.GetBornBefore(),
+	}
+	if !validDate(filter.BornAfter) || !validDate(filter.BornBefore) {
+		return status.Error(codes.InvalidArgument, "invalid birth date bound")
+	}
+	sort, err := sortFields(req.GetSort(), actorSortFields)
+	if err != nil {
+		return err
+	}
+	filter.Sort = sort
+
+	it, err := s.database.Actor().Iterate(filter)
+	if err != nil {
+		return s.internal(err, "Error when getting actors")
+	}
+	defer it.Close()
+
+	for it.Next() {
+		if err := stream.Send(actorMessage(it.Actor())); err != nil {
+			return err
+		}
+	}
+	if err := it.Err(); err != nil {
+		return s.internal(err, "Error when getting actors")
+	}
+	return nil
+}
+
+func (s *actorService) GetActor(ctx context.Context, req *filmdbpb.GetActorRequest) (*filmdbpb.Actor, error) {
+	return s.findActor(int(req.GetId()))
+}
+
+func (s *actorService) CreateActor(ctx context.Context, req *filmdbpb.CreateActorRequest) (*filmdbpb.Actor, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	actor := &models.ActorRequest{
+		Name:      req.GetName(),
+		Gender:    req.GetGender(),
+		BirthDate: req.GetBirthDate(),
+	}
+	if !actor.ValidateForInsert() {
+		return nil, status.Error(codes.InvalidArgument, "invalid fields in request")
+	}
+
+	id, err := s.database.Actor().Create(actor, authorFrom(ctx))
+	if err != nil {
+		return nil, s.internal(err, "Error when creating actor")
+	}
+	s.onChange()
+
+	return s.findActor(id)
+}
+
+func (s *actorService) UpdateActor(ctx context.Context, req *filmdbpb.UpdateActorRequest) (*filmdbpb.Actor, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	actor := &models.ActorRequest{
+		Name:      req.GetName(),
+		Gender:    req.GetGender(),
+		BirthDate: req.GetBirthDate(),
+	}
+	if !actor.ValidateForUpdate() {
+		return nil, status.Error(codes.InvalidArgument, "invalid fields in request")
+	}
+
+	done, err := s.database.Actor().Modify(int(req.GetId()), actor, authorFrom(ctx))
+	if err != nil {
+		return nil, s.internal(err, "Error when modifying actor")
+	}
+	if !done {
+		return nil, status.Error(codes.NotFound, "actor not found")
+	}
+	s.onChange()
+
+	return s.findActor(int(req.GetId()))
+}
+
+func (s *actorService) DeleteActor(ctx context.Context, req *filmdbpb.DeleteActorRequest) (*filmdbpb.DeleteActorResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	done, err := s.database.Actor().Delete(int(req.GetId()), authorFrom(ctx))
+	if err != nil {
+		return nil, s.internal(err, "Error when deleting actor")
+	}
+	if !done {
+		return nil, status.Error(codes.NotFound, "actor not found")
+	}
+	s.onChange()
+
+	return &filmdbpb.DeleteActorResponse{}, nil
+}
+
+func (s *actorService) findActor(id int) (*filmdbpb.Actor, error) {
+	actor, err := s.database.Actor().Find(id)
+	if err == store.ErrRecordNotFound {
+		return nil, status.Error(codes.NotFound, "actor not found")
+	}
+	if err != nil {
+		return nil, s.internal(err, "Error when finding actor")
+	}
+	return actorMessage(actor), nil
+}
+
+func actorMessage(actor *models.Actor) *filmdbpb.Actor {
+	message := &filmdbpb.Actor{
+		Id:        int64(actor.ID),
+		Name:      actor.Name,
+		Gender:    actor.Gender,
+		BirthDate: actor.BirthDate,
+	}
+	for _, film := range actor.Films {
+		message.Films = append(message.Films, &filmdbpb.FilmRef{
+			Id:    int64(film.FilmID),
+			Title: film.Title,
+		})
+	}
+	return message
+}
@@ -0,0 +1,48 @@
+package grpcapi
+
+import (
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/Rbd3178/filmDatabase/pkg/filmdbpb"
+)
+
+func sortFields(fields []*filmdbpb.SortField, allowed []string) ([]store.SortField, error) {
+	var sort []store.SortField
+	for _, field := range fields {
+		if !contains(allowed, field.GetField()) {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown sort field %q", field.GetField())
+		}
+		sort = append(sort, store.SortField{Field: field.GetField(), Desc: field.GetDesc()})
+	}
+	return sort, nil
+}
+
+func ints(values []int64) []int {
+	converted := make([]int, 0, len(values))
+	for _, value := range values {
+		converted = append(converted, int(value))
+	}
+	return converted
+}
+
+func validDate(raw string) bool {
+	if raw == "" {
+		return true
+	}
+
+	_, err := time.Parse("2006-01-02", raw)
+	return err == nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
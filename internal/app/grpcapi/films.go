@@ -0,0 +1,175 @@
+package grpcapi
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/Rbd3178/filmDatabase/pkg/filmdbpb"
+)
+
+var filmSortFields = []string{"title", "rating", "release_date"}
+
+type filmService struct {
+	filmdbpb.UnimplementedFilmServiceServer
+	*server
+}
+
+func (s *filmService) ListFilms(req *filmdbpb.ListFilmsRequest, stream filmdbpb.FilmService_ListFilmsServer) error {
+	filter, err := filmFilter(req)
+	if err != nil {
+		return err
+	}
+
+	it, err := s.database.Film().Iterate(filter)
+	if err != nil {
+		return s.internal(err, "Error when getting films")
+	}
+	defer it.Close()
+
+	for it.Next() {
+		if err := stream.Send(filmMessage(it.Film())); err != nil {
+			return err
+		}
+	}
+	if err := it.Err(); err != nil {
+		return s.internal(err, "Error when getting films")
+	}
+	return nil
+}
+
+func (s *filmService) GetFilm(ctx context.Context, req *filmdbpb.GetFilmRequest) (*filmdbpb.Film, error) {
+	return s.findFilm(int(req.GetId()))
+}
+
+func (s *filmService) CreateFilm(ctx context.Context, req *filmdbpb.CreateFilmRequest) (*filmdbpb.Film, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	film := &models.FilmRequest{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		ReleaseDate: req.GetReleaseDate(),
+		Rating:      req.GetRating(),
+		ActorsIDs:   ints(req.GetActorIds()),
+	}
+	if !film.ValidateForInsert() {
+		return nil, status.Error(codes.InvalidArgument, "invalid fields in request")
+	}
+
+	id, err := s.database.Film().Create(film, authorFrom(ctx))
+	if err != nil {
+		return nil, s.internal(err, "Error when creating film")
+	}
+	s.onChange()
+
+	return s.findFilm(id)
+}
+
+func (s *filmService) UpdateFilm(ctx context.Context, req *filmdbpb.UpdateFilmRequest) (*filmdbpb.Film, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	film := &models.FilmRequest{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		ReleaseDate: req.GetReleaseDate(),
+		Rating:      req.GetRating(),
+		ActorsIDs:   ints(req.GetActorIds()),
+	}
+	if !film.ValidateForUpdate() {
+		return nil, status.Error(codes.InvalidArgument, "invalid fields in request")
+	}
+
+	done, err := s.database.Film().Modify(int(req.GetId()), film, authorFrom(ctx))
+	if err != nil {
+		return nil, s.internal(err, "Error when modifying film")
+	}
+	if !done {
+		return nil, status.Error(codes.NotFound, "film not found")
+	}
+	s.onChange()
+
+	return s.findFilm(int(req.GetId()))
+}
+
+func (s *filmService) DeleteFilm(ctx context.Context, req *filmdbpb.DeleteFilmRequest) (*filmdbpb.DeleteFilmResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	done, err := s.database.Film().Delete(int(req.GetId()), authorFrom(ctx))
+	if err != nil {
+		return nil, s.internal(err, "Error when deleting film")
+	}
+	if !done {
+		return nil, status.Error(codes.NotFound, "film not found")
+	}
+	s.onChange()
+
+	return &filmdbpb.DeleteFilmResponse{}, nil
+}
+
+func (s *filmService) findFilm(id int) (*filmdbpb.Film, error) {
+	film, err := s.database.Film().Find(id)
+	if err == store.ErrRecordNotFound {
+		return nil, status.Error(codes.NotFound, "film not found")
+	}
+	if err != nil {
+		return nil, s.internal(err, "Error when finding film")
+	}
+	return filmMessage(film), nil
+}
+
+func filmFilter(req *filmdbpb.ListFilmsRequest) (*store.FilmFilter, error) {
+	filter := &store.FilmFilter{
+		SearchTitle:    req.GetSearchTitle(),
+		SearchActor:    req.GetSearchActor(),
+		RatingMin:      req.RatingMin,
+		RatingMax:      req.RatingMax,
+		ReleasedAfter:  req.GetReleasedAfter(),
+		ReleasedBefore: req.GetReleasedBefore(),
+		ActorIDs:       ints(req.GetActorIds()),
+		ActorMatch:     store.ActorMatchAny,
+		HasDescription: req.HasDescription,
+	}
+	if req.GetActorMatch() == filmdbpb.ActorMatch_ACTOR_MATCH_ALL {
+		filter.ActorMatch = store.ActorMatchAll
+	}
+	if !validDate(filter.ReleasedAfter) || !validDate(filter.ReleasedBefore) {
+		return nil, status.Error(codes.InvalidArgument, "invalid release date bound")
+	}
+
+	sort, err := sortFields(req.GetSort(), filmSortFields)
+	if err != nil {
+		return nil, err
+	}
+	filter.Sort = sort
+	if len(filter.Sort) == 0 {
+		filter.Sort = []store.SortField{{Field: "rating", Desc: true}}
+	}
+
+	return filter, nil
+}
+
+func filmMessage(film *models.Film) *filmdbpb.Film {
+	message := &filmdbpb.Film{
+		Id:          int64(film.ID),
+		Title:       film.Title,
+		Description: film.Description,
+		ReleaseDate: film.ReleaseDate,
+		Rating:      film.Rating,
+	}
+	for _, actor := range film.Actors {
+		message.Actors = append(message.Actors, &filmdbpb.ActorRef{
+			Id:   int64(actor.ActorID),
+			Name: actor.Name,
+		})
+	}
+	return message
+}
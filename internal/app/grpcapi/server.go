@@ -0,0 +1,195 @@
+// Package grpcapi serves films, actors and users over gRPC on top of the same
+// store.Store as the REST API, with the same roles.
+package grpcapi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/auth"
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/Rbd3178/filmDatabase/pkg/filmdbpb"
+)
+
+// publicMethods are served without credentials.
+var publicMethods = map[string]bool{
+	filmdbpb.UserService_CreateUser_FullMethodName: true,
+}
+
+type contextKey int
+
+const (
+	isAdminKey contextKey = iota
+	loginKey
+	requestIDKey
+)
+
+// requestIDMetadata carries the id of a call, clients may choose it and the
+// server sends it back in the header, like X-Request-ID of the REST API.
+const requestIDMetadata = "x-request-id"
+
+// maxRequestIDLength keeps client chosen ids within the audit trail column.
+const maxRequestIDLength = 100
+
+type server struct {
+	database    store.Store
+	credentials *auth.Verifier
+	logger      *logrus.Logger
+	onChange    func()
+}
+
+// New returns a gRPC server with all services registered. credentials is
+// shared with the REST API so that both forget changed passwords, nil checks
+// every password with bcrypt. onChange is called after every successful
+// change of films or actors.
+func New(database store.Store, credentials *auth.Verifier, logger *logrus.Logger, onChange func()) *grpc.Server {
+	if credentials == nil {
+		credentials = auth.NewVerifier(database.User(), 0, 0)
+	}
+	if onChange == nil {
+		onChange = func() {}
+	}
+	s := &server{
+		database:    database,
+		credentials: credentials,
+		logger:      logger,
+		onChange:    onChange,
+	}
+
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(s.authenticateUnary),
+		grpc.StreamInterceptor(s.authenticateStream),
+	)
+	filmdbpb.RegisterFilmServiceServer(grpcServer, &filmService{server: s})
+	filmdbpb.RegisterActorServiceServer(grpcServer, &actorService{server: s})
+	filmdbpb.RegisterUserServiceServer(grpcServer, &userService{server: s})
+
+	return grpcServer
+}
+
+func (s *server) authenticateUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx = withRequestID(ctx)
+	if publicMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+
+	ctx, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *server) authenticateStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticate(withRequestID(stream.Context()))
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+}
+
+// authenticate checks the basic credentials in the "authorization" metadata
+// the same way the REST API checks the Authorization header.
+func (s *server) authenticate(ctx context.Context) (context.Context, error) {
+	login, password, ok := basicAuth(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "no basic auth present")
+	}
+
+	user, err := s.credentials.Verify(login, password)
+	if err == store.ErrRecordNotFound {
+		return nil, status.Error(codes.Unauthenticated, "incorrect login")
+	}
+	if err == auth.ErrIncorrectPassword {
+		return nil, status.Error(codes.Unauthenticated, "incorrect password")
+	}
+	if err != nil {
+		return nil, s.internal(err, "Error when finding user")
+	}
+
+	ctx = context.WithValue(ctx, loginKey, user.Login)
+	return context.WithValue(ctx, isAdminKey, user.IsAdmin), nil
+}
+
+func basicAuth(ctx context.Context) (string, string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", "", false
+	}
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", "", false
+	}
+
+	const prefix = "Basic "
+	if len(values[0]) < len(prefix) || !strings.EqualFold(values[0][:len(prefix)], prefix) {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(values[0][len(prefix):])
+	if err != nil {
+		return "", "", false
+	}
+	return strings.Cut(string(decoded), ":")
+}
+
+// withRequestID makes sure the call has an id, generating one unless the
+// client sent a usable one.
+func withRequestID(ctx context.Context) context.Context {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDMetadata); len(values) > 0 && len(values[0]) <= maxRequestIDLength {
+			id = values[0]
+		}
+	}
+	if id == "" {
+		b := make([]byte, 16)
+		rand.Read(b)
+		id = hex.EncodeToString(b)
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDMetadata, id))
+
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// authorFrom returns who makes the changes of an authenticated call.
+func authorFrom(ctx context.Context) models.Author {
+	login, _ := ctx.Value(loginKey).(string)
+	return models.Author{Login: login, RequestID: requestIDFrom(ctx)}
+}
+
+func requireAdmin(ctx context.Context) error {
+	if isAdmin, _ := ctx.Value(isAdminKey).(bool); !isAdmin {
+		return status.Error(codes.PermissionDenied, "not enough rights")
+	}
+	return nil
+}
+
+// internal logs the store error and hides it from the client.
+func (s *server) internal(err error, message string) error {
+	s.logger.WithError(err).Info(message)
+	return status.Error(codes.Internal, "internal error")
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
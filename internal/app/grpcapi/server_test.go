@@ -0,0 +1,299 @@
+package grpcapi_test
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/grpcapi"
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/testdb"
+	"github.com/Rbd3178/filmDatabase/pkg/filmdbpb"
+)
+
+func newClient(t *testing.T) (*grpc.ClientConn, *testdb.Store, *int) {
+	t.Helper()
+
+	database := testdb.New()
+	database.Actor().Create(&models.ActorRequest{Name: "Keanu Reeves", Gender: "male", BirthDate: "1964-09-02"}, models.Author{Login: "admin"})
+	database.Film().Create(&models.FilmRequest{Title: "The Matrix", ReleaseDate: "1999-03-31", Rating: 8.7, ActorsIDs: []int{1}}, models.Author{Login: "admin"})
+	database.Film().Create(&models.FilmRequest{Title: "John Wick", ReleaseDate: "2014-10-24", Rating: 7.4, ActorsIDs: []int{1}}, models.Author{Login: "admin"})
+
+	changes := 0
+	listener := bufconn.Listen(1 << 20)
+	server := grpcapi.New(database, nil, logrus.New(), func() { changes++ })
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn, database, &changes
+}
+
+func withAuth(login, password string) context.Context {
+	credentials := base64.StdEncoding.EncodeToString([]byte(login + ":" + password))
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic "+credentials)
+}
+
+func TestServer_Authentication(t *testing.T) {
+	conn, _, _ := newClient(t)
+	films := filmdbpb.NewFilmServiceClient(conn)
+
+	var tests = []struct {
+		name         string
+		ctx          context.Context
+		expectedCode codes.Code
+	}{
+		{
+			name:         "No credentials",
+			ctx:          context.Background(),
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "Incorrect login",
+			ctx:          withAuth("nobody", "correct"),
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "Incorrect password",
+			ctx:          withAuth("normal", "wrong"),
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "Valid",
+			ctx:          withAuth("normal", "correct"),
+			expectedCode: codes.OK,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := films.GetFilm(tc.ctx, &filmdbpb.GetFilmRequest{Id: 1})
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+
+			stream, err := films.ListFilms(tc.ctx, &filmdbpb.ListFilmsRequest{})
+			if err == nil {
+				_, err = stream.Recv()
+			}
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+		})
+	}
+}
+
+func TestServer_RequestID(t *testing.T) {
+	conn, _, _ := newClient(t)
+	films := filmdbpb.NewFilmServiceClient(conn)
+
+	var tests = []struct {
+		name       string
+		ctx        context.Context
+		expectedID string
+	}{
+		{
+			name: "Generated",
+			ctx:  withAuth("normal", "correct"),
+		},
+		{
+			name:       "Chosen by client",
+			ctx:        metadata.AppendToOutgoingContext(withAuth("normal", "correct"), "x-request-id", "req-1"),
+			expectedID: "req-1",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var header metadata.MD
+			_, err := films.GetFilm(tc.ctx, &filmdbpb.GetFilmRequest{Id: 1}, grpc.Header(&header))
+			assert.NoError(t, err)
+			unaryID := header.Get("x-request-id")
+			assert.Len(t, unaryID, 1)
+
+			stream, err := films.ListFilms(tc.ctx, &filmdbpb.ListFilmsRequest{})
+			assert.NoError(t, err)
+			header, err = stream.Header()
+			assert.NoError(t, err)
+			streamID := header.Get("x-request-id")
+			assert.Len(t, streamID, 1)
+
+			if tc.expectedID != "" {
+				assert.Equal(t, []string{tc.expectedID}, unaryID)
+				assert.Equal(t, []string{tc.expectedID}, streamID)
+			} else {
+				assert.NotEqual(t, unaryID, streamID)
+			}
+		})
+	}
+}
+
+func TestServer_ListFilms(t *testing.T) {
+	conn, _, _ := newClient(t)
+	films := filmdbpb.NewFilmServiceClient(conn)
+
+	var tests = []struct {
+		name           string
+		req            *filmdbpb.ListFilmsRequest
+		expectedCode   codes.Code
+		expectedTitles []string
+	}{
+		{
+			name:           "Default order",
+			req:            &filmdbpb.ListFilmsRequest{},
+			expectedTitles: []string{"The Matrix", "John Wick"},
+		},
+		{
+			name:           "Sort by title",
+			req:            &filmdbpb.ListFilmsRequest{Sort: []*filmdbpb.SortField{{Field: "title"}}},
+			expectedTitles: []string{"John Wick", "The Matrix"},
+		},
+		{
+			name:           "Search title",
+			req:            &filmdbpb.ListFilmsRequest{SearchTitle: "wick"},
+			expectedTitles: []string{"John Wick"},
+		},
+		{
+			name:         "Unknown sort field",
+			req:          &filmdbpb.ListFilmsRequest{Sort: []*filmdbpb.SortField{{Field: "budget"}}},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "Invalid date",
+			req:          &filmdbpb.ListFilmsRequest{ReleasedAfter: "yesterday"},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stream, err := films.ListFilms(withAuth("normal", "correct"), tc.req)
+			assert.NoError(t, err)
+
+			var titles []string
+			for {
+				film, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					assert.Equal(t, tc.expectedCode, status.Code(err))
+					return
+				}
+				titles = append(titles, film.GetTitle())
+			}
+			assert.Equal(t, tc.expectedCode, codes.OK)
+			assert.Equal(t, tc.expectedTitles, titles)
+		})
+	}
+}
+
+func TestServer_ModifyFilms(t *testing.T) {
+	conn, _, changes := newClient(t)
+	films := filmdbpb.NewFilmServiceClient(conn)
+
+	_, err := films.CreateFilm(withAuth("normal", "correct"), &filmdbpb.CreateFilmRequest{Title: "Speed", ReleaseDate: "1994-06-10"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = films.CreateFilm(withAuth("admin", "adminpass"), &filmdbpb.CreateFilmRequest{Title: "", ReleaseDate: "1994-06-10"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	film, err := films.CreateFilm(withAuth("admin", "adminpass"), &filmdbpb.CreateFilmRequest{
+		Title:       "Speed",
+		ReleaseDate: "1994-06-10",
+		Rating:      7.2,
+		ActorIds:    []int64{1},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), film.GetId())
+	assert.Equal(t, "Speed", film.GetTitle())
+	assert.Len(t, film.GetActors(), 1)
+
+	film, err = films.UpdateFilm(withAuth("admin", "adminpass"), &filmdbpb.UpdateFilmRequest{Id: 3, Title: "Speed 2", ReleaseDate: "1997-06-13"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Speed 2", film.GetTitle())
+
+	_, err = films.UpdateFilm(withAuth("admin", "adminpass"), &filmdbpb.UpdateFilmRequest{Id: 42, Title: "Nothing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = films.DeleteFilm(withAuth("admin", "adminpass"), &filmdbpb.DeleteFilmRequest{Id: 3})
+	assert.NoError(t, err)
+
+	_, err = films.GetFilm(withAuth("normal", "correct"), &filmdbpb.GetFilmRequest{Id: 3})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	assert.Equal(t, 3, *changes)
+}
+
+func TestServer_Actors(t *testing.T) {
+	conn, _, _ := newClient(t)
+	actors := filmdbpb.NewActorServiceClient(conn)
+
+	actor, err := actors.CreateActor(withAuth("admin", "adminpass"), &filmdbpb.CreateActorRequest{
+		Name:      "Sandra Bullock",
+		Gender:    "female",
+		BirthDate: "1964-07-26",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), actor.GetId())
+
+	stream, err := actors.ListActors(withAuth("normal", "correct"), &filmdbpb.ListActorsRequest{Gender: "female"})
+	assert.NoError(t, err)
+	var names []string
+	for {
+		actor, err := stream.Recv()
+		if err != nil {
+			assert.Equal(t, io.EOF, err)
+			break
+		}
+		names = append(names, actor.GetName())
+	}
+	assert.Equal(t, []string{"Sandra Bullock"}, names)
+
+	_, err = actors.DeleteActor(withAuth("normal", "correct"), &filmdbpb.DeleteActorRequest{Id: 1})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestServer_Users(t *testing.T) {
+	conn, database, _ := newClient(t)
+	users := filmdbpb.NewUserServiceClient(conn)
+
+	_, err := users.CreateUser(context.Background(), &filmdbpb.CreateUserRequest{Login: "somebody", Password: "secret"})
+	assert.NoError(t, err)
+	_, err = database.User().Find("somebody")
+	assert.NoError(t, err)
+
+	_, err = users.CreateUser(context.Background(), &filmdbpb.CreateUserRequest{Login: "somebody", Password: "secret"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	stream, err := users.ListUsers(withAuth("normal", "correct"), &filmdbpb.ListUsersRequest{})
+	assert.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	stream, err = users.ListUsers(withAuth("admin", "adminpass"), &filmdbpb.ListUsersRequest{})
+	assert.NoError(t, err)
+	count := 0
+	for {
+		if _, err := stream.Recv(); err != nil {
+			assert.Equal(t, io.EOF, err)
+			break
+		}
+		count++
+	}
+	assert.Equal(t, 3, count)
+}
@@ -0,0 +1,53 @@
+package grpcapi
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/pkg/filmdbpb"
+)
+
+type userService struct {
+	filmdbpb.UnimplementedUserServiceServer
+	*server
+}
+
+func (s *userService) ListUsers(req *filmdbpb.ListUsersRequest, stream filmdbpb.UserService_ListUsersServer) error {
+	if err := requireAdmin(stream.Context()); err != nil {
+		return err
+	}
+
+	users, err := s.database.User().GetAll()
+	if err != nil {
+		return s.internal(err, "Error when getting all users")
+	}
+	for _, user := range users {
+		if err := stream.Send(&filmdbpb.User{Login: user.Login, IsAdmin: user.IsAdmin}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *userService) CreateUser(ctx context.Context, req *filmdbpb.CreateUserRequest) (*filmdbpb.CreateUserResponse, error) {
+	user := &models.UserRequest{
+		Login:    req.GetLogin(),
+		Password: req.GetPassword(),
+	}
+	if !user.Validate() {
+		return nil, status.Error(codes.InvalidArgument, "invalid login or password")
+	}
+
+	done, err := s.database.User().Create(user, models.Author{Login: user.Login, RequestID: requestIDFrom(ctx)})
+	if err != nil {
+		return nil, s.internal(err, "Error when creating user")
+	}
+	if !done {
+		return nil, status.Error(codes.AlreadyExists, "login already taken")
+	}
+
+	return &filmdbpb.CreateUserResponse{}, nil
+}
@@ -1,13 +1,44 @@
 package hasher
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultCost is the bcrypt cost used until SetCost is called.
+const DefaultCost = 10
+
+var cost atomic.Int64
+
+func init() {
+	cost.Store(DefaultCost)
+}
+
+// SetCost changes the bcrypt cost of new hashes. Existing hashes keep
+// working, NeedsRehash tells which of them were made with another cost.
+func SetCost(c int) error {
+	if c < bcrypt.MinCost || c > bcrypt.MaxCost {
+		return errors.Errorf("bcrypt cost must be from %d to %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	cost.Store(int64(c))
+	return nil
+}
 
 func HashPassword(password string) (string, error) {
-    bytes, err := bcrypt.GenerateFromPassword([]byte(password), 10)
-    return string(bytes), err
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), int(cost.Load()))
+	return string(bytes), err
 }
 
 func CheckPasswordHash(password string, hash string) bool {
-    err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-    return err == nil
-}
\ No newline at end of file
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	return err == nil
+}
+
+// NeedsRehash reports whether hash was made with a cost other than the
+// current one.
+func NeedsRehash(hash string) bool {
+	c, err := bcrypt.Cost([]byte(hash))
+	return err == nil && c != int(cost.Load())
+}
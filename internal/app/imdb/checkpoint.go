@@ -0,0 +1,71 @@
+package imdb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// checkpoint is how many actors and films were stored by previous runs. The
+// rows are produced in the same order every time from the same inputs, so a
+// new run can skip them. Inputs tells whether they still are the same.
+type checkpoint struct {
+	Inputs string `json:"inputs"`
+	Actors int    `json:"actors"`
+	Films  int    `json:"films"`
+}
+
+// fingerprint identifies the dataset files by path, size and modification
+// time, together with the title types that select the films.
+func fingerprint(config *Config) (string, error) {
+	h := sha256.New()
+	for _, path := range []string{config.TitleBasics, config.NameBasics, config.TitlePrincipals} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00%d\x00", path, info.Size(), info.ModTime().UnixNano())
+	}
+	h.Write([]byte(strings.Join(config.TitleTypes, ",")))
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func loadCheckpoint(path string) (*checkpoint, error) {
+	cp := &checkpoint{}
+	if path == "" {
+		return cp, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return cp, json.Unmarshal(data, cp)
+}
+
+// save replaces the file atomically, so an interrupted run never leaves it
+// half written.
+func (cp *checkpoint) save(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
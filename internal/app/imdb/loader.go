@@ -0,0 +1,289 @@
+package imdb
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+const defaultChunkSize = 1000
+
+// author is who the audit trail shows as making the imported changes.
+var author = models.Author{Login: "imdbimport"}
+
+// Config
+type Config struct {
+	// TitleBasics, NameBasics and TitlePrincipals are paths to the dataset
+	// files, gzipped files are recognized by the .gz suffix.
+	TitleBasics     string
+	NameBasics      string
+	TitlePrincipals string
+	// TitleTypes lists the values of titleType that are imported as films.
+	TitleTypes []string
+	// ChunkSize is the number of rows stored in one transaction.
+	ChunkSize int
+	// Checkpoint is the file where progress is kept between runs, resuming
+	// is disabled when it is empty.
+	Checkpoint string
+}
+
+// Progress is called after every stored chunk with the entity and the number
+// of rows stored so far out of total.
+type Progress func(entity string, done, total int)
+
+// Stats
+type Stats struct {
+	Actors        int `json:"actors"`
+	Films         int `json:"films"`
+	SkippedActors int `json:"skipped_actors"`
+	SkippedFilms  int `json:"skipped_films"`
+	ResumedActors int `json:"resumed_actors"`
+	ResumedFilms  int `json:"resumed_films"`
+	// StaleCheckpoint is set when the checkpoint was made from other inputs
+	// and the run started over.
+	StaleCheckpoint bool `json:"stale_checkpoint"`
+	// DroppedCast lists the principals left out of the casts because their
+	// person is missing from name.basics or was skipped.
+	DroppedCast []CastLink `json:"dropped_cast"`
+}
+
+// CastLink is a principal of a title, both given by their dataset ids.
+type CastLink struct {
+	Film  string `json:"film"`
+	Actor string `json:"actor"`
+}
+
+// Loader maps the dataset onto actors and films and stores them through the
+// catalog repository. Titles are matched by tconst and people by nconst,
+// which are kept as external ids, so running it again updates the same rows.
+type Loader struct {
+	config   *Config
+	database store.Store
+	progress Progress
+}
+
+// NewLoader
+func NewLoader(config *Config, database store.Store, progress Progress) *Loader {
+	if progress == nil {
+		progress = func(string, int, int) {}
+	}
+	if config.ChunkSize <= 0 {
+		config.ChunkSize = defaultChunkSize
+	}
+	return &Loader{
+		config:   config,
+		database: database,
+		progress: progress,
+	}
+}
+
+// Run
+func (l *Loader) Run() (*Stats, error) {
+	cp, err := loadCheckpoint(l.config.Checkpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "load checkpoint")
+	}
+	inputs, err := fingerprint(l.config)
+	if err != nil {
+		return nil, errors.Wrap(err, "fingerprint dataset")
+	}
+	// Counts made from other files would point at other rows, so they are
+	// dropped. Imports are upserts, starting over only costs time.
+	stale := cp.Inputs != inputs && (cp.Actors > 0 || cp.Films > 0)
+	if cp.Inputs != inputs {
+		cp = &checkpoint{Inputs: inputs}
+	}
+
+	films, err := l.readTitles()
+	if err != nil {
+		return nil, err
+	}
+	genders, err := l.readPrincipals(films)
+	if err != nil {
+		return nil, err
+	}
+	actors, err := l.readNames(genders)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &Stats{DroppedCast: make([]CastLink, 0), StaleCheckpoint: stale}
+
+	// Birth years are unknown for many people, their birth date stays empty.
+	validActors := make([]models.ActorRecord, 0, len(actors))
+	stored := make(map[string]bool, len(actors))
+	for _, a := range actors {
+		if !a.ValidateForDataset() {
+			stats.SkippedActors++
+			continue
+		}
+		validActors = append(validActors, a)
+		stored[a.ExternalID] = true
+	}
+
+	ids := make([]string, 0, len(films))
+	for id := range films {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	validFilms := make([]models.FilmRecord, 0, len(films))
+	for _, id := range ids {
+		film := films[id]
+		if !film.Request().ValidateForInsert() {
+			stats.SkippedFilms++
+			continue
+		}
+		cast := make([]string, 0, len(film.Cast))
+		for _, nconst := range film.Cast {
+			if !stored[nconst] {
+				stats.DroppedCast = append(stats.DroppedCast, CastLink{Film: id, Actor: nconst})
+				continue
+			}
+			cast = append(cast, nconst)
+		}
+		film.Cast = cast
+		validFilms = append(validFilms, *film)
+	}
+
+	stats.ResumedActors = min(cp.Actors, len(validActors))
+	for i := stats.ResumedActors; i < len(validActors); i += l.config.ChunkSize {
+		chunk := validActors[i:min(i+l.config.ChunkSize, len(validActors))]
+		if _, err := l.database.Catalog().ImportActors(chunk, author); err != nil {
+			return nil, errors.Wrap(err, "import actors")
+		}
+		cp.Actors = i + len(chunk)
+		if err := cp.save(l.config.Checkpoint); err != nil {
+			return nil, errors.Wrap(err, "save checkpoint")
+		}
+		stats.Actors += len(chunk)
+		l.progress("actors", cp.Actors, len(validActors))
+	}
+
+	stats.ResumedFilms = min(cp.Films, len(validFilms))
+	for i := stats.ResumedFilms; i < len(validFilms); i += l.config.ChunkSize {
+		chunk := validFilms[i:min(i+l.config.ChunkSize, len(validFilms))]
+		if _, err := l.database.Catalog().ImportFilms(chunk, author); err != nil {
+			return nil, errors.Wrap(err, "import films")
+		}
+		cp.Films = i + len(chunk)
+		if err := cp.save(l.config.Checkpoint); err != nil {
+			return nil, errors.Wrap(err, "save checkpoint")
+		}
+		stats.Films += len(chunk)
+		l.progress("films", cp.Films, len(validFilms))
+	}
+
+	return stats, nil
+}
+
+// readTitles keeps the titles of the configured types, the start year
+// becomes the first day of that year.
+func (l *Loader) readTitles() (map[string]*models.FilmRecord, error) {
+	t, err := openTSV(l.config.TitleBasics, "tconst", "titleType", "primaryTitle", "startYear")
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+
+	films := make(map[string]*models.FilmRecord)
+	for t.next() {
+		if !contains(l.config.TitleTypes, t.get("titleType")) {
+			continue
+		}
+		films[t.get("tconst")] = &models.FilmRecord{
+			ExternalID:  t.get("tconst"),
+			Title:       t.get("primaryTitle"),
+			ReleaseDate: yearDate(t.get("startYear")),
+		}
+	}
+	if err := t.err(); err != nil {
+		return nil, errors.Wrap(err, "read titles")
+	}
+
+	return films, nil
+}
+
+// readPrincipals fills the cast of films with actors and actresses in billing
+// order and returns the gender of every person found.
+func (l *Loader) readPrincipals(films map[string]*models.FilmRecord) (map[string]string, error) {
+	t, err := openTSV(l.config.TitlePrincipals, "tconst", "nconst", "category")
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+
+	genders := make(map[string]string)
+	for t.next() {
+		film, ok := films[t.get("tconst")]
+		if !ok {
+			continue
+		}
+
+		var gender string
+		switch t.get("category") {
+		case "actor":
+			gender = "male"
+		case "actress":
+			gender = "female"
+		default:
+			continue
+		}
+
+		nconst := t.get("nconst")
+		film.Cast = append(film.Cast, nconst)
+		genders[nconst] = gender
+	}
+	if err := t.err(); err != nil {
+		return nil, errors.Wrap(err, "read principals")
+	}
+
+	return genders, nil
+}
+
+// readNames returns the people that appear in some cast, in file order.
+func (l *Loader) readNames(genders map[string]string) ([]models.ActorRecord, error) {
+	t, err := openTSV(l.config.NameBasics, "nconst", "primaryName", "birthYear")
+	if err != nil {
+		return nil, err
+	}
+	defer t.Close()
+
+	actors := make([]models.ActorRecord, 0, len(genders))
+	for t.next() {
+		nconst := t.get("nconst")
+		gender, ok := genders[nconst]
+		if !ok {
+			continue
+		}
+		actors = append(actors, models.ActorRecord{
+			ExternalID: nconst,
+			Name:       t.get("primaryName"),
+			Gender:     gender,
+			BirthDate:  yearDate(t.get("birthYear")),
+		})
+	}
+	if err := t.err(); err != nil {
+		return nil, errors.Wrap(err, "read names")
+	}
+
+	return actors, nil
+}
+
+func yearDate(year string) string {
+	if len(year) != 4 {
+		return ""
+	}
+	return year + "-01-01"
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package imdb_test
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/imdb"
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	titleBasics = "tconst\ttitleType\tprimaryTitle\toriginalTitle\tisAdult\tstartYear\tendYear\truntimeMinutes\tgenres\n" +
+		"tt0000001\tmovie\tThe Matrix\tThe Matrix\t0\t1999\t\\N\t136\tAction,Sci-Fi\n" +
+		"tt0000002\ttvSeries\tFriends\tFriends\t0\t1994\t2004\t22\tComedy\n" +
+		"tt0000003\tmovie\tJohn Wick\tJohn Wick\t0\t2014\t\\N\t101\tAction\n" +
+		"tt0000004\tmovie\tUndated\tUndated\t0\t\\N\t\\N\t\\N\t\\N\n"
+	titlePrincipals = "tconst\tordering\tnconst\tcategory\tjob\tcharacters\n" +
+		"tt0000001\t1\tnm0000001\tactor\t\\N\t[\"Neo\"]\n" +
+		"tt0000001\t2\tnm0000002\tactress\t\\N\t[\"Trinity\"]\n" +
+		"tt0000001\t3\tnm0000009\tdirector\t\\N\t\\N\n" +
+		"tt0000002\t1\tnm0000003\tactress\t\\N\t[\"Rachel\"]\n" +
+		"tt0000003\t1\tnm0000001\tactor\t\\N\t[\"John Wick\"]\n" +
+		"tt0000003\t2\tnm0000004\tactor\t\\N\t\\N\n" +
+		"tt0000003\t3\tnm0000005\tactress\t\\N\t\\N\n" +
+		"tt0000003\t4\tnm0000006\tactor\t\\N\t\\N\n"
+	nameBasics = "nconst\tprimaryName\tbirthYear\tdeathYear\tprimaryProfession\tknownForTitles\n" +
+		"nm0000001\tKeanu Reeves\t1964\t\\N\tactor\ttt0000001\n" +
+		"nm0000002\tCarrie-Anne Moss\t1967\t\\N\tactress\ttt0000001\n" +
+		"nm0000003\tJennifer Aniston\t1969\t\\N\tactress\ttt0000002\n" +
+		"nm0000004\tUnknown Year\t\\N\t\\N\tactor\ttt0000003\n" +
+		"nm0000005\t\\N\t1970\t\\N\tactress\ttt0000003\n" +
+		"nm0000009\tLana Wachowski\t1965\t\\N\tdirector\ttt0000001\n"
+)
+
+func writeDataset(t *testing.T) *imdb.Config {
+	t.Helper()
+
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, []byte(data), 0644))
+		return path
+	}
+
+	gzPath := filepath.Join(dir, "name.basics.tsv.gz")
+	f, err := os.Create(gzPath)
+	assert.NoError(t, err)
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(nameBasics))
+	gz.Close()
+	f.Close()
+
+	return &imdb.Config{
+		TitleBasics:     write("title.basics.tsv", titleBasics),
+		NameBasics:      gzPath,
+		TitlePrincipals: write("title.principals.tsv", titlePrincipals),
+		TitleTypes:      []string{"movie"},
+		ChunkSize:       1,
+		Checkpoint:      filepath.Join(dir, "checkpoint.json"),
+	}
+}
+
+func TestLoader_Run(t *testing.T) {
+	config := writeDataset(t)
+	s := testdb.New()
+
+	var progress []string
+	stats, err := imdb.NewLoader(config, s, func(entity string, done, total int) {
+		progress = append(progress, entity)
+	}).Run()
+	assert.NoError(t, err)
+	assert.Equal(t, &imdb.Stats{
+		Actors:        3,
+		Films:         2,
+		SkippedActors: 1,
+		SkippedFilms:  1,
+		DroppedCast: []imdb.CastLink{
+			{Film: "tt0000003", Actor: "nm0000005"},
+			{Film: "tt0000003", Actor: "nm0000006"},
+		},
+	}, stats)
+	assert.Equal(t, []string{"actors", "actors", "actors", "films", "films"}, progress)
+
+	films, err := s.Film().GetAll(&store.FilmFilter{Sort: []store.SortField{{Field: "title"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(films))
+	assert.Equal(t, "John Wick", films[0].Title)
+	assert.Equal(t, "2014-01-01", films[0].ReleaseDate)
+	assert.Equal(t, []models.ActorBasic{{ActorID: 1, Name: "Keanu Reeves"}, {ActorID: 3, Name: "Unknown Year"}}, films[0].Actors)
+	assert.Equal(t, "The Matrix", films[1].Title)
+	assert.Equal(t, 2, len(films[1].Actors))
+
+	actor, err := s.Actor().Find(2)
+	assert.NoError(t, err)
+	assert.Equal(t, "Carrie-Anne Moss", actor.Name)
+	assert.Equal(t, "female", actor.Gender)
+	assert.Equal(t, "1967-01-01", actor.BirthDate)
+
+	actor, err = s.Actor().Find(3)
+	assert.NoError(t, err)
+	assert.Equal(t, "Unknown Year", actor.Name)
+	assert.Equal(t, "", actor.BirthDate)
+}
+
+// setCheckpoint keeps the inputs of the checkpoint left by a run and replaces
+// its counts.
+func setCheckpoint(t *testing.T, config *imdb.Config, actors, films int) {
+	t.Helper()
+
+	data, err := os.ReadFile(config.Checkpoint)
+	require.NoError(t, err)
+	cp := make(map[string]any)
+	require.NoError(t, json.Unmarshal(data, &cp))
+	cp["actors"], cp["films"] = actors, films
+	data, err = json.Marshal(cp)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(config.Checkpoint, data, 0644))
+}
+
+func TestLoader_RunResumes(t *testing.T) {
+	config := writeDataset(t)
+	_, err := imdb.NewLoader(config, testdb.New(), nil).Run()
+	require.NoError(t, err)
+	setCheckpoint(t, config, 3, 1)
+	s := testdb.New()
+
+	stats, err := imdb.NewLoader(config, s, nil).Run()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, stats.Actors)
+	assert.Equal(t, 1, stats.Films)
+	assert.Equal(t, 3, stats.ResumedActors)
+	assert.Equal(t, 1, stats.ResumedFilms)
+	assert.False(t, stats.StaleCheckpoint)
+
+	films, _ := s.Film().GetAll(&store.FilmFilter{})
+	assert.Equal(t, 1, len(films))
+	assert.Equal(t, "John Wick", films[0].Title)
+
+	stats, err = imdb.NewLoader(config, s, nil).Run()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, stats.Films)
+}
+
+func TestLoader_RunStaleCheckpoint(t *testing.T) {
+	config := writeDataset(t)
+	s := testdb.New()
+	_, err := imdb.NewLoader(config, s, nil).Run()
+	require.NoError(t, err)
+
+	// A new title sorts first and would shift every film after it.
+	data := titleBasics + "tt0000000\tmovie\tSpeed\tSpeed\t0\t1994\t\\N\t116\tAction\n"
+	require.NoError(t, os.WriteFile(config.TitleBasics, []byte(data), 0644))
+
+	stats, err := imdb.NewLoader(config, s, nil).Run()
+	assert.NoError(t, err)
+	assert.True(t, stats.StaleCheckpoint)
+	assert.Equal(t, 0, stats.ResumedActors)
+	assert.Equal(t, 0, stats.ResumedFilms)
+	assert.Equal(t, 3, stats.Actors)
+	assert.Equal(t, 3, stats.Films)
+
+	films, _ := s.Film().GetAll(&store.FilmFilter{})
+	assert.Equal(t, 3, len(films))
+
+	config.TitleTypes = []string{"movie", "tvSeries"}
+	stats, err = imdb.NewLoader(config, s, nil).Run()
+	assert.NoError(t, err)
+	assert.True(t, stats.StaleCheckpoint)
+	assert.Equal(t, 4, stats.Films)
+}
+
+func TestLoader_RunMissingColumn(t *testing.T) {
+	config := writeDataset(t)
+	config.TitleBasics = config.TitlePrincipals
+
+	_, err := imdb.NewLoader(config, testdb.New(), nil).Run()
+	assert.Error(t, err)
+}
@@ -0,0 +1,101 @@
+package imdb
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// null is how the dataset marks missing values.
+const null = `\N`
+
+// tsvFile reads one of the dataset files. The files have a header line, are
+// separated by tabs and do not quote values, so a plain split is enough.
+type tsvFile struct {
+	file    *os.File
+	gzip    *gzip.Reader
+	scanner *bufio.Scanner
+	columns map[string]int
+	fields  []string
+	line    int
+}
+
+func openTSV(path string, required ...string) (*tsvFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &tsvFile{file: file}
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		t.gzip, err = gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, errors.Wrapf(err, "open %s", path)
+		}
+		r = t.gzip
+	}
+
+	t.scanner = bufio.NewScanner(r)
+	t.scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	if !t.scanner.Scan() {
+		err := t.scanner.Err()
+		t.Close()
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, errors.Wrapf(err, "read header of %s", path)
+	}
+
+	t.columns = make(map[string]int)
+	for i, column := range strings.Split(t.scanner.Text(), "\t") {
+		t.columns[column] = i
+	}
+	for _, column := range required {
+		if _, ok := t.columns[column]; !ok {
+			t.Close()
+			return nil, errors.Errorf("%s has no column %s", path, column)
+		}
+	}
+
+	return t, nil
+}
+
+// next moves to the next line, lines with a wrong number of fields are
+// skipped.
+func (t *tsvFile) next() bool {
+	for t.scanner.Scan() {
+		t.line++
+		t.fields = strings.Split(t.scanner.Text(), "\t")
+		if len(t.fields) == len(t.columns) {
+			return true
+		}
+	}
+	return false
+}
+
+// get returns the value of column in the current line, or "" for a null.
+func (t *tsvFile) get(column string) string {
+	value := t.fields[t.columns[column]]
+	if value == null {
+		return ""
+	}
+	return value
+}
+
+func (t *tsvFile) err() error {
+	return t.scanner.Err()
+}
+
+// Close
+func (t *tsvFile) Close() error {
+	if t.gzip != nil {
+		t.gzip.Close()
+	}
+	return t.file.Close()
+}
@@ -11,17 +11,20 @@ type ActorRequest struct {
 
 // Actor
 type Actor struct {
-	ID        int         `json:"id"`
-	Name      string      `json:"name"`
-	Gender    string      `json:"gender"`
-	BirthDate string      `json:"birth_date"`
-	Films     []FilmBasic `json:"films"`
+	ID        int         `json:"id" xml:"id"`
+	Name      string      `json:"name" xml:"name"`
+	Gender    string      `json:"gender" xml:"gender"`
+	BirthDate string      `json:"birth_date" xml:"birth_date"`
+	Films     []FilmBasic `json:"films" xml:"films>film"`
+	Score     float64     `json:"score,omitempty" xml:"score,omitempty"`
+	DeletedAt string      `json:"deleted_at,omitempty" xml:"deleted_at,omitempty"`
+	DeletedBy string      `json:"deleted_by,omitempty" xml:"deleted_by,omitempty"`
 }
 
 // ActorBasic
 type ActorBasic struct {
-	ActorID int    `json:"actor_id" db:"actor_id"`
-	Name    string `json:"name" db:"name"`
+	ActorID int    `json:"actor_id" db:"actor_id" xml:"actor_id"`
+	Name    string `json:"name" db:"name" xml:"name"`
 }
 
 // ValidateForInsert
@@ -0,0 +1,42 @@
+package models
+
+import "encoding/json"
+
+const (
+	// AuditEntityFilm
+	AuditEntityFilm = "film"
+	// AuditEntityActor
+	AuditEntityActor = "actor"
+	// AuditEntityUser
+	AuditEntityUser = "user"
+
+	// AuditActionCreate
+	AuditActionCreate = "create"
+	// AuditActionModify
+	AuditActionModify = "modify"
+	// AuditActionDelete
+	AuditActionDelete = "delete"
+	// AuditActionRestore
+	AuditActionRestore = "restore"
+)
+
+// Author is the user behind a change and the request that made it, both are
+// recorded in the audit trail.
+type Author struct {
+	Login     string
+	RequestID string
+}
+
+// AuditEvent is one change of a film, actor or user. EntityID is the login
+// for users. Diff maps every changed field to {"before": ..., "after": ...},
+// created records have no before and deleted ones no after.
+type AuditEvent struct {
+	ID        int             `json:"id" db:"id"`
+	At        string          `json:"at" db:"at"`
+	Login     string          `json:"login" db:"login"`
+	RequestID string          `json:"request_id" db:"request_id"`
+	Entity    string          `json:"entity" db:"entity"`
+	EntityID  string          `json:"entity_id" db:"entity_id"`
+	Action    string          `json:"action" db:"action"`
+	Diff      json.RawMessage `json:"diff" db:"diff"`
+}
@@ -0,0 +1,68 @@
+package models
+
+// ActorRecord is an actor as it appears in imports and exports, identified by
+// an external key instead of the database id.
+type ActorRecord struct {
+	ExternalID string `json:"external_id"`
+	Name       string `json:"name"`
+	Gender     string `json:"gender"`
+	BirthDate  string `json:"birth_date"`
+}
+
+// FilmRecord is a film as it appears in imports and exports. Cast holds actor
+// external keys or, for actors without one, their names.
+type FilmRecord struct {
+	ExternalID  string   `json:"external_id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	ReleaseDate string   `json:"release_date"`
+	Rating      float64  `json:"rating"`
+	Cast        []string `json:"cast"`
+}
+
+// ImportResult
+type ImportResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+}
+
+// ImportError
+type ImportError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+// ImportReport
+type ImportReport struct {
+	DryRun bool          `json:"dry_run"`
+	Rows   int           `json:"rows"`
+	Actors ImportResult  `json:"actors"`
+	Films  ImportResult  `json:"films"`
+	Errors []ImportError `json:"errors"`
+}
+
+// Request
+func (r *ActorRecord) Request() *ActorRequest {
+	return &ActorRequest{
+		Name:      r.Name,
+		Gender:    r.Gender,
+		BirthDate: r.BirthDate,
+	}
+}
+
+// ValidateForDataset is ValidateForInsert with an optional birth date, which
+// public datasets often do not know.
+func (r *ActorRecord) ValidateForDataset() bool {
+	req := r.Request()
+	return len(req.Name) >= 1 && req.ValidateForUpdate()
+}
+
+// Request
+func (r *FilmRecord) Request() *FilmRequest {
+	return &FilmRequest{
+		Title:       r.Title,
+		Description: r.Description,
+		ReleaseDate: r.ReleaseDate,
+		Rating:      r.Rating,
+	}
+}
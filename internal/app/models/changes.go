@@ -0,0 +1,13 @@
+package models
+
+// ChangeEvent tells downstream consumers that a film or an actor changed,
+// they read its current state themselves. Entity and Action take the values
+// of AuditEvent. Seq grows with every event in the order the changes were
+// committed, so consumers can poll for the events after the last one seen.
+type ChangeEvent struct {
+	Seq      int    `json:"seq" db:"seq"`
+	At       string `json:"at" db:"at"`
+	Entity   string `json:"entity" db:"entity"`
+	EntityID int    `json:"entity_id" db:"entity_id"`
+	Action   string `json:"action" db:"action"`
+}
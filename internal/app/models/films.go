@@ -4,12 +4,15 @@ import "time"
 
 // Film
 type Film struct {
-	ID          int          `json:"id"`
-	Title       string       `json:"title"`
-	Description string       `json:"description"`
-	ReleaseDate string       `json:"release_date"`
-	Rating      float64      `json:"rating"`
-	Actors      []ActorBasic `json:"actors"`
+	ID          int          `json:"id" xml:"id"`
+	Title       string       `json:"title" xml:"title"`
+	Description string       `json:"description" xml:"description"`
+	ReleaseDate string       `json:"release_date" xml:"release_date"`
+	Rating      float64      `json:"rating" xml:"rating"`
+	Actors      []ActorBasic `json:"actors" xml:"actors>actor"`
+	Score       float64      `json:"score,omitempty" xml:"score,omitempty"`
+	DeletedAt   string       `json:"deleted_at,omitempty" xml:"deleted_at,omitempty"`
+	DeletedBy   string       `json:"deleted_by,omitempty" xml:"deleted_by,omitempty"`
 }
 
 // FilmRequest
@@ -23,8 +26,8 @@ type FilmRequest struct {
 
 // FilmBasic
 type FilmBasic struct {
-	FilmID int    `json:"film_id" db:"film_id"`
-	Title  string `json:"title" db:"title"`
+	FilmID int    `json:"film_id" db:"film_id" xml:"film_id"`
+	Title  string `json:"title" db:"title" xml:"title"`
 }
 
 // VerifyForInsert
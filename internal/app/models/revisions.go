@@ -0,0 +1,24 @@
+package models
+
+import "encoding/json"
+
+// FilmRevision is the state of a film after one of its changes, revisions of
+// every film are numbered from 1. Film is the request that brings the film
+// back to the revision.
+type FilmRevision struct {
+	FilmID    int         `json:"film_id"`
+	Revision  int         `json:"revision"`
+	At        string      `json:"at"`
+	Login     string      `json:"login"`
+	RequestID string      `json:"request_id"`
+	Film      FilmRequest `json:"film"`
+}
+
+// FilmRevisionDiff lists the fields that differ between two revisions in the
+// form of AuditEvent.Diff.
+type FilmRevisionDiff struct {
+	FilmID int             `json:"film_id"`
+	From   int             `json:"from"`
+	To     int             `json:"to"`
+	Diff   json.RawMessage `json:"diff"`
+}
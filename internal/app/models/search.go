@@ -0,0 +1,24 @@
+package models
+
+const (
+	// SearchTypeFilm
+	SearchTypeFilm = "film"
+	// SearchTypeActor
+	SearchTypeActor = "actor"
+)
+
+// SearchResult
+type SearchResult struct {
+	Type    string  `json:"type" db:"type"`
+	ID      int     `json:"id" db:"id"`
+	Label   string  `json:"label" db:"label"`
+	Snippet string  `json:"snippet" db:"snippet"`
+	Rank    float64 `json:"rank" db:"rank"`
+}
+
+// Suggestion
+type Suggestion struct {
+	ID    int    `json:"id" db:"id"`
+	Label string `json:"label" db:"label"`
+	Type  string `json:"type" db:"type"`
+}
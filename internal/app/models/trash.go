@@ -0,0 +1,14 @@
+package models
+
+// TimestampLayout is how the stores report points in time, in UTC.
+const TimestampLayout = "2006-01-02T15:04:05Z"
+
+// DeletedRecord is a film or an actor in the trash. Type is SearchTypeFilm or
+// SearchTypeActor.
+type DeletedRecord struct {
+	Type      string `json:"type" db:"type"`
+	ID        int    `json:"id" db:"id"`
+	Label     string `json:"label" db:"label"`
+	DeletedAt string `json:"deleted_at" db:"deleted_at"`
+	DeletedBy string `json:"deleted_by" db:"deleted_by"`
+}
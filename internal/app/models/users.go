@@ -2,18 +2,34 @@ package models
 
 // User
 type User struct {
-	Login          string `db:"login" json:"login"`
-	HashedPassword string `db:"hashed_password" json:"hashed_password"`
-	IsAdmin        bool   `db:"is_admin" json:"is_admin"`
+	Login          string `db:"login" json:"login" xml:"login"`
+	HashedPassword string `db:"hashed_password" json:"hashed_password" xml:"hashed_password"`
+	IsAdmin        bool   `db:"is_admin" json:"is_admin" xml:"is_admin"`
 }
 
 // UserRequest
 type UserRequest struct {
 	Login    string `json:"login"`
 	Password string `json:"password"`
+	IsAdmin  bool   `json:"is_admin"`
 }
 
 // Validate
 func (r *UserRequest) Validate() bool {
 	return len(r.Login) >= 1 && len(r.Login) <= 50 && len(r.Password) >= 6 && len(r.Password) <= 50
-}
\ No newline at end of file
+}
+
+// UserUpdate changes the role and/or password of a user, nil fields are left
+// as they are.
+type UserUpdate struct {
+	IsAdmin  *bool   `json:"is_admin"`
+	Password *string `json:"password"`
+}
+
+// Validate
+func (r *UserUpdate) Validate() bool {
+	if r.IsAdmin == nil && r.Password == nil {
+		return false
+	}
+	return r.Password == nil || len(*r.Password) >= 6 && len(*r.Password) <= 50
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"encoding/json"
+	"net/url"
+)
+
+const (
+	// WebhookStatusPending
+	WebhookStatusPending = "pending"
+	// WebhookStatusSucceeded
+	WebhookStatusSucceeded = "succeeded"
+	// WebhookStatusFailed is final, the delivery ran out of attempts.
+	WebhookStatusFailed = "failed"
+)
+
+// webhookActions maps change event actions to the past tense used in webhook
+// event types.
+var webhookActions = map[string]string{
+	AuditActionCreate:  "created",
+	AuditActionModify:  "modified",
+	AuditActionDelete:  "deleted",
+	AuditActionRestore: "restored",
+}
+
+// WebhookEvent is the type of the change event in webhooks, e.g.
+// film.created.
+func (e *ChangeEvent) WebhookEvent() string {
+	return e.Entity + "." + webhookActions[e.Action]
+}
+
+// WebhookEvents lists every event type webhooks can subscribe to.
+func WebhookEvents() []string {
+	var events []string
+	for _, entity := range []string{AuditEntityFilm, AuditEntityActor} {
+		for _, action := range []string{AuditActionCreate, AuditActionModify, AuditActionDelete, AuditActionRestore} {
+			events = append(events, entity+"."+webhookActions[action])
+		}
+	}
+	return events
+}
+
+// Webhook is a subscription of URL to the change events of the listed types.
+// Payloads are signed with Secret, which is only shown when the webhook is
+// created.
+type Webhook struct {
+	ID        int      `json:"id"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	Secret    string   `json:"secret,omitempty"`
+	CreatedAt string   `json:"created_at"`
+}
+
+// WebhookRequest
+type WebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+// Validate
+func (r *WebhookRequest) Validate() bool {
+	u, err := url.Parse(r.URL)
+	validURL := err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && len(r.URL) <= 500
+	validEvents := len(r.Events) > 0
+	for _, event := range r.Events {
+		known := false
+		for _, e := range WebhookEvents() {
+			known = known || e == event
+		}
+		validEvents = validEvents && known
+	}
+	validSecret := len(r.Secret) <= 200
+	return validURL && validEvents && validSecret
+}
+
+// WebhookPayload is the body of webhook requests.
+type WebhookPayload struct {
+	Event    string `json:"event"`
+	Seq      int    `json:"seq"`
+	At       string `json:"at"`
+	Entity   string `json:"entity"`
+	EntityID int    `json:"entity_id"`
+}
+
+// WebhookDelivery is one change event on its way to a webhook. Redelivering
+// adds a new delivery with the same payload.
+type WebhookDelivery struct {
+	ID            int             `json:"id" db:"id"`
+	WebhookID     int             `json:"webhook_id" db:"webhook_id"`
+	URL           string          `json:"url" db:"url"`
+	Event         string          `json:"event" db:"event"`
+	Payload       json.RawMessage `json:"payload" db:"payload"`
+	Status        string          `json:"status" db:"status"`
+	Attempts      int             `json:"attempts" db:"attempts"`
+	ResponseCode  int             `json:"response_code,omitempty" db:"response_code"`
+	Error         string          `json:"error,omitempty" db:"error"`
+	CreatedAt     string          `json:"created_at" db:"created_at"`
+	NextAttemptAt string          `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	DeliveredAt   string          `json:"delivered_at,omitempty" db:"delivered_at"`
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+)
+
+// Snapshot is the audited state of a film, actor or user by field name.
+type Snapshot map[string]any
+
+// FilmSnapshot
+func FilmSnapshot(f *models.Film) Snapshot {
+	actorIDs := make([]int, 0, len(f.Actors))
+	for _, a := range f.Actors {
+		actorIDs = append(actorIDs, a.ActorID)
+	}
+
+	return FilmRequestSnapshot(&models.FilmRequest{
+		Title:       f.Title,
+		Description: f.Description,
+		ReleaseDate: f.ReleaseDate,
+		Rating:      f.Rating,
+		ActorsIDs:   actorIDs,
+	})
+}
+
+// FilmRequestSnapshot is the snapshot of the film the request describes as a
+// whole. Snapshots of films decode into models.FilmRequest, which is how film
+// revisions are stored.
+func FilmRequestSnapshot(f *models.FilmRequest) Snapshot {
+	actorIDs := append(make([]int, 0, len(f.ActorsIDs)), f.ActorsIDs...)
+	sort.Ints(actorIDs)
+
+	return Snapshot{
+		"title":        f.Title,
+		"description":  f.Description,
+		"release_date": f.ReleaseDate,
+		"rating":       f.Rating,
+		"actors_ids":   actorIDs,
+	}
+}
+
+// ActorSnapshot
+func ActorSnapshot(a *models.Actor) Snapshot {
+	return Snapshot{
+		"name":       a.Name,
+		"gender":     a.Gender,
+		"birth_date": a.BirthDate,
+	}
+}
+
+// UserSnapshot leaves the password hash out, see PasswordDiff.
+func UserSnapshot(u *models.User) Snapshot {
+	return Snapshot{
+		"login":    u.Login,
+		"is_admin": u.IsAdmin,
+	}
+}
+
+// PasswordDiff is recorded for password changes instead of the hashes.
+var PasswordDiff = json.RawMessage(`{"password":{"before":"***","after":"***"}}`)
+
+type change struct {
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// Diff lists the fields that differ between two snapshots, either of which
+// may be nil, in the form of models.AuditEvent. It is nil when nothing
+// changed.
+func Diff(before, after Snapshot) (json.RawMessage, error) {
+	fields := make(map[string]bool)
+	for field := range before {
+		fields[field] = true
+	}
+	for field := range after {
+		fields[field] = true
+	}
+
+	changes := make(map[string]change)
+	for field := range fields {
+		var c change
+		var err error
+		if value, ok := before[field]; ok {
+			if c.Before, err = json.Marshal(value); err != nil {
+				return nil, err
+			}
+		}
+		if value, ok := after[field]; ok {
+			if c.After, err = json.Marshal(value); err != nil {
+				return nil, err
+			}
+		}
+		if !bytes.Equal(c.Before, c.After) {
+			changes[field] = c
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	return json.Marshal(changes)
+}
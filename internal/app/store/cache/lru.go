@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"container/list"
+	"time"
+)
+
+type key struct {
+	kind string
+	id   int
+}
+
+type entry struct {
+	key     key
+	value   any
+	expires time.Time
+}
+
+// lru is a size bounded map dropping the least recently used entry first.
+// Expired entries are dropped when they are looked up. It is not safe for
+// concurrent use.
+type lru struct {
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[key]*list.Element
+}
+
+func newLRU(size int, ttl time.Duration) *lru {
+	return &lru{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[key]*list.Element),
+	}
+}
+
+func (c *lru) get(k key, now time.Time) (any, bool) {
+	el, ok := c.entries[k]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if now.After(e.expires) {
+		c.remove(el)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+
+	return e.value, true
+}
+
+// set stores value under k and reports whether another entry was evicted to
+// make room for it.
+func (c *lru) set(k key, value any, now time.Time) bool {
+	if el, ok := c.entries[k]; ok {
+		e := el.Value.(*entry)
+		e.value, e.expires = value, now.Add(c.ttl)
+		c.order.MoveToFront(el)
+		return false
+	}
+
+	c.entries[k] = c.order.PushFront(&entry{key: k, value: value, expires: now.Add(c.ttl)})
+	if c.order.Len() <= c.size {
+		return false
+	}
+	c.remove(c.order.Back())
+
+	return true
+}
+
+func (c *lru) delete(k key) {
+	if el, ok := c.entries[k]; ok {
+		c.remove(el)
+	}
+}
+
+// deleteFunc drops every entry of the given kind for which match is true.
+func (c *lru) deleteFunc(kind string, match func(value any) bool) {
+	for el := c.order.Front(); el != nil; {
+		next := el.Next()
+		if e := el.Value.(*entry); e.key.kind == kind && match(e.value) {
+			c.remove(el)
+		}
+		el = next
+	}
+}
+
+func (c *lru) clear() {
+	c.order.Init()
+	c.entries = make(map[key]*list.Element)
+}
+
+func (c *lru) len() int {
+	return c.order.Len()
+}
+
+func (c *lru) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*entry).key)
+}
@@ -0,0 +1,289 @@
+// Package cache wraps a store.Store and keeps recently found films and actors
+// in memory. Writes going through the wrapper drop the entries they affect,
+// so it must not share its database with writers that bypass it.
+package cache
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+const (
+	filmKind  = "film"
+	actorKind = "actor"
+)
+
+// Stats
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Entries   int    `json:"entries"`
+}
+
+// Store
+type Store struct {
+	store.Store
+	mu                sync.Mutex
+	entries           *lru
+	generation        uint64
+	stats             Stats
+	filmRepository    *FilmRepository
+	actorRepository   *ActorRepository
+	catalogRepository *CatalogRepository
+}
+
+// New keeps up to size films and actors found in inner, each for at most ttl.
+func New(inner store.Store, size int, ttl time.Duration) *Store {
+	s := &Store{
+		Store:   inner,
+		entries: newLRU(size, ttl),
+	}
+	s.filmRepository = &FilmRepository{FilmRepository: inner.Film(), cache: s}
+	s.actorRepository = &ActorRepository{ActorRepository: inner.Actor(), cache: s}
+	s.catalogRepository = &CatalogRepository{CatalogRepository: inner.Catalog(), cache: s}
+
+	return s
+}
+
+// Film
+func (s *Store) Film() store.FilmRepository {
+	return s.filmRepository
+}
+
+// Actor
+func (s *Store) Actor() store.ActorRepository {
+	return s.actorRepository
+}
+
+// Catalog
+func (s *Store) Catalog() store.CatalogRepository {
+	return s.catalogRepository
+}
+
+// Stats
+func (s *Store) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.stats
+	stats.Entries = s.entries.len()
+
+	return stats
+}
+
+// lookup returns the cached value of k, or the generation to pass to store
+// once the value has been read from the inner store.
+func (s *Store) lookup(k key) (any, uint64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.entries.get(k, time.Now())
+	if ok {
+		s.stats.Hits++
+		return value, 0, true
+	}
+	s.stats.Misses++
+
+	return nil, s.generation, false
+}
+
+// store caches value unless something was invalidated since generation was
+// handed out, in which case value may already be stale.
+func (s *Store) store(k key, value any, generation uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if generation != s.generation {
+		return
+	}
+	if s.entries.set(k, value, time.Now()) {
+		s.stats.Evictions++
+	}
+}
+
+// invalidateFilm drops the film, the actors it is cached with and the actors
+// given, whose film lists now include or lack it.
+func (s *Store) invalidateFilm(id int, actorIDs []int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.generation++
+	s.entries.delete(key{filmKind, id})
+	for _, actorID := range actorIDs {
+		s.entries.delete(key{actorKind, actorID})
+	}
+	s.entries.deleteFunc(actorKind, func(value any) bool {
+		return slices.ContainsFunc(value.(*models.Actor).Films, func(f models.FilmBasic) bool {
+			return f.FilmID == id
+		})
+	})
+}
+
+// invalidateActor drops the actor and the films listing it.
+func (s *Store) invalidateActor(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.generation++
+	s.entries.delete(key{actorKind, id})
+	s.entries.deleteFunc(filmKind, func(value any) bool {
+		return slices.ContainsFunc(value.(*models.Film).Actors, func(a models.ActorBasic) bool {
+			return a.ActorID == id
+		})
+	})
+}
+
+func (s *Store) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.generation++
+	s.entries.clear()
+}
+
+// FilmRepository
+type FilmRepository struct {
+	store.FilmRepository
+	cache *Store
+}
+
+// Find
+func (r *FilmRepository) Find(id int) (*models.Film, error) {
+	k := key{filmKind, id}
+	value, generation, ok := r.cache.lookup(k)
+	if ok {
+		return copyFilm(value.(*models.Film)), nil
+	}
+
+	film, err := r.FilmRepository.Find(id)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.store(k, copyFilm(film), generation)
+
+	return film, nil
+}
+
+// Create
+func (r *FilmRepository) Create(f *models.FilmRequest, author models.Author) (int, error) {
+	id, err := r.FilmRepository.Create(f, author)
+	if err != nil {
+		return 0, err
+	}
+	r.cache.invalidateFilm(id, f.ActorsIDs)
+
+	return id, nil
+}
+
+// Modify
+func (r *FilmRepository) Modify(id int, f *models.FilmRequest, author models.Author) (bool, error) {
+	defer r.cache.invalidateFilm(id, f.ActorsIDs)
+
+	return r.FilmRepository.Modify(id, f, author)
+}
+
+// Revert drops the whole cache, the actors the film had in the revision are
+// not known before it is read.
+func (r *FilmRepository) Revert(id int, n int, author models.Author) (bool, error) {
+	defer r.cache.clear()
+
+	return r.FilmRepository.Revert(id, n, author)
+}
+
+// Delete
+func (r *FilmRepository) Delete(id int, author models.Author) (bool, error) {
+	defer r.cache.invalidateFilm(id, nil)
+
+	return r.FilmRepository.Delete(id, author)
+}
+
+// Restore drops the whole cache, the film is back in the lists of actors no
+// cached entry names.
+func (r *FilmRepository) Restore(id int, author models.Author) (bool, error) {
+	defer r.cache.clear()
+
+	return r.FilmRepository.Restore(id, author)
+}
+
+// ActorRepository
+type ActorRepository struct {
+	store.ActorRepository
+	cache *Store
+}
+
+// Find
+func (r *ActorRepository) Find(id int) (*models.Actor, error) {
+	k := key{actorKind, id}
+	value, generation, ok := r.cache.lookup(k)
+	if ok {
+		return copyActor(value.(*models.Actor)), nil
+	}
+
+	actor, err := r.ActorRepository.Find(id)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.store(k, copyActor(actor), generation)
+
+	return actor, nil
+}
+
+// Modify
+func (r *ActorRepository) Modify(id int, a *models.ActorRequest, author models.Author) (bool, error) {
+	defer r.cache.invalidateActor(id)
+
+	return r.ActorRepository.Modify(id, a, author)
+}
+
+// Delete
+func (r *ActorRepository) Delete(id int, author models.Author) (bool, error) {
+	defer r.cache.invalidateActor(id)
+
+	return r.ActorRepository.Delete(id, author)
+}
+
+// Restore drops the whole cache, see FilmRepository.Restore.
+func (r *ActorRepository) Restore(id int, author models.Author) (bool, error) {
+	defer r.cache.clear()
+
+	return r.ActorRepository.Restore(id, author)
+}
+
+// CatalogRepository
+type CatalogRepository struct {
+	store.CatalogRepository
+	cache *Store
+}
+
+// ImportActors drops the whole cache, imports may touch any actor.
+func (r *CatalogRepository) ImportActors(actors []models.ActorRecord, author models.Author) (*models.ImportResult, error) {
+	defer r.cache.clear()
+
+	return r.CatalogRepository.ImportActors(actors, author)
+}
+
+// ImportFilms drops the whole cache, imports may touch any film and cast.
+func (r *CatalogRepository) ImportFilms(films []models.FilmRecord, author models.Author) (*models.ImportResult, error) {
+	defer r.cache.clear()
+
+	return r.CatalogRepository.ImportFilms(films, author)
+}
+
+// Callers may change what they get, so the cache hands out copies.
+
+func copyFilm(f *models.Film) *models.Film {
+	c := *f
+	c.Actors = slices.Clone(f.Actors)
+	return &c
+}
+
+func copyActor(a *models.Actor) *models.Actor {
+	c := *a
+	c.Films = slices.Clone(a.Films)
+	return &c
+}
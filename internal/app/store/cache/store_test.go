@@ -0,0 +1,130 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/cache"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/storetest"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/testdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) (store.Store, func()) {
+		return cache.New(testdb.New(), 100, time.Minute), func() {}
+	})
+}
+
+func TestStore_Hits(t *testing.T) {
+	s := cache.New(testdb.New(), 100, time.Minute)
+	id, err := s.Film().Create(&models.FilmRequest{Title: "Alpha", ReleaseDate: "1999-05-01", Rating: 7.8}, models.Author{Login: "admin"})
+	require.NoError(t, err)
+
+	film, err := s.Film().Find(id)
+	require.NoError(t, err)
+	film.Title = "Changed by the caller"
+
+	film, err = s.Film().Find(id)
+	require.NoError(t, err)
+	assert.Equal(t, "Alpha", film.Title)
+
+	_, err = s.Film().Find(id + 100)
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+
+	assert.Equal(t, cache.Stats{Hits: 1, Misses: 2, Entries: 1}, s.Stats())
+}
+
+func TestStore_Invalidation(t *testing.T) {
+	s := cache.New(testdb.New(), 100, time.Minute)
+	actorID, err := s.Actor().Create(&models.ActorRequest{Name: "Keanu Reeves", Gender: "male", BirthDate: "1964-09-02"}, models.Author{Login: "admin"})
+	require.NoError(t, err)
+	filmID, err := s.Film().Create(&models.FilmRequest{Title: "The Matrix", ReleaseDate: "1999-03-31", Rating: 8.7, ActorsIDs: []int{actorID}}, models.Author{Login: "admin"})
+	require.NoError(t, err)
+
+	_, err = s.Film().Find(filmID)
+	require.NoError(t, err)
+	_, err = s.Actor().Find(actorID)
+	require.NoError(t, err)
+
+	t.Run("Actor modify reaches films", func(t *testing.T) {
+		_, err := s.Actor().Modify(actorID, &models.ActorRequest{Name: "Keanu Charles Reeves"}, models.Author{Login: "admin"})
+		require.NoError(t, err)
+
+		film, err := s.Film().Find(filmID)
+		require.NoError(t, err)
+		assert.Equal(t, []models.ActorBasic{{ActorID: actorID, Name: "Keanu Charles Reeves"}}, film.Actors)
+	})
+
+	t.Run("Film modify reaches actors", func(t *testing.T) {
+		_, err := s.Actor().Find(actorID)
+		require.NoError(t, err)
+		_, err = s.Film().Modify(filmID, &models.FilmRequest{Title: "Matrix"}, models.Author{Login: "admin"})
+		require.NoError(t, err)
+
+		actor, err := s.Actor().Find(actorID)
+		require.NoError(t, err)
+		assert.Empty(t, actor.Films)
+	})
+
+	t.Run("New film reaches its cast", func(t *testing.T) {
+		_, err := s.Actor().Find(actorID)
+		require.NoError(t, err)
+		id, err := s.Film().Create(&models.FilmRequest{Title: "Speed", ReleaseDate: "1994-06-10", Rating: 7.2, ActorsIDs: []int{actorID}}, models.Author{Login: "admin"})
+		require.NoError(t, err)
+
+		actor, err := s.Actor().Find(actorID)
+		require.NoError(t, err)
+		assert.Equal(t, []models.FilmBasic{{FilmID: id, Title: "Speed"}}, actor.Films)
+	})
+
+	t.Run("Actor delete reaches films", func(t *testing.T) {
+		films, err := s.Film().GetAll(&store.FilmFilter{})
+		require.NoError(t, err)
+		for _, f := range films {
+			_, err := s.Film().Find(f.ID)
+			require.NoError(t, err)
+		}
+		_, err = s.Actor().Delete(actorID, models.Author{Login: "admin"})
+		require.NoError(t, err)
+
+		for _, f := range films {
+			film, err := s.Film().Find(f.ID)
+			require.NoError(t, err)
+			assert.Empty(t, film.Actors)
+		}
+		_, err = s.Actor().Find(actorID)
+		assert.ErrorIs(t, err, store.ErrRecordNotFound)
+	})
+
+	t.Run("Import clears everything", func(t *testing.T) {
+		_, err := s.Film().Find(filmID)
+		require.NoError(t, err)
+		_, err = s.Catalog().ImportFilms([]models.FilmRecord{{Title: "Matrix", ReleaseDate: "1999-03-31", Rating: 9}}, models.Author{Login: "admin"})
+		require.NoError(t, err)
+
+		film, err := s.Film().Find(filmID)
+		require.NoError(t, err)
+		assert.Equal(t, 9.0, film.Rating)
+	})
+}
+
+func TestStore_Bounds(t *testing.T) {
+	s := cache.New(testdb.New(), 1, 20*time.Millisecond)
+	id1, err := s.Film().Create(&models.FilmRequest{Title: "Alpha", ReleaseDate: "1999-05-01", Rating: 7.8}, models.Author{Login: "admin"})
+	require.NoError(t, err)
+	id2, err := s.Film().Create(&models.FilmRequest{Title: "Beta", ReleaseDate: "2005-01-01", Rating: 6.1}, models.Author{Login: "admin"})
+	require.NoError(t, err)
+
+	s.Film().Find(id1)
+	s.Film().Find(id2)
+	s.Film().Find(id1)
+	assert.Equal(t, cache.Stats{Misses: 3, Evictions: 2, Entries: 1}, s.Stats())
+
+	time.Sleep(40 * time.Millisecond)
+	s.Film().Find(id1)
+	assert.Equal(t, cache.Stats{Misses: 4, Evictions: 2, Entries: 1}, s.Stats())
+}
@@ -0,0 +1,69 @@
+package store
+
+import "time"
+
+const (
+	// ActorMatchAny selects films with at least one of the given actors
+	ActorMatchAny = "any"
+	// ActorMatchAll selects films with every one of the given actors
+	ActorMatchAll = "all"
+)
+
+// SortField
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// FilmFilter describes which films FilmRepository.GetAll returns and in what
+// order. Zero values mean no restriction, bounds are inclusive. Fields limits
+// the filled columns, the id is always filled; OmitActors leaves the cast out.
+// Films in the trash are left out unless IncludeDeleted is set.
+type FilmFilter struct {
+	IDs            []int
+	SearchTitle    string
+	SearchActor    string
+	RatingMin      *float64
+	RatingMax      *float64
+	ReleasedAfter  string
+	ReleasedBefore string
+	ActorIDs       []int
+	ActorMatch     string
+	HasDescription *bool
+	Sort           []SortField
+	Fields         []string
+	OmitActors     bool
+	IncludeDeleted bool
+}
+
+// ActorFilter describes which actors ActorRepository.GetAll returns and in
+// what order. Zero values mean no restriction, bounds are inclusive. Actors are
+// ordered by name unless Sort says otherwise. Fields, OmitFilms and
+// IncludeDeleted work as in FilmFilter.
+type ActorFilter struct {
+	IDs            []int
+	SearchName     string
+	Gender         string
+	BornAfter      string
+	BornBefore     string
+	Sort           []SortField
+	Fields         []string
+	OmitFilms      bool
+	IncludeDeleted bool
+}
+
+// AuditFilter describes which events AuditRepository.List returns, newest
+// first. Zero values mean no restriction, Since is inclusive and Until is
+// not. BeforeID continues a listing after the last event of the previous
+// page.
+type AuditFilter struct {
+	Entity    string
+	EntityID  string
+	Action    string
+	Login     string
+	RequestID string
+	Since     time.Time
+	Until     time.Time
+	BeforeID  int
+	Limit     int
+}
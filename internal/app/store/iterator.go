@@ -0,0 +1,22 @@
+package store
+
+import (
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+)
+
+// FilmIterator walks over films one at a time. Next must be called before
+// the first Film, Close must always be called to release the cursor.
+type FilmIterator interface {
+	Next() bool
+	Film() *models.Film
+	Err() error
+	Close() error
+}
+
+// ActorIterator walks over actors one at a time, see FilmIterator.
+type ActorIterator interface {
+	Next() bool
+	Actor() *models.Actor
+	Err() error
+	Close() error
+}
@@ -2,8 +2,12 @@ package postgres
 
 import (
 	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 
 	"github.com/Rbd3178/filmDatabase/internal/app/entities"
@@ -17,7 +21,7 @@ type ActorRepository struct {
 }
 
 // Create
-func (r *ActorRepository) Create(a *models.ActorRequest) (id int, err error) {
+func (r *ActorRepository) Create(a *models.ActorRequest, author models.Author) (id int, err error) {
 	tx, err := r.store.db.Beginx()
 	if err != nil {
 		return 0, errors.Wrap(err, "could not start transaction")
@@ -37,7 +41,11 @@ func (r *ActorRepository) Create(a *models.ActorRequest) (id int, err error) {
 		err = tx.Commit()
 	}()
 
-	return r.create(tx, a)
+	if id, err = r.create(tx, a); err != nil {
+		return 0, err
+	}
+
+	return id, r.audit(tx, author, models.AuditActionCreate, id, nil)
 }
 
 func (r *ActorRepository) create(tx *sqlx.Tx, a *models.ActorRequest) (int, error) {
@@ -59,7 +67,7 @@ func (r *ActorRepository) create(tx *sqlx.Tx, a *models.ActorRequest) (int, erro
 }
 
 // Modify
-func (r *ActorRepository) Modify(id int, a *models.ActorRequest) (done bool, err error) {
+func (r *ActorRepository) Modify(id int, a *models.ActorRequest, author models.Author) (done bool, err error) {
 	tx, err := r.store.db.Beginx()
 	if err != nil {
 		return false, errors.Wrap(err, "could not start transaction")
@@ -79,13 +87,37 @@ func (r *ActorRepository) Modify(id int, a *models.ActorRequest) (done bool, err
 		err = tx.Commit()
 	}()
 
-	return r.modify(tx, id, a)
+	if err := r.lock(tx, id); err != nil {
+		return false, err
+	}
+	before, err := r.snapshot(tx, id)
+	if err != nil || before == nil {
+		return false, err
+	}
+	if done, err = r.modify(tx, id, a); !done || err != nil {
+		return done, err
+	}
+
+	return true, r.audit(tx, author, models.AuditActionModify, id, before)
 }
 
 func (r *ActorRepository) modify(tx *sqlx.Tx, id int, a *models.ActorRequest) (bool, error) {
+	var exists bool
+	err := tx.Get(
+		&exists,
+		"SELECT EXISTS(SELECT 1 FROM actors WHERE id = $1 AND deleted_at IS NULL)",
+		id,
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "check if actor exists")
+	}
+	if !exists {
+		return false, nil
+	}
+
 	if a.Name != "" {
 		res, err := tx.Exec(
-			"UPDATE actors SET name=$1 WHERE id = $2",
+			"UPDATE actors SET name=$1 WHERE id = $2 AND deleted_at IS NULL",
 			a.Name,
 			id,
 		)
@@ -102,7 +134,7 @@ func (r *ActorRepository) modify(tx *sqlx.Tx, id int, a *models.ActorRequest) (b
 	}
 	if a.Gender != "" {
 		res, err := tx.Exec(
-			"UPDATE actors SET gender=$1 WHERE id = $2",
+			"UPDATE actors SET gender=$1 WHERE id = $2 AND deleted_at IS NULL",
 			a.Gender,
 			id,
 		)
@@ -119,7 +151,7 @@ func (r *ActorRepository) modify(tx *sqlx.Tx, id int, a *models.ActorRequest) (b
 	}
 	if a.BirthDate != "" {
 		res, err := tx.Exec(
-			"UPDATE actors SET birth_date=$1 WHERE id = $2",
+			"UPDATE actors SET birth_date=$1 WHERE id = $2 AND deleted_at IS NULL",
 			a.BirthDate,
 			id,
 		)
@@ -139,7 +171,7 @@ func (r *ActorRepository) modify(tx *sqlx.Tx, id int, a *models.ActorRequest) (b
 }
 
 // Delete
-func (r *ActorRepository) Delete(id int) (done bool, err error) {
+func (r *ActorRepository) Delete(id int, author models.Author) (done bool, err error) {
 	tx, err := r.store.db.Beginx()
 	if err != nil {
 		return false, errors.Wrap(err, "could not start transaction")
@@ -159,24 +191,79 @@ func (r *ActorRepository) Delete(id int) (done bool, err error) {
 		err = tx.Commit()
 	}()
 
-	return r.delete(tx, id)
+	if err := r.lock(tx, id); err != nil {
+		return false, err
+	}
+	before, err := r.snapshot(tx, id)
+	if err != nil || before == nil {
+		return false, err
+	}
+	if done, err = r.delete(tx, id, author.Login); !done || err != nil {
+		return done, err
+	}
+
+	return true, r.audit(tx, author, models.AuditActionDelete, id, before)
 }
 
-func (r *ActorRepository) delete(tx *sqlx.Tx, id int) (bool, error) {
-	_, err := tx.Exec(
-		"DELETE FROM films_x_actors WHERE actor_id = $1",
+func (r *ActorRepository) delete(tx *sqlx.Tx, id int, deletedBy string) (bool, error) {
+	res, err := tx.Exec(
+		"UPDATE actors SET deleted_at = now(), deleted_by = $2 WHERE id = $1 AND deleted_at IS NULL",
 		id,
+		deletedBy,
 	)
 	if err != nil {
-		return false, errors.Wrap(err, "delete from films_x_actors")
+		return false, errors.Wrap(err, "update actors")
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "rows affected")
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// Restore takes the actor out of the trash, back into the casts of their
+// films.
+func (r *ActorRepository) Restore(id int, author models.Author) (done bool, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return false, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	if err := r.lock(tx, id); err != nil {
+		return false, err
+	}
+	if done, err = r.restore(tx, id); !done || err != nil {
+		return done, err
 	}
 
+	return true, r.audit(tx, author, models.AuditActionRestore, id, nil)
+}
+
+func (r *ActorRepository) restore(tx *sqlx.Tx, id int) (bool, error) {
 	res, err := tx.Exec(
-		"DELETE FROM actors WHERE id = $1",
+		"UPDATE actors SET deleted_at = NULL, deleted_by = NULL WHERE id = $1 AND deleted_at IS NOT NULL",
 		id,
 	)
 	if err != nil {
-		return false, errors.Wrap(err, "delete from actors")
+		return false, errors.Wrap(err, "update actors")
 	}
 	rowsAffected, err := res.RowsAffected()
 	if err != nil {
@@ -185,10 +272,53 @@ func (r *ActorRepository) delete(tx *sqlx.Tx, id int) (bool, error) {
 	if rowsAffected == 0 {
 		return false, nil
 	}
-	
+
 	return true, nil
 }
 
+// lock holds off other changes of the actor until the transaction ends, so
+// that the snapshot taken before a change is still current when it is
+// compared with the one after.
+func (r *ActorRepository) lock(tx *sqlx.Tx, id int) error {
+	_, err := tx.Exec("SELECT id FROM actors WHERE id = $1 FOR UPDATE", id)
+	return errors.Wrap(err, "lock actor")
+}
+
+// snapshot returns the audited state of the actor, nil when there is no such
+// actor or it is deleted.
+func (r *ActorRepository) snapshot(tx *sqlx.Tx, id int) (store.Snapshot, error) {
+	actor, err := r.find(tx, id)
+	if err == store.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return store.ActorSnapshot(actor), nil
+}
+
+// audit records how the actor changed since before and tells the outbox.
+func (r *ActorRepository) audit(tx *sqlx.Tx, author models.Author, action string, id int, before store.Snapshot) error {
+	after, err := r.snapshot(tx, id)
+	if err != nil {
+		return err
+	}
+	diff, err := store.Diff(before, after)
+	if err != nil {
+		return errors.Wrap(err, "diff")
+	}
+
+	if diff == nil {
+		return nil
+	}
+	if err := insertAuditEvent(tx, author, models.AuditEntityActor, strconv.Itoa(id), action, diff); err != nil {
+		return err
+	}
+
+	return insertChangeEvent(tx, models.AuditEntityActor, id, action)
+}
+
 // Find
 func (r *ActorRepository) Find(id int) (actor *models.Actor, err error) {
 	tx, err := r.store.db.Beginx()
@@ -218,7 +348,7 @@ func (r *ActorRepository) find(tx *sqlx.Tx, id int) (*models.Actor, error) {
 
 	err := tx.Get(
 		&actorInfo,
-		"SELECT * FROM actors WHERE id = $1",
+		"SELECT id, name, gender, coalesce(to_char(birth_date, 'YYYY-MM-DD'), '') AS birth_date FROM actors WHERE id = $1 AND deleted_at IS NULL",
 		id,
 	)
 	if err != nil {
@@ -238,14 +368,14 @@ func (r *ActorRepository) find(tx *sqlx.Tx, id int) (*models.Actor, error) {
 	err = tx.Select(
 		&actor.Films,
 		`SELECT
-			fxa.film_id,
+			f.id AS film_id,
 			f.title
 		FROM 
 			actors a
 		INNER JOIN
 			films_x_actors fxa on fxa.actor_id = a.id
 		INNER JOIN
-			films f on f.id = fxa.film_id
+			films f on f.id = fxa.film_id AND f.deleted_at IS NULL
 		WHERE a.id = $1`,
 		id,
 	)
@@ -258,7 +388,7 @@ func (r *ActorRepository) find(tx *sqlx.Tx, id int) (*models.Actor, error) {
 }
 
 // GetAll
-func (r *ActorRepository) GetAll() (actors []models.Actor, err error) {
+func (r *ActorRepository) GetAll(filter *store.ActorFilter) (actors []models.Actor, err error) {
 	tx, err := r.store.db.Beginx()
 	if err != nil {
 		return nil, errors.Wrap(err, "could not start transaction")
@@ -278,59 +408,235 @@ func (r *ActorRepository) GetAll() (actors []models.Actor, err error) {
 		err = tx.Commit()
 	}()
 
-	return r.getAll(tx)
+	return r.getAll(tx, filter)
 }
 
-func (r *ActorRepository) getAll(tx *sqlx.Tx) ([]models.Actor, error) {
+var actorSortColumns = map[string]string{
+	"name":       "a.name",
+	"birth_date": "a.birth_date",
+	"film_count": "(SELECT count(*) FROM films_x_actors cfxa INNER JOIN films cf ON cf.id = cfxa.film_id AND cf.deleted_at IS NULL WHERE cfxa.actor_id = a.id)",
+}
+
+func (r *ActorRepository) getAll(tx *sqlx.Tx, filter *store.ActorFilter) ([]models.Actor, error) {
+	query, args, err := actorsQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+
 	var rawActors = make([]entities.ActorWithFilm, 0)
+	err = tx.Select(&rawActors, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
 
+	return groupActors(rawActors), nil
+}
+
+// Iterate returns actors matching the filter in the order of GetAll, reading
+// them through a server-side cursor.
+func (r *ActorRepository) Iterate(filter *store.ActorFilter) (store.ActorIterator, error) {
+	query, args, err := actorsQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := openCursor(r.store.db, "actors_cursor", query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &actorIterator{cursor: c}, nil
+}
+
+// actorsQuery selects one row per actor and film, rows of the same actor are
+// adjacent.
+func actorsQuery(filter *store.ActorFilter) (string, []any, error) {
+	where, args := actorConditions(filter)
+	orderBy, err := actorOrder(filter.Sort)
+	if err != nil {
+		return "", nil, err
+	}
+	columns, err := projection(actorColumns, actorFields, filter.Fields)
+	if err != nil {
+		return "", nil, err
+	}
+	if filter.IncludeDeleted {
+		columns += ", " + deletionColumns("a")
+	}
+
+	if filter.OmitFilms {
+		return `SELECT
+			` + columns + `
+		FROM
+			actors a
+		` + where + `
+		ORDER BY ` + orderBy, args, nil
+	}
+
+	return `SELECT
+			` + columns + `,
+			f.id AS film_id,
+			f.title
+		FROM
+			actors a
+		LEFT JOIN
+			films_x_actors fxa ON fxa.actor_id = a.id
+		LEFT JOIN
+			films f ON f.id = fxa.film_id AND f.deleted_at IS NULL
+		` + where + `
+		ORDER BY ` + orderBy, args, nil
+}
+
+// actorFields are the fields that can be projected, actorColumns maps them to
+// their columns.
+var actorFields = []string{"id", "name", "gender", "birth_date"}
+
+var actorColumns = map[string]string{
+	"id":         "a.id",
+	"name":       "a.name",
+	"gender":     "a.gender",
+	"birth_date": "coalesce(to_char(a.birth_date, 'YYYY-MM-DD'), '') AS birth_date",
+}
+
+func actorConditions(filter *store.ActorFilter) (string, []any) {
+	var conditions []string
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "a.deleted_at IS NULL")
+	}
+	if len(filter.IDs) > 0 {
+		conditions = append(conditions, "a.id = ANY("+arg(pq.Array(filter.IDs))+")")
+	}
+	if filter.SearchName != "" {
+		conditions = append(conditions, "a.name ILIKE "+arg(likeContains(filter.SearchName)))
+	}
+	if filter.Gender != "" {
+		conditions = append(conditions, "lower(a.gender) = lower("+arg(filter.Gender)+")")
+	}
+	if filter.BornAfter != "" {
+		conditions = append(conditions, "a.birth_date >= "+arg(filter.BornAfter)+"::date")
+	}
+	if filter.BornBefore != "" {
+		conditions = append(conditions, "a.birth_date <= "+arg(filter.BornBefore)+"::date")
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func actorOrder(sort []store.SortField) (string, error) {
+	if len(sort) == 0 {
+		sort = []store.SortField{{Field: "name"}}
+	}
+
+	terms := make([]string, 0, len(sort)+1)
+	for _, field := range sort {
+		column, ok := actorSortColumns[field.Field]
+		if !ok {
+			return "", errors.Errorf("unknown sort field %q", field.Field)
+		}
+		if field.Desc {
+			terms = append(terms, column+" DESC")
+		} else {
+			terms = append(terms, column+" ASC")
+		}
+	}
+
+	return strings.Join(append(terms, "a.id ASC"), ", "), nil
+}
+
+// FuzzySearch
+func (r *ActorRepository) FuzzySearch(searchName string, threshold float64) (actors []models.Actor, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.fuzzySearch(tx, searchName, threshold)
+}
+
+func (r *ActorRepository) fuzzySearch(tx *sqlx.Tx, searchName string, threshold float64) ([]models.Actor, error) {
+	if err := setSimilarityThreshold(tx, threshold); err != nil {
+		return nil, err
+	}
+
+	var rawActors = make([]entities.ActorWithFilm, 0)
 	err := tx.Select(
 		&rawActors,
 		`SELECT
 			a.id,
 			a.name,
 			a.gender,
-			a.birth_date,
-			fxa.film_id,
-			f.title
-		FROM 
-			actors a
+			coalesce(to_char(a.birth_date, 'YYYY-MM-DD'), '') AS birth_date,
+			f.id AS film_id,
+			f.title,
+			m.score
+		FROM
+			(SELECT id, word_similarity($1, name) AS score FROM actors WHERE $1 <% name AND deleted_at IS NULL) m
+		INNER JOIN
+			actors a ON a.id = m.id
 		LEFT JOIN
 			films_x_actors fxa ON fxa.actor_id = a.id
 		LEFT JOIN
-			films f ON f.id = fxa.film_id`,
+			films f ON f.id = fxa.film_id AND f.deleted_at IS NULL
+		ORDER BY m.score DESC, a.id ASC`,
+		searchName,
 	)
-
 	if err != nil {
 		return nil, errors.Wrap(err, "select")
 	}
 
-	actorsMap := make(map[int]models.Actor, 0)
+	return groupActors(rawActors), nil
+}
+
+// groupActors collapses joined actor rows, which must be ordered so that rows
+// of the same actor are adjacent.
+func groupActors(rawActors []entities.ActorWithFilm) []models.Actor {
+	actors := make([]models.Actor, 0)
+	curID := -1
 	for _, rawActor := range rawActors {
-		actor, ok := actorsMap[rawActor.ID]
-		if !ok {
-			actorsMap[rawActor.ID] = models.Actor{
-				ID: rawActor.ID,
-				Name: rawActor.Name,
-				Gender: rawActor.Gender,
+		if rawActor.ID != curID {
+			actors = append(actors, models.Actor{
+				ID:        rawActor.ID,
+				Name:      rawActor.Name,
+				Gender:    rawActor.Gender,
 				BirthDate: rawActor.BirthDate,
-			}
-			actor = actorsMap[rawActor.ID]
+				Score:     rawActor.Score,
+				DeletedAt: rawActor.DeletedAt,
+				DeletedBy: rawActor.DeletedBy,
+			})
+			curID = rawActor.ID
 		}
 		if rawActor.FilmId == nil {
 			continue
 		}
-		actor.Films = append(actor.Films, models.FilmBasic{
+		actors[len(actors)-1].Films = append(actors[len(actors)-1].Films, models.FilmBasic{
 			FilmID: *rawActor.FilmId,
-			Title: *rawActor.Title,
+			Title:  *rawActor.Title,
 		})
-		actorsMap[rawActor.ID] = actor
-	}
-
-	actors := make([]models.Actor, 0, len(actorsMap))
-	for _, actor := range actorsMap {
-		actors = append(actors, actor)
 	}
 
-	return actors, nil
+	return actors
 }
@@ -0,0 +1,626 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+// Rows are matched by external id, or by natural key when either side has no
+// external id: name and birth date for actors, title and release date for films.
+// Matched rows in the trash are restored, the import says they exist.
+const (
+	actorMatch = `(a.external_id = i.external_id OR ((a.external_id IS NULL OR i.external_id IS NULL) AND a.name = i.name AND a.birth_date IS NOT DISTINCT FROM i.birth_date))`
+	filmMatch  = `(f.external_id = i.external_id OR ((f.external_id IS NULL OR i.external_id IS NULL) AND f.title = i.title AND f.release_date = i.release_date))`
+)
+
+// CatalogRepository
+type CatalogRepository struct {
+	store *Store
+}
+
+// ImportActors
+func (r *CatalogRepository) ImportActors(actors []models.ActorRecord, author models.Author) (res *models.ImportResult, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.importActors(tx, actors, author)
+}
+
+func (r *CatalogRepository) importActors(tx *sqlx.Tx, actors []models.ActorRecord, author models.Author) (*models.ImportResult, error) {
+	_, err := tx.Exec(
+		`CREATE TEMP TABLE import_actors (
+			external_id varchar(100),
+			name varchar(100),
+			gender varchar(20),
+			birth_date date
+		) ON COMMIT DROP`,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "create temp table")
+	}
+
+	stmt, err := tx.Prepare(pq.CopyIn("import_actors", "external_id", "name", "gender", "birth_date"))
+	if err != nil {
+		return nil, errors.Wrap(err, "prepare copy")
+	}
+	for _, a := range dedupeActors(actors) {
+		if _, err := stmt.Exec(nullString(a.ExternalID), a.Name, a.Gender, nullString(a.BirthDate)); err != nil {
+			stmt.Close()
+			return nil, errors.Wrap(err, "copy")
+		}
+	}
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		return nil, errors.Wrap(err, "flush copy")
+	}
+	if err := stmt.Close(); err != nil {
+		return nil, errors.Wrap(err, "close copy")
+	}
+
+	// The matched actors are locked before their snapshots are taken, the
+	// audit compares them with their snapshots after the import.
+	before, trashed, err := importedActors(tx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE actors a SET
+			name = i.name,
+			gender = i.gender,
+			birth_date = i.birth_date,
+			external_id = coalesce(i.external_id, a.external_id),
+			deleted_at = NULL,
+			deleted_by = NULL
+		FROM
+			import_actors i
+		WHERE ` + actorMatch,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "update actors")
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO actors (external_id, name, gender, birth_date)
+		SELECT
+			i.external_id,
+			i.name,
+			i.gender,
+			i.birth_date
+		FROM
+			import_actors i
+		WHERE NOT EXISTS (SELECT 1 FROM actors a WHERE ` + actorMatch + `)`,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "insert actors")
+	}
+
+	after, _, err := importedActors(tx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return auditImport(tx, author, models.AuditEntityActor, before, after, trashed)
+}
+
+// ImportFilms
+func (r *CatalogRepository) ImportFilms(films []models.FilmRecord, author models.Author) (res *models.ImportResult, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.importFilms(tx, films, author)
+}
+
+func (r *CatalogRepository) importFilms(tx *sqlx.Tx, films []models.FilmRecord, author models.Author) (*models.ImportResult, error) {
+	_, err := tx.Exec(
+		`CREATE TEMP TABLE import_films (
+			external_id varchar(100),
+			title varchar(150),
+			description varchar(1000),
+			release_date date,
+			rating double precision,
+			cast_keys text[]
+		) ON COMMIT DROP`,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "create temp table")
+	}
+
+	stmt, err := tx.Prepare(pq.CopyIn("import_films", "external_id", "title", "description", "release_date", "rating", "cast_keys"))
+	if err != nil {
+		return nil, errors.Wrap(err, "prepare copy")
+	}
+	for _, f := range dedupeFilms(films) {
+		if _, err := stmt.Exec(nullString(f.ExternalID), f.Title, f.Description, f.ReleaseDate, f.Rating, pq.Array(f.Cast)); err != nil {
+			stmt.Close()
+			return nil, errors.Wrap(err, "copy")
+		}
+	}
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		return nil, errors.Wrap(err, "flush copy")
+	}
+	if err := stmt.Close(); err != nil {
+		return nil, errors.Wrap(err, "close copy")
+	}
+
+	// The matched films are locked before their snapshots are taken, the
+	// audit compares them with their snapshots after the import.
+	before, trashed, err := importedFilms(tx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(
+		`UPDATE films f SET
+			title = i.title,
+			description = i.description,
+			release_date = i.release_date,
+			rating = i.rating,
+			external_id = coalesce(i.external_id, f.external_id),
+			deleted_at = NULL,
+			deleted_by = NULL
+		FROM
+			import_films i
+		WHERE ` + filmMatch,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "update films")
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO films (external_id, title, description, release_date, rating)
+		SELECT
+			i.external_id,
+			i.title,
+			i.description,
+			i.release_date,
+			i.rating
+		FROM
+			import_films i
+		WHERE NOT EXISTS (SELECT 1 FROM films f WHERE ` + filmMatch + `)`,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "insert films")
+	}
+
+	// Imported rows describe the whole cast, so existing links are replaced.
+	// Links to deleted actors are kept for their restore, like Modify does.
+	_, err = tx.Exec(
+		`DELETE FROM films_x_actors WHERE film_id IN (
+			SELECT f.id FROM films f INNER JOIN import_films i ON ` + filmMatch + `
+		) AND actor_id IN (SELECT id FROM actors WHERE deleted_at IS NULL)`,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "delete from films_x_actors")
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO films_x_actors (film_id, actor_id)
+		SELECT DISTINCT film_id, actor_id FROM (
+			SELECT
+				f.id AS film_id,
+				coalesce(
+					(SELECT a.id FROM actors a WHERE a.external_id = c.cast_key AND a.deleted_at IS NULL),
+					(SELECT min(a.id) FROM actors a WHERE a.name = c.cast_key AND a.deleted_at IS NULL)
+				) AS actor_id
+			FROM
+				import_films i
+			INNER JOIN
+				films f ON ` + filmMatch + `
+			CROSS JOIN LATERAL
+				unnest(i.cast_keys) AS c(cast_key)
+		) links
+		WHERE actor_id IS NOT NULL`,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "insert into films_x_actors")
+	}
+
+	after, _, err := importedFilms(tx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return auditImport(tx, author, models.AuditEntityFilm, before, after, trashed)
+}
+
+// importedActors returns the snapshots of the actors that match the import
+// by id and which of them are in the trash. lock locks them for the rest of
+// the transaction.
+func importedActors(tx *sqlx.Tx, lock bool) (map[int]store.Snapshot, map[int]bool, error) {
+	query := `SELECT
+			a.id,
+			a.deleted_at IS NOT NULL AS deleted,
+			a.name,
+			coalesce(a.gender, '') AS gender,
+			coalesce(to_char(a.birth_date, 'YYYY-MM-DD'), '') AS birth_date
+		FROM
+			actors a
+		WHERE EXISTS (SELECT 1 FROM import_actors i WHERE ` + actorMatch + `)`
+	if lock {
+		query += " FOR UPDATE"
+	}
+
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "select actors")
+	}
+	defer rows.Close()
+
+	snapshots := make(map[int]store.Snapshot)
+	trashed := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		var deleted bool
+		var a models.Actor
+		if err := rows.Scan(&id, &deleted, &a.Name, &a.Gender, &a.BirthDate); err != nil {
+			return nil, nil, errors.Wrap(err, "scan actor")
+		}
+		snapshots[id] = store.ActorSnapshot(&a)
+		trashed[id] = deleted
+	}
+
+	return snapshots, trashed, errors.Wrap(rows.Err(), "select actors")
+}
+
+// importedFilms is importedActors for films.
+func importedFilms(tx *sqlx.Tx, lock bool) (map[int]store.Snapshot, map[int]bool, error) {
+	query := `SELECT
+			f.id,
+			f.deleted_at IS NOT NULL AS deleted,
+			f.title,
+			coalesce(f.description, '') AS description,
+			coalesce(to_char(f.release_date, 'YYYY-MM-DD'), '') AS release_date,
+			coalesce(f.rating, 0) AS rating,
+			ARRAY(
+				SELECT fxa.actor_id
+				FROM films_x_actors fxa
+				INNER JOIN actors a ON a.id = fxa.actor_id AND a.deleted_at IS NULL
+				WHERE fxa.film_id = f.id
+			) AS actors_ids
+		FROM
+			films f
+		WHERE EXISTS (SELECT 1 FROM import_films i WHERE ` + filmMatch + `)`
+	if lock {
+		query += " FOR UPDATE OF f"
+	}
+
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "select films")
+	}
+	defer rows.Close()
+
+	snapshots := make(map[int]store.Snapshot)
+	trashed := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		var deleted bool
+		var f models.FilmRequest
+		var actorIDs pq.Int64Array
+		if err := rows.Scan(&id, &deleted, &f.Title, &f.Description, &f.ReleaseDate, &f.Rating, &actorIDs); err != nil {
+			return nil, nil, errors.Wrap(err, "scan film")
+		}
+		for _, actorID := range actorIDs {
+			f.ActorsIDs = append(f.ActorsIDs, int(actorID))
+		}
+		snapshots[id] = store.FilmRequestSnapshot(&f)
+		trashed[id] = deleted
+	}
+
+	return snapshots, trashed, errors.Wrap(rows.Err(), "select films")
+}
+
+// auditImport records how every imported row changed and tells the outbox.
+// Rows not matched before the import are created, those it took out of the
+// trash restored, and rows it left as they were are neither recorded nor
+// counted. Films whose content changed also get a revision.
+func auditImport(tx *sqlx.Tx, author models.Author, entity string, before, after map[int]store.Snapshot, trashed map[int]bool) (*models.ImportResult, error) {
+	ids := make([]int, 0, len(after))
+	for id := range after {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	res := &models.ImportResult{}
+	for _, id := range ids {
+		previous, matched := before[id]
+		action := models.AuditActionModify
+		switch {
+		case !matched:
+			action = models.AuditActionCreate
+		case trashed[id]:
+			action = models.AuditActionRestore
+		}
+		change, err := store.Diff(previous, after[id])
+		if err != nil {
+			return nil, errors.Wrap(err, "diff")
+		}
+		if change == nil && action == models.AuditActionModify {
+			continue
+		}
+
+		// A restore is recorded with the whole row, like Restore does.
+		diff := change
+		if action == models.AuditActionRestore {
+			if diff, err = store.Diff(nil, after[id]); err != nil {
+				return nil, errors.Wrap(err, "diff")
+			}
+		}
+		if err := insertAuditEvent(tx, author, entity, strconv.Itoa(id), action, diff); err != nil {
+			return nil, err
+		}
+		if err := insertChangeEvent(tx, entity, id, action); err != nil {
+			return nil, err
+		}
+		if action == models.AuditActionCreate {
+			res.Created++
+		} else {
+			res.Updated++
+		}
+
+		if entity != models.AuditEntityFilm || change == nil {
+			continue
+		}
+		if err := insertFilmRevision(tx, author, id, after[id]); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// UnknownCast
+func (r *CatalogRepository) UnknownCast(keys []string) (unknown []string, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.unknownCast(tx, keys)
+}
+
+func (r *CatalogRepository) unknownCast(tx *sqlx.Tx, keys []string) ([]string, error) {
+	if len(keys) == 0 {
+		return []string{}, nil
+	}
+
+	unknown := make([]string, 0)
+	err := tx.Select(
+		&unknown,
+		`SELECT DISTINCT k.cast_key
+		FROM
+			unnest($1::text[]) AS k(cast_key)
+		WHERE NOT EXISTS (
+			SELECT 1 FROM actors a WHERE (a.external_id = k.cast_key OR a.name = k.cast_key) AND a.deleted_at IS NULL
+		)
+		ORDER BY k.cast_key`,
+		pq.Array(keys),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "select cast keys")
+	}
+
+	return unknown, nil
+}
+
+// Export reads actors and then films from a single repeatable-read snapshot,
+// passing rows to the callbacks as they arrive. A nil callback skips its
+// entity. Cast keys are actor external ids, or names for actors without one.
+func (r *CatalogRepository) Export(actor func(*models.ActorRecord) error, film func(*models.FilmRecord) error) (err error) {
+	tx, err := r.store.db.BeginTxx(context.Background(), &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	if actor != nil {
+		if err := exportActors(tx, actor); err != nil {
+			return err
+		}
+	}
+	if film != nil {
+		if err := exportFilms(tx, film); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func exportActors(tx *sqlx.Tx, fn func(*models.ActorRecord) error) error {
+	rows, err := tx.Query(
+		`SELECT
+			coalesce(external_id, ''),
+			name,
+			coalesce(gender, ''),
+			coalesce(to_char(birth_date, 'YYYY-MM-DD'), '')
+		FROM
+			actors
+		WHERE deleted_at IS NULL
+		ORDER BY id`,
+	)
+	if err != nil {
+		return errors.Wrap(err, "select actors")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.ActorRecord
+		if err := rows.Scan(&a.ExternalID, &a.Name, &a.Gender, &a.BirthDate); err != nil {
+			return errors.Wrap(err, "scan actor")
+		}
+		if err := fn(&a); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(rows.Err(), "select actors")
+}
+
+func exportFilms(tx *sqlx.Tx, fn func(*models.FilmRecord) error) error {
+	rows, err := tx.Query(
+		`SELECT
+			coalesce(f.external_id, ''),
+			f.title,
+			coalesce(f.description, ''),
+			coalesce(to_char(f.release_date, 'YYYY-MM-DD'), ''),
+			coalesce(f.rating, 0),
+			ARRAY(
+				SELECT coalesce(a.external_id, a.name)
+				FROM films_x_actors fxa
+				INNER JOIN actors a ON a.id = fxa.actor_id AND a.deleted_at IS NULL
+				WHERE fxa.film_id = f.id
+				ORDER BY a.id
+			)
+		FROM
+			films f
+		WHERE f.deleted_at IS NULL
+		ORDER BY f.id`,
+	)
+	if err != nil {
+		return errors.Wrap(err, "select films")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var f models.FilmRecord
+		var cast pq.StringArray
+		if err := rows.Scan(&f.ExternalID, &f.Title, &f.Description, &f.ReleaseDate, &f.Rating, &cast); err != nil {
+			return errors.Wrap(err, "scan film")
+		}
+		f.Cast = cast
+		if err := fn(&f); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(rows.Err(), "select films")
+}
+
+// dedupeActors keeps the last row for every key, so that a chunk never updates
+// the same actor twice.
+func dedupeActors(actors []models.ActorRecord) []models.ActorRecord {
+	index := make(map[string]int, len(actors))
+	unique := make([]models.ActorRecord, 0, len(actors))
+	for _, a := range actors {
+		key := "n:" + a.Name + "\x00" + a.BirthDate
+		if a.ExternalID != "" {
+			key = "x:" + a.ExternalID
+		}
+		if i, ok := index[key]; ok {
+			unique[i] = a
+			continue
+		}
+		index[key] = len(unique)
+		unique = append(unique, a)
+	}
+	return unique
+}
+
+// dedupeFilms keeps the last row for every key.
+func dedupeFilms(films []models.FilmRecord) []models.FilmRecord {
+	index := make(map[string]int, len(films))
+	unique := make([]models.FilmRecord, 0, len(films))
+	for _, f := range films {
+		key := "n:" + f.Title + "\x00" + f.ReleaseDate
+		if f.ExternalID != "" {
+			key = "x:" + f.ExternalID
+		}
+		if i, ok := index[key]; ok {
+			unique[i] = f
+			continue
+		}
+		index[key] = len(unique)
+		unique = append(unique, f)
+	}
+	return unique
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func execCount(tx *sqlx.Tx, query string, args ...any) (int, error) {
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "rows affected")
+	}
+	return int(rowsAffected), nil
+}
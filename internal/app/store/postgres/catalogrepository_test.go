@@ -0,0 +1,125 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalogRepository_ImportActors(t *testing.T) {
+	db, teardown := postgres.TestDB(t, databaseURL)
+	defer teardown("films_x_actors, actors, films")
+
+	s := postgres.New(db)
+
+	existingID, _ := s.Actor().Create(&models.ActorRequest{
+		Name:      "Keanu Reeves",
+		Gender:    "male",
+		BirthDate: "1964-09-02",
+	}, models.Author{Login: "admin"})
+
+	res, err := s.Catalog().ImportActors([]models.ActorRecord{
+		{ExternalID: "nm1", Name: "Keanu Reeves", Gender: "Male", BirthDate: "1964-09-02"},
+		{ExternalID: "nm2", Name: "Carrie-Anne Moss", Gender: "female", BirthDate: "1967-08-21"},
+		{ExternalID: "nm2", Name: "Carrie-Anne Moss", Gender: "female", BirthDate: "1967-08-21"},
+	}, models.Author{Login: "admin"})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ImportResult{Created: 1, Updated: 1}, res)
+
+	actor, err := s.Actor().Find(existingID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Male", actor.Gender)
+
+	res, err = s.Catalog().ImportActors([]models.ActorRecord{
+		{ExternalID: "nm2", Name: "Carrie-Anne Moss", Gender: "female", BirthDate: "1967-08-21"},
+	}, models.Author{Login: "admin"})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ImportResult{Created: 0, Updated: 0}, res)
+}
+
+func TestCatalogRepository_ImportFilms(t *testing.T) {
+	db, teardown := postgres.TestDB(t, databaseURL)
+	defer teardown("films_x_actors, actors, films")
+
+	s := postgres.New(db)
+
+	_, err := s.Catalog().ImportActors([]models.ActorRecord{
+		{ExternalID: "nm1", Name: "Keanu Reeves", Gender: "male", BirthDate: "1964-09-02"},
+	}, models.Author{Login: "admin"})
+	assert.NoError(t, err)
+	actorID, _ := s.Actor().Create(&models.ActorRequest{
+		Name:      "Laurence Fishburne",
+		Gender:    "male",
+		BirthDate: "1961-07-30",
+	}, models.Author{Login: "admin"})
+
+	res, err := s.Catalog().ImportFilms([]models.FilmRecord{
+		{
+			ExternalID:  "tt1",
+			Title:       "The Matrix",
+			Description: "Hacker learns the truth",
+			ReleaseDate: "1999-03-31",
+			Rating:      8.7,
+			Cast:        []string{"nm1", "Laurence Fishburne", "Nobody"},
+		},
+	}, models.Author{Login: "admin"})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ImportResult{Created: 1, Updated: 0}, res)
+
+	res, err = s.Catalog().ImportFilms([]models.FilmRecord{
+		{
+			ExternalID:  "tt1",
+			Title:       "The Matrix",
+			ReleaseDate: "1999-03-31",
+			Rating:      8.8,
+			Cast:        []string{"Laurence Fishburne"},
+		},
+	}, models.Author{Login: "admin"})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ImportResult{Created: 0, Updated: 1}, res)
+
+	films, err := s.Film().GetAll(&store.FilmFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(films))
+	assert.Equal(t, 8.8, films[0].Rating)
+	assert.Equal(t, []models.ActorBasic{{ActorID: actorID, Name: "Laurence Fishburne"}}, films[0].Actors)
+}
+
+func TestCatalogRepository_Export(t *testing.T) {
+	db, teardown := postgres.TestDB(t, databaseURL)
+	defer teardown("films_x_actors, actors, films")
+
+	s := postgres.New(db)
+
+	s.Catalog().ImportActors([]models.ActorRecord{
+		{ExternalID: "nm1", Name: "Keanu Reeves", Gender: "male", BirthDate: "1964-09-02"},
+		{Name: "Laurence Fishburne", Gender: "male", BirthDate: "1961-07-30"},
+	}, models.Author{Login: "admin"})
+	s.Catalog().ImportFilms([]models.FilmRecord{
+		{ExternalID: "tt1", Title: "The Matrix", ReleaseDate: "1999-03-31", Rating: 8.7, Cast: []string{"nm1", "Laurence Fishburne"}},
+	}, models.Author{Login: "admin"})
+
+	var actors []models.ActorRecord
+	var films []models.FilmRecord
+	err := s.Catalog().Export(
+		func(a *models.ActorRecord) error {
+			actors = append(actors, *a)
+			return nil
+		},
+		func(f *models.FilmRecord) error {
+			films = append(films, *f)
+			return nil
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ActorRecord{
+		{ExternalID: "nm1", Name: "Keanu Reeves", Gender: "male", BirthDate: "1964-09-02"},
+		{Name: "Laurence Fishburne", Gender: "male", BirthDate: "1961-07-30"},
+	}, actors)
+	assert.Equal(t, []models.FilmRecord{
+		{ExternalID: "tt1", Title: "The Matrix", ReleaseDate: "1999-03-31", Rating: 8.7, Cast: []string{"nm1", "Laurence Fishburne"}},
+	}, films)
+}
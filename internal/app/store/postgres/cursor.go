@@ -0,0 +1,198 @@
+package postgres
+
+import (
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/entities"
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+)
+
+// cursorBatchSize is the number of rows fetched from a cursor at once.
+const cursorBatchSize = 500
+
+// cursor is a server-side cursor living in its own read-only transaction.
+type cursor struct {
+	tx   *sqlx.Tx
+	name string
+	done bool
+}
+
+func openCursor(db *sqlx.DB, name, query string, args []any) (*cursor, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	_, err = tx.Exec("SET TRANSACTION READ ONLY")
+	if err == nil {
+		_, err = tx.Exec("DECLARE "+name+" NO SCROLL CURSOR FOR "+query, args...)
+	}
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.Wrap(err, "declare cursor")
+	}
+
+	return &cursor{tx: tx, name: name}, nil
+}
+
+// fetch fills dest, a pointer to a slice, with the next batch. It leaves the
+// slice empty once the cursor is exhausted.
+func (c *cursor) fetch(dest any) error {
+	if c.done {
+		return nil
+	}
+
+	if err := c.tx.Select(dest, "FETCH "+strconv.Itoa(cursorBatchSize)+" FROM "+c.name); err != nil {
+		return errors.Wrap(err, "fetch")
+	}
+
+	return nil
+}
+
+func (c *cursor) close() error {
+	return c.tx.Rollback()
+}
+
+type filmIterator struct {
+	*cursor
+	rows    []entities.FilmWithActor
+	pos     int
+	pending *models.Film
+	film    *models.Film
+	err     error
+}
+
+// Next
+func (it *filmIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	for {
+		if it.pos == len(it.rows) {
+			if !it.done {
+				it.rows, it.pos = it.rows[:0], 0
+				if it.err = it.fetch(&it.rows); it.err != nil {
+					return false
+				}
+				it.cursor.done = len(it.rows) < cursorBatchSize
+				continue
+			}
+
+			it.film, it.pending = it.pending, nil
+			return it.film != nil
+		}
+
+		raw := it.rows[it.pos]
+		if it.pending != nil && it.pending.ID != raw.ID {
+			it.film, it.pending = it.pending, nil
+			return true
+		}
+		if it.pending == nil {
+			it.pending = &models.Film{
+				ID:          raw.ID,
+				Title:       raw.Title,
+				Description: raw.Description,
+				ReleaseDate: raw.ReleaseDate,
+				Rating:      raw.Rating,
+				DeletedAt:   raw.DeletedAt,
+				DeletedBy:   raw.DeletedBy,
+			}
+		}
+		if raw.ActorID != nil {
+			it.pending.Actors = append(it.pending.Actors, models.ActorBasic{
+				ActorID: *raw.ActorID,
+				Name:    *raw.Name,
+			})
+		}
+		it.pos++
+	}
+}
+
+// Film
+func (it *filmIterator) Film() *models.Film {
+	return it.film
+}
+
+// Err
+func (it *filmIterator) Err() error {
+	return it.err
+}
+
+// Close
+func (it *filmIterator) Close() error {
+	return it.close()
+}
+
+type actorIterator struct {
+	*cursor
+	rows    []entities.ActorWithFilm
+	pos     int
+	pending *models.Actor
+	actor   *models.Actor
+	err     error
+}
+
+// Next
+func (it *actorIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	for {
+		if it.pos == len(it.rows) {
+			if !it.done {
+				it.rows, it.pos = it.rows[:0], 0
+				if it.err = it.fetch(&it.rows); it.err != nil {
+					return false
+				}
+				it.cursor.done = len(it.rows) < cursorBatchSize
+				continue
+			}
+
+			it.actor, it.pending = it.pending, nil
+			return it.actor != nil
+		}
+
+		raw := it.rows[it.pos]
+		if it.pending != nil && it.pending.ID != raw.ID {
+			it.actor, it.pending = it.pending, nil
+			return true
+		}
+		if it.pending == nil {
+			it.pending = &models.Actor{
+				ID:        raw.ID,
+				Name:      raw.Name,
+				Gender:    raw.Gender,
+				BirthDate: raw.BirthDate,
+				DeletedAt: raw.DeletedAt,
+				DeletedBy: raw.DeletedBy,
+			}
+		}
+		if raw.FilmId != nil {
+			it.pending.Films = append(it.pending.Films, models.FilmBasic{
+				FilmID: *raw.FilmId,
+				Title:  *raw.Title,
+			})
+		}
+		it.pos++
+	}
+}
+
+// Actor
+func (it *actorIterator) Actor() *models.Actor {
+	return it.actor
+}
+
+// Err
+func (it *actorIterator) Err() error {
+	return it.err
+}
+
+// Close
+func (it *actorIterator) Close() error {
+	return it.close()
+}
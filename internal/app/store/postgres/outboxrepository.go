@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+)
+
+// OutboxRepository
+type OutboxRepository struct {
+	store *Store
+}
+
+// List
+func (r *OutboxRepository) List(after int, limit int) (events []models.ChangeEvent, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.list(tx, after, limit)
+}
+
+func (r *OutboxRepository) list(tx *sqlx.Tx, after int, limit int) ([]models.ChangeEvent, error) {
+	query := "SELECT seq, " + timestamp("at") + " AS at, entity, entity_id, action FROM change_events WHERE seq > $1 ORDER BY seq"
+	args := []any{after}
+	if limit > 0 {
+		query += " LIMIT $2"
+		args = append(args, limit)
+	}
+
+	events := make([]models.ChangeEvent, 0)
+	err := tx.Select(&events, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+
+	return events, nil
+}
+
+// Purge
+func (r *OutboxRepository) Purge(before time.Time) (purged int, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return 0, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	// Events not yet given to every webhook are kept, whatever their age.
+	purged, err = execCount(
+		tx,
+		"DELETE FROM change_events WHERE at < $1 AND seq <= coalesce((SELECT min(last_seq) FROM webhooks), seq)",
+		before,
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "delete from change_events")
+	}
+
+	return purged, nil
+}
+
+// lockOutbox keeps other writers of change events waiting until tx ends.
+// Sequence values are taken in the order of inserts, without the lock a
+// transaction could commit a smaller seq after a consumer has read a greater
+// one and the consumer would never see it.
+func lockOutbox(tx *sqlx.Tx) error {
+	if _, err := tx.Exec("LOCK TABLE change_events IN EXCLUSIVE MODE"); err != nil {
+		return errors.Wrap(err, "lock change_events")
+	}
+
+	return nil
+}
+
+// insertChangeEvent appends the change of a film or an actor to the outbox.
+func insertChangeEvent(tx *sqlx.Tx, entity string, id int, action string) error {
+	if err := lockOutbox(tx); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(
+		"INSERT INTO change_events (entity, entity_id, action) VALUES ($1, $2, $3)",
+		entity,
+		id,
+		action,
+	)
+	if err != nil {
+		return errors.Wrap(err, "insert into change_events")
+	}
+
+	return nil
+}
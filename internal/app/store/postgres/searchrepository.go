@@ -0,0 +1,161 @@
+package postgres
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+)
+
+const headlineOptions = "StartSel=<b>, StopSel=</b>, MaxWords=35, MinWords=15, MaxFragments=2"
+
+// SearchRepository
+type SearchRepository struct {
+	store *Store
+}
+
+// FullText
+func (r *SearchRepository) FullText(query string, limit int) (results []models.SearchResult, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.fullText(tx, query, limit)
+}
+
+func (r *SearchRepository) fullText(tx *sqlx.Tx, query string, limit int) ([]models.SearchResult, error) {
+	results := make([]models.SearchResult, 0)
+
+	// Films are indexed with both english and russian stemming, so the query is
+	// parsed with both configurations. Actor names are not stemmed.
+	err := tx.Select(
+		&results,
+		`WITH q AS (
+			SELECT
+				websearch_to_tsquery('english', $1) || websearch_to_tsquery('russian', $1) AS films_query,
+				websearch_to_tsquery('simple', $1) AS actors_query
+		)
+		SELECT type, id, label, snippet, rank FROM (
+			SELECT
+				'film' AS type,
+				f.id,
+				f.title AS label,
+				ts_headline($2::regconfig, f.title || '. ' || coalesce(f.description, ''), q.films_query, $3) AS snippet,
+				ts_rank(f.search_vector, q.films_query) AS rank
+			FROM
+				films f, q
+			WHERE f.search_vector @@ q.films_query AND f.deleted_at IS NULL
+			UNION ALL
+			SELECT
+				'actor' AS type,
+				a.id,
+				a.name AS label,
+				ts_headline('simple', a.name, q.actors_query, $3) AS snippet,
+				ts_rank(a.search_vector, q.actors_query) AS rank
+			FROM
+				actors a, q
+			WHERE a.search_vector @@ q.actors_query AND a.deleted_at IS NULL
+		) found
+		ORDER BY rank DESC, type, id
+		LIMIT $4`,
+		query,
+		headlineConfig(query),
+		headlineOptions,
+		limit,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+
+	return results, nil
+}
+
+// headlineConfig picks the text search configuration used to highlight matches,
+// so that stemmed query terms are found in the snippet.
+func headlineConfig(query string) string {
+	for _, r := range query {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return "russian"
+		}
+	}
+	return "english"
+}
+
+// Suggest
+func (r *SearchRepository) Suggest(prefix string, kind string, limit int) (suggestions []models.Suggestion, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.suggest(tx, prefix, kind, limit)
+}
+
+func (r *SearchRepository) suggest(tx *sqlx.Tx, prefix string, kind string, limit int) ([]models.Suggestion, error) {
+	// Each branch is limited separately so that the lower(...) text_pattern_ops
+	// indexes serve the prefix match and the sort.
+	films := `(SELECT id, title AS label, 'film' AS type FROM films WHERE lower(title) LIKE $1 AND deleted_at IS NULL ORDER BY lower(title), id LIMIT $2)`
+	actors := `(SELECT id, name AS label, 'actor' AS type FROM actors WHERE lower(name) LIKE $1 AND deleted_at IS NULL ORDER BY lower(name), id LIMIT $2)`
+
+	var branches string
+	switch kind {
+	case models.SearchTypeFilm:
+		branches = films
+	case models.SearchTypeActor:
+		branches = actors
+	default:
+		branches = films + " UNION ALL " + actors
+	}
+
+	suggestions := make([]models.Suggestion, 0)
+	err := tx.Select(
+		&suggestions,
+		"SELECT id, label, type FROM ("+branches+") found ORDER BY lower(label), type, id LIMIT $2",
+		likePrefix(prefix),
+		limit,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+
+	return suggestions, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likePrefix builds a LIKE pattern matching strings that start with prefix.
+func likePrefix(prefix string) string {
+	return likeEscaper.Replace(strings.ToLower(prefix)) + "%"
+}
@@ -1,18 +1,27 @@
 package postgres
 
 import (
+	"strings"
+
 	"github.com/Rbd3178/filmDatabase/internal/app/store"
 	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
 
 	_ "github.com/lib/pq"
 )
 
 // Store
 type Store struct {
-	db             *sqlx.DB
-	userRepository *UserRepository
-	filmRepository *FilmRepository
-	actorRepository *ActorRepository
+	db                *sqlx.DB
+	userRepository    *UserRepository
+	filmRepository    *FilmRepository
+	actorRepository   *ActorRepository
+	searchRepository  *SearchRepository
+	catalogRepository *CatalogRepository
+	trashRepository   *TrashRepository
+	auditRepository   *AuditRepository
+	outboxRepository  *OutboxRepository
+	webhookRepository *WebhookRepository
 }
 
 // New
@@ -59,4 +68,117 @@ func (s *Store) Actor() store.ActorRepository {
 	}
 
 	return s.actorRepository
-}
\ No newline at end of file
+}
+
+// Search
+func (s *Store) Search() store.SearchRepository {
+	if s.searchRepository != nil {
+		return s.searchRepository
+	}
+
+	s.searchRepository = &SearchRepository{
+		store: s,
+	}
+
+	return s.searchRepository
+}
+
+// Catalog
+func (s *Store) Catalog() store.CatalogRepository {
+	if s.catalogRepository != nil {
+		return s.catalogRepository
+	}
+
+	s.catalogRepository = &CatalogRepository{
+		store: s,
+	}
+
+	return s.catalogRepository
+}
+
+// Trash
+func (s *Store) Trash() store.TrashRepository {
+	if s.trashRepository != nil {
+		return s.trashRepository
+	}
+
+	s.trashRepository = &TrashRepository{
+		store: s,
+	}
+
+	return s.trashRepository
+}
+
+// Audit
+func (s *Store) Audit() store.AuditRepository {
+	if s.auditRepository != nil {
+		return s.auditRepository
+	}
+
+	s.auditRepository = &AuditRepository{
+		store: s,
+	}
+
+	return s.auditRepository
+}
+
+// Outbox
+func (s *Store) Outbox() store.OutboxRepository {
+	if s.outboxRepository != nil {
+		return s.outboxRepository
+	}
+
+	s.outboxRepository = &OutboxRepository{
+		store: s,
+	}
+
+	return s.outboxRepository
+}
+
+// Webhook
+func (s *Store) Webhook() store.WebhookRepository {
+	if s.webhookRepository != nil {
+		return s.webhookRepository
+	}
+
+	s.webhookRepository = &WebhookRepository{
+		store: s,
+	}
+
+	return s.webhookRepository
+}
+
+// projection lists the columns for the requested fields, all of them when
+// fields is empty. The id always comes first since rows are grouped by it.
+func projection(columns map[string]string, order []string, fields []string) (string, error) {
+	if len(fields) == 0 {
+		fields = order
+	}
+
+	selected := []string{columns["id"]}
+	seen := map[string]bool{"id": true}
+	for _, field := range fields {
+		column, ok := columns[field]
+		if !ok {
+			return "", errors.Errorf("unknown field %q", field)
+		}
+		if seen[field] {
+			continue
+		}
+		seen[field] = true
+		selected = append(selected, column)
+	}
+
+	return strings.Join(selected, ", "), nil
+}
+
+// deletionColumns selects when and by whom the rows of table alias t were
+// deleted, empty for rows that are not in the trash.
+func deletionColumns(t string) string {
+	return "coalesce(" + timestamp(t+".deleted_at") + ", '') AS deleted_at, coalesce(" + t + ".deleted_by, '') AS deleted_by"
+}
+
+// timestamp formats a timestamptz column like models.TimestampLayout.
+func timestamp(column string) string {
+	return "to_char(" + column + ` AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"')`
+}
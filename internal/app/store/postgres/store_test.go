@@ -3,6 +3,10 @@ package postgres_test
 import (
 	"os"
 	"testing"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/postgres"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/storetest"
 )
 
 var (
@@ -17,3 +21,12 @@ func TestMain(m *testing.M) {
 
 	os.Exit(m.Run())
 }
+
+func TestStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) (store.Store, func()) {
+		db, teardown := postgres.TestDB(t, databaseURL)
+		return postgres.New(db), func() {
+			teardown("films_x_actors, film_revisions, actors, films, users, audit_events, change_events, webhook_deliveries, webhooks")
+		}
+	})
+}
@@ -3,6 +3,7 @@ package postgres_test
 import (
 	"testing"
 
+	"github.com/Rbd3178/filmDatabase/internal/app/hasher"
 	"github.com/Rbd3178/filmDatabase/internal/app/models"
 	"github.com/Rbd3178/filmDatabase/internal/app/store"
 	"github.com/Rbd3178/filmDatabase/internal/app/store/postgres"
@@ -20,11 +21,11 @@ func TestUserRepository_Create(t *testing.T) {
 		Password: "verysecret",
 	}
 
-	done, err := s.User().Create(userReq)
+	done, err := s.User().Create(userReq, models.Author{})
 	assert.NoError(t, err)
 	assert.True(t, done)
 
-	done, err = s.User().Create(userReq)
+	done, err = s.User().Create(userReq, models.Author{})
 	assert.NoError(t, err)
 	assert.False(t, done)
 }
@@ -43,7 +44,7 @@ func TestUserRepository_Find(t *testing.T) {
 	_, err := s.User().Find(userReq.Login)
 	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
 
-	s.User().Create(userReq)
+	s.User().Create(userReq, models.Author{})
 
 	u, err := s.User().Find(userReq.Login)
 	assert.NoError(t, err)
@@ -64,8 +65,8 @@ func TestUserRepository_GetAll(t *testing.T) {
 		Password: "notsosecret",
 	}
 
-	s.User().Create(userReq1)
-	s.User().Create(userReq2)
+	s.User().Create(userReq1, models.Author{})
+	s.User().Create(userReq2, models.Author{})
 
 	user1, _ := s.User().Find(userReq1.Login)
 	user2, _ := s.User().Find(userReq2.Login)
@@ -74,3 +75,41 @@ func TestUserRepository_GetAll(t *testing.T) {
 	assert.Contains(t, users, *user1)
 	assert.Contains(t, users, *user2)
 }
+
+func TestUserRepository_SetAdmin(t *testing.T) {
+	db, teardown := postgres.TestDB(t, databaseURL)
+	defer teardown("users")
+
+	s := postgres.New(db)
+
+	s.User().Create(&models.UserRequest{Login: "JohnDoe", Password: "verysecret"}, models.Author{})
+
+	done, err := s.User().SetAdmin("JohnDoe", true, models.Author{Login: "admin"})
+	assert.NoError(t, err)
+	assert.True(t, done)
+	u, _ := s.User().Find("JohnDoe")
+	assert.True(t, u.IsAdmin)
+
+	done, err = s.User().SetAdmin("Nobody", true, models.Author{Login: "admin"})
+	assert.NoError(t, err)
+	assert.False(t, done)
+}
+
+func TestUserRepository_SetPassword(t *testing.T) {
+	db, teardown := postgres.TestDB(t, databaseURL)
+	defer teardown("users")
+
+	s := postgres.New(db)
+
+	s.User().Create(&models.UserRequest{Login: "JohnDoe", Password: "verysecret"}, models.Author{})
+
+	done, err := s.User().SetPassword("JohnDoe", "evenmoresecret", models.Author{Login: "admin"})
+	assert.NoError(t, err)
+	assert.True(t, done)
+	u, _ := s.User().Find("JohnDoe")
+	assert.True(t, hasher.CheckPasswordHash("evenmoresecret", u.HashedPassword))
+
+	done, err = s.User().SetPassword("Nobody", "evenmoresecret", models.Author{Login: "admin"})
+	assert.NoError(t, err)
+	assert.False(t, done)
+}
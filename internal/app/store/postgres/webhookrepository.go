@@ -0,0 +1,481 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/entities"
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+// WebhookRepository
+type WebhookRepository struct {
+	store *Store
+}
+
+var webhookColumns = "id, url, events, secret, last_seq, " + timestamp("created_at") + " AS created_at"
+
+var deliveryColumns = `d.id, d.webhook_id, w.url, d.event, d.payload, d.status, d.attempts, d.response_code, d.error,
+	` + timestamp("d.created_at") + ` AS created_at,
+	coalesce(` + timestamp("d.next_attempt_at") + `, '') AS next_attempt_at,
+	coalesce(` + timestamp("d.delivered_at") + `, '') AS delivered_at`
+
+// Create
+func (r *WebhookRepository) Create(req *models.WebhookRequest) (id int, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return 0, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.create(tx, req)
+}
+
+func (r *WebhookRepository) create(tx *sqlx.Tx, req *models.WebhookRequest) (int, error) {
+	events, err := json.Marshal(req.Events)
+	if err != nil {
+		return 0, errors.Wrap(err, "encode events")
+	}
+
+	// Waiting for the writers of change events makes sure that none of them
+	// commits an event at or below last_seq afterwards.
+	if err := lockOutbox(tx); err != nil {
+		return 0, err
+	}
+
+	var id int
+	err = tx.Get(
+		&id,
+		`INSERT INTO webhooks (url, events, secret, last_seq)
+		VALUES ($1, $2, $3, (SELECT coalesce(max(seq), 0) FROM change_events))
+		RETURNING id`,
+		req.URL,
+		string(events),
+		req.Secret,
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "insert into webhooks")
+	}
+
+	return id, nil
+}
+
+// Find
+func (r *WebhookRepository) Find(id int) (webhook *models.Webhook, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.find(tx, id)
+}
+
+func (r *WebhookRepository) find(tx *sqlx.Tx, id int) (*models.Webhook, error) {
+	var rawWebhook entities.Webhook
+	err := tx.Get(&rawWebhook, "SELECT "+webhookColumns+" FROM webhooks WHERE id = $1", id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+		return nil, errors.Wrap(err, "select")
+	}
+
+	return webhook(&rawWebhook)
+}
+
+// GetAll
+func (r *WebhookRepository) GetAll() (webhooks []models.Webhook, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.getAll(tx)
+}
+
+func (r *WebhookRepository) getAll(tx *sqlx.Tx) ([]models.Webhook, error) {
+	var rawWebhooks []entities.Webhook
+	err := tx.Select(&rawWebhooks, "SELECT "+webhookColumns+" FROM webhooks ORDER BY id")
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+
+	webhooks := make([]models.Webhook, 0, len(rawWebhooks))
+	for i := range rawWebhooks {
+		w, err := webhook(&rawWebhooks[i])
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, *w)
+	}
+
+	return webhooks, nil
+}
+
+// Delete
+func (r *WebhookRepository) Delete(id int) (done bool, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return false, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.delete(tx, id)
+}
+
+func (r *WebhookRepository) delete(tx *sqlx.Tx, id int) (bool, error) {
+	_, err := tx.Exec("DELETE FROM webhook_deliveries WHERE webhook_id = $1", id)
+	if err != nil {
+		return false, errors.Wrap(err, "delete from webhook_deliveries")
+	}
+
+	deleted, err := execCount(tx, "DELETE FROM webhooks WHERE id = $1", id)
+	if err != nil {
+		return false, errors.Wrap(err, "delete from webhooks")
+	}
+
+	return deleted > 0, nil
+}
+
+// Enqueue
+func (r *WebhookRepository) Enqueue(limit int) (added int, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return 0, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.enqueue(tx, limit)
+}
+
+func (r *WebhookRepository) enqueue(tx *sqlx.Tx, limit int) (int, error) {
+	// Locking the webhooks keeps concurrent calls from enqueuing the same
+	// events twice.
+	var rawWebhooks []entities.Webhook
+	err := tx.Select(&rawWebhooks, "SELECT "+webhookColumns+" FROM webhooks ORDER BY id FOR UPDATE")
+	if err != nil {
+		return 0, errors.Wrap(err, "select webhooks")
+	}
+
+	outbox := &OutboxRepository{store: r.store}
+	added := 0
+	for i := range rawWebhooks {
+		w, err := webhook(&rawWebhooks[i])
+		if err != nil {
+			return 0, err
+		}
+		changes, err := outbox.list(tx, rawWebhooks[i].LastSeq, limit)
+		if err != nil {
+			return 0, err
+		}
+		if len(changes) == 0 {
+			continue
+		}
+
+		for j := range changes {
+			payload, err := store.WebhookPayload(w.Events, &changes[j])
+			if err != nil {
+				return 0, errors.Wrap(err, "encode payload")
+			}
+			if payload == nil {
+				continue
+			}
+			_, err = tx.Exec(
+				`INSERT INTO webhook_deliveries (webhook_id, event, payload, status, next_attempt_at)
+				VALUES ($1, $2, $3, $4, now())`,
+				w.ID,
+				changes[j].WebhookEvent(),
+				string(payload),
+				models.WebhookStatusPending,
+			)
+			if err != nil {
+				return 0, errors.Wrap(err, "insert into webhook_deliveries")
+			}
+			added++
+		}
+
+		_, err = tx.Exec("UPDATE webhooks SET last_seq = $1 WHERE id = $2", changes[len(changes)-1].Seq, w.ID)
+		if err != nil {
+			return 0, errors.Wrap(err, "update webhooks")
+		}
+	}
+
+	return added, nil
+}
+
+// Due
+func (r *WebhookRepository) Due(before time.Time, limit int) (deliveries []models.WebhookDelivery, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.due(tx, before, limit)
+}
+
+func (r *WebhookRepository) due(tx *sqlx.Tx, before time.Time, limit int) ([]models.WebhookDelivery, error) {
+	deliveries := make([]models.WebhookDelivery, 0)
+	err := tx.Select(
+		&deliveries,
+		`SELECT `+deliveryColumns+`
+		FROM
+			webhook_deliveries d
+		INNER JOIN
+			webhooks w ON w.id = d.webhook_id
+		WHERE d.status = $1 AND d.next_attempt_at <= $2
+		ORDER BY d.next_attempt_at, d.id
+		LIMIT $3`,
+		models.WebhookStatusPending,
+		before,
+		limit,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+
+	return deliveries, nil
+}
+
+// RecordAttempt
+func (r *WebhookRepository) RecordAttempt(id int, attempt *store.WebhookAttempt) (err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.recordAttempt(tx, id, attempt)
+}
+
+func (r *WebhookRepository) recordAttempt(tx *sqlx.Tx, id int, attempt *store.WebhookAttempt) error {
+	var nextAttemptAt any
+	if !attempt.NextAttemptAt.IsZero() {
+		nextAttemptAt = attempt.NextAttemptAt
+	}
+
+	_, err := tx.Exec(
+		`UPDATE webhook_deliveries SET
+			attempts = attempts + 1,
+			status = $2,
+			response_code = $3,
+			error = $4,
+			next_attempt_at = $5,
+			delivered_at = CASE WHEN $6 THEN now() END
+		WHERE id = $1`,
+		id,
+		attempt.Status(),
+		attempt.ResponseCode,
+		attempt.Error,
+		nextAttemptAt,
+		attempt.Succeeded,
+	)
+	if err != nil {
+		return errors.Wrap(err, "update webhook_deliveries")
+	}
+
+	return nil
+}
+
+// GetDeliveries
+func (r *WebhookRepository) GetDeliveries(id int, limit int) (deliveries []models.WebhookDelivery, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.getDeliveries(tx, id, limit)
+}
+
+func (r *WebhookRepository) getDeliveries(tx *sqlx.Tx, id int, limit int) ([]models.WebhookDelivery, error) {
+	deliveries := make([]models.WebhookDelivery, 0)
+	err := tx.Select(
+		&deliveries,
+		`SELECT `+deliveryColumns+`
+		FROM
+			webhook_deliveries d
+		INNER JOIN
+			webhooks w ON w.id = d.webhook_id
+		WHERE d.webhook_id = $1
+		ORDER BY d.id DESC
+		LIMIT $2`,
+		id,
+		limit,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver
+func (r *WebhookRepository) Redeliver(id int, deliveryID int) (newID int, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return 0, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.redeliver(tx, id, deliveryID)
+}
+
+func (r *WebhookRepository) redeliver(tx *sqlx.Tx, id int, deliveryID int) (int, error) {
+	var newID int
+	err := tx.Get(
+		&newID,
+		`INSERT INTO webhook_deliveries (webhook_id, event, payload, status, next_attempt_at)
+		SELECT webhook_id, event, payload, $3, now() FROM webhook_deliveries WHERE id = $2 AND webhook_id = $1
+		RETURNING id`,
+		id,
+		deliveryID,
+		models.WebhookStatusPending,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, store.ErrRecordNotFound
+		}
+		return 0, errors.Wrap(err, "insert into webhook_deliveries")
+	}
+
+	return newID, nil
+}
+
+func webhook(rawWebhook *entities.Webhook) (*models.Webhook, error) {
+	w := &models.Webhook{
+		ID:        rawWebhook.ID,
+		URL:       rawWebhook.URL,
+		Secret:    rawWebhook.Secret,
+		CreatedAt: rawWebhook.CreatedAt,
+	}
+	if err := json.Unmarshal(rawWebhook.Events, &w.Events); err != nil {
+		return nil, errors.Wrap(err, "decode events")
+	}
+
+	return w, nil
+}
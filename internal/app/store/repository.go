@@ -1,30 +1,131 @@
 package store
 
 import (
+	"time"
+
 	"github.com/Rbd3178/filmDatabase/internal/app/models"
 )
 
 // UserRepository
+//
+// Create, Modify, Delete, Restore, SetAdmin and SetPassword of the user, film
+// and actor repositories record an audit event for the given author in the
+// same transaction as the change. Changes of films and actors, including
+// catalog imports, also append a change event to the outbox.
 type UserRepository interface {
-	Create(*models.UserRequest) (bool, error)
+	Create(*models.UserRequest, models.Author) (bool, error)
 	Find(string) (*models.User, error)
 	GetAll() ([]models.User, error)
+	SetAdmin(string, bool, models.Author) (bool, error)
+	SetPassword(string, string, models.Author) (bool, error)
+	// Modify applies a models.UserUpdate as a whole, or nothing of it.
+	Modify(string, *models.UserUpdate, models.Author) (bool, error)
+	// ReplaceHash(login, oldHash, newHash) swaps the password hash for another
+	// one of the same password, unless it is no longer oldHash.
+	ReplaceHash(string, string, string) (bool, error)
 }
 
 // FilmRepository
 type FilmRepository interface {
-	Create(*models.FilmRequest) (int, error)
-	GetAll(string, string, string, string) ([]models.Film, error)
-	Delete(int) (bool, error)
+	Create(*models.FilmRequest, models.Author) (int, error)
+	GetAll(*FilmFilter) ([]models.Film, error)
+	Iterate(*FilmFilter) (FilmIterator, error)
+	// Delete moves the film to the trash, its cast links are kept hidden
+	// until it is restored or purged.
+	Delete(int, models.Author) (bool, error)
+	Restore(int, models.Author) (bool, error)
 	Find(int) (*models.Film, error)
-	Modify(int, *models.FilmRequest) (bool, error)
+	Modify(int, *models.FilmRequest, models.Author) (bool, error)
+	FuzzySearch(string, string, float64) ([]models.Film, error)
+	// GetRevisions returns the revisions of the film, the latest first. Create
+	// and every Modify that changes the film add one.
+	GetRevisions(int) ([]models.FilmRevision, error)
+	// FindRevision(id, n) returns ErrRecordNotFound for unknown revisions.
+	FindRevision(int, int) (*models.FilmRevision, error)
+	// Revert(id, n) sets the film to revision n as a whole, unlike Modify it
+	// also writes empty values. It returns ErrRecordNotFound for unknown
+	// revisions.
+	Revert(int, int, models.Author) (bool, error)
 }
 
 // ActorRepository
 type ActorRepository interface {
-	Create(*models.ActorRequest) (int, error)
-	Modify(int, *models.ActorRequest) (bool, error)
-	Delete(int) (bool, error)
+	Create(*models.ActorRequest, models.Author) (int, error)
+	Modify(int, *models.ActorRequest, models.Author) (bool, error)
+	// Delete moves the actor to the trash, see FilmRepository.
+	Delete(int, models.Author) (bool, error)
+	Restore(int, models.Author) (bool, error)
 	Find(int) (*models.Actor, error)
-	GetAll() ([]models.Actor, error)
-}
\ No newline at end of file
+	GetAll(*ActorFilter) ([]models.Actor, error)
+	Iterate(*ActorFilter) (ActorIterator, error)
+	FuzzySearch(string, float64) ([]models.Actor, error)
+}
+
+// SearchRepository
+type SearchRepository interface {
+	FullText(string, int) ([]models.SearchResult, error)
+	Suggest(string, string, int) ([]models.Suggestion, error)
+}
+
+// CatalogRepository
+type CatalogRepository interface {
+	ImportActors([]models.ActorRecord, models.Author) (*models.ImportResult, error)
+	ImportFilms([]models.FilmRecord, models.Author) (*models.ImportResult, error)
+	// UnknownCast returns the cast keys that match no actor outside the
+	// trash, neither by external id nor by name.
+	UnknownCast([]string) ([]string, error)
+	Export(func(*models.ActorRecord) error, func(*models.FilmRecord) error) error
+}
+
+// TrashRepository
+type TrashRepository interface {
+	List() ([]models.DeletedRecord, error)
+	// Purge removes films and actors deleted before the given time for good
+	// and returns how many there were.
+	Purge(time.Time) (int, error)
+}
+
+// AuditRepository
+type AuditRepository interface {
+	List(*AuditFilter) ([]models.AuditEvent, error)
+}
+
+// OutboxRepository
+type OutboxRepository interface {
+	// List returns up to limit events with a seq greater than the given one,
+	// oldest first. A limit of 0 means no limit.
+	List(int, int) ([]models.ChangeEvent, error)
+	// Purge removes the events recorded before the given time and returns how
+	// many there were. Events that some webhook has not been given deliveries
+	// for yet are kept.
+	Purge(time.Time) (int, error)
+}
+
+// WebhookRepository
+//
+// Deliveries come from the outbox, a webhook is only given the change events
+// recorded after it was created.
+type WebhookRepository interface {
+	Create(*models.WebhookRequest) (int, error)
+	Find(int) (*models.Webhook, error)
+	GetAll() ([]models.Webhook, error)
+	// Delete removes the webhook together with its deliveries.
+	Delete(int) (bool, error)
+	// Enqueue adds pending deliveries for up to limit change events per
+	// webhook, those of the types it subscribes to, and returns how many it
+	// added. Webhooks are not given the same event twice. A limit of 0 means
+	// no limit.
+	Enqueue(int) (int, error)
+	// Due returns up to limit pending deliveries whose next attempt is not
+	// after the given time, the earliest first.
+	Due(time.Time, int) ([]models.WebhookDelivery, error)
+	// RecordAttempt saves the outcome of sending the delivery once.
+	RecordAttempt(int, *WebhookAttempt) error
+	// GetDeliveries(id, limit) returns deliveries of the webhook, the latest
+	// first.
+	GetDeliveries(int, int) ([]models.WebhookDelivery, error)
+	// Redeliver(id, deliveryID) adds a pending delivery with the payload of
+	// the given one and returns its id, ErrRecordNotFound when the webhook
+	// has no such delivery.
+	Redeliver(int, int) (int, error)
+}
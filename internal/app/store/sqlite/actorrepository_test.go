@@ -0,0 +1,343 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/sqlite"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActorRepository_Create(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	actorReq := &models.ActorRequest{
+		Name:      "Tom Hanks",
+		Gender:    "male",
+		BirthDate: "1956-07-09",
+	}
+
+	id, err := s.Actor().Create(actorReq, models.Author{Login: "admin"})
+	assert.NoError(t, err)
+	assert.NotNil(t, id)
+}
+
+func TestActorRepository_Modify(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	actorReq := &models.ActorRequest{
+		Name:      "Tom Hank",
+		Gender:    "male",
+		BirthDate: "1959-07-09",
+	}
+
+	id, _ := s.Actor().Create(actorReq, models.Author{Login: "admin"})
+
+	actorReqMod := &models.ActorRequest{
+		Name:      "Tom Hanks",
+		Gender:    "Male",
+		BirthDate: "1956-07-09",
+	}
+	done, err := s.Actor().Modify(id, actorReqMod, models.Author{Login: "admin"})
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	done, err = s.Actor().Modify(id+10, actorReqMod, models.Author{Login: "admin"})
+	assert.NoError(t, err)
+	assert.False(t, done)
+}
+
+func TestActorRepository_Delete(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	actorReq := &models.ActorRequest{
+		Name:      "Tom Hanks",
+		Gender:    "male",
+		BirthDate: "1956-07-09",
+	}
+	id, _ := s.Actor().Create(actorReq, models.Author{Login: "admin"})
+
+	filmReq := &models.FilmRequest{
+		Title:       "Cool title",
+		Description: "Detailed description",
+		ReleaseDate: "2020-01-01",
+		Rating:      6.8,
+		ActorsIDs:   []int{id},
+	}
+	s.Film().Create(filmReq, models.Author{Login: "admin"})
+
+	done, err := s.Actor().Delete(id, models.Author{Login: "admin"})
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	done, err = s.Actor().Delete(id, models.Author{Login: "admin"})
+	assert.NoError(t, err)
+	assert.False(t, done)
+}
+
+func TestActorRepository_Find(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	actorReq := &models.ActorRequest{
+		Name:      "Tom Hanks",
+		Gender:    "male",
+		BirthDate: "1956-07-09",
+	}
+
+	id, _ := s.Actor().Create(actorReq, models.Author{Login: "admin"})
+
+	filmReq1 := &models.FilmRequest{
+		Title:       "Cool title",
+		Description: "Detailed description",
+		ReleaseDate: "2020-01-01",
+		Rating:      6.8,
+		ActorsIDs:   []int{id},
+	}
+
+	filmReq2 := &models.FilmRequest{
+		Title:       "Cool title 2",
+		Description: "Detailed description",
+		ReleaseDate: "2020-01-01",
+		Rating:      6.8,
+		ActorsIDs:   []int{id},
+	}
+
+	filmID1, _ := s.Film().Create(filmReq1, models.Author{Login: "admin"})
+	filmID2, _ := s.Film().Create(filmReq2, models.Author{Login: "admin"})
+
+	actor, err := s.Actor().Find(id)
+	assert.NoError(t, err)
+	assert.Contains(t, actor.Films, models.FilmBasic{FilmID: filmID1, Title: filmReq1.Title})
+	assert.Contains(t, actor.Films, models.FilmBasic{FilmID: filmID2, Title: filmReq2.Title})
+
+	actor, err = s.Actor().Find(id + 10)
+	assert.Nil(t, actor)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+}
+
+func TestActorRepository_GetAll(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	actorReq1 := &models.ActorRequest{
+		Name:      "Tom Hanks",
+		Gender:    "male",
+		BirthDate: "1956-07-09",
+	}
+	actorReq2 := &models.ActorRequest{
+		Name:      "Sophie Patel",
+		Gender:    "female",
+		BirthDate: "1997-11-03",
+	}
+	actorID1, _ := s.Actor().Create(actorReq1, models.Author{Login: "admin"})
+	actorID2, _ := s.Actor().Create(actorReq2, models.Author{Login: "admin"})
+
+	filmReq1 := &models.FilmRequest{
+		Title:       "Cool title",
+		Description: "Detailed description",
+		ReleaseDate: "2020-01-01",
+		Rating:      6.8,
+		ActorsIDs:   []int{actorID1},
+	}
+	filmReq2 := &models.FilmRequest{
+		Title:       "Cool title 2",
+		Description: "Detailed description",
+		ReleaseDate: "2020-01-01",
+		Rating:      6.8,
+		ActorsIDs:   []int{actorID1, actorID2},
+	}
+	filmID1, _ := s.Film().Create(filmReq1, models.Author{Login: "admin"})
+	filmID2, _ := s.Film().Create(filmReq2, models.Author{Login: "admin"})
+
+	actors, err := s.Actor().GetAll(&store.ActorFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(actors))
+	for _, actor := range actors {
+		if actor.ID == actorID1 {
+			assert.Contains(t, actor.Films, models.FilmBasic{FilmID: filmID1, Title: filmReq1.Title})
+		}
+		assert.Contains(t, actor.Films, models.FilmBasic{FilmID: filmID2, Title: filmReq2.Title})
+	}
+}
+
+func TestActorRepository_FuzzySearch(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	actorReq1 := &models.ActorRequest{
+		Name:      "Leonardo DiCaprio",
+		Gender:    "male",
+		BirthDate: "1974-11-11",
+	}
+	actorReq2 := &models.ActorRequest{
+		Name:      "Kate Winslet",
+		Gender:    "female",
+		BirthDate: "1975-10-05",
+	}
+	actorID1, _ := s.Actor().Create(actorReq1, models.Author{Login: "admin"})
+	s.Actor().Create(actorReq2, models.Author{Login: "admin"})
+
+	actors, err := s.Actor().FuzzySearch("Di Caprio", 0.5)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(actors))
+	assert.Equal(t, actorID1, actors[0].ID)
+	assert.Greater(t, actors[0].Score, 0.5)
+
+	actors, err = s.Actor().FuzzySearch("Di Caprio", 0.95)
+	assert.NoError(t, err)
+	assert.Empty(t, actors)
+}
+
+func TestActorRepository_GetAllFiltered(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	actorReq1 := &models.ActorRequest{
+		Name:      "Tom Hanks",
+		Gender:    "male",
+		BirthDate: "1956-07-09",
+	}
+	actorReq2 := &models.ActorRequest{
+		Name:      "Sophie Patel",
+		Gender:    "female",
+		BirthDate: "1997-11-03",
+	}
+	actorReq3 := &models.ActorRequest{
+		Name:      "Tom Hardy",
+		Gender:    "male",
+		BirthDate: "1977-09-15",
+	}
+	actorID1, _ := s.Actor().Create(actorReq1, models.Author{Login: "admin"})
+	actorID2, _ := s.Actor().Create(actorReq2, models.Author{Login: "admin"})
+	actorID3, _ := s.Actor().Create(actorReq3, models.Author{Login: "admin"})
+
+	s.Film().Create(&models.FilmRequest{
+		Title:       "Cool title",
+		ReleaseDate: "2020-01-01",
+		Rating:      6.8,
+		ActorsIDs:   []int{actorID1, actorID2},
+	}, models.Author{Login: "admin"})
+	s.Film().Create(&models.FilmRequest{
+		Title:       "Cool title 2",
+		ReleaseDate: "2020-01-01",
+		Rating:      6.8,
+		ActorsIDs:   []int{actorID1},
+	}, models.Author{Login: "admin"})
+
+	var tests = []struct {
+		name        string
+		filter      *store.ActorFilter
+		expectedIDs []int
+	}{
+		{
+			name:        "Ordered by name by default",
+			filter:      &store.ActorFilter{},
+			expectedIDs: []int{actorID2, actorID1, actorID3},
+		},
+		{
+			name:        "Name search",
+			filter:      &store.ActorFilter{SearchName: "tom"},
+			expectedIDs: []int{actorID1, actorID3},
+		},
+		{
+			name:        "Gender",
+			filter:      &store.ActorFilter{Gender: "Female"},
+			expectedIDs: []int{actorID2},
+		},
+		{
+			name:        "Birth date range",
+			filter:      &store.ActorFilter{BornAfter: "1960-01-01", BornBefore: "1990-01-01"},
+			expectedIDs: []int{actorID3},
+		},
+		{
+			name:        "Sort by film count",
+			filter:      &store.ActorFilter{Sort: []store.SortField{{Field: "film_count", Desc: true}, {Field: "name"}}},
+			expectedIDs: []int{actorID1, actorID2, actorID3},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actors, err := s.Actor().GetAll(tc.filter)
+			assert.NoError(t, err)
+			ids := make([]int, 0, len(actors))
+			for _, actor := range actors {
+				ids = append(ids, actor.ID)
+			}
+			assert.Equal(t, tc.expectedIDs, ids)
+		})
+	}
+}
+
+func TestActorRepository_Iterate(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	actorID1, _ := s.Actor().Create(&models.ActorRequest{Name: "Tom Hardy", Gender: "male", BirthDate: "1977-09-15"}, models.Author{Login: "admin"})
+	actorID2, _ := s.Actor().Create(&models.ActorRequest{Name: "Tom Hanks", Gender: "male", BirthDate: "1956-07-09"}, models.Author{Login: "admin"})
+	s.Film().Create(&models.FilmRequest{Title: "Alpha", ReleaseDate: "1999-05-01", Rating: 5.5, ActorsIDs: []int{actorID1}}, models.Author{Login: "admin"})
+
+	it, err := s.Actor().Iterate(&store.ActorFilter{SearchName: "tom"})
+	assert.NoError(t, err)
+	defer it.Close()
+
+	var actors []models.Actor
+	for it.Next() {
+		actors = append(actors, *it.Actor())
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, 2, len(actors))
+	assert.Equal(t, actorID2, actors[0].ID)
+	assert.Equal(t, actorID1, actors[1].ID)
+	assert.Equal(t, 1, len(actors[1].Films))
+}
+
+func TestActorRepository_GetAllProjected(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	actorID, _ := s.Actor().Create(&models.ActorRequest{Name: "Tom Hardy", Gender: "male", BirthDate: "1977-09-15"}, models.Author{Login: "admin"})
+
+	actors, err := s.Actor().GetAll(&store.ActorFilter{Fields: []string{"name"}, OmitFilms: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Actor{{ID: actorID, Name: "Tom Hardy"}}, actors)
+
+	_, err = s.Actor().GetAll(&store.ActorFilter{Fields: []string{"height"}})
+	assert.Error(t, err)
+}
+
+func TestActorRepository_GetAllByIDs(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	s.Actor().Create(&models.ActorRequest{Name: "Tom Hardy", Gender: "male", BirthDate: "1977-09-15"}, models.Author{Login: "admin"})
+	actorID, _ := s.Actor().Create(&models.ActorRequest{Name: "Emily Blunt", Gender: "female", BirthDate: "1983-02-23"}, models.Author{Login: "admin"})
+
+	actors, err := s.Actor().GetAll(&store.ActorFilter{IDs: []int{actorID, actorID + 100}, Fields: []string{"name"}, OmitFilms: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Actor{{ID: actorID, Name: "Emily Blunt"}}, actors)
+}
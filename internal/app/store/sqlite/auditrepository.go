@@ -0,0 +1,116 @@
+package sqlite
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+// AuditRepository
+type AuditRepository struct {
+	store *Store
+}
+
+// List
+func (r *AuditRepository) List(filter *store.AuditFilter) (events []models.AuditEvent, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.list(tx, filter)
+}
+
+func (r *AuditRepository) list(tx *sqlx.Tx, filter *store.AuditFilter) ([]models.AuditEvent, error) {
+	var conditions []string
+	var args []any
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.Entity != "" {
+		add("entity = ?%d", filter.Entity)
+	}
+	if filter.EntityID != "" {
+		add("entity_id = ?%d", filter.EntityID)
+	}
+	if filter.Action != "" {
+		add("action = ?%d", filter.Action)
+	}
+	if filter.Login != "" {
+		add("login = ?%d", filter.Login)
+	}
+	if filter.RequestID != "" {
+		add("request_id = ?%d", filter.RequestID)
+	}
+	if !filter.Since.IsZero() {
+		add("at >= ?%d", filter.Since.UTC().Format(models.TimestampLayout))
+	}
+	if !filter.Until.IsZero() {
+		add("at < ?%d", filter.Until.UTC().Format(models.TimestampLayout))
+	}
+	if filter.BeforeID > 0 {
+		add("id < ?%d", filter.BeforeID)
+	}
+
+	// diff is read as a blob, text can not be scanned into json.RawMessage.
+	query := "SELECT id, at, login, request_id, entity, entity_id, action, CAST(diff AS BLOB) AS diff FROM audit_events"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT ?%d", len(args))
+	}
+
+	events := make([]models.AuditEvent, 0)
+	err := tx.Select(&events, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+
+	return events, nil
+}
+
+// insertAuditEvent records the change unless diff is empty.
+func insertAuditEvent(tx *sqlx.Tx, author models.Author, entity string, entityID string, action string, diff json.RawMessage) error {
+	if diff == nil {
+		return nil
+	}
+
+	_, err := tx.Exec(
+		"INSERT INTO audit_events (login, request_id, entity, entity_id, action, diff) VALUES (?1, ?2, ?3, ?4, ?5, ?6)",
+		author.Login,
+		author.RequestID,
+		entity,
+		entityID,
+		action,
+		string(diff),
+	)
+	if err != nil {
+		return errors.Wrap(err, "insert into audit_events")
+	}
+
+	return nil
+}
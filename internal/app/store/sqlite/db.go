@@ -0,0 +1,77 @@
+package sqlite
+
+import (
+	"database/sql/driver"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"modernc.org/sqlite"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/fuzzy"
+)
+
+// SQLite only lowercases ASCII and has no pg_trgm, so the functions the
+// queries need are provided here for every connection.
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction("casefold", 1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		s, ok := text(args[0])
+		if !ok {
+			return nil, nil
+		}
+		return strings.ToLower(s), nil
+	})
+	sqlite.MustRegisterDeterministicScalarFunction("word_similarity", 2, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		query, ok := text(args[0])
+		if !ok {
+			return nil, nil
+		}
+		s, ok := text(args[1])
+		if !ok {
+			return nil, nil
+		}
+		return fuzzy.TrigramWordSimilarity(query, s), nil
+	})
+}
+
+func text(v driver.Value) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	}
+	return "", false
+}
+
+// Open opens the database file at path, creating it when missing, and brings
+// its schema up to date. ":memory:" opens a private in-memory database.
+//
+// SQLite allows a single writer at a time, so the pool is limited to one
+// connection; this also keeps an in-memory database alive between queries.
+func Open(path string) (*sqlx.DB, error) {
+	dsn := "file:" + path
+	if strings.Contains(path, "?") {
+		dsn += "&"
+	} else {
+		dsn += "?"
+	}
+	dsn += "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_txlock=immediate"
+
+	db, err := sqlx.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, errors.Wrap(err, "migrate")
+	}
+
+	return db, nil
+}
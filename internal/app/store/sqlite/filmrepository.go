@@ -0,0 +1,770 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/entities"
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+// FilmRepository
+type FilmRepository struct {
+	store *Store
+}
+
+// Create
+func (r *FilmRepository) Create(f *models.FilmRequest, author models.Author) (id int, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return 0, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	if id, err = r.create(tx, f); err != nil {
+		return 0, err
+	}
+
+	return id, r.audit(tx, author, models.AuditActionCreate, id, nil)
+}
+
+func (r *FilmRepository) create(tx *sqlx.Tx, f *models.FilmRequest) (int, error) {
+	var id int64
+	err := tx.Get(
+		&id,
+		"INSERT INTO films (title, description, release_date, rating) VALUES (?1, ?2, ?3, ?4) RETURNING id",
+		f.Title,
+		f.Description,
+		f.ReleaseDate,
+		f.Rating,
+	)
+
+	if err != nil {
+		return 0, errors.Wrap(err, "insert into films")
+	}
+
+	for _, actorID := range f.ActorsIDs {
+		var exists bool
+		err = tx.Get(
+			&exists,
+			"SELECT EXISTS(SELECT 1 FROM actors WHERE id = ?1 AND deleted_at IS NULL)",
+			actorID,
+		)
+		if err != nil {
+			return 0, errors.Wrap(err, "check if actor exists")
+		}
+		if !exists {
+			continue
+		}
+
+		_, err := tx.Exec(
+			"INSERT INTO films_x_actors (film_id, actor_id) VALUES (?1, ?2) ON CONFLICT DO NOTHING",
+			id,
+			actorID,
+		)
+
+		if err != nil {
+			return 0, errors.Wrap(err, "insert into films_x_actors")
+		}
+
+	}
+
+	return int(id), nil
+}
+
+// GetAll
+func (r *FilmRepository) GetAll(filter *store.FilmFilter) (films []models.Film, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.getAll(tx, filter)
+}
+
+var filmSortColumns = map[string]string{
+	"title":        "f.title",
+	"rating":       "f.rating",
+	"release_date": "f.release_date",
+}
+
+func (r *FilmRepository) getAll(tx *sqlx.Tx, filter *store.FilmFilter) ([]models.Film, error) {
+	query, args, err := filmsQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var rawFilms = make([]entities.FilmWithActor, 0)
+	err = tx.Select(&rawFilms, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+
+	return groupFilms(rawFilms), nil
+}
+
+// filmsQuery selects one row per film and cast member, rows of the same film
+// are adjacent.
+func filmsQuery(filter *store.FilmFilter) (string, []any, error) {
+	where, args := filmConditions(filter)
+	orderBy, err := filmOrder(filter.Sort)
+	if err != nil {
+		return "", nil, err
+	}
+	columns, err := projection(filmColumns, filmFields, filter.Fields)
+	if err != nil {
+		return "", nil, err
+	}
+	if filter.IncludeDeleted {
+		columns += ", " + deletionColumns("f")
+	}
+
+	if filter.OmitActors {
+		return `SELECT
+			` + columns + `
+		FROM
+			films f
+		` + where + `
+		ORDER BY ` + orderBy, args, nil
+	}
+
+	return `SELECT
+			` + columns + `,
+			a.id AS actor_id,
+			a.name
+		FROM
+			films f
+		LEFT JOIN
+			films_x_actors fxa ON fxa.film_id = f.id
+		LEFT JOIN
+			actors a ON a.id = fxa.actor_id AND a.deleted_at IS NULL
+		` + where + `
+		ORDER BY ` + orderBy, args, nil
+}
+
+// filmFields are the fields that can be projected, filmColumns maps them to
+// their columns.
+var filmFields = []string{"id", "title", "description", "release_date", "rating"}
+
+var filmColumns = map[string]string{
+	"id":           "f.id",
+	"title":        "f.title",
+	"description":  "f.description",
+	"release_date": "f.release_date",
+	"rating":       "f.rating",
+}
+
+// filmConditions translates the filter into a WHERE clause on films f. Every
+// condition is about the film as a whole, so the clause can be combined with
+// joins to the cast.
+func filmConditions(filter *store.FilmFilter) (string, []any) {
+	var conditions []string
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("?%d", len(args))
+	}
+	argList := func(ids []int) string {
+		marks := make([]string, len(ids))
+		for i, id := range ids {
+			marks[i] = arg(id)
+		}
+		return "(" + strings.Join(marks, ", ") + ")"
+	}
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "f.deleted_at IS NULL")
+	}
+	if len(filter.IDs) > 0 {
+		conditions = append(conditions, "f.id IN "+argList(filter.IDs))
+	}
+	if filter.SearchTitle != "" {
+		conditions = append(conditions, "casefold(f.title) LIKE "+arg(likeContains(filter.SearchTitle))+` ESCAPE '\'`)
+	}
+	if filter.SearchActor != "" {
+		conditions = append(conditions, `EXISTS (
+			SELECT 1 FROM films_x_actors sfxa INNER JOIN actors sa ON sa.id = sfxa.actor_id
+			WHERE sfxa.film_id = f.id AND sa.deleted_at IS NULL AND casefold(sa.name) LIKE `+arg(likeContains(filter.SearchActor))+` ESCAPE '\')`)
+	}
+	if filter.RatingMin != nil {
+		conditions = append(conditions, "f.rating >= "+arg(*filter.RatingMin))
+	}
+	if filter.RatingMax != nil {
+		conditions = append(conditions, "f.rating <= "+arg(*filter.RatingMax))
+	}
+	if filter.ReleasedAfter != "" {
+		conditions = append(conditions, "f.release_date >= "+arg(filter.ReleasedAfter))
+	}
+	if filter.ReleasedBefore != "" {
+		conditions = append(conditions, "f.release_date <= "+arg(filter.ReleasedBefore))
+	}
+	if len(filter.ActorIDs) > 0 {
+		distinct := make(map[int]struct{}, len(filter.ActorIDs))
+		for _, id := range filter.ActorIDs {
+			distinct[id] = struct{}{}
+		}
+		ids := argList(filter.ActorIDs)
+		if filter.ActorMatch == store.ActorMatchAll {
+			conditions = append(conditions, `(
+				SELECT count(DISTINCT afxa.actor_id) FROM films_x_actors afxa
+				INNER JOIN actors aa ON aa.id = afxa.actor_id AND aa.deleted_at IS NULL
+				WHERE afxa.film_id = f.id AND afxa.actor_id IN `+ids+`
+			) = `+arg(len(distinct)))
+		} else {
+			conditions = append(conditions, `EXISTS (
+				SELECT 1 FROM films_x_actors afxa
+				INNER JOIN actors aa ON aa.id = afxa.actor_id AND aa.deleted_at IS NULL
+				WHERE afxa.film_id = f.id AND afxa.actor_id IN `+ids+`)`)
+		}
+	}
+	if filter.HasDescription != nil {
+		if *filter.HasDescription {
+			conditions = append(conditions, "coalesce(f.description, '') <> ''")
+		} else {
+			conditions = append(conditions, "coalesce(f.description, '') = ''")
+		}
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func filmOrder(sort []store.SortField) (string, error) {
+	terms := make([]string, 0, len(sort)+1)
+	for _, field := range sort {
+		column, ok := filmSortColumns[field.Field]
+		if !ok {
+			return "", errors.Errorf("unknown sort field %q", field.Field)
+		}
+		if field.Desc {
+			terms = append(terms, column+" DESC")
+		} else {
+			terms = append(terms, column+" ASC")
+		}
+	}
+
+	return strings.Join(append(terms, "f.id ASC"), ", "), nil
+}
+
+// likeContains builds a pattern matching casefolded strings that contain s.
+func likeContains(s string) string {
+	return "%" + likeEscaper.Replace(strings.ToLower(s)) + "%"
+}
+
+// Delete
+func (r *FilmRepository) Delete(id int, author models.Author) (done bool, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return false, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	before, err := r.snapshot(tx, id)
+	if err != nil || before == nil {
+		return false, err
+	}
+	if done, err = r.delete(tx, id, author.Login); !done || err != nil {
+		return done, err
+	}
+
+	return true, r.audit(tx, author, models.AuditActionDelete, id, before)
+}
+
+func (r *FilmRepository) delete(tx *sqlx.Tx, id int, deletedBy string) (bool, error) {
+	res, err := tx.Exec(
+		"UPDATE films SET deleted_at = "+nowTimestamp+", deleted_by = ?2 WHERE id = ?1 AND deleted_at IS NULL",
+		id,
+		deletedBy,
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "update films")
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "rows affected")
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// Restore takes the film out of the trash together with its cast links.
+func (r *FilmRepository) Restore(id int, author models.Author) (done bool, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return false, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	if done, err = r.restore(tx, id); !done || err != nil {
+		return done, err
+	}
+
+	return true, r.audit(tx, author, models.AuditActionRestore, id, nil)
+}
+
+func (r *FilmRepository) restore(tx *sqlx.Tx, id int) (bool, error) {
+	res, err := tx.Exec(
+		"UPDATE films SET deleted_at = NULL, deleted_by = NULL WHERE id = ?1 AND deleted_at IS NOT NULL",
+		id,
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "update films")
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "rows affected")
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// snapshot returns the audited state of the film, nil when there is no such
+// film or it is deleted.
+func (r *FilmRepository) snapshot(tx *sqlx.Tx, id int) (store.Snapshot, error) {
+	film, err := r.find(tx, id)
+	if err == store.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return store.FilmSnapshot(film), nil
+}
+
+// audit records how the film changed since before and tells the outbox, a
+// change of its content also adds a revision.
+func (r *FilmRepository) audit(tx *sqlx.Tx, author models.Author, action string, id int, before store.Snapshot) error {
+	after, err := r.snapshot(tx, id)
+	if err != nil {
+		return err
+	}
+	diff, err := store.Diff(before, after)
+	if err != nil {
+		return errors.Wrap(err, "diff")
+	}
+	if diff == nil {
+		return nil
+	}
+	if err := insertAuditEvent(tx, author, models.AuditEntityFilm, strconv.Itoa(id), action, diff); err != nil {
+		return err
+	}
+	if err := insertChangeEvent(tx, models.AuditEntityFilm, id, action); err != nil {
+		return err
+	}
+	if action != models.AuditActionCreate && action != models.AuditActionModify {
+		return nil
+	}
+
+	return insertFilmRevision(tx, author, id, after)
+}
+
+// Find
+func (r *FilmRepository) Find(id int) (actor *models.Film, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.find(tx, id)
+}
+
+func (r *FilmRepository) find(tx *sqlx.Tx, id int) (*models.Film, error) {
+	var filmInfo entities.Film
+
+	err := tx.Get(
+		&filmInfo,
+		"SELECT id, title, description, release_date, rating FROM films WHERE id = ?1 AND deleted_at IS NULL",
+		id,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+		return nil, errors.Wrap(err, "select film")
+	}
+
+	film := models.Film{
+		ID:          filmInfo.ID,
+		Title:       filmInfo.Title,
+		Description: filmInfo.Description,
+		ReleaseDate: filmInfo.ReleaseDate,
+		Rating:      filmInfo.Rating,
+	}
+
+	err = tx.Select(
+		&film.Actors,
+		`SELECT
+			a.id AS actor_id,
+			a.name
+		FROM 
+			films f
+		INNER JOIN
+			films_x_actors fxa ON fxa.film_id = f.id
+		INNER JOIN
+			actors a ON a.id = fxa.actor_id AND a.deleted_at IS NULL
+		WHERE f.id = ?1`,
+		id,
+	)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "select actors")
+	}
+
+	return &film, nil
+}
+
+// Modify
+func (r *FilmRepository) Modify(id int, f *models.FilmRequest, author models.Author) (done bool, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return false, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	before, err := r.snapshot(tx, id)
+	if err != nil || before == nil {
+		return false, err
+	}
+	if done, err = r.modify(tx, id, f); !done || err != nil {
+		return done, err
+	}
+
+	return true, r.audit(tx, author, models.AuditActionModify, id, before)
+}
+
+func (r *FilmRepository) modify(tx *sqlx.Tx, id int, f *models.FilmRequest) (bool, error) {
+	var exists bool
+	err := tx.Get(
+		&exists,
+		"SELECT EXISTS(SELECT 1 FROM films WHERE id = ?1 AND deleted_at IS NULL)",
+		id,
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "check if film exists")
+	}
+	if !exists {
+		return false, nil
+	}
+
+	if f.Title != "" {
+		res, err := tx.Exec(
+			"UPDATE films SET title=?1 WHERE id = ?2",
+			f.Title,
+			id,
+		)
+		if err != nil {
+			return false, errors.Wrap(err, "update title")
+		}
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return false, errors.Wrap(err, "rows affected")
+		}
+		if rowsAffected == 0 {
+			return false, nil
+		}
+	}
+	if f.Description != "" {
+		res, err := tx.Exec(
+			"UPDATE films SET description=?1 WHERE id = ?2",
+			f.Description,
+			id,
+		)
+		if err != nil {
+			return false, errors.Wrap(err, "update description")
+		}
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return false, errors.Wrap(err, "rows affected")
+		}
+		if rowsAffected == 0 {
+			return false, nil
+		}
+	}
+	if f.ReleaseDate != "" {
+		res, err := tx.Exec(
+			"UPDATE films SET release_date=?1 WHERE id = ?2",
+			f.ReleaseDate,
+			id,
+		)
+		if err != nil {
+			return false, errors.Wrap(err, "update release date")
+		}
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return false, errors.Wrap(err, "rows affected")
+		}
+		if rowsAffected == 0 {
+			return false, nil
+		}
+	}
+	if f.Rating != 0 {
+		res, err := tx.Exec(
+			"UPDATE films SET rating=?1 WHERE id = ?2",
+			f.Rating,
+			id,
+		)
+		if err != nil {
+			return false, errors.Wrap(err, "update rating")
+		}
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return false, errors.Wrap(err, "rows affected")
+		}
+		if rowsAffected == 0 {
+			return false, nil
+		}
+	}
+	// Links to deleted actors are not part of the cast anyone sees, they stay
+	// for the actors' restore.
+	_, err = tx.Exec(
+		"DELETE FROM films_x_actors WHERE film_id = ?1 AND actor_id IN (SELECT id FROM actors WHERE deleted_at IS NULL)",
+		id,
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "delete from films_x_actors")
+	}
+
+	for _, actorID := range f.ActorsIDs {
+		var exists bool
+		err = tx.Get(
+			&exists,
+			"SELECT EXISTS(SELECT 1 FROM actors WHERE id = ?1 AND deleted_at IS NULL)",
+			actorID,
+		)
+		if err != nil {
+			return false, errors.Wrap(err, "check if actor exists")
+		}
+		if !exists {
+			continue
+		}
+
+		// Links are unique here, a repeated actor is simply skipped.
+		_, err := tx.Exec(
+			"INSERT INTO films_x_actors (film_id, actor_id) VALUES (?1, ?2) ON CONFLICT DO NOTHING",
+			id,
+			actorID,
+		)
+
+		if err != nil {
+			return false, errors.Wrap(err, "insert into films_x_actors")
+		}
+	}
+
+	return true, nil
+}
+
+// FuzzySearch
+func (r *FilmRepository) FuzzySearch(searchTitle string, searchActor string, threshold float64) (films []models.Film, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.fuzzySearch(tx, searchTitle, searchActor, threshold)
+}
+
+func (r *FilmRepository) fuzzySearch(tx *sqlx.Tx, searchTitle string, searchActor string, threshold float64) ([]models.Film, error) {
+	// There is no pg_trgm, word_similarity is registered in db.go and the
+	// threshold is always the first argument.
+	titleMatches := `SELECT
+				id,
+				word_similarity(?%[1]d, title) AS score
+			FROM
+				films
+			WHERE word_similarity(?%[1]d, title) >= ?1 AND deleted_at IS NULL`
+	actorMatches := `SELECT
+				fxa.film_id AS id,
+				max(word_similarity(?%[1]d, a.name)) AS score
+			FROM
+				films_x_actors fxa
+			INNER JOIN
+				actors a ON a.id = fxa.actor_id AND a.deleted_at IS NULL
+			WHERE word_similarity(?%[1]d, a.name) >= ?1
+			GROUP BY fxa.film_id`
+
+	var matches string
+	var args []any
+	switch {
+	case searchTitle != "" && searchActor != "":
+		matches = fmt.Sprintf(`SELECT t.id, (t.score + am.score) / 2 AS score FROM (%s) t INNER JOIN (%s) am ON am.id = t.id`,
+			fmt.Sprintf(titleMatches, 2), fmt.Sprintf(actorMatches, 3))
+		args = []any{threshold, searchTitle, searchActor}
+	case searchTitle != "":
+		matches = fmt.Sprintf(titleMatches, 2)
+		args = []any{threshold, searchTitle}
+	case searchActor != "":
+		matches = fmt.Sprintf(actorMatches, 2)
+		args = []any{threshold, searchActor}
+	default:
+		return make([]models.Film, 0), nil
+	}
+
+	var rawFilms = make([]entities.FilmWithActor, 0)
+	err := tx.Select(
+		&rawFilms,
+		`SELECT
+			f.id,
+			f.title,
+			f.description,
+			f.release_date,
+			f.rating,
+			a.id AS actor_id,
+			a.name,
+			m.score
+		FROM
+			(`+matches+`) m
+		INNER JOIN
+			films f ON f.id = m.id AND f.deleted_at IS NULL
+		LEFT JOIN
+			films_x_actors fxa ON fxa.film_id = f.id
+		LEFT JOIN
+			actors a ON a.id = fxa.actor_id AND a.deleted_at IS NULL
+		ORDER BY m.score DESC, f.id ASC`,
+		args...,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+
+	return groupFilms(rawFilms), nil
+}
+
+// groupFilms collapses joined film rows, which must be ordered so that rows of
+// the same film are adjacent.
+func groupFilms(rawFilms []entities.FilmWithActor) []models.Film {
+	films := make([]models.Film, 0)
+	curID := -1
+	for _, rawFilm := range rawFilms {
+		if rawFilm.ID != curID {
+			films = append(films, models.Film{
+				ID:          rawFilm.ID,
+				Title:       rawFilm.Title,
+				Description: rawFilm.Description,
+				ReleaseDate: rawFilm.ReleaseDate,
+				Rating:      rawFilm.Rating,
+				Score:       rawFilm.Score,
+				DeletedAt:   rawFilm.DeletedAt,
+				DeletedBy:   rawFilm.DeletedBy,
+			})
+			curID = rawFilm.ID
+		}
+		if rawFilm.ActorID == nil {
+			continue
+		}
+		films[len(films)-1].Actors = append(films[len(films)-1].Actors, models.ActorBasic{
+			ActorID: *rawFilm.ActorID,
+			Name:    *rawFilm.Name,
+		})
+	}
+
+	return films
+}
@@ -0,0 +1,422 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/sqlite"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilmRepository_Create(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	actorReq1 := &models.ActorRequest{
+		Name:      "Tom Hanks",
+		Gender:    "male",
+		BirthDate: "1956-07-09",
+	}
+
+	actorReq2 := &models.ActorRequest{
+		Name:      "Sophie Patel",
+		Gender:    "female",
+		BirthDate: "1997-11-03",
+	}
+
+	actorID1, _ := s.Actor().Create(actorReq1, models.Author{Login: "admin"})
+	actorID2, _ := s.Actor().Create(actorReq2, models.Author{Login: "admin"})
+
+	filmReq := &models.FilmRequest{
+		Title:       "Cool title",
+		Description: "Detailed description",
+		ReleaseDate: "2020-01-01",
+		Rating:      6.8,
+		ActorsIDs:   []int{actorID1, actorID2, actorID2 + 10},
+	}
+
+	id, err := s.Film().Create(filmReq, models.Author{Login: "admin"})
+	assert.NoError(t, err)
+	assert.NotNil(t, id)
+}
+
+func TestFilmRepository_GetAll(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	actorReq1 := &models.ActorRequest{
+		Name:      "Tom Hanks",
+		Gender:    "male",
+		BirthDate: "1956-07-09",
+	}
+	actorReq2 := &models.ActorRequest{
+		Name:      "Sophie Patel",
+		Gender:    "female",
+		BirthDate: "1997-11-03",
+	}
+	actorID1, _ := s.Actor().Create(actorReq1, models.Author{Login: "admin"})
+	actorID2, _ := s.Actor().Create(actorReq2, models.Author{Login: "admin"})
+
+	filmReq1 := &models.FilmRequest{
+		Title:       "Cool title",
+		Description: "Detailed description",
+		ReleaseDate: "2020-01-01",
+		Rating:      7.8,
+		ActorsIDs:   []int{actorID1},
+	}
+	filmReq2 := &models.FilmRequest{
+		Title:       "Cool title 2",
+		Description: "Detailed description",
+		ReleaseDate: "2020-01-01",
+		Rating:      6.8,
+		ActorsIDs:   []int{actorID1, actorID2},
+	}
+	filmID1, _ := s.Film().Create(filmReq1, models.Author{Login: "admin"})
+	filmID2, _ := s.Film().Create(filmReq2, models.Author{Login: "admin"})
+
+	films, err := s.Film().GetAll(&store.FilmFilter{Sort: []store.SortField{{Field: "rating", Desc: true}}})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(films))
+	for _, film := range films {
+		if film.ID == filmID2 {
+			assert.Contains(t, film.Actors, models.ActorBasic{ActorID: actorID2, Name: actorReq2.Name})
+			assert.Equal(t, filmReq2.Title, film.Title)
+		}
+		if film.ID == filmID1 {
+			assert.Equal(t, filmReq1.Title, film.Title)
+		}
+		assert.Contains(t, film.Actors, models.ActorBasic{ActorID: actorID1, Name: actorReq1.Name})
+	}
+}
+
+func TestFilmRepository_GetAllFiltered(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	actorReq1 := &models.ActorRequest{
+		Name:      "Tom Hanks",
+		Gender:    "male",
+		BirthDate: "1956-07-09",
+	}
+	actorReq2 := &models.ActorRequest{
+		Name:      "Sophie Patel",
+		Gender:    "female",
+		BirthDate: "1997-11-03",
+	}
+	actorID1, _ := s.Actor().Create(actorReq1, models.Author{Login: "admin"})
+	actorID2, _ := s.Actor().Create(actorReq2, models.Author{Login: "admin"})
+
+	filmReq1 := &models.FilmRequest{
+		Title:       "Alpha",
+		Description: "Detailed description",
+		ReleaseDate: "1999-05-01",
+		Rating:      7.8,
+		ActorsIDs:   []int{actorID1, actorID2},
+	}
+	filmReq2 := &models.FilmRequest{
+		Title:       "Beta",
+		ReleaseDate: "2005-01-01",
+		Rating:      7.8,
+		ActorsIDs:   []int{actorID2},
+	}
+	filmReq3 := &models.FilmRequest{
+		Title:       "Gamma_100%",
+		Description: "Detailed description",
+		ReleaseDate: "2015-01-01",
+		Rating:      5.5,
+	}
+	filmID1, _ := s.Film().Create(filmReq1, models.Author{Login: "admin"})
+	filmID2, _ := s.Film().Create(filmReq2, models.Author{Login: "admin"})
+	filmID3, _ := s.Film().Create(filmReq3, models.Author{Login: "admin"})
+
+	ratingMin, ratingMax := 6.0, 8.0
+	hasDescription := false
+
+	var tests = []struct {
+		name        string
+		filter      *store.FilmFilter
+		expectedIDs []int
+	}{
+		{
+			name:        "Rating range",
+			filter:      &store.FilmFilter{RatingMin: &ratingMin, RatingMax: &ratingMax},
+			expectedIDs: []int{filmID1, filmID2},
+		},
+		{
+			name:        "Release date range",
+			filter:      &store.FilmFilter{ReleasedAfter: "2000-01-01", ReleasedBefore: "2010-12-31"},
+			expectedIDs: []int{filmID2},
+		},
+		{
+			name:        "Any of actors",
+			filter:      &store.FilmFilter{ActorIDs: []int{actorID1, actorID2}, ActorMatch: store.ActorMatchAny},
+			expectedIDs: []int{filmID1, filmID2},
+		},
+		{
+			name:        "All of actors",
+			filter:      &store.FilmFilter{ActorIDs: []int{actorID1, actorID2, actorID2}, ActorMatch: store.ActorMatchAll},
+			expectedIDs: []int{filmID1},
+		},
+		{
+			name:        "Without description",
+			filter:      &store.FilmFilter{HasDescription: &hasDescription},
+			expectedIDs: []int{filmID2},
+		},
+		{
+			name:        "Title with wildcard characters",
+			filter:      &store.FilmFilter{SearchTitle: "_100%"},
+			expectedIDs: []int{filmID3},
+		},
+		{
+			name: "Multi-key sort",
+			filter: &store.FilmFilter{Sort: []store.SortField{
+				{Field: "rating", Desc: true},
+				{Field: "title", Desc: true},
+			}},
+			expectedIDs: []int{filmID2, filmID1, filmID3},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			films, err := s.Film().GetAll(tc.filter)
+			assert.NoError(t, err)
+			ids := make([]int, 0, len(films))
+			for _, film := range films {
+				ids = append(ids, film.ID)
+			}
+			assert.Equal(t, tc.expectedIDs, ids)
+		})
+	}
+}
+
+func TestFilmRepository_Delete(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	actorReq := &models.ActorRequest{
+		Name:      "Tom Hanks",
+		Gender:    "male",
+		BirthDate: "1956-07-09",
+	}
+	actorID, _ := s.Actor().Create(actorReq, models.Author{Login: "admin"})
+
+	filmReq := &models.FilmRequest{
+		Title:       "Cool title",
+		Description: "Detailed description",
+		ReleaseDate: "2020-01-01",
+		Rating:      7.8,
+		ActorsIDs:   []int{actorID},
+	}
+	filmId, _ := s.Film().Create(filmReq, models.Author{Login: "admin"})
+
+	done, err := s.Film().Delete(filmId, models.Author{Login: "admin"})
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	done, err = s.Film().Delete(filmId, models.Author{Login: "admin"})
+	assert.NoError(t, err)
+	assert.False(t, done)
+}
+
+func TestFilmRepository_Find(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	actorReq1 := &models.ActorRequest{
+		Name:      "Tom Hanks",
+		Gender:    "male",
+		BirthDate: "1956-07-09",
+	}
+	actorReq2 := &models.ActorRequest{
+		Name:      "Sophie Patel",
+		Gender:    "female",
+		BirthDate: "1997-11-03",
+	}
+	actorID1, _ := s.Actor().Create(actorReq1, models.Author{Login: "admin"})
+	actorID2, _ := s.Actor().Create(actorReq2, models.Author{Login: "admin"})
+
+	filmReq := &models.FilmRequest{
+		Title:       "Cool title",
+		Description: "Detailed description",
+		ReleaseDate: "2020-01-01",
+		Rating:      6.8,
+		ActorsIDs:   []int{actorID1, actorID2},
+	}
+
+	filmID, _ := s.Film().Create(filmReq, models.Author{Login: "admin"})
+
+	film, err := s.Film().Find(filmID)
+	assert.NoError(t, err)
+	assert.Contains(t, film.Actors, models.ActorBasic{ActorID: actorID1, Name: actorReq1.Name})
+	assert.Contains(t, film.Actors, models.ActorBasic{ActorID: actorID2, Name: actorReq2.Name})
+
+	film, err = s.Film().Find(filmID + 10)
+	assert.Nil(t, film)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+}
+
+func TestFilmRepository_Modify(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	actorReq1 := &models.ActorRequest{
+		Name:      "Tom Hanks",
+		Gender:    "male",
+		BirthDate: "1956-07-09",
+	}
+	actorReq2 := &models.ActorRequest{
+		Name:      "Sophie Patel",
+		Gender:    "female",
+		BirthDate: "1997-11-03",
+	}
+	actorID1, _ := s.Actor().Create(actorReq1, models.Author{Login: "admin"})
+	actorID2, _ := s.Actor().Create(actorReq2, models.Author{Login: "admin"})
+
+	filmReq := &models.FilmRequest{
+		Title:       "Cool title",
+		Description: "Detailed description",
+		ReleaseDate: "2020-01-01",
+		Rating:      6.8,
+		ActorsIDs:   []int{actorID1, actorID2},
+	}
+	filmID, _ := s.Film().Create(filmReq, models.Author{Login: "admin"})
+
+	filmReqMod := &models.FilmRequest{
+		Description: "Even more detailed description",
+		Rating:      6.9,
+		ActorsIDs:   []int{actorID2},
+	}
+
+	done, err := s.Film().Modify(filmID, filmReqMod, models.Author{Login: "admin"})
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	done, err = s.Film().Modify(filmID+10, filmReqMod, models.Author{Login: "admin"})
+	assert.NoError(t, err)
+	assert.False(t, done)
+}
+func TestFilmRepository_FuzzySearch(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	actorReq := &models.ActorRequest{
+		Name:      "Leonardo DiCaprio",
+		Gender:    "male",
+		BirthDate: "1974-11-11",
+	}
+	actorID, _ := s.Actor().Create(actorReq, models.Author{Login: "admin"})
+
+	filmReq1 := &models.FilmRequest{
+		Title:       "Titanic",
+		Description: "Detailed description",
+		ReleaseDate: "1997-12-19",
+		Rating:      7.9,
+		ActorsIDs:   []int{actorID},
+	}
+	filmReq2 := &models.FilmRequest{
+		Title:       "Inception",
+		Description: "Detailed description",
+		ReleaseDate: "2010-07-16",
+		Rating:      8.8,
+	}
+	filmID1, _ := s.Film().Create(filmReq1, models.Author{Login: "admin"})
+	s.Film().Create(filmReq2, models.Author{Login: "admin"})
+
+	films, err := s.Film().FuzzySearch("Titanc", "", 0.3)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(films))
+	assert.Equal(t, filmID1, films[0].ID)
+	assert.Contains(t, films[0].Actors, models.ActorBasic{ActorID: actorID, Name: actorReq.Name})
+
+	films, err = s.Film().FuzzySearch("", "Di Caprio", 0.5)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(films))
+	assert.Equal(t, filmID1, films[0].ID)
+
+	films, err = s.Film().FuzzySearch("Inception", "Di Caprio", 0.5)
+	assert.NoError(t, err)
+	assert.Empty(t, films)
+}
+
+func TestFilmRepository_Iterate(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	actorID1, _ := s.Actor().Create(&models.ActorRequest{Name: "Keanu Reeves", Gender: "male", BirthDate: "1964-09-02"}, models.Author{Login: "admin"})
+	actorID2, _ := s.Actor().Create(&models.ActorRequest{Name: "Carrie-Anne Moss", Gender: "female", BirthDate: "1967-08-21"}, models.Author{Login: "admin"})
+	filmID1, _ := s.Film().Create(&models.FilmRequest{Title: "Beta", ReleaseDate: "2005-01-01", Rating: 7.8, ActorsIDs: []int{actorID1, actorID2}}, models.Author{Login: "admin"})
+	filmID2, _ := s.Film().Create(&models.FilmRequest{Title: "Alpha", ReleaseDate: "1999-05-01", Rating: 5.5}, models.Author{Login: "admin"})
+
+	it, err := s.Film().Iterate(&store.FilmFilter{Sort: []store.SortField{{Field: "title"}}})
+	assert.NoError(t, err)
+	defer it.Close()
+
+	var films []models.Film
+	for it.Next() {
+		films = append(films, *it.Film())
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, 2, len(films))
+	assert.Equal(t, filmID2, films[0].ID)
+	assert.Empty(t, films[0].Actors)
+	assert.Equal(t, filmID1, films[1].ID)
+	assert.Equal(t, 2, len(films[1].Actors))
+}
+
+func TestFilmRepository_GetAllProjected(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	actorID, _ := s.Actor().Create(&models.ActorRequest{Name: "Keanu Reeves", Gender: "male", BirthDate: "1964-09-02"}, models.Author{Login: "admin"})
+	filmID, _ := s.Film().Create(&models.FilmRequest{
+		Title:       "Alpha",
+		Description: "Detailed description",
+		ReleaseDate: "1999-05-01",
+		Rating:      7.8,
+		ActorsIDs:   []int{actorID},
+	}, models.Author{Login: "admin"})
+
+	films, err := s.Film().GetAll(&store.FilmFilter{Fields: []string{"title"}, OmitActors: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Film{{ID: filmID, Title: "Alpha"}}, films)
+
+	films, err = s.Film().GetAll(&store.FilmFilter{Fields: []string{"rating"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Film{{ID: filmID, Rating: 7.8, Actors: []models.ActorBasic{{ActorID: actorID, Name: "Keanu Reeves"}}}}, films)
+
+	_, err = s.Film().GetAll(&store.FilmFilter{Fields: []string{"budget"}})
+	assert.Error(t, err)
+}
+
+func TestFilmRepository_GetAllByIDs(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	s.Film().Create(&models.FilmRequest{Title: "Alpha", ReleaseDate: "1999-05-01", Rating: 7.8}, models.Author{Login: "admin"})
+	betaID, _ := s.Film().Create(&models.FilmRequest{Title: "Beta", ReleaseDate: "2001-05-01", Rating: 6.1}, models.Author{Login: "admin"})
+
+	films, err := s.Film().GetAll(&store.FilmFilter{IDs: []int{betaID, betaID + 100}, Fields: []string{"title"}, OmitActors: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Film{{ID: betaID, Title: "Beta"}}, films)
+}
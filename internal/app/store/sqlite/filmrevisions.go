@@ -0,0 +1,202 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/entities"
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+const filmRevisionColumns = "film_id, revision, at, login, request_id, film"
+
+// GetRevisions
+func (r *FilmRepository) GetRevisions(id int) (revisions []models.FilmRevision, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.getRevisions(tx, id)
+}
+
+func (r *FilmRepository) getRevisions(tx *sqlx.Tx, id int) ([]models.FilmRevision, error) {
+	var rawRevisions []entities.FilmRevision
+	err := tx.Select(
+		&rawRevisions,
+		"SELECT "+filmRevisionColumns+" FROM film_revisions WHERE film_id = ?1 ORDER BY revision DESC",
+		id,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+
+	revisions := make([]models.FilmRevision, 0, len(rawRevisions))
+	for i := range rawRevisions {
+		revision, err := filmRevision(&rawRevisions[i])
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *revision)
+	}
+
+	return revisions, nil
+}
+
+// FindRevision
+func (r *FilmRepository) FindRevision(id int, n int) (revision *models.FilmRevision, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.findRevision(tx, id, n)
+}
+
+func (r *FilmRepository) findRevision(tx *sqlx.Tx, id int, n int) (*models.FilmRevision, error) {
+	var rawRevision entities.FilmRevision
+	err := tx.Get(
+		&rawRevision,
+		"SELECT "+filmRevisionColumns+" FROM film_revisions WHERE film_id = ?1 AND revision = ?2",
+		id,
+		n,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+		return nil, errors.Wrap(err, "select")
+	}
+
+	return filmRevision(&rawRevision)
+}
+
+// Revert
+func (r *FilmRepository) Revert(id int, n int, author models.Author) (done bool, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return false, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	revision, err := r.findRevision(tx, id, n)
+	if err != nil {
+		return false, err
+	}
+	before, err := r.snapshot(tx, id)
+	if err != nil || before == nil {
+		return false, err
+	}
+	if done, err = r.revert(tx, id, &revision.Film); !done || err != nil {
+		return done, err
+	}
+
+	return true, r.audit(tx, author, models.AuditActionModify, id, before)
+}
+
+// revert writes every column of the film, the cast is then replaced the way
+// modify does it.
+func (r *FilmRepository) revert(tx *sqlx.Tx, id int, f *models.FilmRequest) (bool, error) {
+	res, err := tx.Exec(
+		"UPDATE films SET title = ?1, description = ?2, release_date = ?3, rating = ?4 WHERE id = ?5 AND deleted_at IS NULL",
+		f.Title,
+		f.Description,
+		f.ReleaseDate,
+		f.Rating,
+		id,
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "update films")
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "rows affected")
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	return r.modify(tx, id, &models.FilmRequest{ActorsIDs: f.ActorsIDs})
+}
+
+func filmRevision(rawRevision *entities.FilmRevision) (*models.FilmRevision, error) {
+	revision := &models.FilmRevision{
+		FilmID:    rawRevision.FilmID,
+		Revision:  rawRevision.Revision,
+		At:        rawRevision.At,
+		Login:     rawRevision.Login,
+		RequestID: rawRevision.RequestID,
+	}
+	if err := json.Unmarshal(rawRevision.Film, &revision.Film); err != nil {
+		return nil, errors.Wrap(err, "decode film")
+	}
+
+	return revision, nil
+}
+
+// insertFilmRevision adds the snapshot of the film as its next revision.
+func insertFilmRevision(tx *sqlx.Tx, author models.Author, id int, film store.Snapshot) error {
+	content, err := json.Marshal(film)
+	if err != nil {
+		return errors.Wrap(err, "encode film")
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO film_revisions (film_id, revision, login, request_id, film)
+		SELECT ?1, coalesce(max(revision), 0) + 1, ?2, ?3, ?4 FROM film_revisions WHERE film_id = ?1`,
+		id,
+		author.Login,
+		author.RequestID,
+		string(content),
+	)
+	if err != nil {
+		return errors.Wrap(err, "insert into film_revisions")
+	}
+
+	return nil
+}
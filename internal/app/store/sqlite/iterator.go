@@ -0,0 +1,85 @@
+package sqlite
+
+import (
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+// Iterate returns films matching the filter in the order of GetAll. The pool
+// has a single connection, so rows are read up front rather than through a
+// cursor that would hold it.
+func (r *FilmRepository) Iterate(filter *store.FilmFilter) (store.FilmIterator, error) {
+	films, err := r.GetAll(filter)
+	if err != nil {
+		return nil, err
+	}
+	return &filmIterator{films: films, pos: -1}, nil
+}
+
+type filmIterator struct {
+	films []models.Film
+	pos   int
+}
+
+// Next
+func (it *filmIterator) Next() bool {
+	if it.pos+1 >= len(it.films) {
+		return false
+	}
+	it.pos++
+	return true
+}
+
+// Film
+func (it *filmIterator) Film() *models.Film {
+	return &it.films[it.pos]
+}
+
+// Err
+func (it *filmIterator) Err() error {
+	return nil
+}
+
+// Close
+func (it *filmIterator) Close() error {
+	return nil
+}
+
+// Iterate returns actors matching the filter in the order of GetAll, read up
+// front like films.
+func (r *ActorRepository) Iterate(filter *store.ActorFilter) (store.ActorIterator, error) {
+	actors, err := r.GetAll(filter)
+	if err != nil {
+		return nil, err
+	}
+	return &actorIterator{actors: actors, pos: -1}, nil
+}
+
+type actorIterator struct {
+	actors []models.Actor
+	pos    int
+}
+
+// Next
+func (it *actorIterator) Next() bool {
+	if it.pos+1 >= len(it.actors) {
+		return false
+	}
+	it.pos++
+	return true
+}
+
+// Actor
+func (it *actorIterator) Actor() *models.Actor {
+	return &it.actors[it.pos]
+}
+
+// Err
+func (it *actorIterator) Err() error {
+	return nil
+}
+
+// Close
+func (it *actorIterator) Close() error {
+	return nil
+}
@@ -0,0 +1,76 @@
+package sqlite
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// migrate applies the migrations the database has not seen yet. The number of
+// applied migrations is kept in the user_version pragma, so files must only
+// ever be appended.
+func migrate(db *sqlx.DB) error {
+	names, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	var version int
+	if err := db.Get(&version, "PRAGMA user_version"); err != nil {
+		return errors.Wrap(err, "read schema version")
+	}
+	if version > len(names) {
+		return errors.Errorf("schema version %d is newer than this build", version)
+	}
+
+	for i := version; i < len(names); i++ {
+		if err := apply(db, names[i], i+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func apply(db *sqlx.DB, name string, version int) (err error) {
+	script, err := migrations.ReadFile(name)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	if _, err := tx.Exec(string(script)); err != nil {
+		return errors.Wrap(err, name)
+	}
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version)); err != nil {
+		return errors.Wrap(err, "write schema version")
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+create table films(
+    id integer primary key autoincrement,
+    title text not null check(length(title) > 0 and length(title) <= 150),
+    description text check(length(description) <= 1000),
+    release_date text check(release_date is null or date(release_date) = release_date),
+    rating real check (rating >= 0 and rating <= 10),
+    external_id text unique
+);
+
+create table actors(
+    id integer primary key autoincrement,
+    name text not null check(length(name) <= 100),
+    gender text,
+    birth_date text check(birth_date is null or date(birth_date) = birth_date),
+    external_id text unique
+);
+
+create table films_x_actors(
+    film_id integer references films(id),
+    actor_id integer references actors(id)
+);
+
+create unique index films_x_actors_idx on films_x_actors (film_id, actor_id);
+create index films_x_actors_actor_idx on films_x_actors (actor_id);
+
+create table users(
+    login text primary key,
+    hashed_password text,
+    is_admin boolean
+);
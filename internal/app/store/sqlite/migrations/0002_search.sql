@@ -0,0 +1,43 @@
+-- Full text indexes are external content tables kept in sync by triggers.
+-- Films are stemmed with porter, which only knows english, actor names are
+-- not stemmed.
+create virtual table films_fts using fts5(
+    title,
+    description,
+    content='films',
+    content_rowid='id',
+    tokenize='porter unicode61 remove_diacritics 2'
+);
+
+create trigger films_fts_insert after insert on films begin
+    insert into films_fts (rowid, title, description) values (new.id, new.title, coalesce(new.description, ''));
+end;
+
+create trigger films_fts_delete after delete on films begin
+    insert into films_fts (films_fts, rowid, title, description) values ('delete', old.id, old.title, coalesce(old.description, ''));
+end;
+
+create trigger films_fts_update after update of title, description on films begin
+    insert into films_fts (films_fts, rowid, title, description) values ('delete', old.id, old.title, coalesce(old.description, ''));
+    insert into films_fts (rowid, title, description) values (new.id, new.title, coalesce(new.description, ''));
+end;
+
+create virtual table actors_fts using fts5(
+    name,
+    content='actors',
+    content_rowid='id',
+    tokenize='unicode61 remove_diacritics 2'
+);
+
+create trigger actors_fts_insert after insert on actors begin
+    insert into actors_fts (rowid, name) values (new.id, new.name);
+end;
+
+create trigger actors_fts_delete after delete on actors begin
+    insert into actors_fts (actors_fts, rowid, name) values ('delete', old.id, old.name);
+end;
+
+create trigger actors_fts_update after update of name on actors begin
+    insert into actors_fts (actors_fts, rowid, name) values ('delete', old.id, old.name);
+    insert into actors_fts (rowid, name) values (new.id, new.name);
+end;
@@ -0,0 +1,10 @@
+-- Deleted films and actors stay in the trash until they are restored or
+-- purged, their cast links are kept meanwhile. Deletion times are text in
+-- models.TimestampLayout.
+alter table films add column deleted_at text;
+alter table films add column deleted_by text;
+alter table actors add column deleted_at text;
+alter table actors add column deleted_by text;
+
+create index films_deleted_at_idx on films (deleted_at) where deleted_at is not null;
+create index actors_deleted_at_idx on actors (deleted_at) where deleted_at is not null;
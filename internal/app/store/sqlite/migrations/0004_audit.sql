@@ -0,0 +1,16 @@
+-- Every change of a film, actor or user, written in the transaction of the
+-- change itself. entity_id is the login for users, at is text in
+-- models.TimestampLayout.
+create table audit_events(
+    id integer primary key autoincrement,
+    at text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    login text not null,
+    request_id text not null,
+    entity text not null,
+    entity_id text not null,
+    action text not null,
+    diff text not null
+);
+
+create index audit_events_entity_idx on audit_events (entity, entity_id, id);
+create index audit_events_at_idx on audit_events (at);
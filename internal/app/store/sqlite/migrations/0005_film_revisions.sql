@@ -0,0 +1,12 @@
+-- Every version of a film, numbered from 1 for each film. film is the
+-- models.FilmRequest that brings the film back to the revision, at is text in
+-- models.TimestampLayout.
+create table film_revisions(
+    film_id integer not null references films(id),
+    revision integer not null,
+    at text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    login text not null,
+    request_id text not null,
+    film text not null,
+    primary key (film_id, revision)
+);
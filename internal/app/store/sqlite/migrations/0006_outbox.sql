@@ -0,0 +1,11 @@
+-- Outbox of film and actor changes for downstream consumers, written in the
+-- transaction of the change. at is text in models.TimestampLayout.
+create table change_events(
+    seq integer primary key autoincrement,
+    at text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    entity text not null,
+    entity_id integer not null,
+    action text not null
+);
+
+create index change_events_at_idx on change_events (at);
@@ -0,0 +1,28 @@
+-- Webhook subscriptions, events is a JSON array of event types. last_seq is
+-- the last change event the webhook has been given deliveries for. Times are
+-- text in models.TimestampLayout.
+create table webhooks(
+    id integer primary key autoincrement,
+    url text not null,
+    events text not null,
+    secret text not null,
+    last_seq integer not null,
+    created_at text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+);
+
+create table webhook_deliveries(
+    id integer primary key autoincrement,
+    webhook_id integer not null references webhooks(id),
+    event text not null,
+    payload text not null,
+    status text not null,
+    attempts integer not null default 0,
+    response_code integer not null default 0,
+    error text not null default '',
+    created_at text not null default (strftime('%Y-%m-%dT%H:%M:%SZ', 'now')),
+    next_attempt_at text,
+    delivered_at text
+);
+
+create index webhook_deliveries_webhook_idx on webhook_deliveries (webhook_id, id);
+create index webhook_deliveries_due_idx on webhook_deliveries (next_attempt_at) where status = 'pending';
@@ -0,0 +1,107 @@
+package sqlite
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+)
+
+// OutboxRepository
+type OutboxRepository struct {
+	store *Store
+}
+
+// List
+func (r *OutboxRepository) List(after int, limit int) (events []models.ChangeEvent, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.list(tx, after, limit)
+}
+
+func (r *OutboxRepository) list(tx *sqlx.Tx, after int, limit int) ([]models.ChangeEvent, error) {
+	query := "SELECT seq, at, entity, entity_id, action FROM change_events WHERE seq > ?1 ORDER BY seq"
+	args := []any{after}
+	if limit > 0 {
+		query += " LIMIT ?2"
+		args = append(args, limit)
+	}
+
+	events := make([]models.ChangeEvent, 0)
+	err := tx.Select(&events, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+
+	return events, nil
+}
+
+// Purge
+func (r *OutboxRepository) Purge(before time.Time) (purged int, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return 0, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	// Events not yet given to every webhook are kept, whatever their age.
+	purged, err = execCount(
+		tx,
+		"DELETE FROM change_events WHERE at < ?1 AND seq <= coalesce((SELECT min(last_seq) FROM webhooks), seq)",
+		before.UTC().Format(models.TimestampLayout),
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "delete from change_events")
+	}
+
+	return purged, nil
+}
+
+// insertChangeEvent appends the change of a film or an actor to the outbox.
+// The store has a single connection, so transactions commit in the order
+// they take their sequence values.
+func insertChangeEvent(tx *sqlx.Tx, entity string, id int, action string) error {
+	_, err := tx.Exec(
+		"INSERT INTO change_events (entity, entity_id, action) VALUES (?1, ?2, ?3)",
+		entity,
+		id,
+		action,
+	)
+	if err != nil {
+		return errors.Wrap(err, "insert into change_events")
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package sqlite
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+)
+
+// snippetTokens is the size of the description fragment shown in film results.
+const snippetTokens = 32
+
+// SearchRepository
+type SearchRepository struct {
+	store *Store
+}
+
+// FullText
+func (r *SearchRepository) FullText(query string, limit int) (results []models.SearchResult, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.fullText(tx, query, limit)
+}
+
+func (r *SearchRepository) fullText(tx *sqlx.Tx, query string, limit int) ([]models.SearchResult, error) {
+	results := make([]models.SearchResult, 0)
+
+	match := matchQuery(query)
+	if match == "" {
+		return results, nil
+	}
+
+	// Films are stemmed by the porter tokenizer of films_fts, which only knows
+	// english, actor names are not stemmed. bm25 is lower for better matches,
+	// so it is negated to rank like ts_rank does.
+	err := tx.Select(
+		&results,
+		`SELECT type, id, label, snippet, rank FROM (
+			SELECT
+				'film' AS type,
+				f.id,
+				f.title AS label,
+				highlight(films_fts, 0, '<b>', '</b>') || '. ' || snippet(films_fts, 1, '<b>', '</b>', '...', ?2) AS snippet,
+				-bm25(films_fts, 2.0, 1.0) AS rank
+			FROM
+				films_fts
+			INNER JOIN
+				films f ON f.id = films_fts.rowid
+			WHERE films_fts MATCH ?1 AND f.deleted_at IS NULL
+			UNION ALL
+			SELECT
+				'actor' AS type,
+				a.id,
+				a.name AS label,
+				highlight(actors_fts, 0, '<b>', '</b>') AS snippet,
+				-bm25(actors_fts) AS rank
+			FROM
+				actors_fts
+			INNER JOIN
+				actors a ON a.id = actors_fts.rowid
+			WHERE actors_fts MATCH ?1 AND a.deleted_at IS NULL
+		) found
+		ORDER BY rank DESC, type, id
+		LIMIT ?3`,
+		match,
+		snippetTokens,
+		limit,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+
+	return results, nil
+}
+
+// matchQuery turns a user query into an FTS5 query requiring every word. The
+// words are quoted, so nothing in the query is taken for FTS5 syntax.
+func matchQuery(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = `"` + word + `"`
+	}
+	return strings.Join(words, " ")
+}
+
+// Suggest
+func (r *SearchRepository) Suggest(prefix string, kind string, limit int) (suggestions []models.Suggestion, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.suggest(tx, prefix, kind, limit)
+}
+
+func (r *SearchRepository) suggest(tx *sqlx.Tx, prefix string, kind string, limit int) ([]models.Suggestion, error) {
+	// SQLite does not allow ORDER BY and LIMIT in a compound member, so each
+	// branch is wrapped in a subquery of its own.
+	films := `SELECT * FROM (SELECT id, title AS label, 'film' AS type FROM films WHERE casefold(title) LIKE ?1 ESCAPE '\' AND deleted_at IS NULL ORDER BY casefold(title), id LIMIT ?2)`
+	actors := `SELECT * FROM (SELECT id, name AS label, 'actor' AS type FROM actors WHERE casefold(name) LIKE ?1 ESCAPE '\' AND deleted_at IS NULL ORDER BY casefold(name), id LIMIT ?2)`
+
+	var branches string
+	switch kind {
+	case models.SearchTypeFilm:
+		branches = films
+	case models.SearchTypeActor:
+		branches = actors
+	default:
+		branches = films + " UNION ALL " + actors
+	}
+
+	suggestions := make([]models.Suggestion, 0)
+	err := tx.Select(
+		&suggestions,
+		"SELECT id, label, type FROM ("+branches+") found ORDER BY casefold(label), type, id LIMIT ?2",
+		likePrefix(prefix),
+		limit,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+
+	return suggestions, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likePrefix builds a LIKE pattern matching casefolded strings that start
+// with prefix.
+func likePrefix(prefix string) string {
+	return likeEscaper.Replace(strings.ToLower(prefix)) + "%"
+}
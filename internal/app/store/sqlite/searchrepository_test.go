@@ -0,0 +1,104 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/sqlite"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchRepository_FullText(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	actorReq := &models.ActorRequest{
+		Name:      "Tom Hanks",
+		Gender:    "male",
+		BirthDate: "1956-07-09",
+	}
+	actorID, _ := s.Actor().Create(actorReq, models.Author{Login: "admin"})
+
+	filmReq1 := &models.FilmRequest{
+		Title:       "Forrest Gump",
+		Description: "A man runs across the country",
+		ReleaseDate: "1994-07-06",
+		Rating:      8.8,
+		ActorsIDs:   []int{actorID},
+	}
+	filmReq2 := &models.FilmRequest{
+		Title:       "Брат",
+		Description: "Демобилизованный Данила Багров приезжает в Петербург к старшему брату",
+		ReleaseDate: "1997-12-12",
+		Rating:      8.3,
+	}
+	filmID1, _ := s.Film().Create(filmReq1, models.Author{Login: "admin"})
+	filmID2, _ := s.Film().Create(filmReq2, models.Author{Login: "admin"})
+
+	results, err := s.Search().FullText("running", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(results))
+	assert.Equal(t, models.SearchTypeFilm, results[0].Type)
+	assert.Equal(t, filmID1, results[0].ID)
+	assert.Contains(t, results[0].Snippet, "<b>runs</b>")
+
+	results, err = s.Search().FullText("брату", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(results))
+	assert.Equal(t, filmID2, results[0].ID)
+
+	results, err = s.Search().FullText("hanks", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(results))
+	assert.Equal(t, models.SearchTypeActor, results[0].Type)
+	assert.Equal(t, actorID, results[0].ID)
+
+	results, err = s.Search().FullText("nothing", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestSearchRepository_Suggest(t *testing.T) {
+	db, teardown := sqlite.TestDB(t)
+	defer teardown()
+
+	s := sqlite.New(db)
+
+	actorReq := &models.ActorRequest{
+		Name:      "Tom Hanks",
+		Gender:    "male",
+		BirthDate: "1956-07-09",
+	}
+	actorID, _ := s.Actor().Create(actorReq, models.Author{Login: "admin"})
+
+	filmReq1 := &models.FilmRequest{
+		Title:       "Toy Story",
+		ReleaseDate: "1995-11-22",
+		Rating:      8.3,
+	}
+	filmReq2 := &models.FilmRequest{
+		Title:       "Top_Gun",
+		ReleaseDate: "1986-05-16",
+		Rating:      6.9,
+	}
+	filmID1, _ := s.Film().Create(filmReq1, models.Author{Login: "admin"})
+	filmID2, _ := s.Film().Create(filmReq2, models.Author{Login: "admin"})
+
+	suggestions, err := s.Search().Suggest("to", "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Suggestion{
+		{ID: actorID, Label: "Tom Hanks", Type: models.SearchTypeActor},
+		{ID: filmID2, Label: "Top_Gun", Type: models.SearchTypeFilm},
+		{ID: filmID1, Label: "Toy Story", Type: models.SearchTypeFilm},
+	}, suggestions)
+
+	suggestions, err = s.Search().Suggest("top_", models.SearchTypeFilm, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Suggestion{{ID: filmID2, Label: "Top_Gun", Type: models.SearchTypeFilm}}, suggestions)
+
+	suggestions, err = s.Search().Suggest("to%", "", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, suggestions)
+}
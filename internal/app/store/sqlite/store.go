@@ -0,0 +1,183 @@
+// Package sqlite keeps the store in a single SQLite file. It needs no server
+// and no cgo, and behaves like the postgres store as far as the API can tell.
+package sqlite
+
+import (
+	"strings"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// Store
+type Store struct {
+	db                *sqlx.DB
+	userRepository    *UserRepository
+	filmRepository    *FilmRepository
+	actorRepository   *ActorRepository
+	searchRepository  *SearchRepository
+	catalogRepository *CatalogRepository
+	trashRepository   *TrashRepository
+	auditRepository   *AuditRepository
+	outboxRepository  *OutboxRepository
+	webhookRepository *WebhookRepository
+}
+
+// New
+func New(db *sqlx.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+// User
+func (s *Store) User() store.UserRepository {
+	if s.userRepository != nil {
+		return s.userRepository
+	}
+
+	s.userRepository = &UserRepository{
+		store: s,
+	}
+
+	return s.userRepository
+}
+
+// Film
+func (s *Store) Film() store.FilmRepository {
+	if s.filmRepository != nil {
+		return s.filmRepository
+	}
+
+	s.filmRepository = &FilmRepository{
+		store: s,
+	}
+
+	return s.filmRepository
+}
+
+// Actor
+func (s *Store) Actor() store.ActorRepository {
+	if s.actorRepository != nil {
+		return s.actorRepository
+	}
+
+	s.actorRepository = &ActorRepository{
+		store: s,
+	}
+
+	return s.actorRepository
+}
+
+// Search
+func (s *Store) Search() store.SearchRepository {
+	if s.searchRepository != nil {
+		return s.searchRepository
+	}
+
+	s.searchRepository = &SearchRepository{
+		store: s,
+	}
+
+	return s.searchRepository
+}
+
+// Catalog
+func (s *Store) Catalog() store.CatalogRepository {
+	if s.catalogRepository != nil {
+		return s.catalogRepository
+	}
+
+	s.catalogRepository = &CatalogRepository{
+		store: s,
+	}
+
+	return s.catalogRepository
+}
+
+// Trash
+func (s *Store) Trash() store.TrashRepository {
+	if s.trashRepository != nil {
+		return s.trashRepository
+	}
+
+	s.trashRepository = &TrashRepository{
+		store: s,
+	}
+
+	return s.trashRepository
+}
+
+// Audit
+func (s *Store) Audit() store.AuditRepository {
+	if s.auditRepository != nil {
+		return s.auditRepository
+	}
+
+	s.auditRepository = &AuditRepository{
+		store: s,
+	}
+
+	return s.auditRepository
+}
+
+// Outbox
+func (s *Store) Outbox() store.OutboxRepository {
+	if s.outboxRepository != nil {
+		return s.outboxRepository
+	}
+
+	s.outboxRepository = &OutboxRepository{
+		store: s,
+	}
+
+	return s.outboxRepository
+}
+
+// Webhook
+func (s *Store) Webhook() store.WebhookRepository {
+	if s.webhookRepository != nil {
+		return s.webhookRepository
+	}
+
+	s.webhookRepository = &WebhookRepository{
+		store: s,
+	}
+
+	return s.webhookRepository
+}
+
+// projection lists the columns for the requested fields, all of them when
+// fields is empty. The id always comes first since rows are grouped by it.
+func projection(columns map[string]string, order []string, fields []string) (string, error) {
+	if len(fields) == 0 {
+		fields = order
+	}
+
+	selected := []string{columns["id"]}
+	seen := map[string]bool{"id": true}
+	for _, field := range fields {
+		column, ok := columns[field]
+		if !ok {
+			return "", errors.Errorf("unknown field %q", field)
+		}
+		if seen[field] {
+			continue
+		}
+		seen[field] = true
+		selected = append(selected, column)
+	}
+
+	return strings.Join(selected, ", "), nil
+}
+
+// nowTimestamp is the current time as text in models.TimestampLayout, which
+// is how deletion times are kept so that they compare as strings.
+const nowTimestamp = "strftime('%Y-%m-%dT%H:%M:%SZ', 'now')"
+
+// deletionColumns selects when and by whom the rows of table alias t were
+// deleted, empty for rows that are not in the trash.
+func deletionColumns(t string) string {
+	return "coalesce(" + t + ".deleted_at, '') AS deleted_at, coalesce(" + t + ".deleted_by, '') AS deleted_by"
+}
@@ -0,0 +1,42 @@
+package sqlite_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/sqlite"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/storetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) (store.Store, func()) {
+		db, teardown := sqlite.TestDB(t)
+		return sqlite.New(db), teardown
+	})
+}
+
+func TestOpen_KeepsData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "films.db")
+
+	db, err := sqlite.Open(path)
+	require.NoError(t, err)
+	id, err := sqlite.New(db).Film().Create(&models.FilmRequest{Title: "Alpha", ReleaseDate: "1999-05-01", Rating: 7.8}, models.Author{Login: "admin"})
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db, err = sqlite.Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	film, err := sqlite.New(db).Film().Find(id)
+	assert.NoError(t, err)
+	assert.Equal(t, "Alpha", film.Title)
+
+	results, err := sqlite.New(db).Search().FullText("alpha", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(results))
+}
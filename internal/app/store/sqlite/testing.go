@@ -0,0 +1,22 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// TestDB opens a fresh in-memory database, which goes away once the returned
+// function closes it.
+func TestDB(t *testing.T) (*sqlx.DB, func()) {
+	t.Helper()
+
+	db, err := Open(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db, func() {
+		db.Close()
+	}
+}
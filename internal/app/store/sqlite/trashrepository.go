@@ -0,0 +1,117 @@
+package sqlite
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+)
+
+// TrashRepository
+type TrashRepository struct {
+	store *Store
+}
+
+// List returns deleted films and actors, the latest deletions first.
+func (r *TrashRepository) List() (records []models.DeletedRecord, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.list(tx)
+}
+
+func (r *TrashRepository) list(tx *sqlx.Tx) ([]models.DeletedRecord, error) {
+	records := make([]models.DeletedRecord, 0)
+	err := tx.Select(
+		&records,
+		`SELECT type, id, label, deleted_at, deleted_by FROM (
+			SELECT 'film' AS type, id, title AS label, deleted_at, coalesce(deleted_by, '') AS deleted_by
+			FROM films
+			WHERE deleted_at IS NOT NULL
+			UNION ALL
+			SELECT 'actor' AS type, id, name AS label, deleted_at, coalesce(deleted_by, '') AS deleted_by
+			FROM actors
+			WHERE deleted_at IS NOT NULL
+		) deleted
+		ORDER BY deleted.deleted_at DESC, type, id`,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+
+	return records, nil
+}
+
+// Purge
+func (r *TrashRepository) Purge(before time.Time) (purged int, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return 0, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.purge(tx, before)
+}
+
+func (r *TrashRepository) purge(tx *sqlx.Tx, before time.Time) (int, error) {
+	cutoff := before.UTC().Format(models.TimestampLayout)
+	_, err := tx.Exec(
+		`DELETE FROM films_x_actors
+		WHERE film_id IN (SELECT id FROM films WHERE deleted_at < ?1)
+			OR actor_id IN (SELECT id FROM actors WHERE deleted_at < ?1)`,
+		cutoff,
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "delete from films_x_actors")
+	}
+
+	_, err = tx.Exec(
+		"DELETE FROM film_revisions WHERE film_id IN (SELECT id FROM films WHERE deleted_at < ?1)",
+		cutoff,
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "delete from film_revisions")
+	}
+
+	films, err := execCount(tx, "DELETE FROM films WHERE deleted_at < ?1", cutoff)
+	if err != nil {
+		return 0, errors.Wrap(err, "delete from films")
+	}
+	actors, err := execCount(tx, "DELETE FROM actors WHERE deleted_at < ?1", cutoff)
+	if err != nil {
+		return 0, errors.Wrap(err, "delete from actors")
+	}
+
+	return films + actors, nil
+}
@@ -0,0 +1,332 @@
+package sqlite
+
+import (
+	"database/sql"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/hasher"
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// UserRepository
+type UserRepository struct {
+	store *Store
+}
+
+// Create
+func (r *UserRepository) Create(u *models.UserRequest, author models.Author) (done bool, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return false, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	if done, err = r.create(tx, u); !done || err != nil {
+		return done, err
+	}
+
+	return true, r.audit(tx, author, models.AuditActionCreate, u.Login, nil)
+}
+
+func (r *UserRepository) create(tx *sqlx.Tx, u *models.UserRequest) (bool, error) {
+	hashedPassword, err := hasher.HashPassword(u.Password)
+	if err != nil {
+		return false, errors.Wrap(err, "encryption")
+	}
+
+	res, err := tx.Exec(
+		"INSERT INTO users (login, hashed_password, is_admin) VALUES (?1, ?2, ?3) ON CONFLICT DO NOTHING",
+		u.Login,
+		hashedPassword,
+		u.IsAdmin,
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "insert")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "rows affected")
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// Find
+func (r *UserRepository) Find(login string) (*models.User, error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.find(tx, login)
+}
+
+func (r *UserRepository) find(tx *sqlx.Tx, login string) (*models.User, error) {
+	u := &models.User{}
+	err := tx.Get(
+		u,
+		"SELECT * FROM users WHERE login = ?1",
+		login)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return u, nil
+}
+
+// snapshot returns the audited state of the user, nil when there is no such
+// user.
+func (r *UserRepository) snapshot(tx *sqlx.Tx, login string) (store.Snapshot, error) {
+	user, err := r.find(tx, login)
+	if err == store.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return store.UserSnapshot(user), nil
+}
+
+// audit records how the user changed since before.
+func (r *UserRepository) audit(tx *sqlx.Tx, author models.Author, action string, login string, before store.Snapshot) error {
+	after, err := r.snapshot(tx, login)
+	if err != nil {
+		return err
+	}
+	diff, err := store.Diff(before, after)
+	if err != nil {
+		return errors.Wrap(err, "diff")
+	}
+
+	return insertAuditEvent(tx, author, models.AuditEntityUser, login, action, diff)
+}
+
+// GetAll
+func (r *UserRepository) GetAll() ([]models.User, error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.getAll(tx)
+}
+
+func (r *UserRepository) getAll(tx *sqlx.Tx) ([]models.User, error) {
+	users := make([]models.User, 0)
+	err := tx.Select(
+		&users,
+		"SELECT * FROM users",
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "select")
+	}
+	return users, nil
+}
+
+// SetAdmin
+func (r *UserRepository) SetAdmin(login string, isAdmin bool, author models.Author) (done bool, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return false, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.setAdmin(tx, login, isAdmin, author)
+}
+
+func (r *UserRepository) setAdmin(tx *sqlx.Tx, login string, isAdmin bool, author models.Author) (bool, error) {
+	before, err := r.snapshot(tx, login)
+	if err != nil || before == nil {
+		return false, err
+	}
+	if done, err := r.update(tx, "UPDATE users SET is_admin = ?1 WHERE login = ?2", isAdmin, login); !done || err != nil {
+		return done, err
+	}
+
+	return true, r.audit(tx, author, models.AuditActionModify, login, before)
+}
+
+// SetPassword
+func (r *UserRepository) SetPassword(login string, password string, author models.Author) (done bool, err error) {
+	hashedPassword, err := hasher.HashPassword(password)
+	if err != nil {
+		return false, errors.Wrap(err, "encryption")
+	}
+
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return false, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.setPassword(tx, login, hashedPassword, author)
+}
+
+func (r *UserRepository) setPassword(tx *sqlx.Tx, login string, hashedPassword string, author models.Author) (bool, error) {
+	if done, err := r.update(tx, "UPDATE users SET hashed_password = ?1 WHERE login = ?2", hashedPassword, login); !done || err != nil {
+		return done, err
+	}
+
+	return true, insertAuditEvent(tx, author, models.AuditEntityUser, login, models.AuditActionModify, store.PasswordDiff)
+}
+
+// Modify applies the whole update in one transaction.
+func (r *UserRepository) Modify(login string, u *models.UserUpdate, author models.Author) (done bool, err error) {
+	hashedPassword := ""
+	if u.Password != nil {
+		if hashedPassword, err = hasher.HashPassword(*u.Password); err != nil {
+			return false, errors.Wrap(err, "encryption")
+		}
+	}
+
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return false, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.modify(tx, login, u.IsAdmin, hashedPassword, author)
+}
+
+func (r *UserRepository) modify(tx *sqlx.Tx, login string, isAdmin *bool, hashedPassword string, author models.Author) (bool, error) {
+	if hashedPassword != "" {
+		if done, err := r.setPassword(tx, login, hashedPassword, author); !done || err != nil {
+			return done, err
+		}
+	}
+	if isAdmin != nil {
+		if done, err := r.setAdmin(tx, login, *isAdmin, author); !done || err != nil {
+			return done, err
+		}
+	}
+
+	return true, nil
+}
+
+// ReplaceHash stores newHash unless the password changed since oldHash was
+// read.
+func (r *UserRepository) ReplaceHash(login string, oldHash string, newHash string) (done bool, err error) {
+	tx, err := r.store.db.Beginx()
+	if err != nil {
+		return false, errors.Wrap(err, "could not start transaction")
+	}
+
+	defer func() {
+		if err != nil {
+			errRb := tx.Rollback()
+			if errRb != nil {
+				err = errors.Wrap(err, "error during rollback")
+				return
+			}
+
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return r.update(tx, "UPDATE users SET hashed_password = ?1 WHERE login = ?2 AND hashed_password = ?3", newHash, login, oldHash)
+}
+
+func (r *UserRepository) update(tx *sqlx.Tx, query string, args ...any) (bool, error) {
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		return false, errors.Wrap(err, "update")
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "rows affected")
+	}
+
+	return rowsAffected > 0, nil
+}
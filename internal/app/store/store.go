@@ -5,4 +5,10 @@ type Store interface {
 	User() UserRepository
 	Film() FilmRepository
 	Actor() ActorRepository
+	Search() SearchRepository
+	Catalog() CatalogRepository
+	Trash() TrashRepository
+	Audit() AuditRepository
+	Outbox() OutboxRepository
+	Webhook() WebhookRepository
 }
@@ -0,0 +1,244 @@
+package storetest
+
+import (
+	"testing"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runActors(t *testing.T, newStore Factory) {
+	runTests(t, newStore, []test{
+		{"Not found", testActorNotFound},
+		{"Find lists films", testActorFind},
+		{"Ids are not reused", testActorIDsNotReused},
+		{"Partial modify", testActorPartialModify},
+		{"Delete unlinks films", testActorDeleteCascade},
+		{"Ordering", testActorOrdering},
+		{"Search", testActorSearch},
+		{"Fuzzy search", testActorFuzzySearch},
+		{"Iterate", testActorIterate},
+	})
+}
+
+func testActorNotFound(t *testing.T, s store.Store) {
+	id := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+
+	actor, err := s.Actor().Find(id + 100)
+	assert.Nil(t, actor)
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+
+	done, err := s.Actor().Modify(id+100, &models.ActorRequest{Name: "Tom Hardy"}, author)
+	assert.NoError(t, err)
+	assert.False(t, done)
+
+	done, err = s.Actor().Delete(id+100, author)
+	assert.NoError(t, err)
+	assert.False(t, done)
+}
+
+func testActorFind(t *testing.T, s store.Store) {
+	id := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	filmID1 := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.8, ActorsIDs: []int{id}})
+	filmID2 := createFilm(t, s, &models.FilmRequest{Title: "Cast Away", ReleaseDate: "2000-12-22", Rating: 7.8, ActorsIDs: []int{id}})
+	createFilm(t, s, &models.FilmRequest{Title: "Gladiator", ReleaseDate: "2000-05-05", Rating: 8.5})
+
+	actor, err := s.Actor().Find(id)
+	require.NoError(t, err)
+	assert.Equal(t, id, actor.ID)
+	assert.Equal(t, "Tom Hanks", actor.Name)
+	assert.Equal(t, "male", actor.Gender)
+	assert.Equal(t, "1956-07-09", actor.BirthDate)
+	assert.ElementsMatch(t, []models.FilmBasic{
+		{FilmID: filmID1, Title: "Forrest Gump"},
+		{FilmID: filmID2, Title: "Cast Away"},
+	}, actor.Films)
+}
+
+func testActorIDsNotReused(t *testing.T, s store.Store) {
+	id1 := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	id2 := createActor(t, s, "Tom Hardy", "male", "1977-09-15")
+
+	done, err := s.Actor().Delete(id2, author)
+	require.NoError(t, err)
+	require.True(t, done)
+
+	id3 := createActor(t, s, "Emily Blunt", "female", "1983-02-23")
+	assert.NotEqual(t, id1, id3)
+	assert.NotEqual(t, id2, id3)
+}
+
+func testActorPartialModify(t *testing.T, s store.Store) {
+	id := createActor(t, s, "Tom Hank", "male", "1959-07-09")
+	filmID := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.8, ActorsIDs: []int{id}})
+
+	done, err := s.Actor().Modify(id, &models.ActorRequest{Name: "Tom Hanks"}, author)
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	done, err = s.Actor().Modify(id, &models.ActorRequest{BirthDate: "1956-07-09"}, author)
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	actor, err := s.Actor().Find(id)
+	require.NoError(t, err)
+	assert.Equal(t, "Tom Hanks", actor.Name)
+	assert.Equal(t, "male", actor.Gender)
+	assert.Equal(t, "1956-07-09", actor.BirthDate)
+
+	film, err := s.Film().Find(filmID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ActorBasic{{ActorID: id, Name: "Tom Hanks"}}, film.Actors)
+}
+
+func testActorDeleteCascade(t *testing.T, s store.Store) {
+	id1 := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	id2 := createActor(t, s, "Robin Wright", "female", "1966-04-08")
+	filmID := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.8, ActorsIDs: []int{id1, id2}})
+
+	done, err := s.Actor().Delete(id1, author)
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	film, err := s.Film().Find(filmID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ActorBasic{{ActorID: id2, Name: "Robin Wright"}}, film.Actors)
+
+	films, err := s.Film().GetAll(&store.FilmFilter{ActorIDs: []int{id1}})
+	assert.NoError(t, err)
+	assert.Empty(t, films)
+}
+
+func testActorOrdering(t *testing.T, s store.Store) {
+	id1 := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	id2 := createActor(t, s, "Sophie Patel", "female", "1997-11-03")
+	id3 := createActor(t, s, "Tom Hardy", "male", "1977-09-15")
+	createFilm(t, s, &models.FilmRequest{Title: "Alpha", ReleaseDate: "2020-01-01", Rating: 6.8, ActorsIDs: []int{id1, id2}})
+	createFilm(t, s, &models.FilmRequest{Title: "Beta", ReleaseDate: "2020-01-01", Rating: 6.8, ActorsIDs: []int{id1}})
+
+	var tests = []struct {
+		name        string
+		sort        []store.SortField
+		expectedIDs []int
+	}{
+		{
+			name:        "By name by default",
+			expectedIDs: []int{id2, id1, id3},
+		},
+		{
+			name:        "By birth date descending",
+			sort:        []store.SortField{{Field: "birth_date", Desc: true}},
+			expectedIDs: []int{id2, id3, id1},
+		},
+		{
+			name:        "By film count",
+			sort:        []store.SortField{{Field: "film_count", Desc: true}, {Field: "name"}},
+			expectedIDs: []int{id1, id2, id3},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actors, err := s.Actor().GetAll(&store.ActorFilter{Sort: tc.sort})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedIDs, actorIDs(actors))
+		})
+	}
+
+	_, err := s.Actor().GetAll(&store.ActorFilter{Sort: []store.SortField{{Field: "height"}}})
+	assert.Error(t, err)
+}
+
+func testActorSearch(t *testing.T, s store.Store) {
+	id1 := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	id2 := createActor(t, s, "Sophie Patel", "female", "1997-11-03")
+	id3 := createActor(t, s, "Tom Hardy", "male", "1977-09-15")
+
+	var tests = []struct {
+		name        string
+		filter      *store.ActorFilter
+		expectedIDs []int
+	}{
+		{
+			name:        "Name is case insensitive",
+			filter:      &store.ActorFilter{SearchName: "TOM"},
+			expectedIDs: []int{id1, id3},
+		},
+		{
+			name:        "Name matches in the middle",
+			filter:      &store.ActorFilter{SearchName: "pat"},
+			expectedIDs: []int{id2},
+		},
+		{
+			name:        "Wildcards are literal",
+			filter:      &store.ActorFilter{SearchName: "_"},
+			expectedIDs: []int{},
+		},
+		{
+			name:        "Gender is case insensitive",
+			filter:      &store.ActorFilter{Gender: "MALE"},
+			expectedIDs: []int{id1, id3},
+		},
+		{
+			name:        "Search and sort",
+			filter:      &store.ActorFilter{SearchName: "tom", Sort: []store.SortField{{Field: "birth_date"}}},
+			expectedIDs: []int{id1, id3},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actors, err := s.Actor().GetAll(tc.filter)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedIDs, actorIDs(actors))
+		})
+	}
+}
+
+func testActorFuzzySearch(t *testing.T, s store.Store) {
+	id := createActor(t, s, "Leonardo DiCaprio", "male", "1974-11-11")
+	createActor(t, s, "Kate Winslet", "female", "1975-10-05")
+
+	actors, err := s.Actor().FuzzySearch("Leonardo DiCaprio", 0.5)
+	assert.NoError(t, err)
+	require.Equal(t, 1, len(actors))
+	assert.Equal(t, id, actors[0].ID)
+	assert.Equal(t, "Leonardo DiCaprio", actors[0].Name)
+}
+
+func testActorIterate(t *testing.T, s store.Store) {
+	createActor(t, s, "Tom Hardy", "male", "1977-09-15")
+	createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	createActor(t, s, "Emily Blunt", "female", "1983-02-23")
+
+	filter := &store.ActorFilter{SearchName: "tom"}
+	actors, err := s.Actor().GetAll(filter)
+	require.NoError(t, err)
+
+	it, err := s.Actor().Iterate(filter)
+	require.NoError(t, err)
+	defer it.Close()
+
+	var iterated []models.Actor
+	for it.Next() {
+		iterated = append(iterated, *it.Actor())
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, actorIDs(actors), actorIDs(iterated))
+}
+
+func createActor(t *testing.T, s store.Store, name string, gender string, birthDate string) int {
+	t.Helper()
+
+	id, err := s.Actor().Create(&models.ActorRequest{Name: name, Gender: gender, BirthDate: birthDate}, author)
+	require.NoError(t, err)
+	return id
+}
+
+func actorIDs(actors []models.Actor) []int {
+	ids := make([]int, 0, len(actors))
+	for _, actor := range actors {
+		ids = append(ids, actor.ID)
+	}
+	return ids
+}
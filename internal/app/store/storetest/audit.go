@@ -0,0 +1,311 @@
+package storetest
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runAudit(t *testing.T, newStore Factory) {
+	runTests(t, newStore, []test{
+		{"Film history", testAuditFilmHistory},
+		{"Actor history", testAuditActorHistory},
+		{"User history", testAuditUserHistory},
+		{"Unchanged records are not recorded", testAuditUnchanged},
+		{"Filters", testAuditFilters},
+		{"Concurrent changes", testAuditConcurrentChanges},
+		{"Imports", testAuditImports},
+	})
+}
+
+func testAuditFilmHistory(t *testing.T, s store.Store) {
+	actorID := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	filmID := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.5, ActorsIDs: []int{actorID}})
+	editor := models.Author{Login: "editor", RequestID: "request-2"}
+
+	_, err := s.Film().Modify(filmID, &models.FilmRequest{Rating: 8.8, ActorsIDs: []int{actorID}}, editor)
+	require.NoError(t, err)
+	_, err = s.Film().Delete(filmID, author)
+	require.NoError(t, err)
+	_, err = s.Film().Restore(filmID, author)
+	require.NoError(t, err)
+
+	events, err := s.Audit().List(&store.AuditFilter{Entity: models.AuditEntityFilm, EntityID: strconv.Itoa(filmID)})
+	require.NoError(t, err)
+	require.Equal(t, 4, len(events))
+	for _, event := range events {
+		_, err := time.Parse(models.TimestampLayout, event.At)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, models.AuditActionRestore, events[0].Action)
+	assert.JSONEq(t, `{
+		"title": {"after": "Forrest Gump"},
+		"description": {"after": ""},
+		"release_date": {"after": "1994-07-06"},
+		"rating": {"after": 8.8},
+		"actors_ids": {"after": [`+strconv.Itoa(actorID)+`]}
+	}`, string(events[0].Diff))
+
+	assert.Equal(t, models.AuditActionDelete, events[1].Action)
+	assert.JSONEq(t, `{
+		"title": {"before": "Forrest Gump"},
+		"description": {"before": ""},
+		"release_date": {"before": "1994-07-06"},
+		"rating": {"before": 8.8},
+		"actors_ids": {"before": [`+strconv.Itoa(actorID)+`]}
+	}`, string(events[1].Diff))
+
+	assert.Equal(t, models.AuditActionModify, events[2].Action)
+	assert.Equal(t, "editor", events[2].Login)
+	assert.Equal(t, "request-2", events[2].RequestID)
+	assert.JSONEq(t, `{"rating": {"before": 8.5, "after": 8.8}}`, string(events[2].Diff))
+
+	assert.Equal(t, models.AuditActionCreate, events[3].Action)
+	assert.Equal(t, author.Login, events[3].Login)
+	assert.Equal(t, author.RequestID, events[3].RequestID)
+	assert.Equal(t, models.AuditEntityFilm, events[3].Entity)
+	assert.Equal(t, strconv.Itoa(filmID), events[3].EntityID)
+}
+
+func testAuditActorHistory(t *testing.T, s store.Store) {
+	actorID := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	filmID := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.8, ActorsIDs: []int{actorID}})
+
+	_, err := s.Actor().Modify(actorID, &models.ActorRequest{Name: "Thomas Hanks"}, author)
+	require.NoError(t, err)
+	_, err = s.Actor().Delete(actorID, author)
+	require.NoError(t, err)
+
+	events, err := s.Audit().List(&store.AuditFilter{Entity: models.AuditEntityActor, EntityID: strconv.Itoa(actorID)})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(events))
+	assert.Equal(t, models.AuditActionDelete, events[0].Action)
+	assert.JSONEq(t, `{
+		"name": {"before": "Thomas Hanks"},
+		"gender": {"before": "male"},
+		"birth_date": {"before": "1956-07-09"}
+	}`, string(events[0].Diff))
+	assert.Equal(t, models.AuditActionModify, events[1].Action)
+	assert.JSONEq(t, `{"name": {"before": "Tom Hanks", "after": "Thomas Hanks"}}`, string(events[1].Diff))
+	assert.Equal(t, models.AuditActionCreate, events[2].Action)
+
+	// Deleting the actor changes the cast of the film, but not the film.
+	events, err = s.Audit().List(&store.AuditFilter{Entity: models.AuditEntityFilm, EntityID: strconv.Itoa(filmID)})
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(events))
+}
+
+func testAuditUserHistory(t *testing.T, s store.Store) {
+	login := "storetest-audited"
+	_, err := s.User().Create(&models.UserRequest{Login: login, Password: "password"}, models.Author{Login: login})
+	require.NoError(t, err)
+	_, err = s.User().SetAdmin(login, true, author)
+	require.NoError(t, err)
+	_, err = s.User().SetPassword(login, "new password", author)
+	require.NoError(t, err)
+
+	events, err := s.Audit().List(&store.AuditFilter{Entity: models.AuditEntityUser, EntityID: login})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(events))
+	assert.Equal(t, models.AuditActionModify, events[0].Action)
+	assert.JSONEq(t, `{"password": {"before": "***", "after": "***"}}`, string(events[0].Diff))
+	assert.Equal(t, models.AuditActionModify, events[1].Action)
+	assert.JSONEq(t, `{"is_admin": {"before": false, "after": true}}`, string(events[1].Diff))
+	assert.Equal(t, models.AuditActionCreate, events[2].Action)
+	assert.Equal(t, login, events[2].Login)
+	assert.JSONEq(t, `{"login": {"after": "`+login+`"}, "is_admin": {"after": false}}`, string(events[2].Diff))
+}
+
+func testAuditUnchanged(t *testing.T, s store.Store) {
+	filmID := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.8})
+
+	done, err := s.Film().Modify(filmID, &models.FilmRequest{Rating: 8.8}, author)
+	require.NoError(t, err)
+	require.True(t, done)
+	done, err = s.Film().Modify(filmID+1, &models.FilmRequest{Rating: 8.8}, author)
+	require.NoError(t, err)
+	require.False(t, done)
+	done, err = s.Film().Restore(filmID, author)
+	require.NoError(t, err)
+	require.False(t, done)
+
+	events, err := s.Audit().List(&store.AuditFilter{Entity: models.AuditEntityFilm})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(events))
+	assert.Equal(t, models.AuditActionCreate, events[0].Action)
+}
+
+func testAuditFilters(t *testing.T, s store.Store) {
+	start := time.Now().Add(-time.Second)
+	actorID := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	filmID := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.8})
+	_, err := s.Film().Modify(filmID, &models.FilmRequest{Title: "Cast Away"}, models.Author{Login: "editor", RequestID: "request-2"})
+	require.NoError(t, err)
+	_, err = s.Actor().Delete(actorID, models.Author{Login: "editor", RequestID: "request-3"})
+	require.NoError(t, err)
+
+	var tests = []struct {
+		name            string
+		filter          *store.AuditFilter
+		expectedActions []string
+	}{
+		{
+			name:            "Everything",
+			filter:          &store.AuditFilter{Since: start},
+			expectedActions: []string{"delete", "modify", "create", "create"},
+		},
+		{
+			name:            "Entity",
+			filter:          &store.AuditFilter{Entity: models.AuditEntityActor},
+			expectedActions: []string{"delete", "create"},
+		},
+		{
+			name:            "Action",
+			filter:          &store.AuditFilter{Action: models.AuditActionCreate},
+			expectedActions: []string{"create", "create"},
+		},
+		{
+			name:            "Login",
+			filter:          &store.AuditFilter{Login: "editor"},
+			expectedActions: []string{"delete", "modify"},
+		},
+		{
+			name:            "Request",
+			filter:          &store.AuditFilter{RequestID: "request-2"},
+			expectedActions: []string{"modify"},
+		},
+		{
+			name:            "Limit",
+			filter:          &store.AuditFilter{Limit: 3},
+			expectedActions: []string{"delete", "modify", "create"},
+		},
+		{
+			name:            "Until",
+			filter:          &store.AuditFilter{Until: start},
+			expectedActions: []string{},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			events, err := s.Audit().List(tc.filter)
+			require.NoError(t, err)
+			actions := make([]string, 0, len(events))
+			for _, event := range events {
+				actions = append(actions, event.Action)
+			}
+			assert.Equal(t, tc.expectedActions, actions)
+		})
+	}
+
+	page, err := s.Audit().List(&store.AuditFilter{Limit: 2})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(page))
+	rest, err := s.Audit().List(&store.AuditFilter{BeforeID: page[1].ID})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(rest))
+	assert.Equal(t, models.AuditActionCreate, rest[0].Action)
+	assert.Equal(t, models.AuditEntityFilm, rest[0].Entity)
+	assert.Equal(t, models.AuditEntityActor, rest[1].Entity)
+}
+
+func testAuditConcurrentChanges(t *testing.T, s store.Store) {
+	filmID := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 1})
+
+	var wg sync.WaitGroup
+	for i := 2; i <= 9; i++ {
+		wg.Add(1)
+		go func(rating float64) {
+			defer wg.Done()
+			_, err := s.Film().Modify(filmID, &models.FilmRequest{Rating: rating}, author)
+			assert.NoError(t, err)
+		}(float64(i))
+	}
+	wg.Wait()
+
+	events, err := s.Audit().List(&store.AuditFilter{
+		Entity:   models.AuditEntityFilm,
+		EntityID: strconv.Itoa(filmID),
+		Action:   models.AuditActionModify,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 8, len(events))
+
+	// Every change starts where the one before it ended.
+	rating := 1.0
+	for i := len(events) - 1; i >= 0; i-- {
+		var diff struct {
+			Rating struct {
+				Before float64 `json:"before"`
+				After  float64 `json:"after"`
+			} `json:"rating"`
+		}
+		require.NoError(t, json.Unmarshal(events[i].Diff, &diff))
+		assert.Equal(t, rating, diff.Rating.Before)
+		rating = diff.Rating.After
+	}
+
+	film, err := s.Film().Find(filmID)
+	require.NoError(t, err)
+	assert.Equal(t, rating, film.Rating)
+}
+
+func testAuditImports(t *testing.T, s store.Store) {
+	actorID := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	filmID := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.5})
+	_, err := s.Film().Delete(filmID, author)
+	require.NoError(t, err)
+	importer := models.Author{Login: "importer", RequestID: "request-2"}
+
+	actors := []models.ActorRecord{
+		{Name: "Tom Hanks", Gender: "Male", BirthDate: "1956-07-09"},
+		{ExternalID: "nm1", Name: "Robin Wright", Gender: "female", BirthDate: "1966-04-08"},
+	}
+	_, err = s.Catalog().ImportActors(actors, importer)
+	require.NoError(t, err)
+	_, err = s.Catalog().ImportActors(actors, importer)
+	require.NoError(t, err)
+	_, err = s.Catalog().ImportFilms([]models.FilmRecord{
+		{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.8, Cast: []string{"Tom Hanks"}},
+	}, importer)
+	require.NoError(t, err)
+
+	events, err := s.Audit().List(&store.AuditFilter{Login: "importer"})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(events))
+	for _, event := range events {
+		assert.Equal(t, "request-2", event.RequestID)
+	}
+
+	assert.Equal(t, models.AuditEntityFilm, events[0].Entity)
+	assert.Equal(t, strconv.Itoa(filmID), events[0].EntityID)
+	assert.Equal(t, models.AuditActionRestore, events[0].Action)
+	assert.JSONEq(t, `{
+		"title": {"after": "Forrest Gump"},
+		"description": {"after": ""},
+		"release_date": {"after": "1994-07-06"},
+		"rating": {"after": 8.8},
+		"actors_ids": {"after": [`+strconv.Itoa(actorID)+`]}
+	}`, string(events[0].Diff))
+
+	assert.Equal(t, models.AuditEntityActor, events[1].Entity)
+	assert.NotEqual(t, strconv.Itoa(actorID), events[1].EntityID)
+	assert.Equal(t, models.AuditActionCreate, events[1].Action)
+	assert.JSONEq(t, `{
+		"name": {"after": "Robin Wright"},
+		"gender": {"after": "female"},
+		"birth_date": {"after": "1966-04-08"}
+	}`, string(events[1].Diff))
+
+	assert.Equal(t, models.AuditEntityActor, events[2].Entity)
+	assert.Equal(t, strconv.Itoa(actorID), events[2].EntityID)
+	assert.Equal(t, models.AuditActionModify, events[2].Action)
+	assert.JSONEq(t, `{"gender": {"before": "male", "after": "Male"}}`, string(events[2].Diff))
+}
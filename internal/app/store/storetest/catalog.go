@@ -0,0 +1,152 @@
+package storetest
+
+import (
+	"testing"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runCatalog(t *testing.T, newStore Factory) {
+	runTests(t, newStore, []test{
+		{"Import actors", testCatalogImportActors},
+		{"Import actors without birth date", testCatalogImportActorsWithoutBirthDate},
+		{"Import films", testCatalogImportFilms},
+		{"Export", testCatalogExport},
+		{"Unknown cast", testCatalogUnknownCast},
+	})
+}
+
+func testCatalogImportActors(t *testing.T, s store.Store) {
+	existingID := createActor(t, s, "Keanu Reeves", "male", "1964-09-02")
+
+	res, err := s.Catalog().ImportActors([]models.ActorRecord{
+		{ExternalID: "nm1", Name: "Keanu Reeves", Gender: "Male", BirthDate: "1964-09-02"},
+		{ExternalID: "nm2", Name: "Carrie-Anne Moss", Gender: "female", BirthDate: "1967-08-21"},
+	}, author)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ImportResult{Created: 1, Updated: 1}, res)
+
+	actor, err := s.Actor().Find(existingID)
+	require.NoError(t, err)
+	assert.Equal(t, "Male", actor.Gender)
+
+	res, err = s.Catalog().ImportActors([]models.ActorRecord{
+		{ExternalID: "nm2", Name: "Carrie-Anne Moss", Gender: "female", BirthDate: "1967-08-22"},
+	}, author)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ImportResult{Created: 0, Updated: 1}, res)
+}
+
+func testCatalogImportActorsWithoutBirthDate(t *testing.T, s store.Store) {
+	record := models.ActorRecord{ExternalID: "nm1", Name: "Keanu Reeves", Gender: "male"}
+	res, err := s.Catalog().ImportActors([]models.ActorRecord{record}, author)
+	require.NoError(t, err)
+	assert.Equal(t, &models.ImportResult{Created: 1}, res)
+
+	record.ExternalID, record.Gender = "", "Male"
+	res, err = s.Catalog().ImportActors([]models.ActorRecord{record}, author)
+	require.NoError(t, err)
+	assert.Equal(t, &models.ImportResult{Updated: 1}, res)
+
+	actors, err := s.Actor().GetAll(&store.ActorFilter{})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(actors))
+	assert.Equal(t, "", actors[0].BirthDate)
+
+	actor, err := s.Actor().Find(actors[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "", actor.BirthDate)
+
+	actors, err = s.Actor().GetAll(&store.ActorFilter{Fields: []string{"name", "birth_date"}})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(actors))
+	assert.Equal(t, "", actors[0].BirthDate)
+
+	actors, err = s.Actor().FuzzySearch("Keanu Reves", 0.3)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(actors))
+	assert.Equal(t, "", actors[0].BirthDate)
+}
+
+func testCatalogImportFilms(t *testing.T, s store.Store) {
+	_, err := s.Catalog().ImportActors([]models.ActorRecord{
+		{ExternalID: "nm1", Name: "Keanu Reeves", Gender: "male", BirthDate: "1964-09-02"},
+	}, author)
+	require.NoError(t, err)
+	actorID := createActor(t, s, "Laurence Fishburne", "male", "1961-07-30")
+
+	res, err := s.Catalog().ImportFilms([]models.FilmRecord{
+		{ExternalID: "tt1", Title: "The Matrix", ReleaseDate: "1999-03-31", Rating: 8.7, Cast: []string{"nm1", "Laurence Fishburne", "Nobody"}},
+	}, author)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ImportResult{Created: 1, Updated: 0}, res)
+
+	res, err = s.Catalog().ImportFilms([]models.FilmRecord{
+		{ExternalID: "tt1", Title: "The Matrix", ReleaseDate: "1999-03-31", Rating: 8.8, Cast: []string{"Laurence Fishburne"}},
+	}, author)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ImportResult{Created: 0, Updated: 1}, res)
+
+	films, err := s.Film().GetAll(&store.FilmFilter{})
+	assert.NoError(t, err)
+	require.Equal(t, 1, len(films))
+	assert.Equal(t, 8.8, films[0].Rating)
+	assert.Equal(t, []models.ActorBasic{{ActorID: actorID, Name: "Laurence Fishburne"}}, films[0].Actors)
+
+	id := createFilm(t, s, &models.FilmRequest{Title: "Speed", ReleaseDate: "1994-06-10", Rating: 7.2})
+	assert.NotEqual(t, films[0].ID, id)
+}
+
+func testCatalogUnknownCast(t *testing.T, s store.Store) {
+	_, err := s.Catalog().ImportActors([]models.ActorRecord{
+		{ExternalID: "nm1", Name: "Keanu Reeves", Gender: "male", BirthDate: "1964-09-02"},
+	}, author)
+	require.NoError(t, err)
+	deletedID := createActor(t, s, "Carrie-Anne Moss", "female", "1967-08-21")
+	_, err = s.Actor().Delete(deletedID, author)
+	require.NoError(t, err)
+
+	unknown, err := s.Catalog().UnknownCast([]string{"nm1", "Keanu Reeves", "nm2", "Carrie-Anne Moss", "nm2"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Carrie-Anne Moss", "nm2"}, unknown)
+
+	unknown, err = s.Catalog().UnknownCast(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(unknown))
+}
+
+func testCatalogExport(t *testing.T, s store.Store) {
+	_, err := s.Catalog().ImportActors([]models.ActorRecord{
+		{ExternalID: "nm1", Name: "Keanu Reeves", Gender: "male", BirthDate: "1964-09-02"},
+		{Name: "Laurence Fishburne", Gender: "male", BirthDate: "1961-07-30"},
+	}, author)
+	require.NoError(t, err)
+	_, err = s.Catalog().ImportFilms([]models.FilmRecord{
+		{ExternalID: "tt1", Title: "The Matrix", ReleaseDate: "1999-03-31", Rating: 8.7, Cast: []string{"nm1", "Laurence Fishburne"}},
+	}, author)
+	require.NoError(t, err)
+
+	var actors []models.ActorRecord
+	var films []models.FilmRecord
+	err = s.Catalog().Export(
+		func(a *models.ActorRecord) error {
+			actors = append(actors, *a)
+			return nil
+		},
+		func(f *models.FilmRecord) error {
+			films = append(films, *f)
+			return nil
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ActorRecord{
+		{ExternalID: "nm1", Name: "Keanu Reeves", Gender: "male", BirthDate: "1964-09-02"},
+		{Name: "Laurence Fishburne", Gender: "male", BirthDate: "1961-07-30"},
+	}, actors)
+	require.Equal(t, 1, len(films))
+	assert.Equal(t, "tt1", films[0].ExternalID)
+	assert.ElementsMatch(t, []string{"nm1", "Laurence Fishburne"}, films[0].Cast)
+}
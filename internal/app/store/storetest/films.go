@@ -0,0 +1,301 @@
+package storetest
+
+import (
+	"testing"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runFilms(t *testing.T, newStore Factory) {
+	runTests(t, newStore, []test{
+		{"Not found", testFilmNotFound},
+		{"Create", testFilmCreate},
+		{"Ids are not reused", testFilmIDsNotReused},
+		{"Partial modify", testFilmPartialModify},
+		{"Delete unlinks actors", testFilmDeleteCascade},
+		{"Ordering", testFilmOrdering},
+		{"Search", testFilmSearch},
+		{"Fuzzy search", testFilmFuzzySearch},
+		{"Iterate", testFilmIterate},
+	})
+}
+
+func testFilmNotFound(t *testing.T, s store.Store) {
+	id := createFilm(t, s, &models.FilmRequest{Title: "Alpha", ReleaseDate: "1999-05-01", Rating: 7.8})
+
+	film, err := s.Film().Find(id + 100)
+	assert.Nil(t, film)
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+
+	done, err := s.Film().Modify(id+100, &models.FilmRequest{Title: "Beta"}, author)
+	assert.NoError(t, err)
+	assert.False(t, done)
+
+	done, err = s.Film().Delete(id+100, author)
+	assert.NoError(t, err)
+	assert.False(t, done)
+
+	films, err := s.Film().GetAll(&store.FilmFilter{IDs: []int{id + 100}})
+	assert.NoError(t, err)
+	assert.Empty(t, films)
+}
+
+func testFilmCreate(t *testing.T, s store.Store) {
+	actorID := createActor(t, s, "Keanu Reeves", "male", "1964-09-02")
+	req := &models.FilmRequest{
+		Title:       "The Matrix",
+		Description: "Hacker learns the truth",
+		ReleaseDate: "1999-03-31",
+		Rating:      8.7,
+		ActorsIDs:   []int{actorID, actorID + 100},
+	}
+	id := createFilm(t, s, req)
+
+	film, err := s.Film().Find(id)
+	require.NoError(t, err)
+	assert.Equal(t, &models.Film{
+		ID:          id,
+		Title:       req.Title,
+		Description: req.Description,
+		ReleaseDate: req.ReleaseDate,
+		Rating:      req.Rating,
+		Actors:      []models.ActorBasic{{ActorID: actorID, Name: "Keanu Reeves"}},
+	}, film)
+}
+
+func testFilmIDsNotReused(t *testing.T, s store.Store) {
+	id1 := createFilm(t, s, &models.FilmRequest{Title: "Alpha", ReleaseDate: "1999-05-01", Rating: 7.8})
+	id2 := createFilm(t, s, &models.FilmRequest{Title: "Beta", ReleaseDate: "2005-01-01", Rating: 6.1})
+
+	done, err := s.Film().Delete(id2, author)
+	require.NoError(t, err)
+	require.True(t, done)
+
+	id3 := createFilm(t, s, &models.FilmRequest{Title: "Gamma", ReleaseDate: "2015-01-01", Rating: 5.5})
+	assert.NotEqual(t, id1, id3)
+	assert.NotEqual(t, id2, id3)
+
+	film, err := s.Film().Find(id1)
+	require.NoError(t, err)
+	assert.Equal(t, "Alpha", film.Title)
+}
+
+func testFilmPartialModify(t *testing.T, s store.Store) {
+	actorID1 := createActor(t, s, "Keanu Reeves", "male", "1964-09-02")
+	actorID2 := createActor(t, s, "Laurence Fishburne", "male", "1961-07-30")
+	req := &models.FilmRequest{
+		Title:       "The Matrix",
+		Description: "Hacker learns the truth",
+		ReleaseDate: "1999-03-31",
+		Rating:      8.7,
+		ActorsIDs:   []int{actorID1},
+	}
+	id := createFilm(t, s, req)
+
+	done, err := s.Film().Modify(id, &models.FilmRequest{
+		Description: "Hacker learns the whole truth",
+		ActorsIDs:   []int{actorID1, actorID2},
+	}, author)
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	film, err := s.Film().Find(id)
+	require.NoError(t, err)
+	assert.Equal(t, req.Title, film.Title)
+	assert.Equal(t, "Hacker learns the whole truth", film.Description)
+	assert.Equal(t, req.ReleaseDate, film.ReleaseDate)
+	assert.Equal(t, req.Rating, film.Rating)
+	assert.ElementsMatch(t, []models.ActorBasic{
+		{ActorID: actorID1, Name: "Keanu Reeves"},
+		{ActorID: actorID2, Name: "Laurence Fishburne"},
+	}, film.Actors)
+
+	done, err = s.Film().Modify(id, &models.FilmRequest{Rating: 8.8}, author)
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	film, err = s.Film().Find(id)
+	require.NoError(t, err)
+	assert.Equal(t, 8.8, film.Rating)
+	assert.Equal(t, "Hacker learns the whole truth", film.Description)
+}
+
+func testFilmDeleteCascade(t *testing.T, s store.Store) {
+	actorID := createActor(t, s, "Keanu Reeves", "male", "1964-09-02")
+	id1 := createFilm(t, s, &models.FilmRequest{Title: "The Matrix", ReleaseDate: "1999-03-31", Rating: 8.7, ActorsIDs: []int{actorID}})
+	id2 := createFilm(t, s, &models.FilmRequest{Title: "John Wick", ReleaseDate: "2014-10-24", Rating: 7.4, ActorsIDs: []int{actorID}})
+
+	done, err := s.Film().Delete(id1, author)
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	done, err = s.Film().Delete(id1, author)
+	assert.NoError(t, err)
+	assert.False(t, done)
+
+	actor, err := s.Actor().Find(actorID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.FilmBasic{{FilmID: id2, Title: "John Wick"}}, actor.Films)
+}
+
+func testFilmOrdering(t *testing.T, s store.Store) {
+	id1 := createFilm(t, s, &models.FilmRequest{Title: "Beta", ReleaseDate: "2005-01-01", Rating: 7.8})
+	id2 := createFilm(t, s, &models.FilmRequest{Title: "Alpha", ReleaseDate: "1999-05-01", Rating: 7.8})
+	id3 := createFilm(t, s, &models.FilmRequest{Title: "Gamma", ReleaseDate: "2015-01-01", Rating: 5.5})
+
+	var tests = []struct {
+		name        string
+		sort        []store.SortField
+		expectedIDs []int
+	}{
+		{
+			name:        "By id by default",
+			expectedIDs: []int{id1, id2, id3},
+		},
+		{
+			name:        "By title",
+			sort:        []store.SortField{{Field: "title"}},
+			expectedIDs: []int{id2, id1, id3},
+		},
+		{
+			name:        "By release date descending",
+			sort:        []store.SortField{{Field: "release_date", Desc: true}},
+			expectedIDs: []int{id3, id1, id2},
+		},
+		{
+			name:        "Ties broken by id",
+			sort:        []store.SortField{{Field: "rating", Desc: true}},
+			expectedIDs: []int{id1, id2, id3},
+		},
+		{
+			name:        "Several keys",
+			sort:        []store.SortField{{Field: "rating"}, {Field: "title", Desc: true}},
+			expectedIDs: []int{id3, id1, id2},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			films, err := s.Film().GetAll(&store.FilmFilter{Sort: tc.sort})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedIDs, filmIDs(films))
+		})
+	}
+
+	_, err := s.Film().GetAll(&store.FilmFilter{Sort: []store.SortField{{Field: "budget"}}})
+	assert.Error(t, err)
+}
+
+func testFilmSearch(t *testing.T, s store.Store) {
+	actorID1 := createActor(t, s, "Keanu Reeves", "male", "1964-09-02")
+	actorID2 := createActor(t, s, "Carrie-Anne Moss", "female", "1967-08-21")
+	id1 := createFilm(t, s, &models.FilmRequest{Title: "The Matrix", ReleaseDate: "1999-03-31", Rating: 8.7, ActorsIDs: []int{actorID1, actorID2}})
+	id2 := createFilm(t, s, &models.FilmRequest{Title: "The Matrix Reloaded", ReleaseDate: "2003-05-15", Rating: 7.2, ActorsIDs: []int{actorID2}})
+	id3 := createFilm(t, s, &models.FilmRequest{Title: "John Wick", ReleaseDate: "2014-10-24", Rating: 7.4, ActorsIDs: []int{actorID1}})
+
+	var tests = []struct {
+		name        string
+		filter      *store.FilmFilter
+		expectedIDs []int
+	}{
+		{
+			name:        "Title is case insensitive",
+			filter:      &store.FilmFilter{SearchTitle: "MATRIX"},
+			expectedIDs: []int{id1, id2},
+		},
+		{
+			name:        "Title matches in the middle",
+			filter:      &store.FilmFilter{SearchTitle: "n wi"},
+			expectedIDs: []int{id3},
+		},
+		{
+			name:        "Any actor",
+			filter:      &store.FilmFilter{SearchActor: "reeves"},
+			expectedIDs: []int{id1, id3},
+		},
+		{
+			name:        "Title and actor",
+			filter:      &store.FilmFilter{SearchTitle: "matrix", SearchActor: "keanu"},
+			expectedIDs: []int{id1},
+		},
+		{
+			name:        "Wildcards are literal",
+			filter:      &store.FilmFilter{SearchTitle: "%"},
+			expectedIDs: []int{},
+		},
+		{
+			name:        "Search and sort",
+			filter:      &store.FilmFilter{SearchActor: "moss", Sort: []store.SortField{{Field: "rating"}}},
+			expectedIDs: []int{id2, id1},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			films, err := s.Film().GetAll(tc.filter)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedIDs, filmIDs(films))
+		})
+	}
+
+	films, err := s.Film().GetAll(&store.FilmFilter{SearchActor: "moss"})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(films))
+	assert.ElementsMatch(t, []models.ActorBasic{
+		{ActorID: actorID1, Name: "Keanu Reeves"},
+		{ActorID: actorID2, Name: "Carrie-Anne Moss"},
+	}, films[0].Actors)
+}
+
+func testFilmFuzzySearch(t *testing.T, s store.Store) {
+	id := createFilm(t, s, &models.FilmRequest{Title: "Titanic", ReleaseDate: "1997-12-19", Rating: 7.9})
+	createFilm(t, s, &models.FilmRequest{Title: "Gladiator", ReleaseDate: "2000-05-05", Rating: 8.5})
+
+	films, err := s.Film().FuzzySearch("Titanic", "", 0.5)
+	assert.NoError(t, err)
+	require.Equal(t, 1, len(films))
+	assert.Equal(t, id, films[0].ID)
+	assert.Equal(t, "Titanic", films[0].Title)
+
+	films, err = s.Film().FuzzySearch("", "", 0.5)
+	assert.NoError(t, err)
+	assert.Empty(t, films)
+}
+
+func testFilmIterate(t *testing.T, s store.Store) {
+	createFilm(t, s, &models.FilmRequest{Title: "Beta", ReleaseDate: "2005-01-01", Rating: 7.8})
+	createFilm(t, s, &models.FilmRequest{Title: "Alpha", ReleaseDate: "1999-05-01", Rating: 5.5})
+	createFilm(t, s, &models.FilmRequest{Title: "Gamma", ReleaseDate: "2015-01-01", Rating: 6.1})
+
+	filter := &store.FilmFilter{Sort: []store.SortField{{Field: "title", Desc: true}}}
+	films, err := s.Film().GetAll(filter)
+	require.NoError(t, err)
+
+	it, err := s.Film().Iterate(filter)
+	require.NoError(t, err)
+	defer it.Close()
+
+	var iterated []models.Film
+	for it.Next() {
+		iterated = append(iterated, *it.Film())
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, filmIDs(films), filmIDs(iterated))
+}
+
+func createFilm(t *testing.T, s store.Store, req *models.FilmRequest) int {
+	t.Helper()
+
+	id, err := s.Film().Create(req, author)
+	require.NoError(t, err)
+	return id
+}
+
+func filmIDs(films []models.Film) []int {
+	ids := make([]int, 0, len(films))
+	for _, film := range films {
+		ids = append(ids, film.ID)
+	}
+	return ids
+}
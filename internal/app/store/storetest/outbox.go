@@ -0,0 +1,192 @@
+package storetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runOutbox(t *testing.T, newStore Factory) {
+	runTests(t, newStore, []test{
+		{"Changes", testOutboxChanges},
+		{"Paging", testOutboxPaging},
+		{"Imports", testOutboxImports},
+		{"Purge", testOutboxPurge},
+		{"Purge keeps events for webhooks", testOutboxPurgeWebhooks},
+	})
+}
+
+// change is a models.ChangeEvent without its seq and time.
+type change struct {
+	entity string
+	id     int
+	action string
+}
+
+func changes(events []models.ChangeEvent) []change {
+	res := make([]change, 0, len(events))
+	for _, event := range events {
+		res = append(res, change{event.Entity, event.EntityID, event.Action})
+	}
+	return res
+}
+
+func testOutboxChanges(t *testing.T, s store.Store) {
+	actorID := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	filmID := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.5, ActorsIDs: []int{actorID}})
+
+	_, err := s.Film().Modify(filmID, &models.FilmRequest{Rating: 8.8, ActorsIDs: []int{actorID}}, author)
+	require.NoError(t, err)
+	_, err = s.Film().Modify(filmID, &models.FilmRequest{Rating: 8.8, ActorsIDs: []int{actorID}}, author)
+	require.NoError(t, err)
+	_, err = s.Film().Delete(filmID, author)
+	require.NoError(t, err)
+	_, err = s.Film().Restore(filmID, author)
+	require.NoError(t, err)
+	_, err = s.Actor().Modify(actorID, &models.ActorRequest{Name: "Thomas Hanks"}, author)
+	require.NoError(t, err)
+	_, err = s.User().Create(&models.UserRequest{Login: "outbox", Password: "password"}, author)
+	require.NoError(t, err)
+
+	events, err := s.Outbox().List(0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []change{
+		{models.AuditEntityActor, actorID, models.AuditActionCreate},
+		{models.AuditEntityFilm, filmID, models.AuditActionCreate},
+		{models.AuditEntityFilm, filmID, models.AuditActionModify},
+		{models.AuditEntityFilm, filmID, models.AuditActionDelete},
+		{models.AuditEntityFilm, filmID, models.AuditActionRestore},
+		{models.AuditEntityActor, actorID, models.AuditActionModify},
+	}, changes(events))
+	for i, event := range events {
+		if i > 0 {
+			assert.Greater(t, event.Seq, events[i-1].Seq)
+		}
+		_, err := time.Parse(models.TimestampLayout, event.At)
+		assert.NoError(t, err)
+	}
+}
+
+func testOutboxPaging(t *testing.T, s store.Store) {
+	for _, name := range []string{"Tom Hanks", "Robin Wright", "Gary Sinise", "Sally Field"} {
+		createActor(t, s, name, "", "1956-07-09")
+	}
+
+	all, err := s.Outbox().List(0, 0)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(all))
+
+	events, err := s.Outbox().List(all[0].Seq, 2)
+	require.NoError(t, err)
+	assert.Equal(t, all[1:3], events)
+
+	events, err = s.Outbox().List(all[3].Seq, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(events))
+}
+
+func testOutboxImports(t *testing.T, s store.Store) {
+	actorID := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	actors := []models.ActorRecord{
+		{Name: "Tom Hanks", Gender: "Male", BirthDate: "1956-07-09"},
+		{Name: "Robin Wright", Gender: "female", BirthDate: "1966-04-08"},
+	}
+	films := []models.FilmRecord{
+		{ExternalID: "tt0109830", Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.8, Cast: []string{"Tom Hanks"}},
+	}
+
+	_, err := s.Catalog().ImportActors(actors, author)
+	require.NoError(t, err)
+	_, err = s.Catalog().ImportFilms(films, author)
+	require.NoError(t, err)
+
+	events, err := s.Outbox().List(0, 0)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(events))
+	assert.Equal(t, change{models.AuditEntityActor, actorID, models.AuditActionModify}, changes(events)[1])
+	assert.Equal(t, models.AuditEntityActor, events[2].Entity)
+	assert.NotEqual(t, actorID, events[2].EntityID)
+	assert.Equal(t, models.AuditActionCreate, events[2].Action)
+	assert.Equal(t, models.AuditEntityFilm, events[3].Entity)
+	assert.Equal(t, models.AuditActionCreate, events[3].Action)
+	filmID := events[3].EntityID
+
+	// Rows the import leaves as they were are not changes, those it takes out
+	// of the trash are restored.
+	_, err = s.Film().Delete(filmID, author)
+	require.NoError(t, err)
+	res, err := s.Catalog().ImportActors(actors, author)
+	require.NoError(t, err)
+	assert.Equal(t, &models.ImportResult{}, res)
+	res, err = s.Catalog().ImportFilms(films, author)
+	require.NoError(t, err)
+	assert.Equal(t, &models.ImportResult{Updated: 1}, res)
+
+	events, err = s.Outbox().List(events[3].Seq, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []change{
+		{models.AuditEntityFilm, filmID, models.AuditActionDelete},
+		{models.AuditEntityFilm, filmID, models.AuditActionRestore},
+	}, changes(events))
+}
+
+func testOutboxPurge(t *testing.T, s store.Store) {
+	createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	createActor(t, s, "Robin Wright", "female", "1966-04-08")
+
+	purged, err := s.Outbox().Purge(time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 0, purged)
+
+	events, err := s.Outbox().List(0, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(events))
+	last := events[1].Seq
+
+	purged, err = s.Outbox().Purge(time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+
+	events, err = s.Outbox().List(0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(events))
+
+	createActor(t, s, "Gary Sinise", "male", "1955-03-17")
+	events, err = s.Outbox().List(0, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(events))
+	assert.Greater(t, events[0].Seq, last)
+}
+
+func testOutboxPurgeWebhooks(t *testing.T, s store.Store) {
+	createWebhook(t, s, "actor.created")
+	createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	createActor(t, s, "Robin Wright", "female", "1966-04-08")
+
+	purged, err := s.Outbox().Purge(time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 0, purged)
+
+	_, err = s.Webhook().Enqueue(1)
+	require.NoError(t, err)
+	purged, err = s.Outbox().Purge(time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	events, err := s.Outbox().List(0, 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(events))
+	deliveries, err := s.Webhook().Due(time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(deliveries))
+
+	_, err = s.Webhook().Enqueue(0)
+	require.NoError(t, err)
+	purged, err = s.Outbox().Purge(time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+}
@@ -0,0 +1,170 @@
+package storetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runRevisions(t *testing.T, newStore Factory) {
+	runTests(t, newStore, []test{
+		{"Changes add revisions", testRevisionsChanges},
+		{"Imports add revisions", testRevisionsImports},
+		{"Not found", testRevisionsNotFound},
+		{"Revert", testRevisionsRevert},
+		{"Revert to empty values", testRevisionsRevertEmpty},
+		{"Purged films lose their revisions", testRevisionsPurge},
+	})
+}
+
+func testRevisionsChanges(t *testing.T, s store.Store) {
+	hanks := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	wright := createActor(t, s, "Robin Wright", "female", "1966-04-08")
+	filmID := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.5, ActorsIDs: []int{hanks}})
+	editor := models.Author{Login: "editor", RequestID: "request-2"}
+
+	_, err := s.Film().Modify(filmID, &models.FilmRequest{Rating: 8.8, ActorsIDs: []int{hanks}}, editor)
+	require.NoError(t, err)
+	_, err = s.Film().Modify(filmID, &models.FilmRequest{Rating: 8.8, ActorsIDs: []int{hanks}}, author)
+	require.NoError(t, err)
+	_, err = s.Film().Modify(filmID, &models.FilmRequest{ActorsIDs: []int{wright, hanks}}, author)
+	require.NoError(t, err)
+	_, err = s.Film().Delete(filmID, author)
+	require.NoError(t, err)
+	_, err = s.Film().Restore(filmID, author)
+	require.NoError(t, err)
+
+	revisions, err := s.Film().GetRevisions(filmID)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(revisions))
+	for i, revision := range revisions {
+		assert.Equal(t, filmID, revision.FilmID)
+		assert.Equal(t, 3-i, revision.Revision)
+		_, err := time.Parse(models.TimestampLayout, revision.At)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.8, ActorsIDs: []int{hanks, wright}}, revisions[0].Film)
+	assert.Equal(t, models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.8, ActorsIDs: []int{hanks}}, revisions[1].Film)
+	assert.Equal(t, "editor", revisions[1].Login)
+	assert.Equal(t, "request-2", revisions[1].RequestID)
+	assert.Equal(t, models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.5, ActorsIDs: []int{hanks}}, revisions[2].Film)
+	assert.Equal(t, author.Login, revisions[2].Login)
+
+	revision, err := s.Film().FindRevision(filmID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, revisions[1], *revision)
+}
+
+func testRevisionsImports(t *testing.T, s store.Store) {
+	hanks := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	importer := models.Author{Login: "importer", RequestID: "request-2"}
+	record := models.FilmRecord{ExternalID: "tt0109830", Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.5}
+
+	_, err := s.Catalog().ImportFilms([]models.FilmRecord{record}, importer)
+	require.NoError(t, err)
+	_, err = s.Catalog().ImportFilms([]models.FilmRecord{record}, importer)
+	require.NoError(t, err)
+	record.Rating, record.Cast = 8.8, []string{"Tom Hanks"}
+	_, err = s.Catalog().ImportFilms([]models.FilmRecord{record}, importer)
+	require.NoError(t, err)
+
+	films, err := s.Film().GetAll(&store.FilmFilter{})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(films))
+	filmID := films[0].ID
+
+	revisions, err := s.Film().GetRevisions(filmID)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(revisions))
+	assert.Equal(t, models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.8, ActorsIDs: []int{hanks}}, revisions[0].Film)
+	assert.Equal(t, models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.5, ActorsIDs: []int{}}, revisions[1].Film)
+	for _, revision := range revisions {
+		assert.Equal(t, "importer", revision.Login)
+		assert.Equal(t, "request-2", revision.RequestID)
+	}
+}
+
+func testRevisionsNotFound(t *testing.T, s store.Store) {
+	filmID := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.8})
+
+	_, err := s.Film().FindRevision(filmID, 2)
+	assert.Equal(t, store.ErrRecordNotFound, err)
+	_, err = s.Film().FindRevision(filmID+1, 1)
+	assert.Equal(t, store.ErrRecordNotFound, err)
+
+	revisions, err := s.Film().GetRevisions(filmID + 1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(revisions))
+}
+
+func testRevisionsRevert(t *testing.T, s store.Store) {
+	hanks := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	wright := createActor(t, s, "Robin Wright", "female", "1966-04-08")
+	filmID := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.5, ActorsIDs: []int{hanks}})
+	_, err := s.Film().Modify(filmID, &models.FilmRequest{Title: "Forest Gump", Rating: 9.1, ActorsIDs: []int{wright}}, author)
+	require.NoError(t, err)
+
+	done, err := s.Film().Revert(filmID, 1, author)
+	require.NoError(t, err)
+	require.True(t, done)
+
+	film, err := s.Film().Find(filmID)
+	require.NoError(t, err)
+	assert.Equal(t, "Forrest Gump", film.Title)
+	assert.Equal(t, 8.5, film.Rating)
+	assert.Equal(t, []models.ActorBasic{{ActorID: hanks, Name: "Tom Hanks"}}, film.Actors)
+
+	revisions, err := s.Film().GetRevisions(filmID)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(revisions))
+	assert.Equal(t, revisions[2].Film, revisions[0].Film)
+
+	_, err = s.Film().Revert(filmID, 4, author)
+	assert.Equal(t, store.ErrRecordNotFound, err)
+	_, err = s.Film().Delete(filmID, author)
+	require.NoError(t, err)
+	done, err = s.Film().Revert(filmID, 1, author)
+	require.NoError(t, err)
+	assert.False(t, done)
+}
+
+func testRevisionsRevertEmpty(t *testing.T, s store.Store) {
+	filmID := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06"})
+	_, err := s.Film().Modify(filmID, &models.FilmRequest{Description: "Life is like a box of chocolates", Rating: 8.8}, author)
+	require.NoError(t, err)
+
+	done, err := s.Film().Revert(filmID, 1, author)
+	require.NoError(t, err)
+	require.True(t, done)
+
+	film, err := s.Film().Find(filmID)
+	require.NoError(t, err)
+	assert.Equal(t, "", film.Description)
+	assert.Equal(t, 0.0, film.Rating)
+
+	revisions, err := s.Film().GetRevisions(filmID)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(revisions))
+	assert.Equal(t, models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", ActorsIDs: []int{}}, revisions[0].Film)
+}
+
+func testRevisionsPurge(t *testing.T, s store.Store) {
+	filmID := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.8})
+	_, err := s.Film().Delete(filmID, author)
+	require.NoError(t, err)
+
+	revisions, err := s.Film().GetRevisions(filmID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, len(revisions))
+
+	_, err = s.Trash().Purge(time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	revisions, err = s.Film().GetRevisions(filmID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(revisions))
+}
@@ -0,0 +1,73 @@
+package storetest
+
+import (
+	"testing"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runSearch(t *testing.T, newStore Factory) {
+	runTests(t, newStore, []test{
+		{"Full text", testSearchFullText},
+		{"Suggest", testSearchSuggest},
+	})
+}
+
+func testSearchFullText(t *testing.T, s store.Store) {
+	actorID := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	filmID := createFilm(t, s, &models.FilmRequest{
+		Title:       "Forrest Gump",
+		Description: "A man runs across the country",
+		ReleaseDate: "1994-07-06",
+		Rating:      8.8,
+		ActorsIDs:   []int{actorID},
+	})
+	createFilm(t, s, &models.FilmRequest{Title: "Cast Away", ReleaseDate: "2000-12-22", Rating: 7.8})
+
+	results, err := s.Search().FullText("forrest", 10)
+	assert.NoError(t, err)
+	require.Equal(t, 1, len(results))
+	assert.Equal(t, models.SearchTypeFilm, results[0].Type)
+	assert.Equal(t, filmID, results[0].ID)
+	assert.Equal(t, "Forrest Gump", results[0].Label)
+
+	results, err = s.Search().FullText("country", 10)
+	assert.NoError(t, err)
+	require.Equal(t, 1, len(results))
+	assert.Equal(t, filmID, results[0].ID)
+
+	results, err = s.Search().FullText("hanks", 10)
+	assert.NoError(t, err)
+	require.Equal(t, 1, len(results))
+	assert.Equal(t, models.SearchTypeActor, results[0].Type)
+	assert.Equal(t, actorID, results[0].ID)
+
+	results, err = s.Search().FullText("nothing", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func testSearchSuggest(t *testing.T, s store.Store) {
+	actorID := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	filmID1 := createFilm(t, s, &models.FilmRequest{Title: "Toy Story", ReleaseDate: "1995-11-22", Rating: 8.3})
+	filmID2 := createFilm(t, s, &models.FilmRequest{Title: "Top_Gun", ReleaseDate: "1986-05-16", Rating: 6.9})
+
+	suggestions, err := s.Search().Suggest("to", "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Suggestion{
+		{ID: actorID, Label: "Tom Hanks", Type: models.SearchTypeActor},
+		{ID: filmID2, Label: "Top_Gun", Type: models.SearchTypeFilm},
+		{ID: filmID1, Label: "Toy Story", Type: models.SearchTypeFilm},
+	}, suggestions)
+
+	suggestions, err = s.Search().Suggest("TO", models.SearchTypeFilm, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Suggestion{{ID: filmID2, Label: "Top_Gun", Type: models.SearchTypeFilm}}, suggestions)
+
+	suggestions, err = s.Search().Suggest("to%", "", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, suggestions)
+}
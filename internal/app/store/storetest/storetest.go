@@ -0,0 +1,49 @@
+// Package storetest is a conformance suite for store.Store implementations.
+// It only checks behaviour every implementation has to share, details like
+// fuzzy scores or full text stemming are left to the tests of each package.
+package storetest
+
+import (
+	"testing"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+// author makes every change of the suite.
+var author = models.Author{Login: "admin", RequestID: "storetest"}
+
+// Factory returns an empty store for a single test and a function that
+// releases it. Stores may come with users of their own, but no films and no
+// actors.
+type Factory func(t *testing.T) (store.Store, func())
+
+// Run runs the whole suite, every test gets a store of its own.
+func Run(t *testing.T, newStore Factory) {
+	t.Run("Films", func(t *testing.T) { runFilms(t, newStore) })
+	t.Run("Actors", func(t *testing.T) { runActors(t, newStore) })
+	t.Run("Users", func(t *testing.T) { runUsers(t, newStore) })
+	t.Run("Search", func(t *testing.T) { runSearch(t, newStore) })
+	t.Run("Catalog", func(t *testing.T) { runCatalog(t, newStore) })
+	t.Run("Trash", func(t *testing.T) { runTrash(t, newStore) })
+	t.Run("Audit", func(t *testing.T) { runAudit(t, newStore) })
+	t.Run("Revisions", func(t *testing.T) { runRevisions(t, newStore) })
+	t.Run("Outbox", func(t *testing.T) { runOutbox(t, newStore) })
+	t.Run("Webhooks", func(t *testing.T) { runWebhooks(t, newStore) })
+}
+
+type test struct {
+	name string
+	run  func(t *testing.T, s store.Store)
+}
+
+func runTests(t *testing.T, newStore Factory, tests []test) {
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, teardown := newStore(t)
+			defer teardown()
+
+			tc.run(t, s)
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package storetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runTrash(t *testing.T, newStore Factory) {
+	runTests(t, newStore, []test{
+		{"Deleted records are hidden", testTrashHidden},
+		{"Include deleted", testTrashIncludeDeleted},
+		{"Restore brings links back", testTrashRestore},
+		{"Modify keeps links to deleted actors", testTrashModifyKeepsLinks},
+		{"List", testTrashList},
+		{"Purge", testTrashPurge},
+		{"Import restores", testTrashImport},
+	})
+}
+
+func testTrashHidden(t *testing.T, s store.Store) {
+	actorID := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	filmID := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.8, ActorsIDs: []int{actorID}})
+
+	done, err := s.Film().Delete(filmID, author)
+	require.NoError(t, err)
+	require.True(t, done)
+
+	_, err = s.Film().Find(filmID)
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+	done, err = s.Film().Modify(filmID, &models.FilmRequest{Title: "Gump"}, author)
+	assert.NoError(t, err)
+	assert.False(t, done)
+
+	films, err := s.Film().GetAll(&store.FilmFilter{})
+	assert.NoError(t, err)
+	assert.Empty(t, films)
+	films, err = s.Film().FuzzySearch("Forrest", "", 0.3)
+	assert.NoError(t, err)
+	assert.Empty(t, films)
+
+	actor, err := s.Actor().Find(actorID)
+	require.NoError(t, err)
+	assert.Empty(t, actor.Films)
+
+	results, err := s.Search().FullText("forrest", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+	suggestions, err := s.Search().Suggest("forr", "", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, suggestions)
+
+	var exported []models.FilmRecord
+	err = s.Catalog().Export(nil, func(f *models.FilmRecord) error {
+		exported = append(exported, *f)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, exported)
+}
+
+func testTrashIncludeDeleted(t *testing.T, s store.Store) {
+	actorID1 := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	actorID2 := createActor(t, s, "Robin Wright", "female", "1966-04-08")
+	filmID := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.8, ActorsIDs: []int{actorID1, actorID2}})
+
+	done, err := s.Actor().Delete(actorID2, author)
+	require.NoError(t, err)
+	require.True(t, done)
+
+	film, err := s.Film().Find(filmID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ActorBasic{{ActorID: actorID1, Name: "Tom Hanks"}}, film.Actors)
+
+	films, err := s.Film().GetAll(&store.FilmFilter{ActorIDs: []int{actorID2}})
+	assert.NoError(t, err)
+	assert.Empty(t, films)
+
+	actors, err := s.Actor().GetAll(&store.ActorFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{actorID1}, actorIDs(actors))
+	assert.Empty(t, actors[0].DeletedAt)
+
+	actors, err = s.Actor().GetAll(&store.ActorFilter{IncludeDeleted: true})
+	assert.NoError(t, err)
+	require.Equal(t, []int{actorID2, actorID1}, actorIDs(actors))
+	assert.NotEmpty(t, actors[0].DeletedAt)
+	assert.Equal(t, "admin", actors[0].DeletedBy)
+	assert.Empty(t, actors[1].DeletedAt)
+}
+
+func testTrashRestore(t *testing.T, s store.Store) {
+	actorID := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	filmID := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.8, ActorsIDs: []int{actorID}})
+
+	done, err := s.Film().Restore(filmID, author)
+	assert.NoError(t, err)
+	assert.False(t, done)
+
+	_, err = s.Film().Delete(filmID, author)
+	require.NoError(t, err)
+	_, err = s.Actor().Delete(actorID, author)
+	require.NoError(t, err)
+
+	done, err = s.Film().Restore(filmID, author)
+	assert.NoError(t, err)
+	assert.True(t, done)
+	done, err = s.Film().Restore(filmID, author)
+	assert.NoError(t, err)
+	assert.False(t, done)
+
+	film, err := s.Film().Find(filmID)
+	require.NoError(t, err)
+	assert.Empty(t, film.Actors)
+
+	done, err = s.Actor().Restore(actorID, author)
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	film, err = s.Film().Find(filmID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ActorBasic{{ActorID: actorID, Name: "Tom Hanks"}}, film.Actors)
+	actor, err := s.Actor().Find(actorID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.FilmBasic{{FilmID: filmID, Title: "Forrest Gump"}}, actor.Films)
+}
+
+func testTrashModifyKeepsLinks(t *testing.T, s store.Store) {
+	actorID1 := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	actorID2 := createActor(t, s, "Robin Wright", "female", "1966-04-08")
+	actorID3 := createActor(t, s, "Gary Sinise", "male", "1955-03-17")
+	filmID := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.8, ActorsIDs: []int{actorID1, actorID2}})
+
+	_, err := s.Actor().Delete(actorID2, author)
+	require.NoError(t, err)
+	done, err := s.Film().Modify(filmID, &models.FilmRequest{ActorsIDs: []int{actorID2, actorID3}}, author)
+	require.NoError(t, err)
+	require.True(t, done)
+
+	film, err := s.Film().Find(filmID)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ActorBasic{{ActorID: actorID3, Name: "Gary Sinise"}}, film.Actors)
+
+	_, err = s.Actor().Restore(actorID2, author)
+	require.NoError(t, err)
+
+	film, err = s.Film().Find(filmID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []models.ActorBasic{
+		{ActorID: actorID2, Name: "Robin Wright"},
+		{ActorID: actorID3, Name: "Gary Sinise"},
+	}, film.Actors)
+}
+
+func testTrashList(t *testing.T, s store.Store) {
+	actorID := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	filmID := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.8})
+	createFilm(t, s, &models.FilmRequest{Title: "Cast Away", ReleaseDate: "2000-12-22", Rating: 7.8})
+
+	records, err := s.Trash().List()
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+
+	_, err = s.Film().Delete(filmID, author)
+	require.NoError(t, err)
+	_, err = s.Actor().Delete(actorID, models.Author{Login: "editor"})
+	require.NoError(t, err)
+
+	records, err = s.Trash().List()
+	assert.NoError(t, err)
+	require.Equal(t, 2, len(records))
+	for _, rec := range records {
+		_, err := time.Parse(models.TimestampLayout, rec.DeletedAt)
+		assert.NoError(t, err)
+		rec.DeletedAt = ""
+		switch rec.Type {
+		case models.SearchTypeFilm:
+			assert.Equal(t, models.DeletedRecord{Type: models.SearchTypeFilm, ID: filmID, Label: "Forrest Gump", DeletedBy: "admin"}, rec)
+		default:
+			assert.Equal(t, models.DeletedRecord{Type: models.SearchTypeActor, ID: actorID, Label: "Tom Hanks", DeletedBy: "editor"}, rec)
+		}
+	}
+}
+
+func testTrashPurge(t *testing.T, s store.Store) {
+	actorID := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	filmID1 := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.8, ActorsIDs: []int{actorID}})
+	filmID2 := createFilm(t, s, &models.FilmRequest{Title: "Cast Away", ReleaseDate: "2000-12-22", Rating: 7.8, ActorsIDs: []int{actorID}})
+
+	_, err := s.Film().Delete(filmID1, author)
+	require.NoError(t, err)
+	_, err = s.Actor().Delete(actorID, author)
+	require.NoError(t, err)
+
+	purged, err := s.Trash().Purge(time.Now().Add(-time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, purged)
+
+	purged, err = s.Trash().Purge(time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, purged)
+
+	records, err := s.Trash().List()
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+	done, err := s.Actor().Restore(actorID, author)
+	assert.NoError(t, err)
+	assert.False(t, done)
+
+	films, err := s.Film().GetAll(&store.FilmFilter{IncludeDeleted: true})
+	assert.NoError(t, err)
+	require.Equal(t, []int{filmID2}, filmIDs(films))
+	assert.Empty(t, films[0].Actors)
+}
+
+func testTrashImport(t *testing.T, s store.Store) {
+	filmID := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.8})
+	_, err := s.Film().Delete(filmID, author)
+	require.NoError(t, err)
+
+	res, err := s.Catalog().ImportFilms([]models.FilmRecord{
+		{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.9},
+	}, author)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ImportResult{Created: 0, Updated: 1}, res)
+
+	film, err := s.Film().Find(filmID)
+	require.NoError(t, err)
+	assert.Equal(t, 8.9, film.Rating)
+}
@@ -0,0 +1,148 @@
+package storetest
+
+import (
+	"testing"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/hasher"
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runUsers(t *testing.T, newStore Factory) {
+	runTests(t, newStore, []test{
+		{"Not found", testUserNotFound},
+		{"Create", testUserCreate},
+		{"Roles and passwords", testUserUpdate},
+		{"Modify", testUserModify},
+		{"Replace hash", testUserReplaceHash},
+	})
+}
+
+func testUserNotFound(t *testing.T, s store.Store) {
+	user, err := s.User().Find("storetest-nobody")
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+
+	done, err := s.User().SetAdmin("storetest-nobody", true, author)
+	assert.NoError(t, err)
+	assert.False(t, done)
+
+	done, err = s.User().SetPassword("storetest-nobody", "password", author)
+	assert.NoError(t, err)
+	assert.False(t, done)
+
+	isAdmin := true
+	done, err = s.User().Modify("storetest-nobody", &models.UserUpdate{IsAdmin: &isAdmin}, author)
+	assert.NoError(t, err)
+	assert.False(t, done)
+}
+
+func testUserCreate(t *testing.T, s store.Store) {
+	done, err := s.User().Create(&models.UserRequest{Login: "storetest-user", Password: "password"}, author)
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	done, err = s.User().Create(&models.UserRequest{Login: "storetest-user", Password: "another"}, author)
+	assert.NoError(t, err)
+	assert.False(t, done)
+
+	user, err := s.User().Find("storetest-user")
+	require.NoError(t, err)
+	assert.Equal(t, "storetest-user", user.Login)
+	assert.False(t, user.IsAdmin)
+	assert.True(t, hasher.CheckPasswordHash("password", user.HashedPassword))
+
+	users, err := s.User().GetAll()
+	assert.NoError(t, err)
+	logins := make([]string, 0, len(users))
+	for _, u := range users {
+		logins = append(logins, u.Login)
+	}
+	assert.Contains(t, logins, "storetest-user")
+
+	done, err = s.User().Create(&models.UserRequest{Login: "storetest-admin", Password: "password", IsAdmin: true}, author)
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	admin, err := s.User().Find("storetest-admin")
+	require.NoError(t, err)
+	assert.True(t, admin.IsAdmin)
+}
+
+func testUserUpdate(t *testing.T, s store.Store) {
+	_, err := s.User().Create(&models.UserRequest{Login: "storetest-user", Password: "password"}, author)
+	require.NoError(t, err)
+
+	done, err := s.User().SetAdmin("storetest-user", true, author)
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	done, err = s.User().SetPassword("storetest-user", "changed", author)
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	user, err := s.User().Find("storetest-user")
+	require.NoError(t, err)
+	assert.True(t, user.IsAdmin)
+	assert.True(t, hasher.CheckPasswordHash("changed", user.HashedPassword))
+	assert.False(t, hasher.CheckPasswordHash("password", user.HashedPassword))
+}
+
+func testUserModify(t *testing.T, s store.Store) {
+	_, err := s.User().Create(&models.UserRequest{Login: "storetest-user", Password: "password"}, author)
+	require.NoError(t, err)
+
+	isAdmin, password := true, "changed"
+	done, err := s.User().Modify("storetest-user", &models.UserUpdate{IsAdmin: &isAdmin, Password: &password}, author)
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	user, err := s.User().Find("storetest-user")
+	require.NoError(t, err)
+	assert.True(t, user.IsAdmin)
+	assert.True(t, hasher.CheckPasswordHash("changed", user.HashedPassword))
+
+	isAdmin = false
+	done, err = s.User().Modify("storetest-user", &models.UserUpdate{IsAdmin: &isAdmin}, author)
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	user, err = s.User().Find("storetest-user")
+	require.NoError(t, err)
+	assert.False(t, user.IsAdmin)
+	assert.True(t, hasher.CheckPasswordHash("changed", user.HashedPassword))
+
+	events, err := s.Audit().List(&store.AuditFilter{Entity: models.AuditEntityUser, EntityID: "storetest-user"})
+	require.NoError(t, err)
+	require.Equal(t, 4, len(events))
+	assert.JSONEq(t, `{"is_admin": {"before": true, "after": false}}`, string(events[0].Diff))
+	assert.JSONEq(t, `{"is_admin": {"before": false, "after": true}}`, string(events[1].Diff))
+	assert.JSONEq(t, string(store.PasswordDiff), string(events[2].Diff))
+}
+
+func testUserReplaceHash(t *testing.T, s store.Store) {
+	_, err := s.User().Create(&models.UserRequest{Login: "storetest-user", Password: "password"}, author)
+	require.NoError(t, err)
+	user, err := s.User().Find("storetest-user")
+	require.NoError(t, err)
+
+	rehashed, err := hasher.HashPassword("password")
+	require.NoError(t, err)
+	done, err := s.User().ReplaceHash("storetest-user", user.HashedPassword, rehashed)
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	done, err = s.User().ReplaceHash("storetest-user", user.HashedPassword, "stale")
+	assert.NoError(t, err)
+	assert.False(t, done)
+
+	done, err = s.User().ReplaceHash("storetest-nobody", "", rehashed)
+	assert.NoError(t, err)
+	assert.False(t, done)
+
+	user, err = s.User().Find("storetest-user")
+	require.NoError(t, err)
+	assert.Equal(t, rehashed, user.HashedPassword)
+}
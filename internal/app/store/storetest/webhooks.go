@@ -0,0 +1,218 @@
+package storetest
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runWebhooks(t *testing.T, newStore Factory) {
+	runTests(t, newStore, []test{
+		{"Create", testWebhookCreate},
+		{"Delete", testWebhookDelete},
+		{"Enqueue", testWebhookEnqueue},
+		{"Attempts", testWebhookAttempts},
+		{"Redeliver", testWebhookRedeliver},
+	})
+}
+
+func createWebhook(t *testing.T, s store.Store, events ...string) int {
+	t.Helper()
+
+	id, err := s.Webhook().Create(&models.WebhookRequest{URL: "http://localhost/hook", Events: events, Secret: "secret"})
+	require.NoError(t, err)
+	return id
+}
+
+func deliveryEvents(deliveries []models.WebhookDelivery) []string {
+	res := make([]string, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		res = append(res, delivery.Event)
+	}
+	return res
+}
+
+func testWebhookCreate(t *testing.T, s store.Store) {
+	_, err := s.Webhook().Find(1)
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+
+	first := createWebhook(t, s, "film.created", "actor.deleted")
+	second := createWebhook(t, s, "film.modified")
+
+	webhook, err := s.Webhook().Find(first)
+	require.NoError(t, err)
+	assert.Equal(t, first, webhook.ID)
+	assert.Equal(t, "http://localhost/hook", webhook.URL)
+	assert.Equal(t, []string{"film.created", "actor.deleted"}, webhook.Events)
+	assert.Equal(t, "secret", webhook.Secret)
+	_, err = time.Parse(models.TimestampLayout, webhook.CreatedAt)
+	assert.NoError(t, err)
+
+	webhooks, err := s.Webhook().GetAll()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(webhooks))
+	assert.Equal(t, *webhook, webhooks[0])
+	assert.Equal(t, second, webhooks[1].ID)
+}
+
+func testWebhookDelete(t *testing.T, s store.Store) {
+	id := createWebhook(t, s, "actor.created")
+	createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	_, err := s.Webhook().Enqueue(0)
+	require.NoError(t, err)
+
+	done, err := s.Webhook().Delete(id)
+	require.NoError(t, err)
+	assert.True(t, done)
+
+	done, err = s.Webhook().Delete(id)
+	require.NoError(t, err)
+	assert.False(t, done)
+
+	_, err = s.Webhook().Find(id)
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+	deliveries, err := s.Webhook().Due(time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(deliveries))
+}
+
+func testWebhookEnqueue(t *testing.T, s store.Store) {
+	createActor(t, s, "Before", "male", "1956-07-09")
+	films := createWebhook(t, s, "film.created", "film.deleted")
+	actors := createWebhook(t, s, "actor.created", "actor.modified")
+
+	actorID := createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	filmID := createFilm(t, s, &models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.5, ActorsIDs: []int{actorID}})
+	_, err := s.Actor().Modify(actorID, &models.ActorRequest{Name: "Thomas Hanks"}, author)
+	require.NoError(t, err)
+	_, err = s.Film().Delete(filmID, author)
+	require.NoError(t, err)
+
+	added, err := s.Webhook().Enqueue(1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, added)
+
+	added, err = s.Webhook().Enqueue(0)
+	require.NoError(t, err)
+	assert.Equal(t, 3, added)
+
+	added, err = s.Webhook().Enqueue(0)
+	require.NoError(t, err)
+	assert.Equal(t, 0, added)
+
+	deliveries, err := s.Webhook().GetDeliveries(films, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"film.deleted", "film.created"}, deliveryEvents(deliveries))
+
+	deliveries, err = s.Webhook().GetDeliveries(actors, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"actor.modified", "actor.created"}, deliveryEvents(deliveries))
+
+	var payload models.WebhookPayload
+	require.NoError(t, json.Unmarshal(deliveries[1].Payload, &payload))
+	assert.Equal(t, "actor.created", payload.Event)
+	assert.Equal(t, models.AuditEntityActor, payload.Entity)
+	assert.Equal(t, actorID, payload.EntityID)
+	for _, delivery := range deliveries {
+		assert.Equal(t, actors, delivery.WebhookID)
+		assert.Equal(t, "http://localhost/hook", delivery.URL)
+		assert.Equal(t, models.WebhookStatusPending, delivery.Status)
+		assert.Equal(t, 0, delivery.Attempts)
+	}
+
+	deliveries, err = s.Webhook().GetDeliveries(actors, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"actor.modified"}, deliveryEvents(deliveries))
+}
+
+func testWebhookAttempts(t *testing.T, s store.Store) {
+	id := createWebhook(t, s, "actor.created")
+	createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	createActor(t, s, "Robin Wright", "female", "1966-04-08")
+	_, err := s.Webhook().Enqueue(0)
+	require.NoError(t, err)
+
+	due, err := s.Webhook().Due(time.Now().Add(-time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(due))
+
+	now := time.Now().Add(time.Second)
+	due, err = s.Webhook().Due(now, 10)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(due))
+	first, second := due[0], due[1]
+
+	retryAt := now.Add(time.Minute)
+	require.NoError(t, s.Webhook().RecordAttempt(first.ID, &store.WebhookAttempt{ResponseCode: 500, Error: "unexpected status", NextAttemptAt: retryAt}))
+	require.NoError(t, s.Webhook().RecordAttempt(second.ID, &store.WebhookAttempt{Succeeded: true, ResponseCode: 204}))
+
+	due, err = s.Webhook().Due(now, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(due))
+
+	due, err = s.Webhook().Due(retryAt, 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(due))
+	assert.Equal(t, first.ID, due[0].ID)
+	assert.Equal(t, models.WebhookStatusPending, due[0].Status)
+	assert.Equal(t, 1, due[0].Attempts)
+	assert.Equal(t, 500, due[0].ResponseCode)
+	assert.Equal(t, "unexpected status", due[0].Error)
+	assert.Equal(t, retryAt.UTC().Format(models.TimestampLayout), due[0].NextAttemptAt)
+
+	require.NoError(t, s.Webhook().RecordAttempt(first.ID, &store.WebhookAttempt{Error: "connection refused"}))
+
+	deliveries, err := s.Webhook().GetDeliveries(id, 10)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(deliveries))
+	assert.Equal(t, models.WebhookStatusSucceeded, deliveries[0].Status)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+	assert.Equal(t, 204, deliveries[0].ResponseCode)
+	assert.Empty(t, deliveries[0].NextAttemptAt)
+	_, err = time.Parse(models.TimestampLayout, deliveries[0].DeliveredAt)
+	assert.NoError(t, err)
+	assert.Equal(t, models.WebhookStatusFailed, deliveries[1].Status)
+	assert.Equal(t, 2, deliveries[1].Attempts)
+	assert.Equal(t, 0, deliveries[1].ResponseCode)
+	assert.Equal(t, "connection refused", deliveries[1].Error)
+	assert.Empty(t, deliveries[1].NextAttemptAt)
+	assert.Empty(t, deliveries[1].DeliveredAt)
+}
+
+func testWebhookRedeliver(t *testing.T, s store.Store) {
+	id := createWebhook(t, s, "actor.created")
+	other := createWebhook(t, s, "actor.created")
+	createActor(t, s, "Tom Hanks", "male", "1956-07-09")
+	_, err := s.Webhook().Enqueue(0)
+	require.NoError(t, err)
+
+	deliveries, err := s.Webhook().GetDeliveries(id, 10)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(deliveries))
+	original := deliveries[0]
+	require.NoError(t, s.Webhook().RecordAttempt(original.ID, &store.WebhookAttempt{Error: "connection refused"}))
+
+	_, err = s.Webhook().Redeliver(other, original.ID)
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+	_, err = s.Webhook().Redeliver(id, original.ID+100)
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+
+	redeliveryID, err := s.Webhook().Redeliver(id, original.ID)
+	require.NoError(t, err)
+
+	deliveries, err = s.Webhook().GetDeliveries(id, 10)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(deliveries))
+	redelivery := deliveries[0]
+	assert.Equal(t, redeliveryID, redelivery.ID)
+	assert.Equal(t, original.Event, redelivery.Event)
+	assert.JSONEq(t, string(original.Payload), string(redelivery.Payload))
+	assert.Equal(t, models.WebhookStatusPending, redelivery.Status)
+	assert.Equal(t, 0, redelivery.Attempts)
+	assert.Equal(t, models.WebhookStatusFailed, deliveries[1].Status)
+}
@@ -1,82 +1,241 @@
 package testdb
 
 import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/fuzzy"
 	"github.com/Rbd3178/filmDatabase/internal/app/models"
 	"github.com/Rbd3178/filmDatabase/internal/app/store"
 )
 
 // ActorRepository
 type ActorRepository struct {
-	store  *Store
-	actors map[int]*models.Actor
+	store *Store
 }
 
-var (
-	sampleFilm1 = models.FilmBasic{
-		FilmID: 1,
-		Title: "Title One",
-	}
-
-	sampleFilm2 = models.FilmBasic{
-		FilmID: 2,
-		Title: "Title Two",
-	}
-)
-
 // Create
-func (r *ActorRepository) Create(a *models.ActorRequest) (int, error) {
-	id := len(r.actors) + 1
-	actor := models.Actor{
-		Name: a.Name,
-		Gender: a.Gender,
+func (r *ActorRepository) Create(a *models.ActorRequest, author models.Author) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.lastActorID++
+	id := r.store.lastActorID
+	r.store.actors[id] = &models.Actor{
+		Name:      a.Name,
+		Gender:    a.Gender,
 		BirthDate: a.BirthDate,
-		Films: []models.FilmBasic{sampleFilm1, sampleFilm2},
 	}
-	r.actors[id] = &actor
-	return id, nil
+
+	return id, r.store.audit(author, models.AuditEntityActor, strconv.Itoa(id), models.AuditActionCreate, nil, r.store.actorSnapshot(id))
 }
 
-// Modify
-func (r *ActorRepository) Modify(id int, a *models.ActorRequest) (bool, error) {
-	_, ok := r.actors[id]
-	if !ok {
+// Modify only changes the fields that are set.
+func (r *ActorRepository) Modify(id int, a *models.ActorRequest, author models.Author) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	actor, ok := r.store.actors[id]
+	if !ok || actor.DeletedAt != "" {
 		return false, nil
 	}
+	before := r.store.actorSnapshot(id)
 
-	r.actors[id].Name = a.Name
-	r.actors[id].Gender = a.Gender
-	r.actors[id].BirthDate = a.BirthDate
+	if a.Name != "" {
+		actor.Name = a.Name
+	}
+	if a.Gender != "" {
+		actor.Gender = a.Gender
+	}
+	if a.BirthDate != "" {
+		actor.BirthDate = a.BirthDate
+	}
 
-	return true, nil
+	return true, r.store.audit(author, models.AuditEntityActor, strconv.Itoa(id), models.AuditActionModify, before, r.store.actorSnapshot(id))
 }
 
 // Delete
-func (r *ActorRepository) Delete(id int) (bool, error) {
-	_, ok := r.actors[id]
-	if !ok {
+func (r *ActorRepository) Delete(id int, author models.Author) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	actor, ok := r.store.actors[id]
+	if !ok || actor.DeletedAt != "" {
 		return false, nil
 	}
+	before := r.store.actorSnapshot(id)
+	actor.DeletedAt, actor.DeletedBy = now(), author.Login
 
-	delete(r.actors, id)
+	return true, r.store.audit(author, models.AuditEntityActor, strconv.Itoa(id), models.AuditActionDelete, before, nil)
+}
+
+// Restore
+func (r *ActorRepository) Restore(id int, author models.Author) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
 
-	return true, nil
+	actor, ok := r.store.actors[id]
+	if !ok || actor.DeletedAt == "" {
+		return false, nil
+	}
+	actor.DeletedAt, actor.DeletedBy = "", ""
+
+	return true, r.store.audit(author, models.AuditEntityActor, strconv.Itoa(id), models.AuditActionRestore, nil, r.store.actorSnapshot(id))
 }
 
 // Find
 func (r *ActorRepository) Find(id int) (*models.Actor, error) {
-	actor, ok := r.actors[id]
-	if !ok {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if actor, ok := r.store.actors[id]; !ok || actor.DeletedAt != "" {
 		return nil, store.ErrRecordNotFound
 	}
-	return actor, nil
+	actor := r.store.actor(id)
+	return &actor, nil
 }
 
 // GetAll
-func (r *ActorRepository) GetAll() ([]models.Actor, error) {
-	actors := make([]models.Actor, 0, len(r.actors))
-	for _, actor := range r.actors {
-		actors = append(actors, *actor)
+func (r *ActorRepository) GetAll(filter *store.ActorFilter) ([]models.Actor, error) {
+	sortFields := filter.Sort
+	if len(sortFields) == 0 {
+		sortFields = []store.SortField{{Field: "name"}}
+	}
+	for _, field := range sortFields {
+		if _, ok := actorSortFields[field.Field]; !ok {
+			return nil, errors.Errorf("unknown sort field %q", field.Field)
+		}
+	}
+
+	r.store.mu.RLock()
+	actors := make([]models.Actor, 0, len(r.store.actors))
+	for id, actor := range r.store.actors {
+		if len(filter.IDs) > 0 && !containsID(filter.IDs, id) {
+			continue
+		}
+		if actor.DeletedAt != "" && !filter.IncludeDeleted {
+			continue
+		}
+		if !matchesActorFilter(actor, filter) {
+			continue
+		}
+		actors = append(actors, r.store.actor(id))
+	}
+	r.store.mu.RUnlock()
+
+	sort.Slice(actors, func(i, j int) bool {
+		for _, field := range sortFields {
+			c := compareActors(&actors[i], &actors[j], field.Field)
+			if c == 0 {
+				continue
+			}
+			if field.Desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return actors[i].ID < actors[j].ID
+	})
+
+	for i := range actors {
+		if err := projectActor(&actors[i], filter); err != nil {
+			return nil, err
+		}
 	}
 
 	return actors, nil
 }
+
+var actorSortFields = map[string]struct{}{
+	"name":       {},
+	"birth_date": {},
+	"film_count": {},
+}
+
+func matchesActorFilter(actor *models.Actor, filter *store.ActorFilter) bool {
+	if !containsFold(actor.Name, filter.SearchName) {
+		return false
+	}
+	if filter.Gender != "" && !strings.EqualFold(actor.Gender, filter.Gender) {
+		return false
+	}
+	if filter.BornAfter != "" && actor.BirthDate < filter.BornAfter {
+		return false
+	}
+	if filter.BornBefore != "" && actor.BirthDate > filter.BornBefore {
+		return false
+	}
+
+	return true
+}
+
+func compareActors(a *models.Actor, b *models.Actor, field string) int {
+	switch field {
+	case "name":
+		return strings.Compare(a.Name, b.Name)
+	case "birth_date":
+		return strings.Compare(a.BirthDate, b.BirthDate)
+	case "film_count":
+		return len(a.Films) - len(b.Films)
+	}
+	return 0
+}
+
+// FuzzySearch
+func (r *ActorRepository) FuzzySearch(searchName string, threshold float64) ([]models.Actor, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	actors := make([]models.Actor, 0)
+	for id, actor := range r.store.actors {
+		score := fuzzy.WordSimilarity(searchName, actor.Name)
+		if score < threshold || actor.DeletedAt != "" {
+			continue
+		}
+
+		found := r.store.actor(id)
+		found.Score = score
+		actors = append(actors, found)
+	}
+
+	sort.Slice(actors, func(i, j int) bool {
+		if actors[i].Score != actors[j].Score {
+			return actors[i].Score > actors[j].Score
+		}
+		return actors[i].ID < actors[j].ID
+	})
+
+	return actors, nil
+}
+
+// projectActor clears what the filter does not ask for, like the columns the
+// postgres store does not select.
+func projectActor(actor *models.Actor, filter *store.ActorFilter) error {
+	if filter.OmitFilms {
+		actor.Films = nil
+	}
+	if len(filter.Fields) == 0 {
+		return nil
+	}
+
+	projected := models.Actor{ID: actor.ID, Films: actor.Films, DeletedAt: actor.DeletedAt, DeletedBy: actor.DeletedBy}
+	for _, field := range filter.Fields {
+		switch field {
+		case "id":
+		case "name":
+			projected.Name = actor.Name
+		case "gender":
+			projected.Gender = actor.Gender
+		case "birth_date":
+			projected.BirthDate = actor.BirthDate
+		default:
+			return errors.Errorf("unknown field %q", field)
+		}
+	}
+	*actor = projected
+
+	return nil
+}
@@ -9,18 +9,6 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-var (
-	sampleFilm1 = models.FilmBasic{
-		FilmID: 1,
-		Title: "Title One",
-	}
-
-	sampleFilm2 = models.FilmBasic{
-		FilmID: 2,
-		Title: "Title Two",
-	}
-)
-
 func TestActorRepository_Create(t *testing.T) {
 	s := testdb.New()
 
@@ -30,7 +18,7 @@ func TestActorRepository_Create(t *testing.T) {
 		BirthDate: "1956-07-09",
 	}
 
-	id, err := s.Actor().Create(actorReq)
+	id, err := s.Actor().Create(actorReq, models.Author{Login: "admin"})
 	assert.NoError(t, err)
 	assert.NotNil(t, id)
 }
@@ -44,18 +32,28 @@ func TestActorRepository_Modify(t *testing.T) {
 		BirthDate: "1959-07-09",
 	}
 
-	id, _ := s.Actor().Create(actorReq)
+	id, _ := s.Actor().Create(actorReq, models.Author{Login: "admin"})
 
 	actorReqMod := &models.ActorRequest{
 		Name:      "Tom Hanks",
 		Gender:    "Male",
 		BirthDate: "1956-07-09",
 	}
-	done, err := s.Actor().Modify(id, actorReqMod)
+	done, err := s.Actor().Modify(id, actorReqMod, models.Author{Login: "admin"})
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	done, err = s.Actor().Modify(id, &models.ActorRequest{Gender: "male"}, models.Author{Login: "admin"})
 	assert.NoError(t, err)
 	assert.True(t, done)
 
-	done, err = s.Actor().Modify(id+10, actorReqMod)
+	actor, err := s.Actor().Find(id)
+	assert.NoError(t, err)
+	assert.Equal(t, actorReqMod.Name, actor.Name)
+	assert.Equal(t, "male", actor.Gender)
+	assert.Equal(t, actorReqMod.BirthDate, actor.BirthDate)
+
+	done, err = s.Actor().Modify(id+10, actorReqMod, models.Author{Login: "admin"})
 	assert.NoError(t, err)
 	assert.False(t, done)
 }
@@ -68,7 +66,7 @@ func TestActorRepository_Delete(t *testing.T) {
 		Gender:    "male",
 		BirthDate: "1956-07-09",
 	}
-	id, _ := s.Actor().Create(actorReq)
+	id, _ := s.Actor().Create(actorReq, models.Author{Login: "admin"})
 
 	filmReq := &models.FilmRequest{
 		Title:       "Cool title",
@@ -77,13 +75,17 @@ func TestActorRepository_Delete(t *testing.T) {
 		Rating:      6.8,
 		ActorsIDs:   []int{id},
 	}
-	s.Film().Create(filmReq)
+	filmID, _ := s.Film().Create(filmReq, models.Author{Login: "admin"})
 
-	done, err := s.Actor().Delete(id)
+	done, err := s.Actor().Delete(id, models.Author{Login: "admin"})
 	assert.NoError(t, err)
 	assert.True(t, done)
 
-	done, err = s.Actor().Delete(id)
+	film, err := s.Film().Find(filmID)
+	assert.NoError(t, err)
+	assert.Empty(t, film.Actors)
+
+	done, err = s.Actor().Delete(id, models.Author{Login: "admin"})
 	assert.NoError(t, err)
 	assert.False(t, done)
 }
@@ -97,12 +99,32 @@ func TestActorRepository_Find(t *testing.T) {
 		BirthDate: "1956-07-09",
 	}
 
-	id, _ := s.Actor().Create(actorReq)
+	id, _ := s.Actor().Create(actorReq, models.Author{Login: "admin"})
+
+	filmReq1 := &models.FilmRequest{
+		Title:       "Cool title",
+		Description: "Detailed description",
+		ReleaseDate: "2020-01-01",
+		Rating:      6.8,
+		ActorsIDs:   []int{id},
+	}
+
+	filmReq2 := &models.FilmRequest{
+		Title:       "Cool title 2",
+		Description: "Detailed description",
+		ReleaseDate: "2020-01-01",
+		Rating:      6.8,
+		ActorsIDs:   []int{id},
+	}
+
+	filmID1, _ := s.Film().Create(filmReq1, models.Author{Login: "admin"})
+	filmID2, _ := s.Film().Create(filmReq2, models.Author{Login: "admin"})
 
 	actor, err := s.Actor().Find(id)
 	assert.NoError(t, err)
-	assert.Contains(t, actor.Films, sampleFilm1)
-	assert.Contains(t, actor.Films, sampleFilm2)
+	assert.Equal(t, id, actor.ID)
+	assert.Contains(t, actor.Films, models.FilmBasic{FilmID: filmID1, Title: filmReq1.Title})
+	assert.Contains(t, actor.Films, models.FilmBasic{FilmID: filmID2, Title: filmReq2.Title})
 
 	actor, err = s.Actor().Find(id + 10)
 	assert.Nil(t, actor)
@@ -122,14 +144,174 @@ func TestActorRepository_GetAll(t *testing.T) {
 		Gender:    "female",
 		BirthDate: "1997-11-03",
 	}
-	s.Actor().Create(actorReq1)
-	s.Actor().Create(actorReq2)
+	actorID1, _ := s.Actor().Create(actorReq1, models.Author{Login: "admin"})
+	actorID2, _ := s.Actor().Create(actorReq2, models.Author{Login: "admin"})
 
-	actors, err := s.Actor().GetAll()
+	filmReq1 := &models.FilmRequest{
+		Title:       "Cool title",
+		Description: "Detailed description",
+		ReleaseDate: "2020-01-01",
+		Rating:      6.8,
+		ActorsIDs:   []int{actorID1},
+	}
+	filmReq2 := &models.FilmRequest{
+		Title:       "Cool title 2",
+		Description: "Detailed description",
+		ReleaseDate: "2020-01-01",
+		Rating:      6.8,
+		ActorsIDs:   []int{actorID1, actorID2},
+	}
+	filmID1, _ := s.Film().Create(filmReq1, models.Author{Login: "admin"})
+	filmID2, _ := s.Film().Create(filmReq2, models.Author{Login: "admin"})
+
+	actors, err := s.Actor().GetAll(&store.ActorFilter{})
 	assert.NoError(t, err)
 	assert.Equal(t, 2, len(actors))
 	for _, actor := range actors {
-		assert.Contains(t, actor.Films, sampleFilm1)
-		assert.Contains(t, actor.Films, sampleFilm2)
+		if actor.ID == actorID1 {
+			assert.Contains(t, actor.Films, models.FilmBasic{FilmID: filmID1, Title: filmReq1.Title})
+		}
+		assert.Contains(t, actor.Films, models.FilmBasic{FilmID: filmID2, Title: filmReq2.Title})
+	}
+}
+
+func TestActorRepository_FuzzySearch(t *testing.T) {
+	s := testdb.New()
+
+	actorReq1 := &models.ActorRequest{
+		Name:      "Leonardo DiCaprio",
+		Gender:    "male",
+		BirthDate: "1974-11-11",
+	}
+	actorReq2 := &models.ActorRequest{
+		Name:      "Kate Winslet",
+		Gender:    "female",
+		BirthDate: "1975-10-05",
 	}
+	actorID1, _ := s.Actor().Create(actorReq1, models.Author{Login: "admin"})
+	s.Actor().Create(actorReq2, models.Author{Login: "admin"})
+
+	actors, err := s.Actor().FuzzySearch("Di Caprio", 0.6)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(actors))
+	assert.Equal(t, actorID1, actors[0].ID)
+	assert.Equal(t, 1.0, actors[0].Score)
+
+	actors, err = s.Actor().FuzzySearch("Winslett", 0.6)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(actors))
+	assert.Equal(t, actorReq2.Name, actors[0].Name)
+	assert.Less(t, actors[0].Score, 1.0)
+
+	actors, err = s.Actor().FuzzySearch("Winslett", 0.95)
+	assert.NoError(t, err)
+	assert.Empty(t, actors)
+}
+
+func TestActorRepository_GetAllFiltered(t *testing.T) {
+	s := testdb.New()
+
+	actorReq1 := &models.ActorRequest{
+		Name:      "Tom Hanks",
+		Gender:    "male",
+		BirthDate: "1956-07-09",
+	}
+	actorReq2 := &models.ActorRequest{
+		Name:      "Sophie Patel",
+		Gender:    "female",
+		BirthDate: "1997-11-03",
+	}
+	actorReq3 := &models.ActorRequest{
+		Name:      "Tom Hardy",
+		Gender:    "male",
+		BirthDate: "1977-09-15",
+	}
+	actorID1, _ := s.Actor().Create(actorReq1, models.Author{Login: "admin"})
+	actorID2, _ := s.Actor().Create(actorReq2, models.Author{Login: "admin"})
+	actorID3, _ := s.Actor().Create(actorReq3, models.Author{Login: "admin"})
+
+	var tests = []struct {
+		name        string
+		filter      *store.ActorFilter
+		expectedIDs []int
+	}{
+		{
+			name:        "Ordered by name by default",
+			filter:      &store.ActorFilter{},
+			expectedIDs: []int{actorID2, actorID1, actorID3},
+		},
+		{
+			name:        "Name search",
+			filter:      &store.ActorFilter{SearchName: "tom"},
+			expectedIDs: []int{actorID1, actorID3},
+		},
+		{
+			name:        "Gender",
+			filter:      &store.ActorFilter{Gender: "Female"},
+			expectedIDs: []int{actorID2},
+		},
+		{
+			name:        "Birth date range",
+			filter:      &store.ActorFilter{BornAfter: "1960-01-01", BornBefore: "1990-01-01"},
+			expectedIDs: []int{actorID3},
+		},
+		{
+			name:        "Sort by birth date",
+			filter:      &store.ActorFilter{Sort: []store.SortField{{Field: "birth_date", Desc: true}}},
+			expectedIDs: []int{actorID2, actorID3, actorID1},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actors, err := s.Actor().GetAll(tc.filter)
+			assert.NoError(t, err)
+			ids := make([]int, 0, len(actors))
+			for _, actor := range actors {
+				ids = append(ids, actor.ID)
+			}
+			assert.Equal(t, tc.expectedIDs, ids)
+		})
+	}
+}
+
+func TestActorRepository_Iterate(t *testing.T) {
+	s := testdb.New()
+
+	actorID1, _ := s.Actor().Create(&models.ActorRequest{Name: "Tom Hardy", Gender: "male", BirthDate: "1977-09-15"}, models.Author{Login: "admin"})
+	actorID2, _ := s.Actor().Create(&models.ActorRequest{Name: "Tom Hanks", Gender: "male", BirthDate: "1956-07-09"}, models.Author{Login: "admin"})
+
+	it, err := s.Actor().Iterate(&store.ActorFilter{SearchName: "tom"})
+	assert.NoError(t, err)
+	defer it.Close()
+
+	var ids []int
+	for it.Next() {
+		ids = append(ids, it.Actor().ID)
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []int{actorID2, actorID1}, ids)
+}
+
+func TestActorRepository_GetAllProjected(t *testing.T) {
+	s := testdb.New()
+
+	actorID, _ := s.Actor().Create(&models.ActorRequest{Name: "Tom Hardy", Gender: "male", BirthDate: "1977-09-15"}, models.Author{Login: "admin"})
+
+	actors, err := s.Actor().GetAll(&store.ActorFilter{Fields: []string{"name"}, OmitFilms: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Actor{{ID: actorID, Name: "Tom Hardy"}}, actors)
+
+	_, err = s.Actor().GetAll(&store.ActorFilter{Fields: []string{"height"}})
+	assert.Error(t, err)
+}
+
+func TestActorRepository_GetAllByIDs(t *testing.T) {
+	s := testdb.New()
+
+	s.Actor().Create(&models.ActorRequest{Name: "Tom Hardy", Gender: "male", BirthDate: "1977-09-15"}, models.Author{Login: "admin"})
+	actorID, _ := s.Actor().Create(&models.ActorRequest{Name: "Emily Blunt", Gender: "female", BirthDate: "1983-02-23"}, models.Author{Login: "admin"})
+
+	actors, err := s.Actor().GetAll(&store.ActorFilter{IDs: []int{actorID, 42}, Fields: []string{"name"}, OmitFilms: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Actor{{ID: actorID, Name: "Emily Blunt"}}, actors)
 }
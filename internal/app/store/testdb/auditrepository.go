@@ -0,0 +1,101 @@
+package testdb
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+// AuditRepository
+type AuditRepository struct {
+	store *Store
+}
+
+// List
+func (r *AuditRepository) List(filter *store.AuditFilter) ([]models.AuditEvent, error) {
+	var since, until string
+	if !filter.Since.IsZero() {
+		since = filter.Since.UTC().Format(models.TimestampLayout)
+	}
+	if !filter.Until.IsZero() {
+		until = filter.Until.UTC().Format(models.TimestampLayout)
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	events := make([]models.AuditEvent, 0)
+	for i := len(r.store.auditEvents) - 1; i >= 0; i-- {
+		event := r.store.auditEvents[i]
+		if filter.Limit > 0 && len(events) == filter.Limit {
+			break
+		}
+		if filter.Entity != "" && event.Entity != filter.Entity ||
+			filter.EntityID != "" && event.EntityID != filter.EntityID ||
+			filter.Action != "" && event.Action != filter.Action ||
+			filter.Login != "" && event.Login != filter.Login ||
+			filter.RequestID != "" && event.RequestID != filter.RequestID ||
+			since != "" && event.At < since ||
+			until != "" && event.At >= until ||
+			filter.BeforeID > 0 && event.ID >= filter.BeforeID {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// filmSnapshot is nil for missing and deleted films. Must be called with
+// s.mu held.
+func (s *Store) filmSnapshot(id int) store.Snapshot {
+	if film, ok := s.films[id]; !ok || film.DeletedAt != "" {
+		return nil
+	}
+	film := s.film(id)
+	return store.FilmSnapshot(&film)
+}
+
+// actorSnapshot is nil for missing and deleted actors. Must be called with
+// s.mu held.
+func (s *Store) actorSnapshot(id int) store.Snapshot {
+	if actor, ok := s.actors[id]; !ok || actor.DeletedAt != "" {
+		return nil
+	}
+	actor := s.actor(id)
+	return store.ActorSnapshot(&actor)
+}
+
+// audit records how a record changed, changes of films and actors also go to
+// the outbox. Must be called with s.mu held.
+func (s *Store) audit(author models.Author, entity string, entityID string, action string, before store.Snapshot, after store.Snapshot) error {
+	diff, err := store.Diff(before, after)
+	if err != nil {
+		return err
+	}
+	if diff == nil {
+		return nil
+	}
+	s.record(author, entity, entityID, action, diff)
+	if entity != models.AuditEntityUser {
+		id, _ := strconv.Atoi(entityID)
+		s.change(entity, id, action)
+	}
+	return nil
+}
+
+// record appends an event to the audit trail. Must be called with s.mu held.
+func (s *Store) record(author models.Author, entity string, entityID string, action string, diff json.RawMessage) {
+	s.auditEvents = append(s.auditEvents, models.AuditEvent{
+		ID:        len(s.auditEvents) + 1,
+		At:        now(),
+		Login:     author.Login,
+		RequestID: author.RequestID,
+		Entity:    entity,
+		EntityID:  entityID,
+		Action:    action,
+		Diff:      diff,
+	})
+}
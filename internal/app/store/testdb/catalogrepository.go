@@ -0,0 +1,256 @@
+package testdb
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+// CatalogRepository
+type CatalogRepository struct {
+	store *Store
+}
+
+// ImportActors restores matched actors that are in the trash.
+func (r *CatalogRepository) ImportActors(actors []models.ActorRecord, author models.Author) (*models.ImportResult, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	res := &models.ImportResult{}
+	for _, rec := range actors {
+		id, ok := r.findActor(&rec)
+		if !ok {
+			r.store.lastActorID++
+			id = r.store.lastActorID
+			r.store.actors[id] = &models.Actor{}
+		}
+		actor := r.store.actors[id]
+		trashed := actor.DeletedAt != ""
+		actor.DeletedAt, actor.DeletedBy = "", ""
+		var before store.Snapshot
+		if ok {
+			before = r.store.actorSnapshot(id)
+		}
+		actor.Name = rec.Name
+		actor.Gender = rec.Gender
+		actor.BirthDate = rec.BirthDate
+		if rec.ExternalID != "" {
+			r.store.actorExternal[id] = rec.ExternalID
+		}
+		if err := r.audit(res, author, models.AuditEntityActor, id, importAction(ok, trashed), before, r.store.actorSnapshot(id)); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// importAction is create for rows the import did not match and restore for
+// the matched ones it took out of the trash.
+func importAction(matched bool, trashed bool) string {
+	switch {
+	case !matched:
+		return models.AuditActionCreate
+	case trashed:
+		return models.AuditActionRestore
+	}
+	return models.AuditActionModify
+}
+
+// audit records how an imported row changed and counts it, rows the import
+// left as they were are skipped. before is the row out of the trash, the
+// restore is recorded with the whole row like Restore does. Films whose
+// content changed also get a revision.
+func (r *CatalogRepository) audit(res *models.ImportResult, author models.Author, entity string, id int, action string, before store.Snapshot, after store.Snapshot) error {
+	change, err := store.Diff(before, after)
+	if err != nil {
+		return err
+	}
+	if change == nil && action == models.AuditActionModify {
+		return nil
+	}
+
+	diff := change
+	if action == models.AuditActionRestore {
+		if diff, err = store.Diff(nil, after); err != nil {
+			return err
+		}
+	}
+	r.store.record(author, entity, strconv.Itoa(id), action, diff)
+	r.store.change(entity, id, action)
+	if action == models.AuditActionCreate {
+		res.Created++
+	} else {
+		res.Updated++
+	}
+
+	if entity != models.AuditEntityFilm || change == nil {
+		return nil
+	}
+	return r.store.revise(author, id, after)
+}
+
+func (r *CatalogRepository) findActor(rec *models.ActorRecord) (int, bool) {
+	for id, actor := range r.store.actors {
+		external := r.store.actorExternal[id]
+		if rec.ExternalID != "" && external == rec.ExternalID {
+			return id, true
+		}
+		if (rec.ExternalID == "" || external == "") && actor.Name == rec.Name && actor.BirthDate == rec.BirthDate {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// ImportFilms restores matched films that are in the trash.
+func (r *CatalogRepository) ImportFilms(films []models.FilmRecord, author models.Author) (*models.ImportResult, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	res := &models.ImportResult{}
+	for _, rec := range films {
+		id, ok := r.findFilm(&rec)
+		if !ok {
+			r.store.lastFilmID++
+			id = r.store.lastFilmID
+			r.store.films[id] = &filmRecord{}
+		}
+		film := r.store.films[id]
+		trashed := film.DeletedAt != ""
+		film.DeletedAt, film.DeletedBy = "", ""
+		var before store.Snapshot
+		if ok {
+			before = r.store.filmSnapshot(id)
+		}
+		film.Title = rec.Title
+		film.Description = rec.Description
+		film.ReleaseDate = rec.ReleaseDate
+		film.Rating = rec.Rating
+		r.store.recast(film, r.resolveCast(rec.Cast))
+		if rec.ExternalID != "" {
+			r.store.filmExternal[id] = rec.ExternalID
+		}
+		if err := r.audit(res, author, models.AuditEntityFilm, id, importAction(ok, trashed), before, r.store.filmSnapshot(id)); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+func (r *CatalogRepository) findFilm(rec *models.FilmRecord) (int, bool) {
+	for id, film := range r.store.films {
+		external := r.store.filmExternal[id]
+		if rec.ExternalID != "" && external == rec.ExternalID {
+			return id, true
+		}
+		if (rec.ExternalID == "" || external == "") && film.Title == rec.Title && film.ReleaseDate == rec.ReleaseDate {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// resolveCast looks cast keys up by external id first and by name second,
+// skipping the ones that match no actor. Deleted actors are not matched.
+func (r *CatalogRepository) resolveCast(cast []string) []int {
+	var actors []int
+	seen := make(map[int]bool)
+	for _, key := range cast {
+		id := 0
+		for actorID, external := range r.store.actorExternal {
+			if external == key && r.store.actors[actorID].DeletedAt == "" {
+				id = actorID
+			}
+		}
+		if id == 0 {
+			for actorID, actor := range r.store.actors {
+				if actor.DeletedAt == "" && actor.Name == key && (id == 0 || actorID < id) {
+					id = actorID
+				}
+			}
+		}
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		actors = append(actors, id)
+	}
+	return actors
+}
+
+// UnknownCast
+func (r *CatalogRepository) UnknownCast(keys []string) ([]string, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	unknown := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if len(r.resolveCast([]string{key})) == 0 {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+
+	return unknown, nil
+}
+
+// Export
+func (r *CatalogRepository) Export(actor func(*models.ActorRecord) error, film func(*models.FilmRecord) error) error {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if actor != nil {
+		for _, id := range sortedIDs(r.store.actors) {
+			a := r.store.actors[id]
+			if a.DeletedAt != "" {
+				continue
+			}
+			err := actor(&models.ActorRecord{
+				ExternalID: r.store.actorExternal[id],
+				Name:       a.Name,
+				Gender:     a.Gender,
+				BirthDate:  a.BirthDate,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if film != nil {
+		for _, id := range sortedIDs(r.store.films) {
+			if r.store.films[id].DeletedAt != "" {
+				continue
+			}
+			f := r.store.film(id)
+			rec := &models.FilmRecord{
+				ExternalID:  r.store.filmExternal[id],
+				Title:       f.Title,
+				Description: f.Description,
+				ReleaseDate: f.ReleaseDate,
+				Rating:      f.Rating,
+			}
+			for _, a := range f.Actors {
+				key := r.store.actorExternal[a.ActorID]
+				if key == "" {
+					key = a.Name
+				}
+				rec.Cast = append(rec.Cast, key)
+			}
+			if err := film(rec); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
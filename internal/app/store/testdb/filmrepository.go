@@ -1,87 +1,325 @@
 package testdb
 
 import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/fuzzy"
 	"github.com/Rbd3178/filmDatabase/internal/app/models"
 	"github.com/Rbd3178/filmDatabase/internal/app/store"
 )
 
 // FilmRepository
 type FilmRepository struct {
-	store  *Store
-	films  map[int]*models.Film
-	actors map[int]*models.ActorBasic
+	store *Store
 }
 
 // Create
-func (r *FilmRepository) Create(f *models.FilmRequest) (int, error) {
-	id := len(r.films) + 1
-	film := models.Film{
+func (r *FilmRepository) Create(f *models.FilmRequest, author models.Author) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.lastFilmID++
+	id := r.store.lastFilmID
+	r.store.films[id] = &filmRecord{
 		Title:       f.Title,
 		Description: f.Description,
 		ReleaseDate: f.ReleaseDate,
 		Rating:      f.Rating,
+		ActorIDs:    r.store.cast(f.ActorsIDs),
 	}
-	for _, actorID := range f.ActorsIDs {
-		actor, ok := r.actors[actorID]
-		if !ok {
-			continue
-		}
-		film.Actors = append(film.Actors, *actor)
-	}
-	r.films[id] = &film
-	return id, nil
+
+	return id, r.audit(author, models.AuditActionCreate, id, nil)
 }
 
 // GetAll
-func (r *FilmRepository) GetAll(orderBy string, order string, searchTitle string, searchActor string) ([]models.Film, error) {
+func (r *FilmRepository) GetAll(filter *store.FilmFilter) ([]models.Film, error) {
+	for _, field := range filter.Sort {
+		if _, ok := filmSortFields[field.Field]; !ok {
+			return nil, errors.Errorf("unknown sort field %q", field.Field)
+		}
+	}
+
+	r.store.mu.RLock()
 	films := make([]models.Film, 0)
-	for _, film := range r.films {
-		films = append(films, *film)
+	for id, rec := range r.store.films {
+		if len(filter.IDs) > 0 && !containsID(filter.IDs, id) {
+			continue
+		}
+		if rec.DeletedAt != "" && !filter.IncludeDeleted {
+			continue
+		}
+		film := r.store.film(id)
+		if !matchesFilmFilter(&film, filter) {
+			continue
+		}
+		films = append(films, film)
 	}
+	r.store.mu.RUnlock()
+
+	sort.Slice(films, func(i, j int) bool {
+		for _, field := range filter.Sort {
+			c := compareFilms(&films[i], &films[j], field.Field)
+			if c == 0 {
+				continue
+			}
+			if field.Desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return films[i].ID < films[j].ID
+	})
+
+	for i := range films {
+		if err := projectFilm(&films[i], filter); err != nil {
+			return nil, err
+		}
+	}
+
 	return films, nil
 }
 
+var filmSortFields = map[string]struct{}{
+	"title":        {},
+	"rating":       {},
+	"release_date": {},
+}
+
+func matchesFilmFilter(film *models.Film, filter *store.FilmFilter) bool {
+	if !containsFold(film.Title, filter.SearchTitle) {
+		return false
+	}
+	if filter.SearchActor != "" {
+		found := false
+		for _, actor := range film.Actors {
+			found = found || containsFold(actor.Name, filter.SearchActor)
+		}
+		if !found {
+			return false
+		}
+	}
+	if filter.RatingMin != nil && film.Rating < *filter.RatingMin {
+		return false
+	}
+	if filter.RatingMax != nil && film.Rating > *filter.RatingMax {
+		return false
+	}
+	if filter.ReleasedAfter != "" && film.ReleaseDate < filter.ReleasedAfter {
+		return false
+	}
+	if filter.ReleasedBefore != "" && film.ReleaseDate > filter.ReleasedBefore {
+		return false
+	}
+	if filter.HasDescription != nil && *filter.HasDescription != (film.Description != "") {
+		return false
+	}
+	if len(filter.ActorIDs) > 0 {
+		cast := make(map[int]struct{}, len(film.Actors))
+		for _, actor := range film.Actors {
+			cast[actor.ActorID] = struct{}{}
+		}
+		matched := 0
+		for _, id := range filter.ActorIDs {
+			if _, ok := cast[id]; ok {
+				matched++
+			}
+		}
+		if matched == 0 || filter.ActorMatch == store.ActorMatchAll && matched < len(filter.ActorIDs) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func compareFilms(a *models.Film, b *models.Film, field string) int {
+	switch field {
+	case "title":
+		return strings.Compare(a.Title, b.Title)
+	case "release_date":
+		return strings.Compare(a.ReleaseDate, b.ReleaseDate)
+	case "rating":
+		switch {
+		case a.Rating < b.Rating:
+			return -1
+		case a.Rating > b.Rating:
+			return 1
+		}
+	}
+	return 0
+}
+
+func containsFold(s string, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
 // Delete
-func (r *FilmRepository) Delete(id int) (bool, error) {
-	_, ok := r.films[id]
-	if !ok {
+func (r *FilmRepository) Delete(id int, author models.Author) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	film, ok := r.store.films[id]
+	if !ok || film.DeletedAt != "" {
 		return false, nil
 	}
+	before := r.store.filmSnapshot(id)
+	film.DeletedAt, film.DeletedBy = now(), author.Login
+
+	return true, r.store.audit(author, models.AuditEntityFilm, strconv.Itoa(id), models.AuditActionDelete, before, nil)
+}
+
+// Restore
+func (r *FilmRepository) Restore(id int, author models.Author) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	film, ok := r.store.films[id]
+	if !ok || film.DeletedAt == "" {
+		return false, nil
+	}
+	film.DeletedAt, film.DeletedBy = "", ""
+
+	return true, r.store.audit(author, models.AuditEntityFilm, strconv.Itoa(id), models.AuditActionRestore, nil, r.store.filmSnapshot(id))
+}
 
-	delete(r.films, id)
+// audit records how the created or modified film changed since before, a
+// change of its content also adds a revision. Must be called with s.mu held.
+func (r *FilmRepository) audit(author models.Author, action string, id int, before store.Snapshot) error {
+	after := r.store.filmSnapshot(id)
+	if err := r.store.audit(author, models.AuditEntityFilm, strconv.Itoa(id), action, before, after); err != nil {
+		return err
+	}
+	diff, err := store.Diff(before, after)
+	if err != nil || diff == nil {
+		return err
+	}
 
-	return true, nil
+	return r.store.revise(author, id, after)
 }
 
 // Find
 func (r *FilmRepository) Find(id int) (*models.Film, error) {
-	film, ok := r.films[id]
-	if !ok {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if film, ok := r.store.films[id]; !ok || film.DeletedAt != "" {
 		return nil, store.ErrRecordNotFound
 	}
-	return film, nil
+	film := r.store.film(id)
+	return &film, nil
 }
 
-// Modify
-func (r *FilmRepository) Modify(id int, f *models.FilmRequest) (bool, error) {
-	_, ok := r.films[id]
-	if !ok {
+// Modify only changes the fields that are set, the cast is replaced as a
+// whole apart from deleted actors.
+func (r *FilmRepository) Modify(id int, f *models.FilmRequest, author models.Author) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	film, ok := r.store.films[id]
+	if !ok || film.DeletedAt != "" {
 		return false, nil
 	}
+	before := r.store.filmSnapshot(id)
 
-	r.films[id].Title = f.Title
-	r.films[id].Description = f.Description
-	r.films[id].ReleaseDate = f.ReleaseDate
-	r.films[id].Rating = f.Rating
+	if f.Title != "" {
+		film.Title = f.Title
+	}
+	if f.Description != "" {
+		film.Description = f.Description
+	}
+	if f.ReleaseDate != "" {
+		film.ReleaseDate = f.ReleaseDate
+	}
+	if f.Rating != 0 {
+		film.Rating = f.Rating
+	}
+	r.store.recast(film, f.ActorsIDs)
 
-	r.films[id].Actors = make([]models.ActorBasic, 0)
-	for _, actorID := range f.ActorsIDs {
-		actor, ok := r.actors[actorID]
-		if !ok {
+	return true, r.audit(author, models.AuditActionModify, id, before)
+}
+
+// FuzzySearch
+func (r *FilmRepository) FuzzySearch(searchTitle string, searchActor string, threshold float64) ([]models.Film, error) {
+	films := make([]models.Film, 0)
+	if searchTitle == "" && searchActor == "" {
+		return films, nil
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for id, rec := range r.store.films {
+		if rec.DeletedAt != "" {
 			continue
 		}
-		r.films[id].Actors = append(r.films[id].Actors, *actor)
+		film := r.store.film(id)
+		var scores []float64
+		if searchTitle != "" {
+			scores = append(scores, fuzzy.WordSimilarity(searchTitle, film.Title))
+		}
+		if searchActor != "" {
+			var best float64
+			for _, actor := range film.Actors {
+				best = max(best, fuzzy.WordSimilarity(searchActor, actor.Name))
+			}
+			scores = append(scores, best)
+		}
+
+		var total float64
+		matches := true
+		for _, score := range scores {
+			matches = matches && score >= threshold
+			total += score
+		}
+		if !matches {
+			continue
+		}
+
+		film.Score = total / float64(len(scores))
+		films = append(films, film)
+	}
+
+	sort.Slice(films, func(i, j int) bool {
+		if films[i].Score != films[j].Score {
+			return films[i].Score > films[j].Score
+		}
+		return films[i].ID < films[j].ID
+	})
+
+	return films, nil
+}
+
+// projectFilm clears what the filter does not ask for, like the columns the
+// postgres store does not select.
+func projectFilm(film *models.Film, filter *store.FilmFilter) error {
+	if filter.OmitActors {
+		film.Actors = nil
+	}
+	if len(filter.Fields) == 0 {
+		return nil
+	}
+
+	projected := models.Film{ID: film.ID, Actors: film.Actors, DeletedAt: film.DeletedAt, DeletedBy: film.DeletedBy}
+	for _, field := range filter.Fields {
+		switch field {
+		case "id":
+		case "title":
+			projected.Title = film.Title
+		case "description":
+			projected.Description = film.Description
+		case "release_date":
+			projected.ReleaseDate = film.ReleaseDate
+		case "rating":
+			projected.Rating = film.Rating
+		default:
+			return errors.Errorf("unknown field %q", field)
+		}
 	}
+	*film = projected
 
-	return true, nil
+	return nil
 }
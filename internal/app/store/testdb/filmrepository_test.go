@@ -9,8 +9,16 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// createActors adds the actors with ids 1, 2 and 3 the film tests refer to.
+func createActors(s *testdb.Store) {
+	for _, name := range []string{"First Actor", "Second Actor", "Third Actor"} {
+		s.Actor().Create(&models.ActorRequest{Name: name, Gender: "male", BirthDate: "1970-01-01"}, models.Author{Login: "admin"})
+	}
+}
+
 func TestFilmRepository_Create(t *testing.T) {
 	s := testdb.New()
+	createActors(s)
 
 	filmReq := &models.FilmRequest{
 		Title:       "Cool title",
@@ -20,13 +28,14 @@ func TestFilmRepository_Create(t *testing.T) {
 		ActorsIDs:   []int{1, 2, 5},
 	}
 
-	id, err := s.Film().Create(filmReq)
+	id, err := s.Film().Create(filmReq, models.Author{Login: "admin"})
 	assert.NoError(t, err)
 	assert.NotNil(t, id)
 }
 
 func TestFilmRepository_Delete(t *testing.T) {
 	s := testdb.New()
+	createActors(s)
 
 	filmReq := &models.FilmRequest{
 		Title:       "Cool title",
@@ -35,19 +44,37 @@ func TestFilmRepository_Delete(t *testing.T) {
 		Rating:      7.8,
 		ActorsIDs:   []int{2, 3},
 	}
-	filmId, _ := s.Film().Create(filmReq)
+	filmId, _ := s.Film().Create(filmReq, models.Author{Login: "admin"})
 
-	done, err := s.Film().Delete(filmId)
+	done, err := s.Film().Delete(filmId, models.Author{Login: "admin"})
 	assert.NoError(t, err)
 	assert.True(t, done)
 
-	done, err = s.Film().Delete(filmId)
+	done, err = s.Film().Delete(filmId, models.Author{Login: "admin"})
 	assert.NoError(t, err)
 	assert.False(t, done)
 }
 
+func TestFilmRepository_CreateAfterDelete(t *testing.T) {
+	s := testdb.New()
+
+	filmID1, _ := s.Film().Create(&models.FilmRequest{Title: "Alpha", ReleaseDate: "1999-05-01", Rating: 7.8}, models.Author{Login: "admin"})
+	filmID2, _ := s.Film().Create(&models.FilmRequest{Title: "Beta", ReleaseDate: "2005-01-01", Rating: 6.1}, models.Author{Login: "admin"})
+	s.Film().Delete(filmID1, models.Author{Login: "admin"})
+
+	filmID3, err := s.Film().Create(&models.FilmRequest{Title: "Gamma", ReleaseDate: "2015-01-01", Rating: 5.5}, models.Author{Login: "admin"})
+	assert.NoError(t, err)
+	assert.NotEqual(t, filmID1, filmID3)
+	assert.NotEqual(t, filmID2, filmID3)
+
+	film, err := s.Film().Find(filmID2)
+	assert.NoError(t, err)
+	assert.Equal(t, "Beta", film.Title)
+}
+
 func TestFilmRepository_Modify(t *testing.T) {
 	s := testdb.New()
+	createActors(s)
 
 	filmReq := &models.FilmRequest{
 		Title:       "Cool title",
@@ -56,7 +83,7 @@ func TestFilmRepository_Modify(t *testing.T) {
 		Rating:      6.8,
 		ActorsIDs:   []int{2},
 	}
-	filmID, _ := s.Film().Create(filmReq)
+	filmID, _ := s.Film().Create(filmReq, models.Author{Login: "admin"})
 
 	filmReqMod := &models.FilmRequest{
 		Description: "Even more detailed description",
@@ -64,17 +91,26 @@ func TestFilmRepository_Modify(t *testing.T) {
 		ActorsIDs:   []int{1, 3},
 	}
 
-	done, err := s.Film().Modify(filmID, filmReqMod)
+	done, err := s.Film().Modify(filmID, filmReqMod, models.Author{Login: "admin"})
 	assert.NoError(t, err)
 	assert.True(t, done)
 
-	done, err = s.Film().Modify(filmID+10, filmReqMod)
+	film, err := s.Film().Find(filmID)
+	assert.NoError(t, err)
+	assert.Equal(t, filmReq.Title, film.Title)
+	assert.Equal(t, filmReqMod.Description, film.Description)
+	assert.Equal(t, filmReq.ReleaseDate, film.ReleaseDate)
+	assert.Equal(t, filmReqMod.Rating, film.Rating)
+	assert.Equal(t, []models.ActorBasic{{ActorID: 1, Name: "First Actor"}, {ActorID: 3, Name: "Third Actor"}}, film.Actors)
+
+	done, err = s.Film().Modify(filmID+10, filmReqMod, models.Author{Login: "admin"})
 	assert.NoError(t, err)
 	assert.False(t, done)
 }
 
 func TestFilmRepository_Find(t *testing.T) {
 	s := testdb.New()
+	createActors(s)
 
 	filmReq := &models.FilmRequest{
 		Title:       "Cool title",
@@ -83,10 +119,11 @@ func TestFilmRepository_Find(t *testing.T) {
 		Rating:      6.8,
 		ActorsIDs:   []int{2, 3},
 	}
-	filmID, _ := s.Film().Create(filmReq)
+	filmID, _ := s.Film().Create(filmReq, models.Author{Login: "admin"})
 
 	film, err := s.Film().Find(filmID)
 	assert.NoError(t, err)
+	assert.Equal(t, filmID, film.ID)
 	assert.Contains(t, film.Actors, models.ActorBasic{
 		ActorID: 2,
 		Name: "Second Actor",
@@ -103,6 +140,7 @@ func TestFilmRepository_Find(t *testing.T) {
 
 func TestFilmRepository_GetAll(t *testing.T) {
 	s := testdb.New()
+	createActors(s)
 
 	filmReq1 := &models.FilmRequest{
 		Title:       "Cool title",
@@ -118,21 +156,200 @@ func TestFilmRepository_GetAll(t *testing.T) {
 		Rating:      6.8,
 		ActorsIDs:   []int{},
 	}
-	filmID1, _ := s.Film().Create(filmReq1)
-	filmID2, _ := s.Film().Create(filmReq2)
+	filmID1, _ := s.Film().Create(filmReq1, models.Author{Login: "admin"})
+	filmID2, _ := s.Film().Create(filmReq2, models.Author{Login: "admin"})
 
-	films, err := s.Film().GetAll("rating", "desc", "cool", "third")
+	films, err := s.Film().GetAll(&store.FilmFilter{Sort: []store.SortField{{Field: "rating", Desc: true}}})
 	assert.NoError(t, err)
 	assert.Equal(t, 2, len(films))
-	for _, film := range films {
-		if film.ID == filmID2 {
-			assert.Contains(t, film.Actors, models.ActorBasic{ActorID: 1, Name: "First Actor"})
-			assert.Contains(t, film.Actors, models.ActorBasic{ActorID: 2, Name: "Second Actor"})
-			assert.Contains(t, film.Actors, models.ActorBasic{ActorID: 3, Name: "Third Actor"})
-			assert.Equal(t, filmReq2.Title, film.Title)
-		}
-		if film.ID == filmID1 {
-			assert.Empty(t, film.Actors)
-		}
-	}
-}
\ No newline at end of file
+	assert.Equal(t, filmID1, films[0].ID)
+	assert.Equal(t, filmID2, films[1].ID)
+	assert.Empty(t, films[1].Actors)
+
+	films, err = s.Film().GetAll(&store.FilmFilter{SearchTitle: "cool", SearchActor: "third"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(films))
+	assert.Equal(t, filmID1, films[0].ID)
+	assert.Equal(t, filmReq1.Title, films[0].Title)
+	assert.Contains(t, films[0].Actors, models.ActorBasic{ActorID: 1, Name: "First Actor"})
+	assert.Contains(t, films[0].Actors, models.ActorBasic{ActorID: 2, Name: "Second Actor"})
+	assert.Contains(t, films[0].Actors, models.ActorBasic{ActorID: 3, Name: "Third Actor"})
+}
+
+func TestFilmRepository_GetAllFiltered(t *testing.T) {
+	s := testdb.New()
+	createActors(s)
+
+	filmReq1 := &models.FilmRequest{
+		Title:       "Alpha",
+		Description: "Detailed description",
+		ReleaseDate: "1999-05-01",
+		Rating:      7.8,
+		ActorsIDs:   []int{1, 2},
+	}
+	filmReq2 := &models.FilmRequest{
+		Title:       "Beta",
+		ReleaseDate: "2005-01-01",
+		Rating:      7.8,
+		ActorsIDs:   []int{2},
+	}
+	filmReq3 := &models.FilmRequest{
+		Title:       "Gamma",
+		Description: "Detailed description",
+		ReleaseDate: "2015-01-01",
+		Rating:      5.5,
+		ActorsIDs:   []int{3},
+	}
+	filmID1, _ := s.Film().Create(filmReq1, models.Author{Login: "admin"})
+	filmID2, _ := s.Film().Create(filmReq2, models.Author{Login: "admin"})
+	filmID3, _ := s.Film().Create(filmReq3, models.Author{Login: "admin"})
+
+	ratingMin, ratingMax := 6.0, 8.0
+	hasDescription := true
+
+	var tests = []struct {
+		name        string
+		filter      *store.FilmFilter
+		expectedIDs []int
+	}{
+		{
+			name:        "Rating range",
+			filter:      &store.FilmFilter{RatingMin: &ratingMin, RatingMax: &ratingMax},
+			expectedIDs: []int{filmID1, filmID2},
+		},
+		{
+			name:        "Release date range",
+			filter:      &store.FilmFilter{ReleasedAfter: "2000-01-01", ReleasedBefore: "2010-12-31"},
+			expectedIDs: []int{filmID2},
+		},
+		{
+			name:        "Any of actors",
+			filter:      &store.FilmFilter{ActorIDs: []int{1, 3}, ActorMatch: store.ActorMatchAny},
+			expectedIDs: []int{filmID1, filmID3},
+		},
+		{
+			name:        "All of actors",
+			filter:      &store.FilmFilter{ActorIDs: []int{1, 2}, ActorMatch: store.ActorMatchAll},
+			expectedIDs: []int{filmID1},
+		},
+		{
+			name:        "Has description",
+			filter:      &store.FilmFilter{HasDescription: &hasDescription},
+			expectedIDs: []int{filmID1, filmID3},
+		},
+		{
+			name: "Multi-key sort",
+			filter: &store.FilmFilter{Sort: []store.SortField{
+				{Field: "rating", Desc: true},
+				{Field: "title", Desc: true},
+			}},
+			expectedIDs: []int{filmID2, filmID1, filmID3},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			films, err := s.Film().GetAll(tc.filter)
+			assert.NoError(t, err)
+			ids := make([]int, 0, len(films))
+			for _, film := range films {
+				ids = append(ids, film.ID)
+			}
+			assert.Equal(t, tc.expectedIDs, ids)
+		})
+	}
+}
+
+func TestFilmRepository_FuzzySearch(t *testing.T) {
+	s := testdb.New()
+	createActors(s)
+
+	filmReq1 := &models.FilmRequest{
+		Title:       "Titanic",
+		Description: "Detailed description",
+		ReleaseDate: "1997-12-19",
+		Rating:      7.9,
+		ActorsIDs:   []int{1},
+	}
+	filmReq2 := &models.FilmRequest{
+		Title:       "Titan A.E.",
+		Description: "Detailed description",
+		ReleaseDate: "2000-06-16",
+		Rating:      6.6,
+		ActorsIDs:   []int{2},
+	}
+	filmID1, _ := s.Film().Create(filmReq1, models.Author{Login: "admin"})
+	filmID2, _ := s.Film().Create(filmReq2, models.Author{Login: "admin"})
+
+	films, err := s.Film().FuzzySearch("Titanc", "", 0.5)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(films))
+	assert.Equal(t, filmID1, films[0].ID)
+	assert.Equal(t, filmID2, films[1].ID)
+	assert.Greater(t, films[0].Score, films[1].Score)
+
+	films, err = s.Film().FuzzySearch("Titanc", "Secnd Actor", 0.7)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(films))
+	assert.Equal(t, filmID2, films[0].ID)
+
+	films, err = s.Film().FuzzySearch("", "", 0.5)
+	assert.NoError(t, err)
+	assert.Empty(t, films)
+}
+
+func TestFilmRepository_Iterate(t *testing.T) {
+	s := testdb.New()
+	createActors(s)
+
+	filmID1, _ := s.Film().Create(&models.FilmRequest{Title: "Beta", ReleaseDate: "2005-01-01", Rating: 7.8, ActorsIDs: []int{1, 2}}, models.Author{Login: "admin"})
+	filmID2, _ := s.Film().Create(&models.FilmRequest{Title: "Alpha", ReleaseDate: "1999-05-01", Rating: 5.5}, models.Author{Login: "admin"})
+
+	it, err := s.Film().Iterate(&store.FilmFilter{Sort: []store.SortField{{Field: "title"}}})
+	assert.NoError(t, err)
+	defer it.Close()
+
+	var ids []int
+	for it.Next() {
+		ids = append(ids, it.Film().ID)
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []int{filmID2, filmID1}, ids)
+}
+
+func TestFilmRepository_GetAllProjected(t *testing.T) {
+	s := testdb.New()
+	createActors(s)
+
+	filmID, _ := s.Film().Create(&models.FilmRequest{
+		Title:       "Alpha",
+		Description: "Detailed description",
+		ReleaseDate: "1999-05-01",
+		Rating:      7.8,
+		ActorsIDs:   []int{1},
+	}, models.Author{Login: "admin"})
+
+	films, err := s.Film().GetAll(&store.FilmFilter{Fields: []string{"title"}, OmitActors: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Film{{ID: filmID, Title: "Alpha"}}, films)
+
+	films, err = s.Film().GetAll(&store.FilmFilter{Fields: []string{"rating"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Film{{ID: filmID, Rating: 7.8, Actors: []models.ActorBasic{{ActorID: 1, Name: "First Actor"}}}}, films)
+
+	_, err = s.Film().GetAll(&store.FilmFilter{Fields: []string{"budget"}})
+	assert.Error(t, err)
+
+	_, err = s.Film().GetAll(&store.FilmFilter{Sort: []store.SortField{{Field: "budget"}}})
+	assert.Error(t, err)
+}
+
+func TestFilmRepository_GetAllByIDs(t *testing.T) {
+	s := testdb.New()
+
+	s.Film().Create(&models.FilmRequest{Title: "Alpha", ReleaseDate: "1999-05-01", Rating: 7.8}, models.Author{Login: "admin"})
+	betaID, _ := s.Film().Create(&models.FilmRequest{Title: "Beta", ReleaseDate: "2001-05-01", Rating: 6.1}, models.Author{Login: "admin"})
+
+	films, err := s.Film().GetAll(&store.FilmFilter{IDs: []int{betaID, 42}, Fields: []string{"title"}, OmitActors: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Film{{ID: betaID, Title: "Beta"}}, films)
+}
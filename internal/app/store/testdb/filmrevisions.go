@@ -0,0 +1,82 @@
+package testdb
+
+import (
+	"encoding/json"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+// GetRevisions
+func (r *FilmRepository) GetRevisions(id int) ([]models.FilmRevision, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	stored := r.store.filmRevisions[id]
+	revisions := make([]models.FilmRevision, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- {
+		revisions = append(revisions, stored[i])
+	}
+
+	return revisions, nil
+}
+
+// FindRevision
+func (r *FilmRepository) FindRevision(id int, n int) (*models.FilmRevision, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	stored := r.store.filmRevisions[id]
+	if n < 1 || n > len(stored) {
+		return nil, store.ErrRecordNotFound
+	}
+	revision := stored[n-1]
+	return &revision, nil
+}
+
+// Revert
+func (r *FilmRepository) Revert(id int, n int, author models.Author) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored := r.store.filmRevisions[id]
+	if n < 1 || n > len(stored) {
+		return false, store.ErrRecordNotFound
+	}
+	film, ok := r.store.films[id]
+	if !ok || film.DeletedAt != "" {
+		return false, nil
+	}
+	before := r.store.filmSnapshot(id)
+
+	revision := stored[n-1].Film
+	film.Title = revision.Title
+	film.Description = revision.Description
+	film.ReleaseDate = revision.ReleaseDate
+	film.Rating = revision.Rating
+	r.store.recast(film, revision.ActorsIDs)
+
+	return true, r.audit(author, models.AuditActionModify, id, before)
+}
+
+// revise adds the snapshot of the film as its next revision, decoded the way
+// the sql stores read it back. Must be called with s.mu held.
+func (s *Store) revise(author models.Author, id int, film store.Snapshot) error {
+	content, err := json.Marshal(film)
+	if err != nil {
+		return err
+	}
+	revision := models.FilmRevision{
+		FilmID:    id,
+		Revision:  len(s.filmRevisions[id]) + 1,
+		At:        now(),
+		Login:     author.Login,
+		RequestID: author.RequestID,
+	}
+	if err := json.Unmarshal(content, &revision.Film); err != nil {
+		return err
+	}
+	s.filmRevisions[id] = append(s.filmRevisions[id], revision)
+
+	return nil
+}
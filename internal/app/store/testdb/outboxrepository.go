@@ -0,0 +1,67 @@
+package testdb
+
+import (
+	"time"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+)
+
+// OutboxRepository
+type OutboxRepository struct {
+	store *Store
+}
+
+// List
+func (r *OutboxRepository) List(after int, limit int) ([]models.ChangeEvent, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	events := make([]models.ChangeEvent, 0)
+	for _, event := range r.store.changeEvents {
+		if limit > 0 && len(events) == limit {
+			break
+		}
+		if event.Seq > after {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+// Purge
+func (r *OutboxRepository) Purge(before time.Time) (int, error) {
+	cutoff := before.UTC().Format(models.TimestampLayout)
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	// Events not yet given to every webhook are kept, whatever their age.
+	given := r.store.lastChangeSeq
+	for _, rec := range r.store.webhooks {
+		given = min(given, rec.lastSeq)
+	}
+
+	var kept []models.ChangeEvent
+	for _, event := range r.store.changeEvents {
+		if event.At >= cutoff || event.Seq > given {
+			kept = append(kept, event)
+		}
+	}
+	purged := len(r.store.changeEvents) - len(kept)
+	r.store.changeEvents = kept
+
+	return purged, nil
+}
+
+// change appends an event to the outbox. Must be called with s.mu held.
+func (s *Store) change(entity string, id int, action string) {
+	s.lastChangeSeq++
+	s.changeEvents = append(s.changeEvents, models.ChangeEvent{
+		Seq:      s.lastChangeSeq,
+		At:       now(),
+		Entity:   entity,
+		EntityID: id,
+		Action:   action,
+	})
+}
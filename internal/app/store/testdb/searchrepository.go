@@ -0,0 +1,187 @@
+package testdb
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+)
+
+// SearchRepository
+type SearchRepository struct {
+	store *Store
+}
+
+// FullText is a simplified version of the postgres search: every query word
+// has to be a prefix of some word of the document, titles weigh more than
+// descriptions.
+func (r *SearchRepository) FullText(query string, limit int) ([]models.SearchResult, error) {
+	terms := splitWords(strings.ToLower(query))
+	results := make([]models.SearchResult, 0)
+	if len(terms) == 0 {
+		return results, nil
+	}
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for id, film := range r.store.films {
+		if film.DeletedAt != "" {
+			continue
+		}
+		rank := matchRank(terms, film.Title, 1) + matchRank(terms, film.Description, 0.4)
+		if !matchesAll(terms, film.Title+" "+film.Description) {
+			continue
+		}
+		results = append(results, models.SearchResult{
+			Type:    models.SearchTypeFilm,
+			ID:      id,
+			Label:   film.Title,
+			Snippet: highlight(terms, film.Title+". "+film.Description),
+			Rank:    rank,
+		})
+	}
+
+	for id, actor := range r.store.actors {
+		if actor.DeletedAt != "" || !matchesAll(terms, actor.Name) {
+			continue
+		}
+		results = append(results, models.SearchResult{
+			Type:    models.SearchTypeActor,
+			ID:      id,
+			Label:   actor.Name,
+			Snippet: highlight(terms, actor.Name),
+			Rank:    matchRank(terms, actor.Name, 1),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		if results[i].Type != results[j].Type {
+			return results[i].Type < results[j].Type
+		}
+		return results[i].ID < results[j].ID
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+func splitWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func matchesTerm(term string, word string) bool {
+	return strings.HasPrefix(strings.ToLower(word), term)
+}
+
+func matchesAll(terms []string, text string) bool {
+	words := splitWords(text)
+	for _, term := range terms {
+		found := false
+		for _, word := range words {
+			if matchesTerm(term, word) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func matchRank(terms []string, text string, weight float64) float64 {
+	var rank float64
+	for _, word := range splitWords(text) {
+		for _, term := range terms {
+			if matchesTerm(term, word) {
+				rank += weight
+			}
+		}
+	}
+	return rank
+}
+
+func highlight(terms []string, text string) string {
+	var b strings.Builder
+	word := make([]rune, 0)
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		w := string(word)
+		marked := false
+		for _, term := range terms {
+			if matchesTerm(term, w) {
+				marked = true
+				break
+			}
+		}
+		if marked {
+			b.WriteString("<b>" + w + "</b>")
+		} else {
+			b.WriteString(w)
+		}
+		word = word[:0]
+	}
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			word = append(word, r)
+			continue
+		}
+		flush()
+		b.WriteRune(r)
+	}
+	flush()
+	return b.String()
+}
+
+// Suggest
+func (r *SearchRepository) Suggest(prefix string, kind string, limit int) ([]models.Suggestion, error) {
+	prefix = strings.ToLower(prefix)
+	suggestions := make([]models.Suggestion, 0)
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	if kind != models.SearchTypeActor {
+		for id, film := range r.store.films {
+			if film.DeletedAt == "" && strings.HasPrefix(strings.ToLower(film.Title), prefix) {
+				suggestions = append(suggestions, models.Suggestion{ID: id, Label: film.Title, Type: models.SearchTypeFilm})
+			}
+		}
+	}
+
+	if kind != models.SearchTypeFilm {
+		for id, actor := range r.store.actors {
+			if actor.DeletedAt == "" && strings.HasPrefix(strings.ToLower(actor.Name), prefix) {
+				suggestions = append(suggestions, models.Suggestion{ID: id, Label: actor.Name, Type: models.SearchTypeActor})
+			}
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		li, lj := strings.ToLower(suggestions[i].Label), strings.ToLower(suggestions[j].Label)
+		if li != lj {
+			return li < lj
+		}
+		if suggestions[i].Type != suggestions[j].Type {
+			return suggestions[i].Type < suggestions[j].Type
+		}
+		return suggestions[i].ID < suggestions[j].ID
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	return suggestions, nil
+}
@@ -0,0 +1,105 @@
+package testdb_test
+
+import (
+	"testing"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/testdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchRepository_FullText(t *testing.T) {
+	s := testdb.New()
+
+	actorReq := &models.ActorRequest{
+		Name:      "Tom Hanks",
+		Gender:    "male",
+		BirthDate: "1956-07-09",
+	}
+	actorID, _ := s.Actor().Create(actorReq, models.Author{Login: "admin"})
+
+	filmReq1 := &models.FilmRequest{
+		Title:       "Forrest Gump",
+		Description: "Tom runs across the country",
+		ReleaseDate: "1994-07-06",
+		Rating:      8.8,
+	}
+	filmReq2 := &models.FilmRequest{
+		Title:       "Cast Away",
+		Description: "Stranded on an island, Tom talks to a volleyball",
+		ReleaseDate: "2000-12-22",
+		Rating:      7.8,
+	}
+	filmID1, _ := s.Film().Create(filmReq1, models.Author{Login: "admin"})
+	filmID2, _ := s.Film().Create(filmReq2, models.Author{Login: "admin"})
+
+	results, err := s.Search().FullText("tom", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(results))
+	assert.Equal(t, models.SearchTypeActor, results[0].Type)
+	assert.Equal(t, actorID, results[0].ID)
+	assert.Equal(t, "<b>Tom</b> Hanks", results[0].Snippet)
+
+	results, err = s.Search().FullText("island", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(results))
+	assert.Equal(t, filmID2, results[0].ID)
+	assert.Contains(t, results[0].Snippet, "<b>island</b>")
+
+	results, err = s.Search().FullText("forrest", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(results))
+	assert.Equal(t, filmID1, results[0].ID)
+
+	results, err = s.Search().FullText("tom", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(results))
+
+	results, err = s.Search().FullText("nothing", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestSearchRepository_Suggest(t *testing.T) {
+	s := testdb.New()
+
+	actorReq := &models.ActorRequest{
+		Name:      "Tom Hanks",
+		Gender:    "male",
+		BirthDate: "1956-07-09",
+	}
+	actorID, _ := s.Actor().Create(actorReq, models.Author{Login: "admin"})
+
+	filmReq1 := &models.FilmRequest{
+		Title:       "Toy Story",
+		ReleaseDate: "1995-11-22",
+		Rating:      8.3,
+	}
+	filmReq2 := &models.FilmRequest{
+		Title:       "Top Gun",
+		ReleaseDate: "1986-05-16",
+		Rating:      6.9,
+	}
+	filmID1, _ := s.Film().Create(filmReq1, models.Author{Login: "admin"})
+	filmID2, _ := s.Film().Create(filmReq2, models.Author{Login: "admin"})
+
+	suggestions, err := s.Search().Suggest("to", "", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Suggestion{
+		{ID: actorID, Label: "Tom Hanks", Type: models.SearchTypeActor},
+		{ID: filmID2, Label: "Top Gun", Type: models.SearchTypeFilm},
+		{ID: filmID1, Label: "Toy Story", Type: models.SearchTypeFilm},
+	}, suggestions)
+
+	suggestions, err = s.Search().Suggest("to", models.SearchTypeFilm, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Suggestion{{ID: filmID2, Label: "Top Gun", Type: models.SearchTypeFilm}}, suggestions)
+
+	suggestions, err = s.Search().Suggest("TOM", models.SearchTypeActor, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(suggestions))
+
+	suggestions, err = s.Search().Suggest("story", "", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, suggestions)
+}
@@ -1,85 +1,253 @@
 package testdb
 
 import (
+	"sort"
+	"sync"
+	"time"
+
 	"github.com/Rbd3178/filmDatabase/internal/app/hasher"
 	"github.com/Rbd3178/filmDatabase/internal/app/models"
 	"github.com/Rbd3178/filmDatabase/internal/app/store"
 )
 
-// Store
+// Store keeps everything in memory. All repositories share the same state
+// and the same lock, so films and actors see each other the way the tables
+// of the postgres store do.
 type Store struct {
-	userRepository *UserRepository
-	actorRepository *ActorRepository
-	filmRepository *FilmRepository
+	mu sync.RWMutex
+
+	// users are seeded on first use, hashing their passwords is slow.
+	seedUsers sync.Once
+
+	users  map[string]*models.User
+	actors map[int]*models.Actor
+	films  map[int]*filmRecord
+
+	// lastActorID and lastFilmID work like serial sequences, ids of deleted
+	// records are never handed out again.
+	lastActorID int
+	lastFilmID  int
+
+	actorExternal map[int]string
+	filmExternal  map[int]string
+
+	auditEvents   []models.AuditEvent
+	filmRevisions map[int][]models.FilmRevision
+
+	// changeEvents is the outbox, lastChangeSeq keeps growing when old events
+	// are purged.
+	changeEvents  []models.ChangeEvent
+	lastChangeSeq int
+
+	webhooks       map[int]*webhookRecord
+	deliveries     map[int]*models.WebhookDelivery
+	lastWebhookID  int
+	lastDeliveryID int
+
+	userRepository    *UserRepository
+	actorRepository   *ActorRepository
+	filmRepository    *FilmRepository
+	searchRepository  *SearchRepository
+	catalogRepository *CatalogRepository
+	trashRepository   *TrashRepository
+	auditRepository   *AuditRepository
+	outboxRepository  *OutboxRepository
+	webhookRepository *WebhookRepository
 }
 
-// New
-func New() *Store {
-	return &Store{}
+// filmRecord is a film as it is stored, the cast is kept as actor ids and
+// resolved on every read.
+type filmRecord struct {
+	Title       string
+	Description string
+	ReleaseDate string
+	Rating      float64
+	ActorIDs    []int
+	DeletedAt   string
+	DeletedBy   string
 }
 
-// User
-func (s *Store) User() store.UserRepository {
-	if s.userRepository != nil {
-		return s.userRepository
+// New returns an empty store with two users for tests, normal:correct and
+// admin:adminpass. Their passwords are known, so never serve it, see
+// NewEmpty.
+func New() *Store {
+	s := &Store{
+		users:         make(map[string]*models.User),
+		actors:        make(map[int]*models.Actor),
+		films:         make(map[int]*filmRecord),
+		actorExternal: make(map[int]string),
+		filmExternal:  make(map[int]string),
+		filmRevisions: make(map[int][]models.FilmRevision),
+		webhooks:      make(map[int]*webhookRecord),
+		deliveries:    make(map[int]*models.WebhookDelivery),
 	}
+	s.userRepository = &UserRepository{store: s}
+	s.actorRepository = &ActorRepository{store: s}
+	s.filmRepository = &FilmRepository{store: s}
+	s.searchRepository = &SearchRepository{store: s}
+	s.catalogRepository = &CatalogRepository{store: s}
+	s.trashRepository = &TrashRepository{store: s}
+	s.auditRepository = &AuditRepository{store: s}
+	s.outboxRepository = &OutboxRepository{store: s}
+	s.webhookRepository = &WebhookRepository{store: s}
 
-	s.userRepository = &UserRepository{
-		store: s,
-		users: make(map[string]*models.User),
-	}
+	return s
+}
+
+// NewEmpty returns a store without any users, for serving from memory.
+func NewEmpty() *Store {
+	s := New()
+	s.seedUsers.Do(func() {})
+	return s
+}
+
+func (s *Store) createUsers() {
 	normalHashedPass, _ := hasher.HashPassword("correct")
 	adminHashedPass, _ := hasher.HashPassword("adminpass")
-	s.userRepository.users["normal"] = &models.User{
-		Login: "normal",
+	s.users["normal"] = &models.User{
+		Login:          "normal",
 		HashedPassword: normalHashedPass,
-		IsAdmin: false,
+		IsAdmin:        false,
 	}
-	s.userRepository.users["admin"] = &models.User{
-		Login: "admin",
+	s.users["admin"] = &models.User{
+		Login:          "admin",
 		HashedPassword: adminHashedPass,
-		IsAdmin: true,
+		IsAdmin:        true,
 	}
+}
 
+// User
+func (s *Store) User() store.UserRepository {
+	s.seedUsers.Do(s.createUsers)
 	return s.userRepository
 }
 
 // Actor
 func (s *Store) Actor() store.ActorRepository {
-	if s.actorRepository != nil {
-		return s.actorRepository
-	}
-
-	s.actorRepository = &ActorRepository{
-		store: s,
-		actors: make(map[int]*models.Actor),
-	}
-
 	return s.actorRepository
 }
 
 // Film
 func (s *Store) Film() store.FilmRepository {
-	if s.filmRepository != nil {
-		return s.filmRepository
+	return s.filmRepository
+}
+
+// Search
+func (s *Store) Search() store.SearchRepository {
+	return s.searchRepository
+}
+
+// Catalog
+func (s *Store) Catalog() store.CatalogRepository {
+	return s.catalogRepository
+}
+
+// Trash
+func (s *Store) Trash() store.TrashRepository {
+	return s.trashRepository
+}
+
+// Audit
+func (s *Store) Audit() store.AuditRepository {
+	return s.auditRepository
+}
+
+// Outbox
+func (s *Store) Outbox() store.OutboxRepository {
+	return s.outboxRepository
+}
+
+// Webhook
+func (s *Store) Webhook() store.WebhookRepository {
+	return s.webhookRepository
+}
+
+// film resolves the cast of the stored film, leaving deleted actors out.
+// Must be called with s.mu held.
+func (s *Store) film(id int) models.Film {
+	rec := s.films[id]
+	film := models.Film{
+		ID:          id,
+		Title:       rec.Title,
+		Description: rec.Description,
+		ReleaseDate: rec.ReleaseDate,
+		Rating:      rec.Rating,
+		DeletedAt:   rec.DeletedAt,
+		DeletedBy:   rec.DeletedBy,
+	}
+	for _, actorID := range rec.ActorIDs {
+		if s.actors[actorID].DeletedAt != "" {
+			continue
+		}
+		film.Actors = append(film.Actors, models.ActorBasic{
+			ActorID: actorID,
+			Name:    s.actors[actorID].Name,
+		})
 	}
+	return film
+}
 
-	s.filmRepository = &FilmRepository{
-		store: s,
-		films: make(map[int]*models.Film),
-		actors: make(map[int]*models.ActorBasic),
+// actor collects the films the stored actor plays in, leaving deleted films
+// out. Must be called with s.mu held.
+func (s *Store) actor(id int) models.Actor {
+	actor := *s.actors[id]
+	actor.ID = id
+	actor.Films = nil
+	for _, filmID := range sortedIDs(s.films) {
+		if s.films[filmID].DeletedAt == "" && containsID(s.films[filmID].ActorIDs, id) {
+			actor.Films = append(actor.Films, models.FilmBasic{
+				FilmID: filmID,
+				Title:  s.films[filmID].Title,
+			})
+		}
 	}
-	s.filmRepository.actors[1] = &models.ActorBasic{
-		ActorID: 1,
-		Name: "First Actor",
+	return actor
+}
+
+// cast drops the ids of missing or deleted actors and duplicates, like the
+// inserts into films_x_actors do. Must be called with s.mu held.
+func (s *Store) cast(actorIDs []int) []int {
+	var ids []int
+	for _, id := range actorIDs {
+		if actor, ok := s.actors[id]; !ok || actor.DeletedAt != "" || containsID(ids, id) {
+			continue
+		}
+		ids = append(ids, id)
 	}
-	s.filmRepository.actors[2] = &models.ActorBasic{
-		ActorID: 2,
-		Name: "Second Actor",
+	return ids
+}
+
+// recast replaces the cast of the film with actorIDs but keeps the links to
+// deleted actors for their restore. Must be called with s.mu held.
+func (s *Store) recast(film *filmRecord, actorIDs []int) {
+	ids := s.cast(actorIDs)
+	for _, id := range film.ActorIDs {
+		if s.actors[id].DeletedAt != "" {
+			ids = append(ids, id)
+		}
 	}
-	s.filmRepository.actors[3] = &models.ActorBasic{
-		ActorID: 3,
-		Name: "Third Actor",
+	film.ActorIDs = ids
+}
+
+// now is the deletion time of records deleted now.
+func now() string {
+	return time.Now().UTC().Format(models.TimestampLayout)
+}
+
+func sortedIDs[T any](m map[int]T) []int {
+	ids := make([]int, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
 	}
-	return s.filmRepository
-}
\ No newline at end of file
+	sort.Ints(ids)
+	return ids
+}
+
+func containsID(ids []int, id int) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
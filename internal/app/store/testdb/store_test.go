@@ -0,0 +1,45 @@
+package testdb_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/storetest"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/testdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) (store.Store, func()) {
+		return testdb.New(), func() {}
+	})
+}
+
+func TestStore_ConcurrentAccess(t *testing.T) {
+	s := testdb.New()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			actorID, _ := s.Actor().Create(&models.ActorRequest{Name: "Tom Hardy", Gender: "male", BirthDate: "1977-09-15"}, models.Author{Login: "admin"})
+			filmID, _ := s.Film().Create(&models.FilmRequest{Title: "Alpha", ReleaseDate: "1999-05-01", Rating: 5.5, ActorsIDs: []int{actorID}}, models.Author{Login: "admin"})
+			s.Film().Modify(filmID, &models.FilmRequest{Rating: 6.5, ActorsIDs: []int{actorID}}, models.Author{Login: "admin"})
+			s.Film().GetAll(&store.FilmFilter{SearchActor: "tom"})
+			s.Actor().GetAll(&store.ActorFilter{})
+			s.Search().FullText("alpha", 10)
+		}()
+	}
+	wg.Wait()
+
+	films, err := s.Film().GetAll(&store.FilmFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 8, len(films))
+	for _, film := range films {
+		assert.Equal(t, 6.5, film.Rating)
+		assert.Equal(t, 1, len(film.Actors))
+	}
+}
@@ -0,0 +1,94 @@
+package testdb
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+)
+
+// TrashRepository
+type TrashRepository struct {
+	store *Store
+}
+
+// List returns deleted films and actors, the latest deletions first.
+func (r *TrashRepository) List() ([]models.DeletedRecord, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	records := make([]models.DeletedRecord, 0)
+	for id, film := range r.store.films {
+		if film.DeletedAt == "" {
+			continue
+		}
+		records = append(records, models.DeletedRecord{
+			Type:      models.SearchTypeFilm,
+			ID:        id,
+			Label:     film.Title,
+			DeletedAt: film.DeletedAt,
+			DeletedBy: film.DeletedBy,
+		})
+	}
+	for id, actor := range r.store.actors {
+		if actor.DeletedAt == "" {
+			continue
+		}
+		records = append(records, models.DeletedRecord{
+			Type:      models.SearchTypeActor,
+			ID:        id,
+			Label:     actor.Name,
+			DeletedAt: actor.DeletedAt,
+			DeletedBy: actor.DeletedBy,
+		})
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].DeletedAt != records[j].DeletedAt {
+			return records[i].DeletedAt > records[j].DeletedAt
+		}
+		if records[i].Type != records[j].Type {
+			return records[i].Type < records[j].Type
+		}
+		return records[i].ID < records[j].ID
+	})
+
+	return records, nil
+}
+
+// Purge also drops the purged actors from the casts they were kept in.
+func (r *TrashRepository) Purge(before time.Time) (int, error) {
+	cutoff := before.UTC().Format(models.TimestampLayout)
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	purged := 0
+	for id, film := range r.store.films {
+		if film.DeletedAt != "" && film.DeletedAt < cutoff {
+			delete(r.store.films, id)
+			delete(r.store.filmExternal, id)
+			delete(r.store.filmRevisions, id)
+			purged++
+		}
+	}
+	for id, actor := range r.store.actors {
+		if actor.DeletedAt == "" || actor.DeletedAt >= cutoff {
+			continue
+		}
+		for _, film := range r.store.films {
+			var cast []int
+			for _, actorID := range film.ActorIDs {
+				if actorID != id {
+					cast = append(cast, actorID)
+				}
+			}
+			film.ActorIDs = cast
+		}
+		delete(r.store.actors, id)
+		delete(r.store.actorExternal, id)
+		purged++
+	}
+
+	return purged, nil
+}
@@ -9,43 +9,133 @@ import (
 // UserRepository
 type UserRepository struct {
 	store *Store
-	users map[string]*models.User
 }
 
 // Create
-func (r *UserRepository) Create(u *models.UserRequest) (bool, error) {
+func (r *UserRepository) Create(u *models.UserRequest, author models.Author) (bool, error) {
 	hashedPassword, err := hasher.HashPassword(u.Password)
 	if err != nil {
 		return false, err
 	}
-	if _, ok := r.users[u.Login]; ok {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.users[u.Login]; ok {
 		return false, nil
 	}
 	user := &models.User{
 		Login:          u.Login,
 		HashedPassword: hashedPassword,
-		IsAdmin:        false,
+		IsAdmin:        u.IsAdmin,
 	}
-	r.users[u.Login] = user
+	r.store.users[u.Login] = user
 
-	return true, nil
+	return true, r.store.audit(author, models.AuditEntityUser, u.Login, models.AuditActionCreate, nil, store.UserSnapshot(user))
 }
 
 // Find ...
 func (r *UserRepository) Find(login string) (*models.User, error) {
-	u, ok := r.users[login]
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	u, ok := r.store.users[login]
 	if !ok {
 		return nil, store.ErrRecordNotFound
 	}
+	found := *u
 
-	return u, nil
+	return &found, nil
 }
 
 // GetAll
 func (r *UserRepository) GetAll() ([]models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
 	users := make([]models.User, 0)
-	for _, u := range r.users {
+	for _, u := range r.store.users {
 		users = append(users, *u)
 	}
 	return users, nil
 }
+
+// SetAdmin
+func (r *UserRepository) SetAdmin(login string, isAdmin bool, author models.Author) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	u, ok := r.store.users[login]
+	if !ok {
+		return false, nil
+	}
+	before := store.UserSnapshot(u)
+	u.IsAdmin = isAdmin
+
+	return true, r.store.audit(author, models.AuditEntityUser, login, models.AuditActionModify, before, store.UserSnapshot(u))
+}
+
+// SetPassword
+func (r *UserRepository) SetPassword(login string, password string, author models.Author) (bool, error) {
+	hashedPassword, err := hasher.HashPassword(password)
+	if err != nil {
+		return false, err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	u, ok := r.store.users[login]
+	if !ok {
+		return false, nil
+	}
+	u.HashedPassword = hashedPassword
+	r.store.record(author, models.AuditEntityUser, login, models.AuditActionModify, store.PasswordDiff)
+
+	return true, nil
+}
+
+// Modify
+func (r *UserRepository) Modify(login string, update *models.UserUpdate, author models.Author) (bool, error) {
+	hashedPassword := ""
+	if update.Password != nil {
+		var err error
+		if hashedPassword, err = hasher.HashPassword(*update.Password); err != nil {
+			return false, err
+		}
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	u, ok := r.store.users[login]
+	if !ok {
+		return false, nil
+	}
+	if hashedPassword != "" {
+		u.HashedPassword = hashedPassword
+		r.store.record(author, models.AuditEntityUser, login, models.AuditActionModify, store.PasswordDiff)
+	}
+	if update.IsAdmin != nil {
+		before := store.UserSnapshot(u)
+		u.IsAdmin = *update.IsAdmin
+		if err := r.store.audit(author, models.AuditEntityUser, login, models.AuditActionModify, before, store.UserSnapshot(u)); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// ReplaceHash
+func (r *UserRepository) ReplaceHash(login string, oldHash string, newHash string) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	u, ok := r.store.users[login]
+	if !ok || u.HashedPassword != oldHash {
+		return false, nil
+	}
+	u.HashedPassword = newHash
+
+	return true, nil
+}
@@ -0,0 +1,223 @@
+package testdb
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+)
+
+// WebhookRepository
+type WebhookRepository struct {
+	store *Store
+}
+
+// webhookRecord is a webhook as it is stored, lastSeq is the last change
+// event it has been given deliveries for.
+type webhookRecord struct {
+	webhook models.Webhook
+	lastSeq int
+}
+
+// Create
+func (r *WebhookRepository) Create(req *models.WebhookRequest) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.store.lastWebhookID++
+	id := r.store.lastWebhookID
+	r.store.webhooks[id] = &webhookRecord{
+		webhook: models.Webhook{
+			ID:        id,
+			URL:       req.URL,
+			Events:    append([]string(nil), req.Events...),
+			Secret:    req.Secret,
+			CreatedAt: now(),
+		},
+		lastSeq: r.store.lastChangeSeq,
+	}
+
+	return id, nil
+}
+
+// Find
+func (r *WebhookRepository) Find(id int) (*models.Webhook, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	rec, ok := r.store.webhooks[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+	webhook := rec.webhook
+	return &webhook, nil
+}
+
+// GetAll
+func (r *WebhookRepository) GetAll() ([]models.Webhook, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	webhooks := make([]models.Webhook, 0, len(r.store.webhooks))
+	for _, id := range sortedIDs(r.store.webhooks) {
+		webhooks = append(webhooks, r.store.webhooks[id].webhook)
+	}
+
+	return webhooks, nil
+}
+
+// Delete
+func (r *WebhookRepository) Delete(id int) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.webhooks[id]; !ok {
+		return false, nil
+	}
+	delete(r.store.webhooks, id)
+	for deliveryID, delivery := range r.store.deliveries {
+		if delivery.WebhookID == id {
+			delete(r.store.deliveries, deliveryID)
+		}
+	}
+
+	return true, nil
+}
+
+// Enqueue
+func (r *WebhookRepository) Enqueue(limit int) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	added := 0
+	for _, id := range sortedIDs(r.store.webhooks) {
+		rec := r.store.webhooks[id]
+		seen := 0
+		for i := range r.store.changeEvents {
+			event := &r.store.changeEvents[i]
+			if event.Seq <= rec.lastSeq {
+				continue
+			}
+			if limit > 0 && seen == limit {
+				break
+			}
+			seen++
+
+			payload, err := store.WebhookPayload(rec.webhook.Events, event)
+			if err != nil {
+				return 0, err
+			}
+			rec.lastSeq = event.Seq
+			if payload == nil {
+				continue
+			}
+			r.store.deliver(id, event.WebhookEvent(), payload)
+			added++
+		}
+	}
+
+	return added, nil
+}
+
+// Due
+func (r *WebhookRepository) Due(before time.Time, limit int) ([]models.WebhookDelivery, error) {
+	cutoff := before.UTC().Format(models.TimestampLayout)
+
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	for _, id := range sortedIDs(r.store.deliveries) {
+		delivery := r.store.delivery(id)
+		if delivery.Status == models.WebhookStatusPending && delivery.NextAttemptAt <= cutoff {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].NextAttemptAt < deliveries[j].NextAttemptAt
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+
+	return deliveries, nil
+}
+
+// RecordAttempt
+func (r *WebhookRepository) RecordAttempt(id int, attempt *store.WebhookAttempt) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delivery, ok := r.store.deliveries[id]
+	if !ok {
+		return nil
+	}
+	delivery.Attempts++
+	delivery.Status = attempt.Status()
+	delivery.ResponseCode = attempt.ResponseCode
+	delivery.Error = attempt.Error
+	delivery.NextAttemptAt = ""
+	if !attempt.NextAttemptAt.IsZero() {
+		delivery.NextAttemptAt = attempt.NextAttemptAt.UTC().Format(models.TimestampLayout)
+	}
+	if attempt.Succeeded {
+		delivery.DeliveredAt = now()
+	}
+
+	return nil
+}
+
+// GetDeliveries
+func (r *WebhookRepository) GetDeliveries(id int, limit int) ([]models.WebhookDelivery, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	ids := sortedIDs(r.store.deliveries)
+	for i := len(ids) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if r.store.deliveries[ids[i]].WebhookID == id {
+			deliveries = append(deliveries, r.store.delivery(ids[i]))
+		}
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver
+func (r *WebhookRepository) Redeliver(id int, deliveryID int) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delivery, ok := r.store.deliveries[deliveryID]
+	if !ok || delivery.WebhookID != id {
+		return 0, store.ErrRecordNotFound
+	}
+
+	return r.store.deliver(id, delivery.Event, delivery.Payload), nil
+}
+
+// deliver adds a pending delivery and returns its id. Must be called with
+// s.mu held.
+func (s *Store) deliver(webhookID int, event string, payload []byte) int {
+	s.lastDeliveryID++
+	at := now()
+	s.deliveries[s.lastDeliveryID] = &models.WebhookDelivery{
+		ID:            s.lastDeliveryID,
+		WebhookID:     webhookID,
+		Event:         event,
+		Payload:       payload,
+		Status:        models.WebhookStatusPending,
+		CreatedAt:     at,
+		NextAttemptAt: at,
+	}
+	return s.lastDeliveryID
+}
+
+// delivery fills in the url of the webhook, like the join of the sql stores.
+// Must be called with s.mu held.
+func (s *Store) delivery(id int) models.WebhookDelivery {
+	delivery := *s.deliveries[id]
+	delivery.URL = s.webhooks[delivery.WebhookID].webhook.URL
+	return delivery
+}
@@ -0,0 +1,47 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+)
+
+// WebhookAttempt is the outcome of sending a delivery once. A failed attempt
+// is retried at NextAttemptAt, or never when it is zero.
+type WebhookAttempt struct {
+	Succeeded     bool
+	ResponseCode  int
+	Error         string
+	NextAttemptAt time.Time
+}
+
+// Status is the status of the delivery after the attempt.
+func (a *WebhookAttempt) Status() string {
+	switch {
+	case a.Succeeded:
+		return models.WebhookStatusSucceeded
+	case !a.NextAttemptAt.IsZero():
+		return models.WebhookStatusPending
+	default:
+		return models.WebhookStatusFailed
+	}
+}
+
+// WebhookPayload is the payload of the change event, nil when events is not
+// subscribed to its type.
+func WebhookPayload(events []string, e *models.ChangeEvent) (json.RawMessage, error) {
+	event := e.WebhookEvent()
+	for _, subscribed := range events {
+		if subscribed == event {
+			return json.Marshal(&models.WebhookPayload{
+				Event:    event,
+				Seq:      e.Seq,
+				At:       e.At,
+				Entity:   e.Entity,
+				EntityID: e.EntityID,
+			})
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,167 @@
+// Package webhook sends the change events of the outbox to the subscribed
+// webhooks. Payloads are signed with the secret of the webhook and failed
+// deliveries are retried with exponential backoff.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// SignatureHeader carries the HMAC-SHA256 of the body as sha256=<hex>.
+	SignatureHeader = "X-Webhook-Signature"
+	// EventHeader carries the event type, e.g. film.created.
+	EventHeader = "X-Webhook-Event"
+	// DeliveryHeader carries the id of the delivery, redeliveries get a new
+	// one.
+	DeliveryHeader = "X-Webhook-Delivery"
+
+	batchSize  = 100
+	maxBackoff = 6 * time.Hour
+	maxError   = 500
+)
+
+// Sign returns the signature header value of body under secret.
+func Sign(secret string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(body)
+	return "sha256=" + hex.EncodeToString(h.Sum(nil))
+}
+
+// Dispatcher
+type Dispatcher struct {
+	webhooks    store.WebhookRepository
+	client      *http.Client
+	logger      *logrus.Logger
+	backoff     time.Duration
+	maxAttempts int
+}
+
+// NewDispatcher retries a failed delivery after backoff, doubling it on
+// every attempt, until maxAttempts attempts have failed.
+func NewDispatcher(webhooks store.WebhookRepository, client *http.Client, logger *logrus.Logger, backoff time.Duration, maxAttempts int) *Dispatcher {
+	return &Dispatcher{
+		webhooks:    webhooks,
+		client:      client,
+		logger:      logger,
+		backoff:     backoff,
+		maxAttempts: maxAttempts,
+	}
+}
+
+// Run dispatches every interval until the process exits. Only one dispatcher
+// should run per database, or deliveries are sent more than once.
+func (d *Dispatcher) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ; ; <-ticker.C {
+		sent, err := d.Dispatch(time.Now())
+		if err != nil {
+			d.logger.WithError(err).Info("Error when dispatching webhooks")
+			continue
+		}
+		if sent > 0 {
+			d.logger.Infof("Sent %d webhook deliveries", sent)
+		}
+	}
+}
+
+// Dispatch queues the new change events and sends the deliveries due at now,
+// returning how many it sent.
+func (d *Dispatcher) Dispatch(now time.Time) (int, error) {
+	if _, err := d.webhooks.Enqueue(batchSize); err != nil {
+		return 0, err
+	}
+
+	deliveries, err := d.webhooks.Due(now, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	secrets := make(map[int]string)
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		secret, ok := secrets[delivery.WebhookID]
+		if !ok {
+			webhook, err := d.webhooks.Find(delivery.WebhookID)
+			if err == store.ErrRecordNotFound {
+				// Deleted in the meantime, its deliveries went with it.
+				continue
+			}
+			if err != nil {
+				return i, err
+			}
+			secret = webhook.Secret
+			secrets[delivery.WebhookID] = secret
+		}
+
+		attempt := d.send(delivery, secret)
+		if !attempt.Succeeded && delivery.Attempts+1 < d.maxAttempts {
+			attempt.NextAttemptAt = now.Add(d.delay(delivery.Attempts))
+		}
+		if err := d.webhooks.RecordAttempt(delivery.ID, attempt); err != nil {
+			return i, err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// delay is the wait before the next attempt after attempts earlier failures.
+func (d *Dispatcher) delay(attempts int) time.Duration {
+	delay := d.backoff
+	for i := 0; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
+
+func (d *Dispatcher) send(delivery *models.WebhookDelivery, secret string) *store.WebhookAttempt {
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return &store.WebhookAttempt{Error: truncate(err.Error())}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, strconv.Itoa(delivery.ID))
+	req.Header.Set(SignatureHeader, Sign(secret, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return &store.WebhookAttempt{Error: truncate(err.Error())}
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	attempt := &store.WebhookAttempt{
+		Succeeded:    resp.StatusCode >= 200 && resp.StatusCode < 300,
+		ResponseCode: resp.StatusCode,
+	}
+	if !attempt.Succeeded {
+		attempt.Error = fmt.Sprintf("unexpected status %s", resp.Status)
+	}
+	return attempt
+}
+
+func truncate(s string) string {
+	if len(s) > maxError {
+		return s[:maxError]
+	}
+	return s
+}
@@ -0,0 +1,233 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/models"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/testdb"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// received is a request the receiver got.
+type received struct {
+	event     string
+	delivery  string
+	signature string
+	body      []byte
+}
+
+// receiver records the requests it gets and answers them with the next of
+// codes, 200 once they run out.
+type receiver struct {
+	mu       sync.Mutex
+	codes    []int
+	requests []received
+}
+
+func newReceiver(t *testing.T, codes ...int) (*receiver, string) {
+	rec := &receiver{codes: codes}
+	srv := httptest.NewServer(rec)
+	t.Cleanup(srv.Close)
+	return rec, srv.URL
+}
+
+func (rec *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	rec.requests = append(rec.requests, received{
+		event:     r.Header.Get(EventHeader),
+		delivery:  r.Header.Get(DeliveryHeader),
+		signature: r.Header.Get(SignatureHeader),
+		body:      body,
+	})
+	code := http.StatusOK
+	if len(rec.codes) > 0 {
+		code, rec.codes = rec.codes[0], rec.codes[1:]
+	}
+	w.WriteHeader(code)
+}
+
+func (rec *receiver) received() []received {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	return append([]received(nil), rec.requests...)
+}
+
+func newDispatcher(s *testdb.Store) *Dispatcher {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewDispatcher(s.Webhook(), http.DefaultClient, logger, time.Minute, 3)
+}
+
+func TestSign(t *testing.T) {
+	assert.Equal(t,
+		"sha256=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8",
+		Sign("key", []byte("The quick brown fox jumps over the lazy dog")),
+	)
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	rec, url := newReceiver(t)
+	s := testdb.New()
+	_, err := s.Actor().Create(&models.ActorRequest{Name: "Before", Gender: "male", BirthDate: "1956-07-09"}, models.Author{})
+	require.NoError(t, err)
+	_, err = s.Webhook().Create(&models.WebhookRequest{URL: url, Events: []string{"film.created", "actor.deleted"}, Secret: "secret"})
+	require.NoError(t, err)
+	d := newDispatcher(s)
+
+	filmID, err := s.Film().Create(&models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.5}, models.Author{})
+	require.NoError(t, err)
+	actorID, err := s.Actor().Create(&models.ActorRequest{Name: "Tom Hanks", Gender: "male", BirthDate: "1956-07-09"}, models.Author{})
+	require.NoError(t, err)
+	_, err = s.Actor().Delete(actorID, models.Author{})
+	require.NoError(t, err)
+
+	sent, err := d.Dispatch(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+
+	requests := rec.received()
+	require.Len(t, requests, 2)
+	assert.Equal(t, "film.created", requests[0].event)
+	assert.Equal(t, "actor.deleted", requests[1].event)
+	for _, r := range requests {
+		assert.Equal(t, Sign("secret", r.body), r.signature)
+		assert.NotEqual(t, Sign("other", r.body), r.signature)
+	}
+
+	var payload models.WebhookPayload
+	require.NoError(t, json.Unmarshal(requests[0].body, &payload))
+	assert.Equal(t, "film.created", payload.Event)
+	assert.Equal(t, models.AuditEntityFilm, payload.Entity)
+	assert.Equal(t, filmID, payload.EntityID)
+
+	deliveries, err := s.Webhook().GetDeliveries(1, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	for _, delivery := range deliveries {
+		assert.Equal(t, models.WebhookStatusSucceeded, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, http.StatusOK, delivery.ResponseCode)
+		assert.NotEmpty(t, delivery.DeliveredAt)
+	}
+
+	sent, err = d.Dispatch(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Len(t, rec.received(), 2)
+}
+
+func TestDispatcher_Retries(t *testing.T) {
+	rec, url := newReceiver(t, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable)
+	s := testdb.New()
+	webhookID, err := s.Webhook().Create(&models.WebhookRequest{URL: url, Events: []string{"film.created"}, Secret: "secret"})
+	require.NoError(t, err)
+	d := newDispatcher(s)
+
+	_, err = s.Film().Create(&models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.5}, models.Author{})
+	require.NoError(t, err)
+
+	start := time.Now()
+	delivery := func() models.WebhookDelivery {
+		deliveries, err := s.Webhook().GetDeliveries(webhookID, 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		return deliveries[0]
+	}
+	retryAt := func(delay time.Duration) string {
+		return start.Add(delay).UTC().Format(models.TimestampLayout)
+	}
+
+	sent, err := d.Dispatch(start)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	first := delivery()
+	assert.Equal(t, models.WebhookStatusPending, first.Status)
+	assert.Equal(t, 1, first.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, first.ResponseCode)
+	assert.Equal(t, retryAt(time.Minute), first.NextAttemptAt)
+
+	// Not due yet.
+	sent, err = d.Dispatch(start.Add(30 * time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	sent, err = d.Dispatch(start.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	second := delivery()
+	assert.Equal(t, 2, second.Attempts)
+	assert.Equal(t, http.StatusBadGateway, second.ResponseCode)
+	assert.Equal(t, retryAt(3*time.Minute), second.NextAttemptAt)
+
+	sent, err = d.Dispatch(start.Add(3 * time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	last := delivery()
+	assert.Equal(t, models.WebhookStatusFailed, last.Status)
+	assert.Equal(t, 3, last.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, last.ResponseCode)
+	assert.Empty(t, last.NextAttemptAt)
+	assert.Empty(t, last.DeliveredAt)
+
+	sent, err = d.Dispatch(start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	requests := rec.received()
+	require.Len(t, requests, 3)
+	for _, r := range requests {
+		assert.Equal(t, strconv.Itoa(last.ID), r.delivery)
+		assert.Equal(t, requests[0].body, r.body)
+	}
+}
+
+func TestDispatcher_Redeliver(t *testing.T) {
+	rec, url := newReceiver(t, http.StatusInternalServerError)
+	s := testdb.New()
+	webhookID, err := s.Webhook().Create(&models.WebhookRequest{URL: url, Events: []string{"film.created"}, Secret: "secret"})
+	require.NoError(t, err)
+	d := NewDispatcher(s.Webhook(), http.DefaultClient, logrus.New(), time.Minute, 1)
+
+	_, err = s.Film().Create(&models.FilmRequest{Title: "Forrest Gump", ReleaseDate: "1994-07-06", Rating: 8.5}, models.Author{})
+	require.NoError(t, err)
+
+	_, err = d.Dispatch(time.Now())
+	require.NoError(t, err)
+	deliveries, err := s.Webhook().GetDeliveries(webhookID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, models.WebhookStatusFailed, deliveries[0].Status)
+
+	redeliveryID, err := s.Webhook().Redeliver(webhookID, deliveries[0].ID)
+	require.NoError(t, err)
+	sent, err := d.Dispatch(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+
+	deliveries, err = s.Webhook().GetDeliveries(webhookID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, redeliveryID, deliveries[0].ID)
+	assert.Equal(t, models.WebhookStatusSucceeded, deliveries[0].Status)
+	assert.Equal(t, models.WebhookStatusFailed, deliveries[1].Status)
+
+	requests := rec.received()
+	require.Len(t, requests, 2)
+	assert.Equal(t, requests[0].body, requests[1].body)
+	assert.Equal(t, strconv.Itoa(redeliveryID), requests[1].delivery)
+	assert.Equal(t, Sign("secret", requests[1].body), requests[1].signature)
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Actors returns the actors matching q, q may be nil.
+func (c *Client) Actors(ctx context.Context, q *ActorQuery) ([]Actor, error) {
+	var actors []Actor
+	err := c.getJSON(ctx, &request{method: http.MethodGet, path: "/actors", query: q.values()}, &actors)
+	return actors, err
+}
+
+// IterateActors walks over the actors matching q, see IterateFilms.
+func (c *Client) IterateActors(ctx context.Context, q *ActorQuery) *Iterator[Actor] {
+	if q != nil && q.Fuzzy {
+		return &Iterator[Actor]{err: errors.New("fuzzy search can not be iterated")}
+	}
+	return iterate[Actor](ctx, c, &request{method: http.MethodGet, path: "/actors", query: q.values()})
+}
+
+// Actor returns the actor with the given id.
+func (c *Client) Actor(ctx context.Context, id int) (*Actor, error) {
+	actor := &Actor{}
+	if err := c.getJSON(ctx, &request{method: http.MethodGet, path: "/actors/" + strconv.Itoa(id)}, actor); err != nil {
+		return nil, err
+	}
+	return actor, nil
+}
+
+// CreateActor adds an actor and returns its id. Requires an administrator.
+func (c *Client) CreateActor(ctx context.Context, actor *ActorRequest) (int, error) {
+	req, err := jsonRequest(http.MethodPost, "/actors", actor)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.exec(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+	return createdID(resp, "/actors/")
+}
+
+// UpdateActor changes the non-empty fields of an actor. Requires an
+// administrator.
+func (c *Client) UpdateActor(ctx context.Context, id int, actor *ActorRequest) error {
+	req, err := jsonRequest(http.MethodPatch, "/actors/"+strconv.Itoa(id), actor)
+	if err != nil {
+		return err
+	}
+	_, err = c.exec(ctx, req)
+	return err
+}
+
+// DeleteActor removes an actor. Requires an administrator.
+func (c *Client) DeleteActor(ctx context.Context, id int) error {
+	_, err := c.exec(ctx, &request{method: http.MethodDelete, path: "/actors/" + strconv.Itoa(id)})
+	return err
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Catalogue formats accepted by Import and Export.
+const (
+	FormatJSON   = "json"
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+var formatContentTypes = map[string]string{
+	FormatJSON:   "application/json",
+	FormatCSV:    "text/csv",
+	FormatNDJSON: "application/x-ndjson",
+}
+
+// Import loads actors or films ("actor" or "film") in the given format.
+// Requires an administrator. The call is never retried, so data may be a
+// one-shot reader.
+func (c *Client) Import(ctx context.Context, entity, format string, data io.Reader, dryRun bool) (*ImportReport, error) {
+	contentType, ok := formatContentTypes[format]
+	if !ok {
+		return nil, errors.Errorf("unknown format %q", format)
+	}
+	body, err := io.ReadAll(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "read import data")
+	}
+
+	report := &ImportReport{}
+	err = c.getJSON(ctx, &request{
+		method:      http.MethodPost,
+		path:        "/import",
+		query:       url.Values{"entity": {entity}, "dry_run": {strconv.FormatBool(dryRun)}},
+		body:        body,
+		contentType: contentType,
+	}, report)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Export streams the catalogue in the given format. entities may list
+// "actors" and "films", empty means both. The caller closes the reader.
+func (c *Client) Export(ctx context.Context, format string, entities ...string) (io.ReadCloser, error) {
+	query := url.Values{"format": {format}}
+	setString(query, "entities", strings.Join(entities, ","))
+
+	resp, err := c.do(ctx, &request{method: http.MethodGet, path: "/export", query: query})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// GraphQLError
+type GraphQLError struct {
+	Message string `json:"message"`
+}
+
+// GraphQLRequest is a query or mutation with its variables.
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+}
+
+// GraphQLResponse holds the raw data, decode it into the shape of the query.
+type GraphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []GraphQLError  `json:"errors"`
+}
+
+// GraphQL runs a query or mutation. Field errors such as missing rights come
+// back in the response, not as the returned error.
+func (c *Client) GraphQL(ctx context.Context, query *GraphQLRequest) (*GraphQLResponse, error) {
+	req, err := jsonRequest(http.MethodPost, "/graphql", query)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &GraphQLResponse{}
+	if err := c.getJSON(ctx, req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
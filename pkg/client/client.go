@@ -0,0 +1,216 @@
+// Package client is a Go client for the film database REST API.
+//
+//	c, err := client.New("http://localhost:8080", client.WithBasicAuth("admin", "secret"))
+//	films, err := c.Films(ctx, &client.FilmQuery{SearchTitle: "matrix"})
+//
+// Calls that are safe to repeat (GET, PATCH, DELETE) are retried with
+// exponential backoff on network errors, 429 and 5xx responses. A repeated
+// DELETE that finds the resource gone succeeds.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultRetries = 3
+	defaultBackoff = 200 * time.Millisecond
+	maxBackoff     = 5 * time.Second
+)
+
+// Client
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	authorize  func(*http.Request)
+	retries    int
+	backoff    time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithBasicAuth sends the login and password with every request.
+func WithBasicAuth(login, password string) Option {
+	return func(c *Client) {
+		c.authorize = func(r *http.Request) {
+			r.SetBasicAuth(login, password)
+		}
+	}
+}
+
+// WithHTTPClient replaces http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetries sets how many times an idempotent call is repeated and the
+// delay before the first repetition, which doubles after every attempt.
+// Zero retries disables retrying.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+// New returns a client for the API at baseURL, e.g. "http://localhost:8080".
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse base url")
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, errors.Errorf("base url %q must be absolute", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		authorize:  func(*http.Request) {},
+		retries:    defaultRetries,
+		backoff:    defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// request describes a call. body is kept as bytes so it can be sent again.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        []byte
+	contentType string
+	accept      string
+}
+
+func jsonRequest(method, path string, payload any) (*request, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "encode request")
+	}
+	return &request{method: method, path: path, body: body, contentType: "application/json"}, nil
+}
+
+// do sends the request and returns the response for a 2xx status, any other
+// status is turned into an *Error. The caller closes the body.
+func (c *Client) do(ctx context.Context, req *request) (*http.Response, error) {
+	attempts := 1
+	if idempotent(req.method) {
+		attempts += c.retries
+	}
+
+	backoff := c.backoff
+	for attempt := 1; ; attempt++ {
+		resp, err := c.send(ctx, req)
+		if err == nil && resp.StatusCode < 300 {
+			return resp, nil
+		}
+		// An earlier attempt of a DELETE may have succeeded with its response
+		// lost, the resource is gone either way.
+		if err == nil && resp.StatusCode == http.StatusNotFound && req.method == http.MethodDelete && attempt > 1 {
+			return resp, nil
+		}
+		if err == nil {
+			err = newError(resp)
+		}
+		if attempt == attempts || !retryable(err) || ctx.Err() != nil {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, req *request) (*http.Response, error) {
+	u := *c.baseURL
+	u.Path += req.path
+	u.RawQuery = req.query.Encode()
+
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), body)
+	if err != nil {
+		return nil, errors.Wrap(err, "build request")
+	}
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+	if req.accept != "" {
+		httpReq.Header.Set("Accept", req.accept)
+	}
+	c.authorize(httpReq)
+
+	return c.httpClient.Do(httpReq)
+}
+
+// getJSON sends the request and decodes a JSON response into out.
+func (c *Client) getJSON(ctx context.Context, req *request, out any) error {
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return errors.Wrap(err, "decode response")
+	}
+	return nil
+}
+
+// exec sends the request and discards the response body.
+func (c *Client) exec(ctx context.Context, req *request) (*http.Response, error) {
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp, nil
+}
+
+// idempotent reports whether repeating the call is harmless. PATCH qualifies
+// here because the API only ever sets fields to the sent values.
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func retryable(err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests ||
+			apiErr.StatusCode >= 500 && apiErr.StatusCode != http.StatusNotImplemented
+	}
+	// Transport errors, the server may not have seen the request at all.
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,284 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Rbd3178/filmDatabase/internal/app/apiserver"
+	"github.com/Rbd3178/filmDatabase/internal/app/store/testdb"
+	"github.com/Rbd3178/filmDatabase/pkg/client"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(apiserver.NewHandler(testdb.New()))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newClient(t *testing.T, url string, opts ...client.Option) *client.Client {
+	t.Helper()
+
+	c, err := client.New(url, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestClient_Films(t *testing.T) {
+	server := newTestServer(t)
+	admin := newClient(t, server.URL, client.WithBasicAuth("admin", "adminpass"))
+	normal := newClient(t, server.URL, client.WithBasicAuth("normal", "correct"))
+	ctx := context.Background()
+
+	id, err := admin.CreateFilm(ctx, &client.FilmRequest{Title: "The Matrix", ReleaseDate: "1999-03-31", Rating: 8.7, ActorsIDs: []int{1}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, id)
+	_, err = admin.CreateFilm(ctx, &client.FilmRequest{Title: "John Wick", ReleaseDate: "2014-10-24", Rating: 7.4})
+	assert.NoError(t, err)
+
+	_, err = normal.CreateFilm(ctx, &client.FilmRequest{Title: "Speed", ReleaseDate: "1994-06-10"})
+	assert.True(t, errors.Is(err, client.ErrForbidden))
+
+	_, err = admin.CreateFilm(ctx, &client.FilmRequest{Title: "", ReleaseDate: "1994-06-10"})
+	assert.True(t, errors.Is(err, client.ErrInvalidPayload))
+
+	films, err := normal.Films(ctx, &client.FilmQuery{Sort: []string{"title"}})
+	assert.NoError(t, err)
+	if assert.Len(t, films, 2) {
+		assert.Equal(t, "John Wick", films[0].Title)
+		assert.Equal(t, "The Matrix", films[1].Title)
+	}
+
+	film, err := normal.Film(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "The Matrix", film.Title)
+
+	assert.NoError(t, admin.UpdateFilm(ctx, 1, &client.FilmRequest{Rating: 9}))
+	film, _ = normal.Film(ctx, 1)
+	assert.Equal(t, 9.0, film.Rating)
+
+	assert.NoError(t, admin.DeleteFilm(ctx, 2))
+	err = admin.DeleteFilm(ctx, 2)
+	assert.True(t, errors.Is(err, client.ErrNotFound))
+
+	var apiErr *client.Error
+	if assert.True(t, errors.As(err, &apiErr)) {
+		assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	}
+}
+
+func TestClient_Actors(t *testing.T) {
+	server := newTestServer(t)
+	admin := newClient(t, server.URL, client.WithBasicAuth("admin", "adminpass"))
+	ctx := context.Background()
+
+	id, err := admin.CreateActor(ctx, &client.ActorRequest{Name: "Keanu Reeves", Gender: "male", BirthDate: "1964-09-02"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, id)
+
+	assert.NoError(t, admin.UpdateActor(ctx, id, &client.ActorRequest{Name: "Keanu Charles Reeves", Gender: "male", BirthDate: "1964-09-02"}))
+
+	actors, err := admin.Actors(ctx, &client.ActorQuery{Gender: "male"})
+	assert.NoError(t, err)
+	if assert.Len(t, actors, 1) {
+		assert.Equal(t, "Keanu Charles Reeves", actors[0].Name)
+	}
+
+	actor, err := admin.Actor(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, "Keanu Charles Reeves", actor.Name)
+
+	assert.NoError(t, admin.DeleteActor(ctx, id))
+	_, err = admin.Actor(ctx, id)
+	assert.True(t, errors.Is(err, client.ErrNotFound))
+}
+
+func TestClient_Iterate(t *testing.T) {
+	server := newTestServer(t)
+	admin := newClient(t, server.URL, client.WithBasicAuth("admin", "adminpass"))
+	ctx := context.Background()
+
+	for _, title := range []string{"Alpha", "Beta", "Gamma"} {
+		admin.CreateFilm(ctx, &client.FilmRequest{Title: title, ReleaseDate: "2000-01-01"})
+	}
+
+	it := admin.IterateFilms(ctx, &client.FilmQuery{Sort: []string{"-title"}})
+	defer it.Close()
+	var titles []string
+	for it.Next() {
+		titles = append(titles, it.Value().Title)
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []string{"Gamma", "Beta", "Alpha"}, titles)
+
+	unauthorized := newClient(t, server.URL)
+	it = unauthorized.IterateFilms(ctx, nil)
+	defer it.Close()
+	assert.False(t, it.Next())
+	assert.True(t, errors.Is(it.Err(), client.ErrUnauthorized))
+}
+
+func TestClient_Users(t *testing.T) {
+	server := newTestServer(t)
+	anonymous := newClient(t, server.URL)
+	ctx := context.Background()
+
+	assert.NoError(t, anonymous.Register(ctx, "somebody", "secret"))
+	err := anonymous.Register(ctx, "somebody", "secret")
+	assert.True(t, errors.Is(err, client.ErrConflict))
+
+	_, err = anonymous.Users(ctx)
+	assert.True(t, errors.Is(err, client.ErrUnauthorized))
+
+	_, err = newClient(t, server.URL, client.WithBasicAuth("somebody", "secret")).Users(ctx)
+	assert.True(t, errors.Is(err, client.ErrForbidden))
+
+	admin := newClient(t, server.URL, client.WithBasicAuth("admin", "adminpass"))
+	users, err := admin.Users(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, users, 3)
+
+	isAdmin, password := true, "newsecret"
+	assert.NoError(t, admin.UpdateUser(ctx, "somebody", &client.UserUpdate{IsAdmin: &isAdmin, Password: &password}))
+	_, err = newClient(t, server.URL, client.WithBasicAuth("somebody", "newsecret")).Users(ctx)
+	assert.NoError(t, err)
+
+	err = admin.UpdateUser(ctx, "nobody", &client.UserUpdate{IsAdmin: &isAdmin})
+	assert.True(t, errors.Is(err, client.ErrNotFound))
+
+	assert.NoError(t, admin.CreateUser(ctx, "chief", "chiefpass", true))
+	_, err = newClient(t, server.URL, client.WithBasicAuth("chief", "chiefpass")).Users(ctx)
+	assert.NoError(t, err)
+	err = admin.CreateUser(ctx, "chief", "chiefpass", false)
+	assert.True(t, errors.Is(err, client.ErrConflict))
+	err = anonymous.CreateUser(ctx, "intruder", "secret", true)
+	assert.True(t, errors.Is(err, client.ErrUnauthorized))
+}
+
+func TestClient_Catalog(t *testing.T) {
+	server := newTestServer(t)
+	admin := newClient(t, server.URL, client.WithBasicAuth("admin", "adminpass"))
+	ctx := context.Background()
+
+	report, err := admin.Import(ctx, "actor", client.FormatCSV, strings.NewReader("external_id,name,gender,birth_date\nnm1,Keanu Reeves,male,1964-09-02\n"), false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Actors.Created)
+
+	_, err = admin.Import(ctx, "actor", "yaml", strings.NewReader(""), false)
+	assert.Error(t, err)
+
+	body, err := admin.Export(ctx, client.FormatNDJSON, "actors")
+	assert.NoError(t, err)
+	exported, _ := io.ReadAll(body)
+	body.Close()
+	assert.Contains(t, string(exported), `"name":"Keanu Reeves"`)
+
+	resp, err := admin.GraphQL(ctx, &client.GraphQLRequest{Query: `{ actors { name } }`})
+	assert.NoError(t, err)
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"actors":[{"name":"Keanu Reeves"}]}`, string(resp.Data))
+}
+
+func TestClient_Retries(t *testing.T) {
+	handler := apiserver.NewHandler(testdb.New())
+	var calls, failures int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			http.Error(w, "try later", http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	var tests = []struct {
+		name          string
+		failures      int32
+		call          func(c *client.Client) error
+		expectedCalls int32
+		expectedErr   error
+	}{
+		{
+			name:     "GET recovers",
+			failures: 2,
+			call: func(c *client.Client) error {
+				_, err := c.Films(context.Background(), nil)
+				return err
+			},
+			expectedCalls: 3,
+		},
+		{
+			name:     "GET gives up",
+			failures: 5,
+			call: func(c *client.Client) error {
+				_, err := c.Films(context.Background(), nil)
+				return err
+			},
+			expectedCalls: 3,
+			expectedErr:   client.ErrServer,
+		},
+		{
+			name:     "POST is not retried",
+			failures: 1,
+			call: func(c *client.Client) error {
+				_, err := c.CreateActor(context.Background(), &client.ActorRequest{Name: "Tom Hardy", BirthDate: "1977-09-15"})
+				return err
+			},
+			expectedCalls: 1,
+			expectedErr:   client.ErrServer,
+		},
+		{
+			name:     "Repeated DELETE finds the film gone",
+			failures: 1,
+			call: func(c *client.Client) error {
+				return c.DeleteFilm(context.Background(), 42)
+			},
+			expectedCalls: 2,
+		},
+		{
+			name:     "Client errors are not retried",
+			failures: 0,
+			call: func(c *client.Client) error {
+				return c.DeleteFilm(context.Background(), 42)
+			},
+			expectedCalls: 1,
+			expectedErr:   client.ErrNotFound,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			atomic.StoreInt32(&calls, 0)
+			atomic.StoreInt32(&failures, tc.failures)
+			c := newClient(t, server.URL, client.WithBasicAuth("admin", "adminpass"), client.WithRetries(2, time.Millisecond))
+
+			err := tc.call(c)
+			if tc.expectedErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, tc.expectedErr), "got %v", err)
+			}
+			assert.Equal(t, tc.expectedCalls, atomic.LoadInt32(&calls))
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	_, err := client.New("localhost:8080")
+	assert.Error(t, err)
+
+	_, err = client.New("http://localhost:8080/")
+	assert.NoError(t, err)
+}
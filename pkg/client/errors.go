@@ -0,0 +1,77 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Errors matched by errors.Is against an *Error with the corresponding status.
+var (
+	ErrBadRequest       = errors.New("bad request")
+	ErrUnauthorized     = errors.New("unauthorized")
+	ErrForbidden        = errors.New("forbidden")
+	ErrNotFound         = errors.New("not found")
+	ErrConflict         = errors.New("conflict")
+	ErrUnsupported      = errors.New("unsupported media type")
+	ErrInvalidPayload   = errors.New("invalid payload")
+	ErrTooManyRequests  = errors.New("too many requests")
+	ErrServer           = errors.New("server error")
+	errUnexpectedStatus = errors.New("unexpected status")
+)
+
+// Error is a response with a non-2xx status. Message is the text the server
+// sent in the body.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Is reports whether target is the sentinel error for the status.
+func (e *Error) Is(target error) bool {
+	return target == statusError(e.StatusCode)
+}
+
+func statusError(code int) error {
+	switch code {
+	case http.StatusBadRequest:
+		return ErrBadRequest
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusConflict:
+		return ErrConflict
+	case http.StatusUnsupportedMediaType, http.StatusNotAcceptable:
+		return ErrUnsupported
+	case http.StatusUnprocessableEntity:
+		return ErrInvalidPayload
+	case http.StatusTooManyRequests:
+		return ErrTooManyRequests
+	}
+	if code >= 500 {
+		return ErrServer
+	}
+	return errUnexpectedStatus
+}
+
+// newError reads and closes the body of a failed response.
+func newError(resp *http.Response) *Error {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return &Error{
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(body)),
+	}
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Films returns the films matching q, q may be nil.
+func (c *Client) Films(ctx context.Context, q *FilmQuery) ([]Film, error) {
+	var films []Film
+	err := c.getJSON(ctx, &request{method: http.MethodGet, path: "/films", query: q.values()}, &films)
+	return films, err
+}
+
+// IterateFilms walks over the films matching q one at a time as the server
+// streams them, without holding the whole list in memory. Fuzzy queries are
+// not streamed by the server and are rejected.
+func (c *Client) IterateFilms(ctx context.Context, q *FilmQuery) *Iterator[Film] {
+	if q != nil && q.Fuzzy {
+		return &Iterator[Film]{err: errors.New("fuzzy search can not be iterated")}
+	}
+	return iterate[Film](ctx, c, &request{method: http.MethodGet, path: "/films", query: q.values()})
+}
+
+// Film returns the film with the given id.
+func (c *Client) Film(ctx context.Context, id int) (*Film, error) {
+	film := &Film{}
+	if err := c.getJSON(ctx, &request{method: http.MethodGet, path: "/films/" + strconv.Itoa(id)}, film); err != nil {
+		return nil, err
+	}
+	return film, nil
+}
+
+// CreateFilm adds a film and returns its id. Requires an administrator.
+func (c *Client) CreateFilm(ctx context.Context, film *FilmRequest) (int, error) {
+	req, err := jsonRequest(http.MethodPost, "/films", film)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.exec(ctx, req)
+	if err != nil {
+		return 0, err
+	}
+	return createdID(resp, "/films/")
+}
+
+// UpdateFilm changes the non-empty fields of a film. Requires an
+// administrator.
+func (c *Client) UpdateFilm(ctx context.Context, id int, film *FilmRequest) error {
+	req, err := jsonRequest(http.MethodPatch, "/films/"+strconv.Itoa(id), film)
+	if err != nil {
+		return err
+	}
+	_, err = c.exec(ctx, req)
+	return err
+}
+
+// DeleteFilm removes a film. Requires an administrator.
+func (c *Client) DeleteFilm(ctx context.Context, id int) error {
+	_, err := c.exec(ctx, &request{method: http.MethodDelete, path: "/films/" + strconv.Itoa(id)})
+	return err
+}
+
+// createdID reads the id from the Location header of a 201 response.
+func createdID(resp *http.Response, prefix string) (int, error) {
+	location := resp.Header.Get("Location")
+	id, err := strconv.Atoi(strings.TrimPrefix(location, prefix))
+	if err != nil {
+		return 0, errors.Errorf("unexpected location %q", location)
+	}
+	return id, nil
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// Iterator walks over a listing the server streams as NDJSON. The request is
+// sent on the first call to Next; Close must be called when done.
+//
+//	it := c.IterateFilms(ctx, nil)
+//	defer it.Close()
+//	for it.Next() {
+//		film := it.Value()
+//	}
+//	if err := it.Err(); err != nil {
+type Iterator[T any] struct {
+	open    func() (*http.Response, error)
+	body    io.ReadCloser
+	decoder *json.Decoder
+	value   *T
+	err     error
+}
+
+func iterate[T any](ctx context.Context, c *Client, req *request) *Iterator[T] {
+	req.accept = "application/x-ndjson"
+	return &Iterator[T]{
+		open: func() (*http.Response, error) {
+			return c.do(ctx, req)
+		},
+	}
+}
+
+// Next advances to the next value, it returns false at the end of the
+// listing or on error.
+func (it *Iterator[T]) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.decoder == nil {
+		if it.open == nil {
+			return false
+		}
+		resp, err := it.open()
+		it.open = nil
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.body = resp.Body
+		it.decoder = json.NewDecoder(resp.Body)
+	}
+
+	value := new(T)
+	if err := it.decoder.Decode(value); err != nil {
+		if err != io.EOF {
+			it.err = errors.Wrap(err, "decode value")
+		}
+		it.value = nil
+		return false
+	}
+	it.value = value
+	return true
+}
+
+// Value returns the current value.
+func (it *Iterator[T]) Value() *T {
+	return it.value
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Close releases the connection.
+func (it *Iterator[T]) Close() error {
+	it.open = nil
+	if it.body == nil {
+		return nil
+	}
+	err := it.body.Close()
+	it.body = nil
+	return err
+}
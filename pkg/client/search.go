@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Search runs a full text search over films and actors. A zero limit uses
+// the server default.
+func (c *Client) Search(ctx context.Context, q string, limit int) ([]SearchResult, error) {
+	query := url.Values{"q": {q}}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var results []SearchResult
+	err := c.getJSON(ctx, &request{method: http.MethodGet, path: "/search", query: query}, &results)
+	return results, err
+}
+
+// Suggest completes a film title or actor name prefix. kind is "film",
+// "actor" or empty for both, a zero limit uses the server default.
+func (c *Client) Suggest(ctx context.Context, prefix, kind string, limit int) ([]Suggestion, error) {
+	query := url.Values{"q": {prefix}}
+	setString(query, "type", kind)
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var suggestions []Suggestion
+	err := c.getJSON(ctx, &request{method: http.MethodGet, path: "/suggest", query: query}, &suggestions)
+	return suggestions, err
+}
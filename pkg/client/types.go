@@ -0,0 +1,198 @@
+package client
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Film
+type Film struct {
+	ID          int          `json:"id"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	ReleaseDate string       `json:"release_date"`
+	Rating      float64      `json:"rating"`
+	Actors      []ActorBasic `json:"actors"`
+}
+
+// FilmRequest creates or updates a film, empty fields are left as they are
+// by UpdateFilm.
+type FilmRequest struct {
+	Title       string  `json:"title"`
+	Description string  `json:"description"`
+	ReleaseDate string  `json:"release_date"`
+	Rating      float64 `json:"rating"`
+	ActorsIDs   []int   `json:"actors_ids"`
+}
+
+// FilmBasic
+type FilmBasic struct {
+	FilmID int    `json:"film_id"`
+	Title  string `json:"title"`
+}
+
+// Actor
+type Actor struct {
+	ID        int         `json:"id"`
+	Name      string      `json:"name"`
+	Gender    string      `json:"gender"`
+	BirthDate string      `json:"birth_date"`
+	Films     []FilmBasic `json:"films"`
+}
+
+// ActorRequest creates or updates an actor, see FilmRequest.
+type ActorRequest struct {
+	Name      string `json:"name"`
+	Gender    string `json:"gender"`
+	BirthDate string `json:"birth_date"`
+}
+
+// ActorBasic
+type ActorBasic struct {
+	ActorID int    `json:"actor_id"`
+	Name    string `json:"name"`
+}
+
+// User
+type User struct {
+	Login   string `json:"login"`
+	IsAdmin bool   `json:"is_admin"`
+}
+
+// UserUpdate
+type UserUpdate struct {
+	IsAdmin  *bool   `json:"is_admin"`
+	Password *string `json:"password"`
+}
+
+// SearchResult
+type SearchResult struct {
+	Type    string  `json:"type"`
+	ID      int     `json:"id"`
+	Label   string  `json:"label"`
+	Snippet string  `json:"snippet"`
+	Rank    float64 `json:"rank"`
+}
+
+// Suggestion
+type Suggestion struct {
+	ID    int    `json:"id"`
+	Label string `json:"label"`
+	Type  string `json:"type"`
+}
+
+// ImportReport
+type ImportReport struct {
+	DryRun bool          `json:"dry_run"`
+	Rows   int           `json:"rows"`
+	Actors ImportResult  `json:"actors"`
+	Films  ImportResult  `json:"films"`
+	Errors []ImportError `json:"errors"`
+}
+
+// ImportResult
+type ImportResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+}
+
+// ImportError
+type ImportError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+const (
+	// ActorMatchAny selects films with at least one of the given actors
+	ActorMatchAny = "any"
+	// ActorMatchAll selects films with every one of the given actors
+	ActorMatchAll = "all"
+)
+
+// FilmQuery holds the GET /films parameters, zero values are left out. Sort
+// items are field names, prefixed with "-" for descending order. Fuzzy
+// switches to typo tolerant search by SearchTitle or SearchActor.
+type FilmQuery struct {
+	SearchTitle    string
+	SearchActor    string
+	RatingMin      *float64
+	RatingMax      *float64
+	ReleasedAfter  string
+	ReleasedBefore string
+	ActorIDs       []int
+	ActorMatch     string
+	HasDescription *bool
+	Sort           []string
+	Fuzzy          bool
+	Threshold      float64
+}
+
+func (q *FilmQuery) values() url.Values {
+	values := url.Values{}
+	if q == nil {
+		return values
+	}
+	setString(values, "searchtitle", q.SearchTitle)
+	setString(values, "searchactor", q.SearchActor)
+	if q.RatingMin != nil {
+		values.Set("rating_min", strconv.FormatFloat(*q.RatingMin, 'f', -1, 64))
+	}
+	if q.RatingMax != nil {
+		values.Set("rating_max", strconv.FormatFloat(*q.RatingMax, 'f', -1, 64))
+	}
+	setString(values, "released_after", q.ReleasedAfter)
+	setString(values, "released_before", q.ReleasedBefore)
+	for _, id := range q.ActorIDs {
+		values.Add("actor_id", strconv.Itoa(id))
+	}
+	setString(values, "actor_match", q.ActorMatch)
+	if q.HasDescription != nil {
+		values.Set("has_description", strconv.FormatBool(*q.HasDescription))
+	}
+	setString(values, "sort", strings.Join(q.Sort, ","))
+	setFuzzy(values, q.Fuzzy, q.Threshold)
+	return values
+}
+
+// ActorQuery holds the GET /actors parameters, see FilmQuery. Fuzzy search
+// uses SearchName.
+type ActorQuery struct {
+	SearchName string
+	Gender     string
+	BornAfter  string
+	BornBefore string
+	Sort       []string
+	Fuzzy      bool
+	Threshold  float64
+}
+
+func (q *ActorQuery) values() url.Values {
+	values := url.Values{}
+	if q == nil {
+		return values
+	}
+	setString(values, "searchname", q.SearchName)
+	setString(values, "gender", q.Gender)
+	setString(values, "born_after", q.BornAfter)
+	setString(values, "born_before", q.BornBefore)
+	setString(values, "sort", strings.Join(q.Sort, ","))
+	setFuzzy(values, q.Fuzzy, q.Threshold)
+	return values
+}
+
+func setString(values url.Values, key, value string) {
+	if value != "" {
+		values.Set(key, value)
+	}
+}
+
+func setFuzzy(values url.Values, fuzzy bool, threshold float64) {
+	if !fuzzy {
+		return
+	}
+	values.Set("mode", "fuzzy")
+	if threshold != 0 {
+		values.Set("threshold", strconv.FormatFloat(threshold, 'f', -1, 64))
+	}
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Register creates a user with the normal role, no credentials are needed.
+func (c *Client) Register(ctx context.Context, login, password string) error {
+	req, err := jsonRequest(http.MethodPost, "/register", map[string]string{
+		"login":    login,
+		"password": password,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.exec(ctx, req)
+	return err
+}
+
+// CreateUser creates a user, with the admin role when isAdmin is set.
+// Requires an administrator.
+func (c *Client) CreateUser(ctx context.Context, login, password string, isAdmin bool) error {
+	req, err := jsonRequest(http.MethodPost, "/users", map[string]interface{}{
+		"login":    login,
+		"password": password,
+		"is_admin": isAdmin,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.exec(ctx, req)
+	return err
+}
+
+// Users returns all users. Requires an administrator.
+func (c *Client) Users(ctx context.Context) ([]User, error) {
+	var users []User
+	err := c.getJSON(ctx, &request{method: http.MethodGet, path: "/users"}, &users)
+	return users, err
+}
+
+// UpdateUser changes the role and/or password of a user, nil fields are left
+// as they are. Requires an administrator.
+func (c *Client) UpdateUser(ctx context.Context, login string, update *UserUpdate) error {
+	req, err := jsonRequest(http.MethodPatch, "/users/"+url.PathEscape(login), update)
+	if err != nil {
+		return err
+	}
+	_, err = c.exec(ctx, req)
+	return err
+}